@@ -0,0 +1,430 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/login_throttle"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/mailer"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/password_generator"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/random_token"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/refresh_session"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/roles_credentials"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/validator"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/wrapper"
+	"github.com/create-go-app/fiber-go-template/platform/database"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// GetUsers method to list, search and paginate users.
+// @Description List users filtered by email, role, status and creation date, newest first.
+// @Summary list users
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param email query string false "Part of the email"
+// @Param user_role query string false "Role"
+// @Param user_status query int false "Status (0 == blocked, 1 == active)"
+// @Param created_from query string false "Created on or after (YYYY-MM-DD)"
+// @Param created_to query string false "Created on or before (YYYY-MM-DD)"
+// @Param page query int false "Page (default 1)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} models.UserPage
+// @Security ApiKeyAuth
+// @Router /v1/admin/users [get]
+func GetUsers(c *fiber.Ctx) error {
+	if _, status, err := authorize(c, repository.UserManageCredential); err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	filter := models.UserFilter{}
+	if err := c.QueryParser(&filter); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(filter); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	search := filter.Search()
+	users, total, err := db.GetUsers(search)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	page := models.UserPage{
+		Users: make([]models.UserProfile, 0, len(users)),
+		Total: total,
+		Page:  search.Offset/search.Limit + 1,
+		Limit: search.Limit,
+	}
+	for _, user := range users {
+		credentials, _ := roles_credentials.GetCredentialsByRole(user.UserRole)
+		page.Users = append(page.Users, models.NewUserProfile(user, credentials))
+	}
+
+	return wrapper.SuccessResponse(c, "", page)
+}
+
+// GetUser method to get one user with their book count.
+// @Description Get a user by given ID along with their book count.
+// @Summary get user by given ID
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} models.UserDetails
+// @Security ApiKeyAuth
+// @Router /v1/admin/users/{id} [get]
+func GetUser(c *fiber.Ctx) error {
+	if _, status, err := authorize(c, repository.UserManageCredential); err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	foundedUser, err := db.GetUserByID(id)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	memberships, err := db.GetMemberships(id)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	bookCount := 0
+	for _, membership := range memberships {
+		count, err := db.CountBooksByUserID(membership.OrganizationID, id)
+		if err != nil {
+			return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+		}
+		bookCount += count
+	}
+
+	credentials, _ := roles_credentials.GetCredentialsByRole(foundedUser.UserRole)
+
+	return wrapper.SuccessResponse(c, "", models.UserDetails{
+		UserProfile: models.NewUserProfile(foundedUser, credentials),
+		BookCount:   bookCount,
+		Memberships: memberships,
+	})
+}
+
+// UpdateUser method to change the role and status of a user.
+// @Description Change the role and/or status of a user. A new role also applies in the default organization; roles in other organizations are left unchanged. The user is signed out so new tokens carry the change.
+// @Summary change user role and status
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body models.UserAdminUpdate true "Role and status"
+// @Success 200 {object} models.UserProfile
+// @Security ApiKeyAuth
+// @Router /v1/admin/users/{id} [patch]
+func UpdateUser(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, repository.UserManageCredential)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	if id == claims.UserID {
+		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", errors.New(repository.SelfModificationErrorMessage))
+	}
+
+	userUpdate := &models.UserAdminUpdate{}
+	if err := c.BodyParser(userUpdate); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(userUpdate); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	foundedUser, err := db.GetUserByID(id)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	if userUpdate.UserRole != nil {
+		role, err := roles_credentials.VerifyRole(*userUpdate.UserRole)
+		if err != nil {
+			return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+		}
+		foundedUser.UserRole = role
+	}
+	if userUpdate.UserStatus != nil {
+		foundedUser.UserStatus = *userUpdate.UserStatus
+	}
+
+	if err := db.UpdateUserRoleAndStatus(id, foundedUser.UserRole, foundedUser.UserStatus, repository.DefaultOrganizationID); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if err := revokeRefreshToken(id); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	credentials, _ := roles_credentials.GetCredentialsByRole(foundedUser.UserRole)
+
+	return wrapper.SuccessResponse(c, "", models.NewUserProfile(foundedUser, credentials))
+}
+
+// ForceUserPasswordReset method to invalidate the password of a user and mail them a reset token.
+// @Description Invalidate the password of a user, sign them out and mail them a password reset token.
+// @Summary force a password reset
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/admin/users/{id}/password-reset [post]
+func ForceUserPasswordReset(c *fiber.Ctx) error {
+	if _, status, err := authorize(c, repository.UserManageCredential); err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	foundedUser, err := db.GetUserByID(id)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	// Replace the password with one nobody knows, so the old one stops working
+	// right away.
+	unusablePassword, _, err := random_token.Generate()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if err := db.UpdateUserPassword(id, password_generator.GeneratePassword(unusablePassword)); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if err := revokeRefreshToken(id); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if err := sendPasswordReset(db, foundedUser, time.Now()); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", "ok")
+}
+
+// DeleteUser method to delete a user account along with their books.
+// @Description Delete a user account along with their books and the history of those books. The user is scrubbed from the history of books others own.
+// @Summary delete user by given ID
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/admin/users/{id} [delete]
+func DeleteUser(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, repository.UserManageCredential)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	if id == claims.UserID {
+		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", errors.New(repository.SelfModificationErrorMessage))
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if _, err := db.GetUserByID(id); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	if err := db.DeleteUser(id); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if err := revokeRefreshToken(id); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", "ok")
+}
+
+// UnlockUser method to lift a sign in lockout of the given user.
+// @Description Lift a sign in lockout and reset failed attempt counters of the given user.
+// @Summary unlock user sign in
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/admin/users/{id}/unlock [post]
+func UnlockUser(c *fiber.Ctx) error {
+	if _, status, err := authorize(c, repository.UserManageCredential); err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	foundedUser, err := db.GetUserByID(id)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	if err := login_throttle.NewDefault().Unlock(context.Background(), foundedUser.Email); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", "ok")
+}
+
+// GetLoginAttempts method to list recorded sign in attempts for security review.
+// @Description List recorded sign in attempts, newest first.
+// @Summary list sign in attempts
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param email query string false "Email"
+// @Param ip_address query string false "IP address"
+// @Param limit query int false "Limit (default 100, max 500)"
+// @Success 200 {array} models.LoginAttempt
+// @Security ApiKeyAuth
+// @Router /v1/admin/login-attempts [get]
+func GetLoginAttempts(c *fiber.Ctx) error {
+	if _, status, err := authorize(c, repository.UserManageCredential); err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	filter := models.LoginAttemptFilter{}
+	if err := c.QueryParser(&filter); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(filter); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = 100
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	attempts, err := db.GetLoginAttempts(filter)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", attempts)
+}
+
+// revokeRefreshToken ends every session of the user so they have to sign in
+// again.
+func revokeRefreshToken(userID uuid.UUID) error {
+	store, err := refresh_session.NewDefault()
+	if err != nil {
+		return err
+	}
+
+	return refresh_session.RevokeAll(context.Background(), store, userID)
+}
+
+// sendPasswordReset replaces pending resets of the user with a new one and
+// mails its token.
+func sendPasswordReset(db *database.Queries, user models.User, now time.Time) error {
+	token, hash, err := random_token.Generate()
+	if err != nil {
+		return err
+	}
+
+	if err := db.DeletePasswordResets(user.ID); err != nil {
+		return err
+	}
+
+	hoursCount, err := strconv.Atoi(os.Getenv("PASSWORD_RESET_EXPIRE_HOURS"))
+	if err != nil || hoursCount <= 0 {
+		hoursCount = 24
+	}
+
+	reset := &models.PasswordReset{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: now.Add(time.Hour * time.Duration(hoursCount)),
+	}
+
+	if err := db.CreatePasswordReset(reset); err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf(
+		"Your password has been reset by an administrator. Choose a new one with this token (valid for %d hours):\n\n%s\n",
+		hoursCount, token,
+	)
+
+	return mailer.NewMailer().Send(user.Email, "Reset your password", body)
+}
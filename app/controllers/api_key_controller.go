@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/api_key"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/jwt"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/validator"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/wrapper"
+	"github.com/create-go-app/fiber-go-template/platform/database"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// CreateAPIKey method to mint a new named, scoped and expiring API key.
+// @Description Create a new API key. The plaintext key is returned only once.
+// @Summary create a new API key
+// @Tags APIKey
+// @Accept json
+// @Produce json
+// @Param request body models.APIKeyCreate true "API Key"
+// @Success 200 {object} models.APIKeyCreated
+// @Security ApiKeyAuth
+// @Router /v1/user/api-keys [post]
+func CreateAPIKey(c *fiber.Ctx) error {
+	now := time.Now()
+
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if now.Unix() > claims.Expires {
+		return wrapper.ErrorResponse(c, fiber.StatusUnauthorized, "", errors.New(repository.UnauthorizedErrorMessage))
+	}
+
+	if err := requirePersonalSession(claims); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", err)
+	}
+
+	if err := requireTenant(claims); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", err)
+	}
+
+	apiKeyCreate := &models.APIKeyCreate{}
+	if err := c.BodyParser(apiKeyCreate); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(apiKeyCreate); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	foundedUser, err := db.GetUserByID(claims.UserID)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	// Keys act in the organization the session is in, with at most the
+	// credentials the user holds there.
+	tenantID, roleCredentials, err := sessionTenant(db, foundedUser, claims.TenantID)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+	if tenantID != claims.TenantID {
+		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", errors.New(repository.NotMemberErrorMessage))
+	}
+
+	credentials := api_key.FilterCredentials(apiKeyCreate.Credentials, roleCredentials)
+	if len(credentials) != len(apiKeyCreate.Credentials) {
+		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", errors.New(repository.APIKeyCredentialsErrorMessage))
+	}
+
+	key, prefix, hash, err := api_key.GenerateAPIKey()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	apiKey := models.APIKey{
+		ID:          uuid.New(),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		UserID:      claims.UserID,
+		Name:        apiKeyCreate.Name,
+		KeyPrefix:   prefix,
+		KeyHash:     hash,
+		Credentials: credentials,
+		ExpiresAt:   now.AddDate(0, 0, apiKeyCreate.ExpiresInDays),
+		TenantID:    tenantID,
+	}
+
+	if err := db.CreateAPIKey(&apiKey); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", models.APIKeyCreated{
+		APIKey: apiKey,
+		Key:    key,
+	})
+}
+
+// GetAPIKeys method to list API keys of the current user.
+// @Description List API keys of the current user. Plaintext keys are never returned.
+// @Summary list API keys of the current user
+// @Tags APIKey
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.APIKey
+// @Security ApiKeyAuth
+// @Router /v1/user/api-keys [get]
+func GetAPIKeys(c *fiber.Ctx) error {
+	now := time.Now().Unix()
+
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if now > claims.Expires {
+		return wrapper.ErrorResponse(c, fiber.StatusUnauthorized, "", errors.New(repository.UnauthorizedErrorMessage))
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	keys, err := db.GetAPIKeysByUserID(claims.UserID)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", keys)
+}
+
+// RevokeAPIKey method to revoke an API key of the current user.
+// @Description Revoke an API key of the current user.
+// @Summary revoke an API key
+// @Tags APIKey
+// @Accept json
+// @Produce json
+// @Param id path string true "API Key ID"
+// @Success 200 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/user/api-keys/{id} [delete]
+func RevokeAPIKey(c *fiber.Ctx) error {
+	now := time.Now()
+
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if now.Unix() > claims.Expires {
+		return wrapper.ErrorResponse(c, fiber.StatusUnauthorized, "", errors.New(repository.UnauthorizedErrorMessage))
+	}
+
+	if err := requirePersonalSession(claims); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", err)
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if err := db.RevokeAPIKey(id, claims.UserID, now); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+		}
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", "ok")
+}
@@ -2,15 +2,24 @@ package controllers
 
 import (
 	"context"
+	"errors"
+	"log"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
 	"github.com/create-go-app/fiber-go-template/pkg/utils/jwt"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/login_throttle"
 	"github.com/create-go-app/fiber-go-template/pkg/utils/password_generator"
-	"github.com/create-go-app/fiber-go-template/pkg/utils/roles_credentials"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/random_token"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/refresh_session"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/session_cookie"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/text"
 	"github.com/create-go-app/fiber-go-template/pkg/utils/validator"
 	"github.com/create-go-app/fiber-go-template/pkg/utils/wrapper"
-	"github.com/create-go-app/fiber-go-template/platform/cache"
 	"github.com/create-go-app/fiber-go-template/platform/database"
 
 	"github.com/gofiber/fiber/v2"
@@ -18,13 +27,13 @@ import (
 )
 
 // UserSignUp method to create a new user.
-// @Description Create a new user.
+// @Description Create a new user with the user role. The response is the same whether or not the email is already registered.
 // @Summary create a new user
 // @Tags User
 // @Accept json
 // @Produce json
 // @Param request body models.SignUp true "Sign Up Request"
-// @Success 200 {object} models.SignUp
+// @Success 200 {object} models.SignUpResponse
 // @Router /v1/user/sign/up [post]
 func UserSignUp(c *fiber.Ctx) error {
 	signUp := &models.SignUp{}
@@ -35,7 +44,7 @@ func UserSignUp(c *fiber.Ctx) error {
 
 	validate := validator.NewValidator()
 	if err := validate.Struct(signUp); err != nil {
-		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
 	}
 
 	db, err := database.OpenDBConnection()
@@ -43,35 +52,50 @@ func UserSignUp(c *fiber.Ctx) error {
 		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
 	}
 
-	role, err := roles_credentials.VerifyRole(signUp.UserRole)
-	if err != nil {
-		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
-	}
-
-	user := &models.SignUp{}
-	user.Email = signUp.Email
-	user.Password = password_generator.GeneratePassword(signUp.Password)
-	user.UserRole = role
-
+	// Hash before the lookup so both branches cost the same time.
 	userCreate := &models.User{
 		ID:           uuid.New(),
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
-		Email:        user.Email,
-		PasswordHash: password_generator.GeneratePassword(user.Password),
+		Email:        signUp.Email,
+		PasswordHash: password_generator.GeneratePassword(signUp.Password),
 		UserStatus:   1, // 0 == blocked, 1 == active
-		UserRole:     user.UserRole,
+		UserRole:     repository.UserRoleName,
 	}
 
 	if err := validate.Struct(userCreate); err != nil {
 		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
 	}
 
+	response := models.SignUpResponse{
+		Email:    userCreate.Email,
+		UserRole: userCreate.UserRole,
+	}
+
+	if _, err := db.GetUserByEmail(userCreate.Email); err == nil {
+		return wrapper.SuccessResponse(c, repository.SignUpAcceptedMessage, response)
+	}
+
 	if err := db.CreateUser(userCreate); err != nil {
-		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+		// A concurrent sign up for the same email hits the unique constraint;
+		// answer it like any other existing address.
+		if _, errLookup := db.GetUserByEmail(userCreate.Email); errLookup == nil {
+			return wrapper.SuccessResponse(c, repository.SignUpAcceptedMessage, response)
+		}
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", errors.New(repository.InternalServerErrorMessage))
 	}
 
-	return wrapper.SuccessResponse(c, "", user)
+	// New users join the default organization as plain users.
+	if err := db.SaveOrganizationMember(&models.OrganizationMember{
+		OrganizationID: repository.DefaultOrganizationID,
+		UserID:         userCreate.ID,
+		MemberRole:     repository.UserRoleName,
+		CreatedAt:      userCreate.CreatedAt,
+	}); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", errors.New(repository.InternalServerErrorMessage))
+	}
+
+	return wrapper.SuccessResponse(c, repository.SignUpAcceptedMessage, response)
 }
 
 // UserSignIn method to auth user and return access and refresh tokens.
@@ -90,47 +114,108 @@ func UserSignIn(c *fiber.Ctx) error {
 		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
 	}
 
+	throttler := login_throttle.NewDefault()
+	ctx := context.Background()
+
+	retryAfter, err := throttler.Check(ctx, signIn.Email, c.IP())
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
 	db, err := database.OpenDBConnection()
 	if err != nil {
 		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
 	}
 
+	if retryAfter > 0 {
+		recordLoginAttempt(c, db, signIn.Email, nil, repository.LoginAttemptThrottled)
+		return throttledResponse(c, retryAfter)
+	}
+
 	foundedUser, err := db.GetUserByEmail(signIn.Email)
 	if err != nil {
-		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", err)
+		// Burn the same bcrypt time as a real comparison so unknown emails
+		// cannot be told apart by latency.
+		password_generator.CompareDummyPassword(signIn.Password)
+		recordLoginAttempt(c, db, signIn.Email, nil, repository.LoginAttemptUnknownEmail)
+		setRetryAfter(c, throttler, signIn.Email)
+		return invalidCredentialsResponse(c)
 	}
 
 	compareUserPassword := password_generator.ComparePasswords(foundedUser.PasswordHash, signIn.Password)
 	if !compareUserPassword {
-		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+		recordLoginAttempt(c, db, signIn.Email, &foundedUser.ID, repository.LoginAttemptWrongPassword)
+		setRetryAfter(c, throttler, signIn.Email)
+		return invalidCredentialsResponse(c)
+	}
+
+	if foundedUser.UserStatus != 1 {
+		recordLoginAttempt(c, db, signIn.Email, &foundedUser.ID, repository.LoginAttemptBlocked)
+		return invalidCredentialsResponse(c)
+	}
+
+	if err := throttler.RecordSuccess(ctx, signIn.Email); err != nil {
+		log.Printf("login throttle: failed to reset counters: %v", err)
 	}
+	recordLoginAttempt(c, db, signIn.Email, &foundedUser.ID, repository.LoginAttemptSucceeded)
 
-	credentials, err := roles_credentials.GetCredentialsByRole(foundedUser.UserRole)
+	tenantID, credentials, err := sessionTenant(db, foundedUser, uuid.Nil)
 	if err != nil {
 		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
 	}
 
-	tokens, err := jwt.GenerateNewTokens(foundedUser.ID.String(), credentials)
+	store, err := refresh_session.NewDefault()
 	if err != nil {
 		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
 	}
 
-	userID := foundedUser.ID.String()
+	return startSession(c, store, foundedUser.ID, tenantID, credentials)
+}
 
-	connRedis, err := cache.RedisConnection()
+// setRetryAfter counts a failed sign in and tells the client when it may try
+// again.
+func setRetryAfter(c *fiber.Ctx, throttler *login_throttle.Throttler, email string) {
+	retryAfter, err := throttler.RecordFailure(context.Background(), email, c.IP())
 	if err != nil {
-		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+		log.Printf("login throttle: failed to record failure: %v", err)
+		return
 	}
 
-	errSaveToRedis := connRedis.Set(context.Background(), userID, tokens.Refresh, 0).Err()
-	if errSaveToRedis != nil {
-		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", errSaveToRedis)
+	c.Set(fiber.HeaderRetryAfter, retryAfterSeconds(retryAfter))
+}
+
+// invalidCredentialsResponse is the only answer to a failed sign in, whatever
+// the reason, so it cannot be used to enumerate accounts.
+func invalidCredentialsResponse(c *fiber.Ctx) error {
+	return wrapper.ErrorResponse(c, fiber.StatusUnauthorized, "", errors.New(repository.InvalidCredentialsErrorMessage))
+}
+
+func throttledResponse(c *fiber.Ctx, retryAfter time.Duration) error {
+	c.Set(fiber.HeaderRetryAfter, retryAfterSeconds(retryAfter))
+	return wrapper.ErrorResponse(c, fiber.StatusTooManyRequests, "", errors.New(repository.TooManyAttemptsErrorMessage))
+}
+
+func retryAfterSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// recordLoginAttempt writes the audit entry. A failing write is logged but
+// does not change the outcome of the sign in.
+func recordLoginAttempt(c *fiber.Ctx, db *database.Queries, email string, userID *uuid.UUID, reason string) {
+	attempt := &models.LoginAttempt{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		Email:     strings.ToLower(strings.TrimSpace(email)),
+		UserID:    userID,
+		IPAddress: c.IP(),
+		UserAgent: text.Truncate(c.Get(fiber.HeaderUserAgent), 255),
+		Succeeded: reason == repository.LoginAttemptSucceeded,
+		Reason:    reason,
 	}
 
-	return wrapper.SuccessResponse(c, "", models.TokenResponse{
-		Access:  tokens.Access,
-		Refresh: tokens.Refresh,
-	})
+	if err := db.CreateLoginAttempt(attempt); err != nil {
+		log.Printf("login audit: failed to record attempt: %v", err)
+	}
 }
 
 // UserSignOut method to de-authorize user and delete refresh token from Redis.
@@ -148,16 +233,148 @@ func UserSignOut(c *fiber.Ctx) error {
 		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
 	}
 
-	userID := claims.UserID.String()
+	// Impersonation tokens have no session, signing out would end the
+	// sessions of the user.
+	if err := requirePersonalSession(claims); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", err)
+	}
+
+	store, err := refresh_session.NewDefault()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	// Tokens without a session ID predate sessions, so sign out everywhere.
+	if claims.SessionID != uuid.Nil {
+		err = refresh_session.Revoke(context.Background(), store, claims.UserID, claims.SessionID)
+	} else {
+		err = refresh_session.RevokeAll(context.Background(), store, claims.UserID)
+	}
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if cookieConfig := session_cookie.ConfigFromEnv(); cookieConfig.Enabled {
+		session_cookie.Clear(c, cookieConfig)
+	}
+
+	return wrapper.SuccessResponse(c, "", "ok")
+}
+
+// UserChangePassword method to change the password of the current user.
+// @Description Change the password of the current user and sign out other sessions.
+// @Summary change password of the current user
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param request body models.PasswordChange true "Password Change Request"
+// @Success 200 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/user/password [put]
+func UserChangePassword(c *fiber.Ctx) error {
+	now := time.Now().Unix()
+
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if now > claims.Expires {
+		return wrapper.ErrorResponse(c, fiber.StatusUnauthorized, "", errors.New(repository.UnauthorizedErrorMessage))
+	}
+
+	if err := requirePersonalSession(claims); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", err)
+	}
+
+	passwordChange := &models.PasswordChange{}
+	if err := c.BodyParser(passwordChange); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(passwordChange); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	foundedUser, err := db.GetUserByID(claims.UserID)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	if !password_generator.ComparePasswords(foundedUser.PasswordHash, passwordChange.CurrentPassword) {
+		return invalidCredentialsResponse(c)
+	}
+
+	passwordHash := password_generator.GeneratePassword(passwordChange.NewPassword)
+	if err := db.UpdateUserPassword(foundedUser.ID, passwordHash); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if err := revokeRefreshToken(foundedUser.ID); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", "ok")
+}
+
+// UserResetPassword method to set a new password with a password reset token.
+// @Description Set a new password with a password reset token. Every session of the user ends and any sign in lockout is lifted.
+// @Summary reset password
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param request body models.PasswordResetConfirm true "Password Reset Request"
+// @Success 200 {string} status "ok"
+// @Router /v1/user/password/reset [post]
+func UserResetPassword(c *fiber.Ctx) error {
+	resetConfirm := &models.PasswordResetConfirm{}
+	if err := c.BodyParser(resetConfirm); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(resetConfirm); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	reset, err := db.GetPasswordResetByHash(random_token.Hash(resetConfirm.Token))
+	if err != nil || time.Now().After(reset.ExpiresAt) {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(repository.InvalidVerificationTokenErrorMessage))
+	}
+
+	passwordHash := password_generator.GeneratePassword(resetConfirm.NewPassword)
+	if err := db.UpdateUserPassword(reset.UserID, passwordHash); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if err := db.DeletePasswordResets(reset.UserID); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
 
-	connRedis, err := cache.RedisConnection()
+	// Sessions started with the old password end, and a lockout earned by
+	// guessing it is lifted.
+	if err := revokeRefreshToken(reset.UserID); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	user, err := db.GetUserByID(reset.UserID)
 	if err != nil {
 		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
 	}
 
-	errDelFromRedis := connRedis.Del(context.Background(), userID).Err()
-	if errDelFromRedis != nil {
-		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", errDelFromRedis)
+	if err := login_throttle.NewDefault().Unlock(context.Background(), user.Email); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
 	}
 
 	return wrapper.SuccessResponse(c, "", "ok")
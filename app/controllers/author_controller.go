@@ -0,0 +1,421 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/book_policy"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/validator"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/wrapper"
+	"github.com/create-go-app/fiber-go-template/platform/database"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// GetAuthors func gets the authors of the organization.
+// @Description Get a page of the authors of the organization named by the X-Tenant header (the default organization without it), by name.
+// @Summary list authors
+// @Tags Author
+// @Accept json
+// @Produce json
+// @Param X-Tenant header string false "Organization slug"
+// @Param name query string false "Part of the name"
+// @Param page query int false "Page, from 1"
+// @Param limit query int false "Authors per page, 20 by default"
+// @Success 200 {object} models.AuthorPage
+// @Router /v1/authors [get]
+func GetAuthors(c *fiber.Ctx) error {
+	filter := models.AuthorFilter{}
+	if err := c.QueryParser(&filter); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(filter); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	tenantID, err := publicTenant(c, db)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	limit, offset := filter.LimitOffset()
+	authors, total, err := db.GetAuthors(tenantID, filter.Name, limit, offset)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", models.AuthorPage{
+		Authors: authors,
+		Total:   total,
+		Page:    offset/limit + 1,
+		Limit:   limit,
+	})
+}
+
+// GetAuthor func gets an author by given ID.
+// @Description Get an author of the organization named by the X-Tenant header (the default organization without it).
+// @Summary get author by given ID
+// @Tags Author
+// @Accept json
+// @Produce json
+// @Param id path string true "Author ID"
+// @Param X-Tenant header string false "Organization slug"
+// @Success 200 {object} models.Author
+// @Router /v1/authors/{id} [get]
+func GetAuthor(c *fiber.Ctx) error {
+	author, status, err := publicAuthor(c)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", author)
+}
+
+// GetAuthorBooks func gets the books of an author.
+// @Description Get the books credited to an author in any role, by title, in the organization named by the X-Tenant header (the default organization without it).
+// @Summary get books of an author
+// @Tags Author
+// @Accept json
+// @Produce json
+// @Param id path string true "Author ID"
+// @Param X-Tenant header string false "Organization slug"
+// @Success 200 {array} models.Book
+// @Router /v1/authors/{id}/books [get]
+func GetAuthorBooks(c *fiber.Ctx) error {
+	author, status, err := publicAuthor(c)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	books, err := db.GetBooksByAuthorID(author.TenantID, author.ID)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", books)
+}
+
+// CreateAuthor func creates an author.
+// @Description Create an author in the current organization. Requires book:create. Names are unique regardless of punctuation, spacing, case and "Last, First" order.
+// @Summary create an author
+// @Tags Author
+// @Accept json
+// @Produce json
+// @Param request body models.AuthorUpdate true "Author"
+// @Success 200 {object} models.Author
+// @Security ApiKeyAuth
+// @Router /v1/authors [post]
+func CreateAuthor(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, repository.BookCreateCredential)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	if err := requireTenant(claims); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", err)
+	}
+
+	authorUpdate, err := parseAuthorUpdate(c)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	now := time.Now()
+	author := models.Author{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		TenantID:  claims.TenantID,
+		Name:      authorUpdate.Name,
+	}
+
+	err = db.CreateAuthor(&author)
+	if errors.Is(err, sql.ErrNoRows) {
+		return wrapper.ErrorResponse(c, fiber.StatusConflict, "", errors.New(repository.AuthorExistsErrorMessage))
+	}
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", author)
+}
+
+// UpdateAuthor func renames an author.
+// @Description Rename an author of the current organization. Requires book:update:any. The author fields of the books credited to it are left as printed.
+// @Summary rename an author
+// @Tags Author
+// @Accept json
+// @Produce json
+// @Param id path string true "Author ID"
+// @Param request body models.AuthorUpdate true "Author"
+// @Success 200 {object} models.Author
+// @Security ApiKeyAuth
+// @Router /v1/authors/{id} [put]
+func UpdateAuthor(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, repository.BookUpdateAnyCredential)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	authorUpdate, err := parseAuthorUpdate(c)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	author, status, err := tenantAuthor(c, db, claims)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	namesake, err := db.GetAuthorByName(claims.TenantID, authorUpdate.Name)
+	if err == nil && namesake.ID != author.ID {
+		return wrapper.ErrorResponse(c, fiber.StatusConflict, "", errors.New(repository.AuthorExistsErrorMessage))
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	author.UpdatedAt = time.Now()
+	author.Name = authorUpdate.Name
+
+	err = db.UpdateAuthor(&author)
+	if errors.Is(err, sql.ErrNoRows) {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", author)
+}
+
+// DeleteAuthor func deletes an author.
+// @Description Delete an author of the current organization no book is credited to. Requires book:delete:any.
+// @Summary delete an author
+// @Tags Author
+// @Accept json
+// @Produce json
+// @Param id path string true "Author ID"
+// @Success 200 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/authors/{id} [delete]
+func DeleteAuthor(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, repository.BookDeleteAnyCredential)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	author, status, err := tenantAuthor(c, db, claims)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	// The author was found above, so no row means it is still credited.
+	err = db.DeleteAuthor(claims.TenantID, author.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return wrapper.ErrorResponse(c, fiber.StatusConflict, "", errors.New(repository.AuthorInUseErrorMessage))
+	}
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", "ok")
+}
+
+// GetBookAuthors func gets the credits of a book.
+// @Description Get the authors, editors and translators of a book in order, in the organization named by the X-Tenant header (the default organization without it).
+// @Summary get book authors
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param X-Tenant header string false "Organization slug"
+// @Success 200 {array} models.BookAuthor
+// @Router /v1/books/{id}/authors [get]
+func GetBookAuthors(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	tenantID, err := publicTenant(c, db)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	if _, err := db.GetBook(tenantID, id); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	authors, err := db.GetBookAuthors(tenantID, id)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", authors)
+}
+
+// SetBookAuthors func replaces the credits of a book.
+// @Description Replace the authors, editors and translators of a book, numbered in the order given. Credentials are checked as for replacing the book; the author field of the book is left as printed.
+// @Summary set book authors
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param request body models.BookAuthorsUpdate true "Credits"
+// @Success 200 {array} models.BookAuthor
+// @Security ApiKeyAuth
+// @Router /v1/books/{id}/authors [put]
+func SetBookAuthors(c *fiber.Ctx) error {
+	claims, status, err := authorizeBook(c, book_policy.Update)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	authorsUpdate := &models.BookAuthorsUpdate{}
+	if err := c.BodyParser(authorsUpdate); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(authorsUpdate); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	}
+
+	credited := map[models.BookAuthorUpdate]bool{}
+	for _, author := range authorsUpdate.Authors {
+		if credited[author] {
+			return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(repository.BookAuthorsErrorMessage))
+		}
+		credited[author] = true
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if _, _, status, err := authorizedBook(db, claims, id, book_policy.Update); err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	// The book was found above, so no row means an unknown author.
+	err = db.SetBookAuthors(claims.TenantID, id, authorsUpdate.Authors)
+	if errors.Is(err, sql.ErrNoRows) {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(repository.BookAuthorsErrorMessage))
+	}
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	authors, err := db.GetBookAuthors(claims.TenantID, id)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", authors)
+}
+
+// parseAuthorUpdate reads and validates the body of author writes.
+func parseAuthorUpdate(c *fiber.Ctx) (*models.AuthorUpdate, error) {
+	authorUpdate := &models.AuthorUpdate{}
+	if err := c.BodyParser(authorUpdate); err != nil {
+		return nil, err
+	}
+	authorUpdate.Name = strings.TrimSpace(authorUpdate.Name)
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(authorUpdate); err != nil {
+		return nil, errors.New(validator.ValidatorErrors(err))
+	}
+
+	return authorUpdate, nil
+}
+
+// publicAuthor loads the author named in the path from the organization of
+// an unauthenticated request.
+func publicAuthor(c *fiber.Ctx) (models.Author, int, error) {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return models.Author{}, fiber.StatusBadRequest, err
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return models.Author{}, fiber.StatusInternalServerError, err
+	}
+
+	tenantID, err := publicTenant(c, db)
+	if err != nil {
+		return models.Author{}, fiber.StatusNotFound, errors.New(repository.NotFoundErrorMessage)
+	}
+
+	author, err := db.GetAuthor(tenantID, id)
+	if err != nil {
+		return models.Author{}, fiber.StatusNotFound, errors.New(repository.NotFoundErrorMessage)
+	}
+
+	return author, 0, nil
+}
+
+// tenantAuthor loads the author named in the path from the organization of
+// the token.
+func tenantAuthor(c *fiber.Ctx, db *database.Queries, claims *models.TokenMetadata) (models.Author, int, error) {
+	if err := requireTenant(claims); err != nil {
+		return models.Author{}, fiber.StatusForbidden, err
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return models.Author{}, fiber.StatusBadRequest, err
+	}
+
+	author, err := db.GetAuthor(claims.TenantID, id)
+	if err != nil {
+		return models.Author{}, fiber.StatusNotFound, errors.New(repository.NotFoundErrorMessage)
+	}
+
+	return author, 0, nil
+}
@@ -0,0 +1,394 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/book_policy"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/validator"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/wrapper"
+	"github.com/create-go-app/fiber-go-template/platform/database"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// bookTransferTTL is how long a recipient has to accept a transfer.
+const bookTransferTTL = 7 * 24 * time.Hour
+
+// GetBookCollaborators func gets who a book is shared with.
+// @Description List the collaborators of a book and its pending ownership transfer. Open to the owner holding book:update, collaborators and holders of book:update:any.
+// @Summary list book collaborators
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Success 200 {object} models.BookSharing
+// @Security ApiKeyAuth
+// @Router /v1/books/{id}/collaborators [get]
+func GetBookCollaborators(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, "")
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if _, _, status, err := authorizedBook(db, claims, id, book_policy.ViewCollaborators); err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	collaborators, err := db.GetBookCollaborators(claims.TenantID, id)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	sharing := models.BookSharing{Collaborators: collaborators}
+
+	transfer, err := db.GetBookTransfer(claims.TenantID, id)
+	if err == nil && time.Now().Before(transfer.ExpiresAt) {
+		sharing.Transfer = &transfer
+	} else if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", sharing)
+}
+
+// SaveBookCollaborator func shares a book with a user or changes their role.
+// @Description Share a book with another member of the organization as editor or viewer, or change their role. Open to the owner holding book:update and holders of book:update:any.
+// @Summary add or update a book collaborator
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param request body models.BookCollaboratorUpdate true "Collaborator"
+// @Success 200 {object} models.BookCollaborator
+// @Security ApiKeyAuth
+// @Router /v1/books/{id}/collaborators [put]
+func SaveBookCollaborator(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, "")
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	collaboratorUpdate := &models.BookCollaboratorUpdate{}
+	if err := c.BodyParser(collaboratorUpdate); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(collaboratorUpdate); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	foundedBook, _, status, err := authorizedBook(db, claims, id, book_policy.Share)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	if collaboratorUpdate.UserID == foundedBook.UserID {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(repository.CollaboratorTargetErrorMessage))
+	}
+
+	member, err := isMember(db, collaboratorUpdate.UserID, claims.TenantID)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+	if !member {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(repository.CollaboratorTargetErrorMessage))
+	}
+
+	collaborator := models.BookCollaborator{
+		BookID:           foundedBook.ID,
+		UserID:           collaboratorUpdate.UserID,
+		CollaboratorRole: collaboratorUpdate.CollaboratorRole,
+		CreatedAt:        time.Now(),
+	}
+
+	if err := db.SaveBookCollaborator(claims.TenantID, &collaborator); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", collaborator)
+}
+
+// DeleteBookCollaborator func stops sharing a book with a user.
+// @Description Stop sharing a book with a user. Open to the owner holding book:update, holders of book:update:any and collaborators removing themselves.
+// @Summary remove a book collaborator
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param user_id path string true "User ID"
+// @Success 200 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/books/{id}/collaborators/{user_id} [delete]
+func DeleteBookCollaborator(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, "")
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	userID, err := uuid.Parse(c.Params("user_id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	// Collaborators may always leave a book.
+	if userID != claims.UserID {
+		if _, _, status, err := authorizedBook(db, claims, id, book_policy.Share); err != nil {
+			return wrapper.ErrorResponse(c, status, "", err)
+		}
+	}
+
+	if err := db.DeleteBookCollaborator(claims.TenantID, id, userID); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	return wrapper.SuccessResponse(c, "", "ok")
+}
+
+// TransferBook func offers the ownership of a book to another user.
+// @Description Offer the ownership of a book to another member of the organization. The transfer happens once they accept it, within seven days. A new offer replaces the pending one. The owner needs book:update.
+// @Summary offer book ownership
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param request body models.BookTransferCreate true "Recipient"
+// @Success 200 {object} models.BookTransfer
+// @Security ApiKeyAuth
+// @Router /v1/books/{id}/transfer [post]
+func TransferBook(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, "")
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	transferCreate := &models.BookTransferCreate{}
+	if err := c.BodyParser(transferCreate); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(transferCreate); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	foundedBook, _, status, err := authorizedBook(db, claims, id, book_policy.Transfer)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	if transferCreate.UserID == foundedBook.UserID {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(repository.TransferTargetErrorMessage))
+	}
+
+	member, err := isMember(db, transferCreate.UserID, claims.TenantID)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+	if !member {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(repository.TransferTargetErrorMessage))
+	}
+
+	now := time.Now()
+	transfer := models.BookTransfer{
+		BookID:     foundedBook.ID,
+		CreatedAt:  now,
+		FromUserID: foundedBook.UserID,
+		ToUserID:   transferCreate.UserID,
+		ExpiresAt:  now.Add(bookTransferTTL),
+	}
+
+	if err := db.SaveBookTransfer(claims.TenantID, &transfer); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", transfer)
+}
+
+// AcceptBookTransfer func takes over the ownership of a book offered to the current user.
+// @Description Accept the ownership of a book offered to the current user.
+// @Summary accept book ownership
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Success 200 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/books/{id}/transfer/accept [post]
+func AcceptBookTransfer(c *fiber.Ctx) error {
+	now := time.Now()
+
+	claims, status, err := authorize(c, "")
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	if err := requirePersonalSession(claims); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", err)
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	transfer, err := db.GetBookTransfer(claims.TenantID, id)
+	if err != nil || transfer.ToUserID != claims.UserID || !now.Before(transfer.ExpiresAt) {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	err = db.AcceptBookTransfer(claims.TenantID, transfer, now)
+	if errors.Is(err, sql.ErrNoRows) {
+		// The book changed hands after the offer was made.
+		return wrapper.ErrorResponse(c, fiber.StatusConflict, "", errors.New(repository.NotFoundErrorMessage))
+	}
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", "ok")
+}
+
+// CancelBookTransfer func withdraws or declines an ownership transfer.
+// @Description Withdraw a pending ownership transfer as the owner, or decline it as the recipient.
+// @Summary cancel book ownership transfer
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Success 200 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/books/{id}/transfer [delete]
+func CancelBookTransfer(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, "")
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	transfer, err := db.GetBookTransfer(claims.TenantID, id)
+	if err != nil || (transfer.FromUserID != claims.UserID && transfer.ToUserID != claims.UserID) {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	if err := db.DeleteBookTransfer(claims.TenantID, id); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	return wrapper.SuccessResponse(c, "", "ok")
+}
+
+// GetSharedBooks func gets the books shared with the current user.
+// @Description List the books of the current organization shared with the current user.
+// @Summary list books shared with the current user
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.Book
+// @Security ApiKeyAuth
+// @Router /v1/user/books/shared [get]
+func GetSharedBooks(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, "")
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	books, err := db.GetSharedBooks(claims.TenantID, claims.UserID)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", books)
+}
+
+// GetBookTransfers func gets the ownership transfers offered to the current user.
+// @Description List the pending ownership transfers offered to the current user in the current organization.
+// @Summary list book transfers offered to the current user
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.BookTransfer
+// @Security ApiKeyAuth
+// @Router /v1/user/book-transfers [get]
+func GetBookTransfers(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, "")
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	transfers, err := db.GetBookTransfersTo(claims.TenantID, claims.UserID, time.Now())
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", transfers)
+}
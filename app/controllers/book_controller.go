@@ -1,49 +1,95 @@
 package controllers
 
 import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
 	"errors"
+	"log"
+	"strings"
 	"time"
 
 	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/app/queries"
 	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/book_policy"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/etag"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/isbn"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/json_patch"
 	"github.com/create-go-app/fiber-go-template/pkg/utils/jwt"
 	"github.com/create-go-app/fiber-go-template/pkg/utils/validator"
 	"github.com/create-go-app/fiber-go-template/pkg/utils/wrapper"
 	"github.com/create-go-app/fiber-go-template/platform/database"
+	"github.com/create-go-app/fiber-go-template/platform/storage"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
 
 // GetBooks func gets all exists books.
-// @Description Get all exists books.
+// @Description Get all exists books of the organization named by the X-Tenant header (the default organization without it).
 // @Summary get all exists books
 // @Tags Books
 // @Accept json
 // @Produce json
+// @Param X-Tenant header string false "Organization slug"
+// @Param tag query string false "Tag"
+// @Param category query string false "Category ID, matching its subcategories too"
+// @Param sort query string false "rating to list the best rated books first"
+// @Param include query string false "shelves to list the shelves of the caller holding each book"
 // @Success 200 {array} models.Book
 // @Router /v1/books [get]
 func GetBooks(c *fiber.Ctx) error {
+	filter := models.BookListFilter{}
+	if err := c.QueryParser(&filter); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+	filter.Tag = normalizeTag(filter.Tag)
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(filter); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	}
+
 	db, err := database.OpenDBConnection()
 	if err != nil {
 		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
 	}
 
-	books, err := db.GetBooks()
+	tenantID, err := publicTenant(c, db)
 	if err != nil {
 		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
 	}
 
+	var books []models.Book
+	if filter == (models.BookListFilter{}) {
+		books, err = db.GetBooks(tenantID)
+	} else {
+		books, err = db.SearchBooks(tenantID, filter)
+	}
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	if status, err := withShelves(c, db, tenantID, books); err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
 	return wrapper.SuccessResponse(c, "", books)
 }
 
 // GetBook func gets book by given ID or 404 error.
-// @Description Get book by given ID.
+// @Description Get book by given ID in the organization named by the X-Tenant header (the default organization without it).
 // @Summary get book by given ID
 // @Tags Book
 // @Accept json
 // @Produce json
 // @Param id path string true "Book ID"
+// @Param X-Tenant header string false "Organization slug"
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Param include query string false "shelves to list the shelves of the caller holding the book, the answer then has no ETag"
 // @Success 200 {object} models.Book
+// @Success 304 "Not Modified"
+// @Router /v1/books/{id} [get]
 // @Router /v1/book/{id} [get]
 func GetBook(c *fiber.Ctx) error {
 	id, err := uuid.Parse(c.Params("id"))
@@ -56,16 +102,85 @@ func GetBook(c *fiber.Ctx) error {
 		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
 	}
 
-	book, err := db.GetBook(id)
+	tenantID, err := publicTenant(c, db)
 	if err != nil {
 		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
 	}
 
-	return wrapper.SuccessResponse(c, "", book)
+	book, err := db.GetBook(tenantID, id)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	// The shelves of the caller are not part of the version, so the answer
+	// listing them is not cached by it.
+	if c.Query("include") != "shelves" {
+		tag := etag.FromVersion(book.Version)
+		c.Set(fiber.HeaderETag, tag)
+		if etag.NoneMatch(c.Get(fiber.HeaderIfNoneMatch), tag) {
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+	}
+
+	books := []models.Book{book}
+	if status, err := withShelves(c, db, tenantID, books); err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", books[0])
+}
+
+// GetBookByISBN func gets book by given ISBN or 404 error.
+// @Description Get book by given ISBN-10 or ISBN-13, hyphenated or not, in the organization named by the X-Tenant header (the default organization without it).
+// @Summary get book by given ISBN
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Param isbn path string true "ISBN"
+// @Param X-Tenant header string false "Organization slug"
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Param include query string false "shelves to list the shelves of the caller holding the book, the answer then has no ETag"
+// @Success 200 {object} models.Book
+// @Success 304 "Not Modified"
+// @Router /v1/books/isbn/{isbn} [get]
+func GetBookByISBN(c *fiber.Ctx) error {
+	if !isbn.Valid(c.Params("isbn")) {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(repository.InvalidISBNErrorMessage))
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	tenantID, err := publicTenant(c, db)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	book, err := db.GetBookByISBN(tenantID, isbn.Normalize(c.Params("isbn")))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	if c.Query("include") != "shelves" {
+		tag := etag.FromVersion(book.Version)
+		c.Set(fiber.HeaderETag, tag)
+		if etag.NoneMatch(c.Get(fiber.HeaderIfNoneMatch), tag) {
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+	}
+
+	books := []models.Book{book}
+	if status, err := withShelves(c, db, tenantID, books); err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", books[0])
 }
 
 // CreateBook func for creates a new book.
-// @Description Create a new book.
+// @Description Create a new book. ISBN-10s are stored as ISBN-13s, and an ISBN may only be used by one book of the organization.
 // @Summary create a new book
 // @Tags Book
 // @Accept json
@@ -92,6 +207,10 @@ func CreateBook(c *fiber.Ctx) error {
 		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", errors.New(repository.ForbiddenErrorMessage))
 	}
 
+	if err := requireTenant(claims); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", err)
+	}
+
 	book := &models.BookCreate{}
 
 	if err := c.BodyParser(book); err != nil {
@@ -113,123 +232,443 @@ func CreateBook(c *fiber.Ctx) error {
 	bookCreate.Title = book.Title
 	bookCreate.Author = book.Author
 	bookCreate.BookAttrs = book.BookAttrs
+	bookCreate.BookMetadata = book.BookMetadata
 	bookCreate.BookStatus = 1 // 0 == draft, 1 == active
+	bookCreate.TenantID = claims.TenantID
+	bookCreate.Version = 1
 
 	if err := validate.Struct(book); err != nil {
 		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
 	}
 
-	if err := db.CreateBook(bookCreate); err != nil {
-		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	bookCreate.ISBN = isbn.Normalize(bookCreate.ISBN)
+	if status, err := checkISBN(db, bookCreate.TenantID, bookCreate.ID, bookCreate.ISBN); err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	if err := db.CreateBook(bookCreate, models.BookChange{ActorID: claims.UserID}); err != nil {
+		status, err := saveBookError(err)
+		return wrapper.ErrorResponse(c, status, "", err)
 	}
 
 	return wrapper.SuccessResponse(c, "", book)
 }
 
-// UpdateBook func for updates book by given ID.
-// @Description Update book.
-// @Summary update book
+// UpdateBook func for replaces book by given ID.
+// @Description Replace the editable fields of a book. Owners need book:update, editors the book was shared with need nothing more, anyone else needs book:update:any and the change is recorded. PUT /v1/book, taking the ID from the body, is deprecated.
+// @Summary replace book
 // @Tags Book
 // @Accept json
 // @Produce json
-// @Param request body models.BookUpdate true "Book"
-// @Success 201 {object} models.Book
+// @Param id path string true "Book ID"
+// @Param If-Match header string false "ETag the change is based on"
+// @Param request body models.BookReplace true "Book"
+// @Success 200 {object} models.Book
 // @Security ApiKeyAuth
-// @Router /v1/book [put]
+// @Router /v1/books/{id} [put]
 func UpdateBook(c *fiber.Ctx) error {
-	now := time.Now().Unix()
+	claims, status, err := authorizeBook(c, book_policy.Update)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
 
-	claims, err := jwt.ExtractTokenMetadata(c)
+	book := &models.BookUpdate{}
+	if err := c.BodyParser(book); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	if c.Params("id") != "" {
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+		}
+		if book.ID != uuid.Nil && book.ID != id {
+			return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(repository.BookIDMismatchErrorMessage))
+		}
+		book.ID = id
+	}
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(book); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	}
+
+	db, err := database.OpenDBConnection()
 	if err != nil {
 		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
 	}
 
-	expires := claims.Expires
-	if now > expires {
-		return wrapper.ErrorResponse(c, fiber.StatusUnauthorized, "", errors.New(repository.UnauthorizedErrorMessage))
+	foundedBook, grant, status, err := editableBook(c, db, claims, book.ID, book_policy.Update)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
 	}
 
-	credential := claims.Credentials[repository.BookUpdateCredential]
-	if !credential {
-		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", errors.New(repository.ForbiddenErrorMessage))
+	return saveBook(c, db, claims, foundedBook, grant, models.BookReplace{
+		Title:        book.Title,
+		Author:       book.Author,
+		BookStatus:   book.BookStatus,
+		BookAttrs:    book.BookAttrs,
+		BookMetadata: book.BookMetadata,
+	})
+}
+
+// PatchBook func for partially updates book by given ID.
+// @Description Update some fields of a book, including single book_attrs members, with a JSON Merge Patch (application/merge-patch+json) or a JSON Patch (application/json-patch+json). Credentials are checked as for replacing.
+// @Summary patch book
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param If-Match header string false "ETag the change is based on"
+// @Param request body object true "Merge patch or JSON patch"
+// @Success 200 {object} models.Book
+// @Security ApiKeyAuth
+// @Router /v1/books/{id} [patch]
+func PatchBook(c *fiber.Ctx) error {
+	claims, status, err := authorizeBook(c, book_policy.Update)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
 	}
 
-	book := &models.BookUpdate{}
-	if err := c.BodyParser(book); err != nil {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
 		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
 	}
 
+	var applyPatch func(document, patch []byte) ([]byte, error)
+	mediaType, _, _ := strings.Cut(c.Get(fiber.HeaderContentType), ";")
+	switch strings.ToLower(strings.TrimSpace(mediaType)) {
+	case json_patch.MergePatchContentType, fiber.MIMEApplicationJSON:
+		applyPatch = json_patch.MergePatch
+	case json_patch.JSONPatchContentType:
+		applyPatch = json_patch.Apply
+	default:
+		return wrapper.ErrorResponse(c, fiber.StatusUnsupportedMediaType, "", errors.New(repository.UnsupportedPatchErrorMessage))
+	}
+
 	db, err := database.OpenDBConnection()
 	if err != nil {
 		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
 	}
 
-	foundedBook, err := db.GetBook(book.ID)
+	foundedBook, grant, status, err := editableBook(c, db, claims, id, book_policy.Update)
 	if err != nil {
-		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+		return wrapper.ErrorResponse(c, status, "", err)
 	}
 
-	userID := claims.UserID
+	document, err := json.Marshal(foundedBook.Editable())
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
 
-	if foundedBook.UserID == userID {
-		bookUpdate := &models.Book{}
-		bookUpdate.ID = book.ID
-		bookUpdate.UpdatedAt = time.Now()
-		bookUpdate.Title = book.Title
-		bookUpdate.Author = book.Author
-		bookUpdate.BookAttrs = book.BookAttrs
-		validate := validator.NewValidator()
-		if err := validate.Struct(book); err != nil {
-			return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	patched, err := applyPatch(document, c.Body())
+	if errors.Is(err, json_patch.ErrTestFailed) {
+		return wrapper.ErrorResponse(c, fiber.StatusConflict, "", err)
+	}
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusUnprocessableEntity, "", err)
+	}
+
+	// Patches may only touch editable fields, anything else is refused
+	// instead of being silently dropped.
+	book := models.BookReplace{}
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&book); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusUnprocessableEntity, "", err)
+	}
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(book); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	}
+
+	return saveBook(c, db, claims, foundedBook, grant, book)
+}
+
+// authorizeBook checks the token like authorize and that its credentials
+// allow the action on at least some books.
+func authorizeBook(c *fiber.Ctx, action book_policy.Action) (*models.TokenMetadata, int, error) {
+	claims, status, err := authorize(c, "")
+	if err != nil {
+		return nil, status, err
+	}
+
+	if !book_policy.Permits(claims.Credentials, action) {
+		return nil, fiber.StatusForbidden, errors.New(repository.ForbiddenErrorMessage)
+	}
+
+	return claims, 0, nil
+}
+
+// authorizedBook loads a book of the current organization that the policy
+// lets the user act on. On failure it returns the status code to answer with.
+func authorizedBook(db *database.Queries, claims *models.TokenMetadata, id uuid.UUID, action book_policy.Action) (models.Book, book_policy.Grant, int, error) {
+	foundedBook, err := db.GetBook(claims.TenantID, id)
+	if err != nil {
+		return foundedBook, book_policy.Denied, fiber.StatusNotFound, errors.New(repository.NotFoundErrorMessage)
+	}
+
+	collaboratorRole := ""
+	if foundedBook.UserID != claims.UserID {
+		collaboratorRole, err = db.GetCollaboratorRole(claims.TenantID, id, claims.UserID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return foundedBook, book_policy.Denied, fiber.StatusInternalServerError, err
 		}
+	}
+
+	relation := book_policy.RelationOf(claims.UserID, foundedBook, collaboratorRole)
+	grant := book_policy.Decide(claims.Credentials, action, relation)
+	if grant == book_policy.Denied {
+		return foundedBook, grant, fiber.StatusForbidden, errors.New(repository.ForbiddenDataModificationErrorMessage)
+	}
+
+	return foundedBook, grant, 0, nil
+}
 
-		if err := db.UpdateBook(foundedBook.ID, bookUpdate); err != nil {
-			return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+// editableBook loads a book like authorizedBook, as long as it is still the
+// version named by the If-Match header.
+func editableBook(c *fiber.Ctx, db *database.Queries, claims *models.TokenMetadata, id uuid.UUID, action book_policy.Action) (models.Book, book_policy.Grant, int, error) {
+	foundedBook, grant, status, err := authorizedBook(db, claims, id, action)
+	if err != nil {
+		return foundedBook, grant, status, err
+	}
+
+	if !etag.Match(c.Get(fiber.HeaderIfMatch), etag.FromVersion(foundedBook.Version)) {
+		return foundedBook, grant, fiber.StatusPreconditionFailed, errors.New(repository.PreconditionFailedErrorMessage)
+	}
+
+	return foundedBook, grant, 0, nil
+}
+
+// bookAudit returns the record of a change to someone else's book, or nil
+// for changes owners make to their own books. after is nil for deletions.
+func bookAudit(claims *models.TokenMetadata, grant book_policy.Grant, action book_policy.Action, before models.Book, after *models.Book) (*models.BookAuditLog, error) {
+	if grant != book_policy.AsModerator {
+		return nil, nil
+	}
+
+	beforeJSON, err := json.Marshal(before)
+	if err != nil {
+		return nil, err
+	}
+
+	var afterJSON json.RawMessage
+	if after != nil {
+		if afterJSON, err = json.Marshal(after); err != nil {
+			return nil, err
 		}
+	}
 
-		return wrapper.SuccessResponse(c, "", book)
-	} else {
-		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", errors.New(repository.ForbiddenDataModificationErrorMessage))
+	actorID, ownerID := claims.UserID, before.UserID
+
+	return &models.BookAuditLog{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		TenantID:  before.TenantID,
+		BookID:    before.ID,
+		OwnerID:   &ownerID,
+		ActorID:   &actorID,
+		Action:    action.String(),
+		Before:    beforeJSON,
+		After:     afterJSON,
+	}, nil
+}
+
+// saveBook writes the changed fields and responds with the updated book. The
+// write fails with 412 when someone else changed the book after it was read.
+func saveBook(c *fiber.Ctx, db *database.Queries, claims *models.TokenMetadata, book models.Book, grant book_policy.Grant, changes models.BookReplace) error {
+	book, status, err := updateBook(db, claims, book, grant, changes)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
 	}
+
+	c.Set(fiber.HeaderETag, etag.FromVersion(book.Version))
+
+	return wrapper.SuccessResponse(c, "", book)
+}
+
+// updateBook writes the changed fields and returns the updated book. On
+// failure it returns the status code to answer with.
+func updateBook(db *database.Queries, claims *models.TokenMetadata, book models.Book, grant book_policy.Grant, changes models.BookReplace) (models.Book, int, error) {
+	return changeBook(db, claims, book, grant, changes, 0)
+}
+
+// changeBook is updateBook for changes restoring the revision restoredFrom,
+// or none when it is 0.
+func changeBook(db *database.Queries, claims *models.TokenMetadata, book models.Book, grant book_policy.Grant, changes models.BookReplace, restoredFrom int) (models.Book, int, error) {
+	before := book
+
+	book.UpdatedAt = time.Now()
+	book.Title = changes.Title
+	book.Author = changes.Author
+	book.BookStatus = changes.BookStatus
+	book.BookAttrs = changes.BookAttrs
+	book.BookMetadata = changes.BookMetadata
+	book.ISBN = isbn.Normalize(book.ISBN)
+
+	if book.ISBN != before.ISBN {
+		if status, err := checkISBN(db, claims.TenantID, book.ID, book.ISBN); err != nil {
+			return before, status, err
+		}
+	}
+
+	action := book_policy.Update
+	if restoredFrom > 0 {
+		action = book_policy.Restore
+	}
+
+	audit, err := bookAudit(claims, grant, action, before, &book)
+	if err != nil {
+		return before, fiber.StatusInternalServerError, err
+	}
+
+	change := models.BookChange{ActorID: claims.UserID, RestoredFrom: restoredFrom, Audit: audit}
+	err = db.UpdateBook(claims.TenantID, book.ID, &book, change)
+	if errors.Is(err, sql.ErrNoRows) {
+		return before, fiber.StatusPreconditionFailed, errors.New(repository.PreconditionFailedErrorMessage)
+	}
+	if err != nil {
+		status, err := saveBookError(err)
+		return before, status, err
+	}
+
+	book.Version++
+
+	return book, 0, nil
+}
+
+// checkISBN makes sure no other book of the organization has the ISBN. On
+// failure it returns the status code to answer with.
+func checkISBN(db *database.Queries, tenantID, bookID uuid.UUID, code string) (int, error) {
+	if code == "" {
+		return 0, nil
+	}
+
+	other, err := db.GetBookByISBN(tenantID, code)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return fiber.StatusInternalServerError, err
+	}
+	if other.ID != bookID {
+		return fiber.StatusConflict, errors.New(repository.ISBNExistsErrorMessage)
+	}
+
+	return 0, nil
+}
+
+// saveBookError returns the status code and error to answer a failed insert
+// or update of a book with. The unique index on ISBNs still refuses a book
+// whose ISBN another request took after checkISBN.
+func saveBookError(err error) (int, error) {
+	if queries.IsISBNTaken(err) {
+		return fiber.StatusConflict, errors.New(repository.ISBNExistsErrorMessage)
+	}
+
+	return fiber.StatusInternalServerError, err
 }
 
 // DeleteBook func for deletes book by given ID.
-// @Description Delete book by given ID.
+// @Description Delete book by given ID. Owners need book:delete, anyone else book:delete:any and the deletion is recorded. The files of an uploaded cover are deleted with the book. DELETE /v1/book, taking the ID from the body, is deprecated.
 // @Summary delete book by given ID
 // @Tags Book
 // @Accept json
 // @Produce json
-// @Param request body models.BookDelete true "Book ID"
+// @Param id path string true "Book ID"
+// @Param If-Match header string false "ETag the deletion is based on"
 // @Success 200 {string} status "ok"
 // @Security ApiKeyAuth
-// @Router /v1/book [delete]
+// @Router /v1/books/{id} [delete]
 func DeleteBook(c *fiber.Ctx) error {
-	now := time.Now().Unix()
+	claims, status, err := authorizeBook(c, book_policy.Delete)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
 
-	claims, err := jwt.ExtractTokenMetadata(c)
+	book := &models.BookDelete{}
+	if c.Params("id") != "" {
+		if book.ID, err = uuid.Parse(c.Params("id")); err != nil {
+			return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+		}
+	} else {
+		if err := c.BodyParser(book); err != nil {
+			return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+		}
+
+		validate := validator.NewValidator()
+		if err := validate.StructPartial(book, "id"); err != nil {
+			return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+		}
+	}
+
+	db, err := database.OpenDBConnection()
 	if err != nil {
 		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
 	}
 
-	expires := claims.Expires
-	if now > expires {
-		return wrapper.ErrorResponse(c, fiber.StatusUnauthorized, "", errors.New(repository.UnauthorizedErrorMessage))
+	foundedBook, grant, status, err := editableBook(c, db, claims, book.ID, book_policy.Delete)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
 	}
 
-	credential := claims.Credentials[repository.BookDeleteCredential]
-	if !credential {
-		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", errors.New(repository.ForbiddenErrorMessage))
+	audit, err := bookAudit(claims, grant, book_policy.Delete, foundedBook, nil)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
 	}
 
-	book := &models.BookDelete{}
+	err = db.DeleteBook(claims.TenantID, foundedBook.ID, foundedBook.Version, models.BookChange{ActorID: claims.UserID, Audit: audit})
+	if errors.Is(err, sql.ErrNoRows) {
+		return wrapper.ErrorResponse(c, fiber.StatusPreconditionFailed, "", errors.New(repository.PreconditionFailedErrorMessage))
+	}
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
 
-	if err := c.BodyParser(book); err != nil {
+	if store, err := storage.NewBlobStore(); err != nil {
+		log.Printf("cover: %v", err)
+	} else {
+		deleteBlobs(store, coverKeys(store, foundedBook))
+	}
+
+	return wrapper.SuccessResponse(c, "", "ok")
+}
+
+// GetBookAuditLogs func gets changes made to books by users who do not own them.
+// @Description List changes made to books of the current organization by users acting through book:update:any or book:delete:any, newest first.
+// @Summary list book audit log
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param book_id query string false "Book ID"
+// @Param actor_id query string false "Acting user ID"
+// @Param limit query int false "Number of entries (default 100, max 500)"
+// @Success 200 {array} models.BookAuditLog
+// @Security ApiKeyAuth
+// @Router /v1/admin/book-audit-logs [get]
+func GetBookAuditLogs(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, repository.UserManageCredential)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	if err := requireTenant(claims); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", err)
+	}
+
+	filter := models.BookAuditLogFilter{}
+	if err := c.QueryParser(&filter); err != nil {
 		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
 	}
 
 	validate := validator.NewValidator()
-	if err := validate.StructPartial(book, "id"); err != nil {
-		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	if err := validate.Struct(filter); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = 100
 	}
 
 	db, err := database.OpenDBConnection()
@@ -237,19 +676,10 @@ func DeleteBook(c *fiber.Ctx) error {
 		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
 	}
 
-	foundedBook, err := db.GetBook(book.ID)
+	logs, err := db.GetBookAuditLogs(claims.TenantID, filter)
 	if err != nil {
-		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
 	}
 
-	userID := claims.UserID
-	if foundedBook.UserID == userID {
-		if err := db.DeleteBook(foundedBook.ID); err != nil {
-			return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
-		}
-
-		return wrapper.SuccessResponse(c, "", "ok")
-	} else {
-		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", errors.New(repository.ForbiddenDataModificationErrorMessage))
-	}
+	return wrapper.SuccessResponse(c, "", logs)
 }
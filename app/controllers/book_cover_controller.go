@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/book_policy"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/cover_image"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/etag"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/wrapper"
+	"github.com/create-go-app/fiber-go-template/platform/database"
+	"github.com/create-go-app/fiber-go-template/platform/storage"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// UploadBookCover func replaces the cover of a book.
+// @Description Upload the cover of a book as the "cover" field of a multipart form. JPEG, PNG and GIF images up to COVER_MAX_BYTES are accepted, whatever their declared type. The image is re-encoded, thumbnails are made in the COVER_THUMBNAIL_WIDTHS, and book_attrs.picture and book_attrs.thumbnails are set to their URLs. The files of the previous cover are deleted. Credentials are checked as for replacing the book.
+// @Summary upload book cover
+// @Tags Book
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param If-Match header string false "ETag the change is based on"
+// @Param cover formData file true "Cover image"
+// @Success 200 {object} models.Book
+// @Security ApiKeyAuth
+// @Router /v1/books/{id}/cover [post]
+func UploadBookCover(c *fiber.Ctx) error {
+	claims, status, err := authorizeBook(c, book_policy.Update)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	fileHeader, err := c.FormFile("cover")
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	maxBytes := cover_image.MaxBytes()
+	if fileHeader.Size > maxBytes {
+		return wrapper.ErrorResponse(c, fiber.StatusRequestEntityTooLarge, "", errors.New(repository.CoverTooLargeErrorMessage))
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+	if int64(len(data)) > maxBytes {
+		return wrapper.ErrorResponse(c, fiber.StatusRequestEntityTooLarge, "", errors.New(repository.CoverTooLargeErrorMessage))
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	foundedBook, grant, status, err := editableBook(c, db, claims, id, book_policy.Update)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	cover, err := cover_image.Process(data, cover_image.ThumbnailWidths())
+	if errors.Is(err, cover_image.ErrTooLarge) {
+		return wrapper.ErrorResponse(c, fiber.StatusRequestEntityTooLarge, "", err)
+	}
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusUnsupportedMediaType, "", err)
+	}
+
+	store, err := storage.NewBlobStore()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	// Every upload gets its own prefix, so cached copies of the previous
+	// cover are never served for the new one.
+	prefix := "covers/" + claims.TenantID.String() + "/" + foundedBook.ID.String() + "/" + uuid.NewString() + "/"
+	ctx := c.UserContext()
+	stored := []string{}
+
+	put := func(key string, image cover_image.Encoded) (string, error) {
+		if err := store.Put(ctx, key, image.Data, image.ContentType); err != nil {
+			return "", err
+		}
+		stored = append(stored, key)
+		return store.URL(key), nil
+	}
+
+	changes := foundedBook.Editable()
+	changes.BookAttrs.Thumbnails = map[string]string{}
+
+	changes.BookAttrs.Picture, err = put(prefix+"original."+cover.Original.Extension, cover.Original)
+	for width, thumbnail := range cover.Thumbnails {
+		if err != nil {
+			break
+		}
+		name := strconv.Itoa(width)
+		changes.BookAttrs.Thumbnails[name], err = put(prefix+name+"."+thumbnail.Extension, thumbnail)
+	}
+	if err != nil {
+		deleteBlobs(store, stored)
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	book, status, err := updateBook(db, claims, foundedBook, grant, changes)
+	if err != nil {
+		deleteBlobs(store, stored)
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+	deleteBlobs(store, coverKeys(store, foundedBook))
+
+	c.Set(fiber.HeaderETag, etag.FromVersion(book.Version))
+
+	return wrapper.SuccessResponse(c, "", book)
+}
+
+// deleteBlobs removes the files of a cover that could not be saved or is no
+// longer used. The request may be gone already, so it does not use its
+// context.
+func deleteBlobs(store storage.BlobStore, keys []string) {
+	for _, key := range keys {
+		if err := store.Delete(context.Background(), key); err != nil {
+			log.Printf("cover: failed to delete %s: %v", key, err)
+		}
+	}
+}
+
+// hasUploadedCover reports whether attrs name uploaded cover files of the
+// book.
+func hasUploadedCover(store storage.BlobStore, book models.Book, attrs models.BookAttrs) bool {
+	book.BookAttrs = attrs
+	return len(coverKeys(store, book)) > 0
+}
+
+// coverKeys returns the keys of the uploaded files behind the cover of the
+// book. A picture set to a URL elsewhere has none.
+func coverKeys(store storage.BlobStore, book models.Book) []string {
+	prefix := "covers/" + book.TenantID.String() + "/" + book.ID.String() + "/"
+	urls := []string{book.BookAttrs.Picture}
+	for _, url := range book.BookAttrs.Thumbnails {
+		urls = append(urls, url)
+	}
+
+	keys := []string{}
+	for _, url := range urls {
+		if key, found := strings.CutPrefix(url, store.URL("")); found && strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	"bufio"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/book_export"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/validator"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/wrapper"
+	"github.com/create-go-app/fiber-go-template/platform/database"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// exportFlushInterval is how many books are streamed between two flushes of
+// the response.
+const exportFlushInterval = 100
+
+// ExportBooks func exports the books of the organization.
+// @Description Export the books of the current organization, with book_attrs and the metadata fields flattened into columns, or as MARC 21, MARCXML, ONIX 3.0, BibTeX or RIS records. The file is streamed in the response, unless more books match than BOOK_EXPORT_STREAM_LIMIT: the export then runs as a background job, returned instead, whose file is downloaded from its download_url once done.
+// @Summary export books
+// @Tags Books
+// @Accept json
+// @Produce text/csv,application/x-ndjson,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/marc,application/marcxml+xml,application/xml,application/x-bibtex,application/x-research-info-systems,json
+// @Param format query string false "csv (default), ndjson, xlsx, marc, marcxml, onix, bibtex or ris"
+// @Param author query string false "Author"
+// @Param book_status query string false "Book status, 0 or 1"
+// @Param user_id query string false "Owner ID"
+// @Param created_from query string false "Created at or after, RFC 3339"
+// @Param created_to query string false "Created before, RFC 3339"
+// @Success 200 {object} models.BookExportJob
+// @Security ApiKeyAuth
+// @Router /v1/books/export [get]
+func ExportBooks(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, "")
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	if err := requireTenant(claims); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", err)
+	}
+
+	format := c.Query("format", repository.BookExportFormatCSV)
+	if !book_export.IsFormat(format) {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(repository.UnsupportedFormatErrorMessage))
+	}
+
+	filter := models.BookExportFilter{}
+	if err := c.QueryParser(&filter); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(filter); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	count, err := db.CountBooksForExport(claims.TenantID, filter)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if count > book_export.StreamLimit() {
+		now := time.Now()
+		job := models.BookExportJob{
+			ID:        uuid.New(),
+			CreatedAt: now,
+			UpdatedAt: now,
+			TenantID:  claims.TenantID,
+			UserID:    claims.UserID,
+			Format:    format,
+			Filter:    filter,
+			Status:    repository.JobStatusQueued,
+			TotalRows: count,
+		}
+
+		if err := db.CreateBookExportJob(&job); err != nil {
+			return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+		}
+
+		book_export.Start(job)
+
+		return wrapper.SuccessResponse(c, "", job)
+	}
+
+	tenantID := claims.TenantID
+	c.Set(fiber.HeaderContentType, book_export.ContentType(format))
+	c.Attachment("books." + book_export.Extension(format))
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		_, err := book_export.Export(db, tenantID, filter, format, w, func(count int) error {
+			if count%exportFlushInterval == 0 {
+				return w.Flush()
+			}
+			return nil
+		})
+		if err != nil {
+			// The status is already sent, the client gets a truncated file.
+			log.Printf("book export: %v", err)
+		}
+	})
+
+	return nil
+}
+
+// GetBookExportJob func gets the status of a background book export.
+// @Description Get the progress of a background book export started by the current user, with its download_url once done.
+// @Summary get book export status
+// @Tags Books
+// @Accept json
+// @Produce json
+// @Param id path string true "Export job ID"
+// @Success 200 {object} models.BookExportJob
+// @Security ApiKeyAuth
+// @Router /v1/books/export/{id} [get]
+func GetBookExportJob(c *fiber.Ctx) error {
+	job, status, err := currentUserExportJob(c)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", job)
+}
+
+// DownloadBookExport func downloads the file of a background book export.
+// @Description Download the file of a finished background book export started by the current user. The file is deleted BOOK_EXPORT_RETENTION_HOURS after the export.
+// @Summary download book export
+// @Tags Books
+// @Produce text/csv,application/x-ndjson,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param id path string true "Export job ID"
+// @Success 200 {file} file
+// @Security ApiKeyAuth
+// @Router /v1/books/export/{id}/download [get]
+func DownloadBookExport(c *fiber.Ctx) error {
+	job, status, err := currentUserExportJob(c)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	if job.Status != repository.JobStatusDone {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	if err := c.Download(book_export.Path(job), "books."+book_export.Extension(job.Format)); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+	c.Set(fiber.HeaderContentType, book_export.ContentType(job.Format))
+
+	return nil
+}
+
+// currentUserExportJob loads the export job named in the path, which only
+// the user who started it may see.
+func currentUserExportJob(c *fiber.Ctx) (models.BookExportJob, int, error) {
+	claims, status, err := authorize(c, "")
+	if err != nil {
+		return models.BookExportJob{}, status, err
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return models.BookExportJob{}, fiber.StatusBadRequest, err
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return models.BookExportJob{}, fiber.StatusInternalServerError, err
+	}
+
+	job, err := db.GetBookExportJob(claims.TenantID, id)
+	if err != nil || job.UserID != claims.UserID {
+		return models.BookExportJob{}, fiber.StatusNotFound, errors.New(repository.NotFoundErrorMessage)
+	}
+
+	if job.Status == repository.JobStatusDone {
+		job.DownloadURL = "/api/v1/books/export/" + job.ID.String() + "/download"
+	}
+
+	return job, 0, nil
+}
@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/book_import"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/book_interchange"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/wrapper"
+	"github.com/create-go-app/fiber-go-template/platform/database"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ImportBooks func starts the import of a file of books.
+// @Description Import books from a CSV file (columns title, author, book_status, picture, description, rating, isbn, publisher, publication_date, language, page_count, edition, book_format), a JSON Lines file of book objects, or MARC 21 (ISO 2709 or MARCXML), ONIX 3.0, BibTeX or RIS records, sent as the request body or as the "file" field of a multipart form. Rows are validated like in POST /v1/book and inserted in batches by a background job, whose status and per-row error report are returned by GET /v1/books/import/{id}. Books read from records are imported unrated, with a rating of 0, and fields of the records that have no book column are counted in unmapped_fields. With dry_run nothing is written.
+// @Summary import books
+// @Tags Books
+// @Accept text/csv,application/x-ndjson,application/marc,application/marcxml+xml,application/xml,application/x-bibtex,application/x-research-info-systems,multipart/form-data
+// @Produce json
+// @Param format query string false "csv, ndjson, marc, marcxml, onix, bibtex or ris, guessed from the content type, file name or XML root by default"
+// @Param dry_run query bool false "Only validate the rows"
+// @Param file formData file false "File to import"
+// @Success 200 {object} models.BookImportJob
+// @Security ApiKeyAuth
+// @Router /v1/books/import [post]
+func ImportBooks(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, repository.BookCreateCredential)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	if err := requireTenant(claims); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", err)
+	}
+
+	var (
+		body        []byte
+		contentType = c.Get(fiber.HeaderContentType)
+		filename    string
+	)
+
+	if strings.HasPrefix(contentType, fiber.MIMEMultipartForm) {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+		}
+
+		file, err := fileHeader.Open()
+		if err != nil {
+			return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+		}
+		defer file.Close()
+
+		if body, err = io.ReadAll(file); err != nil {
+			return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+		}
+		contentType = fileHeader.Header.Get(fiber.HeaderContentType)
+		filename = fileHeader.Filename
+	} else {
+		body = c.Body()
+	}
+
+	format := importFormat(c.Query("format"), contentType, filename, body)
+	if format == "" {
+		return wrapper.ErrorResponse(c, fiber.StatusUnsupportedMediaType, "", errors.New(repository.UnsupportedImportErrorMessage))
+	}
+
+	rows, err := book_import.Parse(format, bytes.NewReader(body))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	now := time.Now()
+	job := models.BookImportJob{
+		ID:             uuid.New(),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		TenantID:       claims.TenantID,
+		UserID:         claims.UserID,
+		Format:         format,
+		DryRun:         c.QueryBool("dry_run"),
+		Status:         repository.JobStatusQueued,
+		TotalRows:      len(rows),
+		RowErrors:      models.BookImportRowErrors{},
+		UnmappedFields: book_import.UnmappedFields(rows),
+	}
+
+	if err := db.CreateBookImportJob(&job); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	book_import.Start(job, rows)
+
+	return wrapper.SuccessResponse(c, "", job)
+}
+
+// importFormat picks the format from the format parameter, then the content
+// type, then the file extension. XML is told apart as MARCXML or ONIX by the
+// root element of the body. It returns "" when none of them tells.
+func importFormat(format, contentType, filename string, body []byte) string {
+	if format != "" {
+		if book_import.IsFormat(format) {
+			return format
+		}
+		return ""
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv":
+		return repository.BookImportFormatCSV
+	case "application/x-ndjson", "application/jsonl", "application/x-jsonlines":
+		return repository.BookImportFormatNDJSON
+	case "application/marc":
+		return repository.BookImportFormatMARC
+	case "application/marcxml+xml":
+		return repository.BookImportFormatMARCXML
+	case "application/x-bibtex", "text/x-bibtex":
+		return repository.BookImportFormatBibTeX
+	case "application/x-research-info-systems":
+		return repository.BookImportFormatRIS
+	case "application/xml", "text/xml":
+		return book_interchange.SniffXML(body)
+	}
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return repository.BookImportFormatCSV
+	case ".ndjson", ".jsonl":
+		return repository.BookImportFormatNDJSON
+	case ".mrc", ".marc":
+		return repository.BookImportFormatMARC
+	case ".bib":
+		return repository.BookImportFormatBibTeX
+	case ".ris":
+		return repository.BookImportFormatRIS
+	case ".xml":
+		return book_interchange.SniffXML(body)
+	}
+
+	return ""
+}
+
+// GetBookImportJob func gets the status of a book import.
+// @Description Get the progress and the per-row error report of a book import started by the current user.
+// @Summary get book import status
+// @Tags Books
+// @Accept json
+// @Produce json
+// @Param id path string true "Import job ID"
+// @Success 200 {object} models.BookImportJob
+// @Security ApiKeyAuth
+// @Router /v1/books/import/{id} [get]
+func GetBookImportJob(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, "")
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	job, err := db.GetBookImportJob(claims.TenantID, id)
+	if err != nil || job.UserID != claims.UserID {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	return wrapper.SuccessResponse(c, "", job)
+}
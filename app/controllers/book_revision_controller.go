@@ -0,0 +1,282 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/book_policy"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/etag"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/isbn"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/validator"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/wrapper"
+	"github.com/create-go-app/fiber-go-template/platform/database"
+	"github.com/create-go-app/fiber-go-template/platform/storage"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// GetBookRevisions func gets the history of a book.
+// @Description List the revisions of a book, newest first, each with a snapshot of the book and the fields it changed. Open to the owner holding book:update, collaborators and holders of book:update:any, and for deleted books to their last owner holding book:update and holders of book:update:any.
+// @Summary list book revisions
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Success 200 {array} models.BookRevision
+// @Security ApiKeyAuth
+// @Router /v1/books/{id}/revisions [get]
+func GetBookRevisions(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, "")
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if _, _, _, status, err := revisionBook(db, claims, id, book_policy.ViewRevisions); err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	revisions, err := db.GetBookRevisions(claims.TenantID, id)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", revisions)
+}
+
+// DiffBookRevisions func compares two revisions of a book.
+// @Description Compare the book as of two of its revisions: each field that differs is returned with its value in from and in to. Open like the revision list.
+// @Summary compare book revisions
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param from query int true "Revision to compare from"
+// @Param to query int true "Revision to compare to"
+// @Success 200 {object} models.BookRevisionDiff
+// @Security ApiKeyAuth
+// @Router /v1/books/{id}/revisions/diff [get]
+func DiffBookRevisions(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, "")
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	filter := models.BookRevisionDiffFilter{}
+	if err := c.QueryParser(&filter); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(filter); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if _, _, _, status, err := revisionBook(db, claims, id, book_policy.ViewRevisions); err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	diff, err := db.DiffBookRevisions(claims.TenantID, id, filter.From, filter.To)
+	if errors.Is(err, sql.ErrNoRows) {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", diff)
+}
+
+// RestoreBookRevision func brings a book back to one of its revisions.
+// @Description Replace the editable fields of a book with those of one of its revisions, recorded as a new revision. A deleted book is created again from the revision, with its ID and last owner, unless that owner no longer exists. Its tags, categories, credits, collaborators and shelves are not restored. An uploaded cover is never restored, the book keeps its current one. Owners need book:update, anyone else needs book:update:any and the change is recorded.
+// @Summary restore book revision
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param rev path int true "Revision to restore"
+// @Param If-Match header string false "ETag the restore is based on"
+// @Success 200 {object} models.Book
+// @Security ApiKeyAuth
+// @Router /v1/books/{id}/revisions/{rev}/restore [post]
+func RestoreBookRevision(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, "")
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	rev, err := strconv.Atoi(c.Params("rev"))
+	if err != nil || rev < 1 {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(repository.InvalidRevisionErrorMessage))
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	book, exists, grant, status, err := revisionBook(db, claims, id, book_policy.Restore)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	revision, err := db.GetBookRevision(claims.TenantID, id, rev)
+	if errors.Is(err, sql.ErrNoRows) {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	snapshot := models.BookRevisionSnapshot{}
+	if err := json.Unmarshal(revision.Snapshot, &snapshot); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	// Revisions may predate the current rules.
+	validate := validator.NewValidator()
+	if err := validate.Struct(snapshot.BookReplace); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	}
+
+	// Cover files are deleted once replaced and with the book, so a restore
+	// keeps the current cover rather than name files that may be gone.
+	store, err := storage.NewBlobStore()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+	if hasUploadedCover(store, book, snapshot.BookAttrs) {
+		current := models.BookAttrs{}
+		if exists {
+			current = book.BookAttrs
+		}
+		snapshot.BookAttrs.Picture, snapshot.BookAttrs.Thumbnails = current.Picture, current.Thumbnails
+	}
+
+	if exists {
+		if !etag.Match(c.Get(fiber.HeaderIfMatch), etag.FromVersion(book.Version)) {
+			return wrapper.ErrorResponse(c, fiber.StatusPreconditionFailed, "", errors.New(repository.PreconditionFailedErrorMessage))
+		}
+
+		book, status, err := changeBook(db, claims, book, grant, snapshot.BookReplace, rev)
+		if err != nil {
+			return wrapper.ErrorResponse(c, status, "", err)
+		}
+
+		c.Set(fiber.HeaderETag, etag.FromVersion(book.Version))
+
+		return wrapper.SuccessResponse(c, "", book)
+	}
+
+	// The owner is gone with their account, the book cannot come back.
+	_, err = db.GetUserByID(book.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return wrapper.ErrorResponse(c, fiber.StatusConflict, "", errors.New(repository.BookOwnerGoneErrorMessage))
+	}
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	restored := book
+	restored.UpdatedAt = time.Now()
+	restored.Title = snapshot.Title
+	restored.Author = snapshot.Author
+	restored.BookStatus = snapshot.BookStatus
+	restored.BookAttrs = snapshot.BookAttrs
+	restored.BookMetadata = snapshot.BookMetadata
+	restored.ISBN = isbn.Normalize(restored.ISBN)
+	restored.Version = book.Version + 1
+
+	if status, err := checkISBN(db, claims.TenantID, restored.ID, restored.ISBN); err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	audit, err := bookAudit(claims, grant, book_policy.Restore, book, &restored)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	change := models.BookChange{ActorID: claims.UserID, RestoredFrom: rev, Audit: audit}
+	if err := db.CreateBook(&restored, change); err != nil {
+		status, err := saveBookError(err)
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	c.Set(fiber.HeaderETag, etag.FromVersion(restored.Version))
+
+	return wrapper.SuccessResponse(c, "", restored)
+}
+
+// revisionBook loads the book the revisions belong to like authorizedBook,
+// and reports whether it still exists. A deleted book is read back from its
+// last revision: it has no collaborators anymore, so only its last owner and
+// moderators may act on it.
+func revisionBook(db *database.Queries, claims *models.TokenMetadata, id uuid.UUID, action book_policy.Action) (models.Book, bool, book_policy.Grant, int, error) {
+	book, grant, status, err := authorizedBook(db, claims, id, action)
+	if status != fiber.StatusNotFound {
+		return book, true, grant, status, err
+	}
+
+	latest, err := db.GetLatestBookRevision(claims.TenantID, id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && latest.Action != repository.BookRevisionActionDelete) {
+		return book, false, book_policy.Denied, fiber.StatusNotFound, errors.New(repository.NotFoundErrorMessage)
+	}
+	if err != nil {
+		return book, false, book_policy.Denied, fiber.StatusInternalServerError, err
+	}
+
+	snapshot := models.BookRevisionSnapshot{}
+	if err := json.Unmarshal(latest.Snapshot, &snapshot); err != nil {
+		return book, false, book_policy.Denied, fiber.StatusInternalServerError, err
+	}
+
+	book = models.Book{
+		ID:           id,
+		CreatedAt:    snapshot.CreatedAt,
+		UserID:       snapshot.UserID,
+		Title:        snapshot.Title,
+		Author:       snapshot.Author,
+		BookStatus:   snapshot.BookStatus,
+		BookAttrs:    snapshot.BookAttrs,
+		TenantID:     claims.TenantID,
+		Version:      snapshot.Version,
+		BookMetadata: snapshot.BookMetadata,
+	}
+
+	grant = book_policy.Decide(claims.Credentials, action, book_policy.RelationOf(claims.UserID, book, ""))
+	if grant == book_policy.Denied {
+		return book, false, grant, fiber.StatusForbidden, errors.New(repository.ForbiddenDataModificationErrorMessage)
+	}
+
+	return book, false, grant, 0, nil
+}
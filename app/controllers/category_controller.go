@@ -0,0 +1,347 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/book_policy"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/validator"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/wrapper"
+	"github.com/create-go-app/fiber-go-template/platform/database"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// GetCategories func gets the category tree of the organization.
+// @Description Get the categories of the organization named by the X-Tenant header (the default organization without it) as a tree, siblings by name.
+// @Summary get category tree
+// @Tags Category
+// @Accept json
+// @Produce json
+// @Param X-Tenant header string false "Organization slug"
+// @Success 200 {array} models.CategoryNode
+// @Router /v1/categories [get]
+func GetCategories(c *fiber.Ctx) error {
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	tenantID, err := publicTenant(c, db)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	categories, err := db.GetCategories(tenantID)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", models.CategoryTree(categories))
+}
+
+// CreateCategory func creates a category.
+// @Description Create a category of the current organization, under parent_id or at the top level. Requires category:manage.
+// @Summary create a category
+// @Tags Category
+// @Accept json
+// @Produce json
+// @Param request body models.CategoryUpdate true "Category"
+// @Success 200 {object} models.Category
+// @Security ApiKeyAuth
+// @Router /v1/categories [post]
+func CreateCategory(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, repository.CategoryManageCredential)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	if err := requireTenant(claims); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", err)
+	}
+
+	categoryUpdate, err := parseCategoryUpdate(c)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	now := time.Now()
+	category := models.Category{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		TenantID:  claims.TenantID,
+		ParentID:  categoryUpdate.ParentID,
+		Name:      categoryUpdate.Name,
+	}
+
+	if status, err := checkCategoryName(db, category); err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	err = db.CreateCategory(&category)
+	if errors.Is(err, sql.ErrNoRows) {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(repository.CategoryParentErrorMessage))
+	}
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", category)
+}
+
+// UpdateCategory func renames or moves a category.
+// @Description Rename a category of the current organization or move it under another parent, with its subcategories. Requires category:manage.
+// @Summary update a category
+// @Tags Category
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Param request body models.CategoryUpdate true "Category"
+// @Success 200 {object} models.Category
+// @Security ApiKeyAuth
+// @Router /v1/categories/{id} [put]
+func UpdateCategory(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, repository.CategoryManageCredential)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	categoryUpdate, err := parseCategoryUpdate(c)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	category, status, err := tenantCategory(c, db, claims)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	category.UpdatedAt = time.Now()
+	category.ParentID = categoryUpdate.ParentID
+	category.Name = categoryUpdate.Name
+
+	if status, err := checkCategoryName(db, category); err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	// The category was found above, so no row means an unknown parent or one
+	// inside the category.
+	err = db.UpdateCategory(&category)
+	if errors.Is(err, sql.ErrNoRows) {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(repository.CategoryParentErrorMessage))
+	}
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", category)
+}
+
+// DeleteCategory func deletes a category.
+// @Description Delete a category of the current organization without subcategories. Its books lose the category. Requires category:manage.
+// @Summary delete a category
+// @Tags Category
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Success 200 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/categories/{id} [delete]
+func DeleteCategory(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, repository.CategoryManageCredential)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	category, status, err := tenantCategory(c, db, claims)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	// The category was found above, so no row means it has subcategories.
+	err = db.DeleteCategory(claims.TenantID, category.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return wrapper.ErrorResponse(c, fiber.StatusConflict, "", errors.New(repository.CategoryInUseErrorMessage))
+	}
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", "ok")
+}
+
+// GetBookCategories func gets the categories of a book.
+// @Description Get the categories of a book in the organization named by the X-Tenant header (the default organization without it).
+// @Summary get book categories
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param X-Tenant header string false "Organization slug"
+// @Success 200 {array} models.Category
+// @Router /v1/books/{id}/categories [get]
+func GetBookCategories(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	tenantID, err := publicTenant(c, db)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	if _, err := db.GetBook(tenantID, id); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	categories, err := db.GetBookCategories(tenantID, id)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", categories)
+}
+
+// SetBookCategories func replaces the categories of a book.
+// @Description Replace the categories of a book with categories of the organization. Credentials are checked as for replacing the book.
+// @Summary set book categories
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param request body models.BookCategoriesUpdate true "Categories"
+// @Success 200 {array} models.Category
+// @Security ApiKeyAuth
+// @Router /v1/books/{id}/categories [put]
+func SetBookCategories(c *fiber.Ctx) error {
+	claims, status, err := authorizeBook(c, book_policy.Update)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	categoriesUpdate := &models.BookCategoriesUpdate{}
+	if err := c.BodyParser(categoriesUpdate); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(categoriesUpdate); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	}
+
+	categoryIDs := []uuid.UUID{}
+	seen := map[uuid.UUID]bool{}
+	for _, categoryID := range categoriesUpdate.CategoryIDs {
+		if !seen[categoryID] {
+			seen[categoryID] = true
+			categoryIDs = append(categoryIDs, categoryID)
+		}
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if _, _, status, err := authorizedBook(db, claims, id, book_policy.Update); err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	// The book was found above, so no row means an unknown category.
+	err = db.SetBookCategories(claims.TenantID, id, categoryIDs)
+	if errors.Is(err, sql.ErrNoRows) {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(repository.BookCategoriesErrorMessage))
+	}
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	categories, err := db.GetBookCategories(claims.TenantID, id)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", categories)
+}
+
+// parseCategoryUpdate reads and validates the body of category writes.
+func parseCategoryUpdate(c *fiber.Ctx) (*models.CategoryUpdate, error) {
+	categoryUpdate := &models.CategoryUpdate{}
+	if err := c.BodyParser(categoryUpdate); err != nil {
+		return nil, err
+	}
+	categoryUpdate.Name = strings.TrimSpace(categoryUpdate.Name)
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(categoryUpdate); err != nil {
+		return nil, errors.New(validator.ValidatorErrors(err))
+	}
+
+	return categoryUpdate, nil
+}
+
+// checkCategoryName refuses a name another category under the same parent
+// already has.
+func checkCategoryName(db *database.Queries, category models.Category) (int, error) {
+	taken, err := db.CategoryNameTaken(category.TenantID, category.ParentID, category.Name, category.ID)
+	if err != nil {
+		return fiber.StatusInternalServerError, err
+	}
+	if taken {
+		return fiber.StatusConflict, errors.New(repository.CategoryExistsErrorMessage)
+	}
+
+	return 0, nil
+}
+
+// tenantCategory loads the category named in the path from the organization
+// of the token.
+func tenantCategory(c *fiber.Ctx, db *database.Queries, claims *models.TokenMetadata) (models.Category, int, error) {
+	if err := requireTenant(claims); err != nil {
+		return models.Category{}, fiber.StatusForbidden, err
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return models.Category{}, fiber.StatusBadRequest, err
+	}
+
+	category, err := db.GetCategory(claims.TenantID, id)
+	if err != nil {
+		return models.Category{}, fiber.StatusNotFound, errors.New(repository.NotFoundErrorMessage)
+	}
+
+	return category, 0, nil
+}
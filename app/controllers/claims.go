@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/jwt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// authorize extracts the token metadata, checks that it has not expired and,
+// when credential is not empty, that the token carries it. On failure it
+// returns the status code to answer with.
+func authorize(c *fiber.Ctx, credential string) (*models.TokenMetadata, int, error) {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return nil, fiber.StatusInternalServerError, err
+	}
+
+	if time.Now().Unix() > claims.Expires {
+		return nil, fiber.StatusUnauthorized, errors.New(repository.UnauthorizedErrorMessage)
+	}
+
+	if credential != "" && !claims.Credentials[credential] {
+		return nil, fiber.StatusForbidden, errors.New(repository.ForbiddenErrorMessage)
+	}
+
+	return claims, 0, nil
+}
+
+// requirePersonalSession refuses API keys and impersonation tokens for
+// actions only the account holder may take in person.
+func requirePersonalSession(claims *models.TokenMetadata) error {
+	if claims.APIKeyID != uuid.Nil {
+		return errors.New(repository.APIKeyNotAllowedErrorMessage)
+	}
+
+	if claims.Impersonated() {
+		return errors.New(repository.ImpersonationNotAllowedErrorMessage)
+	}
+
+	return nil
+}
+
+// optionalClaims returns the token metadata of a request that passed
+// JWTOptional with credentials, and nil for an anonymous one.
+func optionalClaims(c *fiber.Ctx) (*models.TokenMetadata, int, error) {
+	if c.Locals("jwt") == nil && c.Locals(jwt.TokenMetadataKey) == nil {
+		return nil, 0, nil
+	}
+
+	return authorize(c, "")
+}
@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/jwt"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/roles_credentials"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/text"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/validator"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/wrapper"
+	"github.com/create-go-app/fiber-go-template/platform/database"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ImpersonateUser method to act as a user for support purposes.
+// @Description Issue a short-lived access token for the user that names the administrator in its act claim. Every request made with it is recorded in the impersonation log. Administrators and blocked users cannot be impersonated.
+// @Summary impersonate a user
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} models.ImpersonationResponse
+// @Security ApiKeyAuth
+// @Router /v1/admin/users/{id}/impersonate [post]
+func ImpersonateUser(c *fiber.Ctx) error {
+	now := time.Now()
+
+	claims, status, err := authorize(c, repository.UserManageCredential)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	if err := requirePersonalSession(claims); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", err)
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	if id == claims.UserID {
+		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", errors.New(repository.ImpersonationTargetErrorMessage))
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	foundedUser, err := db.GetUserByID(id)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	credentials, err := roles_credentials.GetCredentialsByRole(foundedUser.UserRole)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	if foundedUser.UserStatus != 1 || slices.Contains(credentials, repository.UserManageCredential) {
+		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", errors.New(repository.ImpersonationTargetErrorMessage))
+	}
+
+	// The token acts in the admin's current organization when the user belongs
+	// to it, with the credentials the user holds there.
+	tenantID, credentials, err := sessionTenant(db, foundedUser, claims.TenantID)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	expiresAt := now.Add(impersonationTTL())
+	token, err := jwt.GenerateImpersonationToken(foundedUser.ID.String(), claims.UserID.String(), tenantID, credentials, expiresAt)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	// The token is only handed out once its issue is on record.
+	actorID := claims.UserID
+	if err := db.CreateImpersonationLog(&models.ImpersonationLog{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		ActorID:   &actorID,
+		UserID:    &foundedUser.ID,
+		Method:    c.Method(),
+		Path:      text.Truncate(c.Path(), 255),
+		Status:    fiber.StatusOK,
+		IPAddress: c.IP(),
+	}); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", models.ImpersonationResponse{
+		Access:    token,
+		ExpiresAt: expiresAt,
+		UserID:    foundedUser.ID,
+		ActorID:   actorID,
+	})
+}
+
+// impersonationTTL reads IMPERSONATION_EXPIRE_MINUTES, 15 minutes by default.
+func impersonationTTL() time.Duration {
+	minutesCount, err := strconv.Atoi(os.Getenv("IMPERSONATION_EXPIRE_MINUTES"))
+	if err != nil || minutesCount <= 0 {
+		minutesCount = 15
+	}
+
+	return time.Minute * time.Duration(minutesCount)
+}
+
+// GetImpersonationLogs method to list requests made while impersonating users.
+// @Description List impersonation log entries, newest first.
+// @Summary list impersonation log entries
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param actor_id query string false "Administrator ID"
+// @Param user_id query string false "Impersonated user ID"
+// @Param limit query int false "Limit (default 100, max 500)"
+// @Success 200 {array} models.ImpersonationLog
+// @Security ApiKeyAuth
+// @Router /v1/admin/impersonation-logs [get]
+func GetImpersonationLogs(c *fiber.Ctx) error {
+	if _, status, err := authorize(c, repository.UserManageCredential); err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	filter := models.ImpersonationLogFilter{}
+	if err := c.QueryParser(&filter); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(filter); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = 100
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	logs, err := db.GetImpersonationLogs(filter)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", logs)
+}
@@ -0,0 +1,335 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/refresh_session"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/roles_credentials"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/validator"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/wrapper"
+	"github.com/create-go-app/fiber-go-template/platform/database"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// GetCurrentUserOrganizations method to list the organizations of the current user.
+// @Description List the organizations the current user is a member of, with their role in each.
+// @Summary list organizations of the current user
+// @Tags Organization
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.Membership
+// @Security ApiKeyAuth
+// @Router /v1/user/organizations [get]
+func GetCurrentUserOrganizations(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, "")
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	memberships, err := db.GetMemberships(claims.UserID)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", memberships)
+}
+
+// SwitchTenant method to move the current session to another organization.
+// @Description End the current session and start a new one acting in the given organization.
+// @Summary switch organization
+// @Tags Organization
+// @Accept json
+// @Produce json
+// @Param request body models.TenantSwitch true "Organization"
+// @Success 200 {object} models.TokenResponse
+// @Security ApiKeyAuth
+// @Router /v1/user/tenant [post]
+func SwitchTenant(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, "")
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	if err := requirePersonalSession(claims); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", err)
+	}
+
+	tenantSwitch := &models.TenantSwitch{}
+	if err := c.BodyParser(tenantSwitch); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(tenantSwitch); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	foundedUser, err := db.GetUserByID(claims.UserID)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	tenantID, credentials, err := sessionTenant(db, foundedUser, tenantSwitch.OrganizationID)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+	if tenantID != tenantSwitch.OrganizationID {
+		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", errors.New(repository.NotMemberErrorMessage))
+	}
+
+	store, err := refresh_session.NewDefault()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	// The old session would otherwise renew into the old organization.
+	if claims.SessionID != uuid.Nil {
+		if err := refresh_session.Revoke(context.Background(), store, claims.UserID, claims.SessionID); err != nil {
+			return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+		}
+	}
+
+	return startSession(c, store, foundedUser.ID, tenantID, credentials)
+}
+
+// GetOrganizations method to list all organizations.
+// @Description List all organizations.
+// @Summary list organizations
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.Organization
+// @Security ApiKeyAuth
+// @Router /v1/admin/organizations [get]
+func GetOrganizations(c *fiber.Ctx) error {
+	if _, status, err := authorize(c, repository.UserManageCredential); err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	organizations, err := db.GetOrganizations()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", organizations)
+}
+
+// CreateOrganization method to create a new organization.
+// @Description Create a new organization. The slug selects it in the X-Tenant header.
+// @Summary create a new organization
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body models.OrganizationCreate true "Organization"
+// @Success 200 {object} models.Organization
+// @Security ApiKeyAuth
+// @Router /v1/admin/organizations [post]
+func CreateOrganization(c *fiber.Ctx) error {
+	if _, status, err := authorize(c, repository.UserManageCredential); err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	organizationCreate := &models.OrganizationCreate{}
+	if err := c.BodyParser(organizationCreate); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(organizationCreate); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if _, err := db.GetOrganizationBySlug(organizationCreate.Slug); err == nil {
+		return wrapper.ErrorResponse(c, fiber.StatusConflict, "", errors.New(repository.SlugTakenErrorMessage))
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	now := time.Now()
+	organization := models.Organization{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Name:      organizationCreate.Name,
+		Slug:      organizationCreate.Slug,
+	}
+
+	if err := db.CreateOrganization(&organization); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", organization)
+}
+
+// GetOrganizationMembers method to list the members of an organization.
+// @Description List the members of an organization with their roles.
+// @Summary list organization members
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Success 200 {array} models.OrganizationMember
+// @Security ApiKeyAuth
+// @Router /v1/admin/organizations/{id}/members [get]
+func GetOrganizationMembers(c *fiber.Ctx) error {
+	if _, status, err := authorize(c, repository.UserManageCredential); err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if _, err := db.GetOrganization(id); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	members, err := db.GetOrganizationMembers(id)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", members)
+}
+
+// SaveOrganizationMember method to add a member or change their role.
+// @Description Add a user to an organization or change their role in it. The user is signed out so new tokens carry the change.
+// @Summary add or update an organization member
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Param request body models.OrganizationMemberUpdate true "Member"
+// @Success 200 {object} models.OrganizationMember
+// @Security ApiKeyAuth
+// @Router /v1/admin/organizations/{id}/members [put]
+func SaveOrganizationMember(c *fiber.Ctx) error {
+	if _, status, err := authorize(c, repository.UserManageCredential); err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	memberUpdate := &models.OrganizationMemberUpdate{}
+	if err := c.BodyParser(memberUpdate); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(memberUpdate); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	}
+
+	role, err := roles_credentials.VerifyRole(memberUpdate.MemberRole)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if _, err := db.GetOrganization(id); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	if _, err := db.GetUserByID(memberUpdate.UserID); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	member := models.OrganizationMember{
+		OrganizationID: id,
+		UserID:         memberUpdate.UserID,
+		MemberRole:     role,
+		CreatedAt:      time.Now(),
+	}
+
+	if err := db.SaveOrganizationMember(&member); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if err := revokeRefreshToken(member.UserID); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", member)
+}
+
+// DeleteOrganizationMember method to remove a user from an organization.
+// @Description Remove a user from an organization. The user is signed out so new tokens carry the change.
+// @Summary remove an organization member
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Param user_id path string true "User ID"
+// @Success 200 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/admin/organizations/{id}/members/{user_id} [delete]
+func DeleteOrganizationMember(c *fiber.Ctx) error {
+	if _, status, err := authorize(c, repository.UserManageCredential); err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	userID, err := uuid.Parse(c.Params("user_id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if err := db.DeleteOrganizationMember(id, userID); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	if err := revokeRefreshToken(userID); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", "ok")
+}
@@ -0,0 +1,360 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/validator"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/wrapper"
+	"github.com/create-go-app/fiber-go-template/platform/database"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// GetReviews func gets the reviews of a book.
+// @Description Get a page of the published reviews of a book, newest first, in the organization named by the X-Tenant header (the default organization without it).
+// @Summary list book reviews
+// @Tags Review
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param X-Tenant header string false "Organization slug"
+// @Param page query int false "Page, from 1"
+// @Param limit query int false "Reviews per page, 20 by default"
+// @Success 200 {object} models.ReviewPage
+// @Router /v1/books/{id}/reviews [get]
+func GetReviews(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	filter := models.ReviewFilter{}
+	if err := c.QueryParser(&filter); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(filter); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	tenantID, err := publicTenant(c, db)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	if _, err := db.GetBook(tenantID, id); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	limit, offset := filter.LimitOffset()
+	reviews, total, err := db.GetReviews(tenantID, id, limit, offset)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", models.ReviewPage{
+		Reviews: reviews,
+		Total:   total,
+		Page:    offset/limit + 1,
+		Limit:   limit,
+	})
+}
+
+// GetBookRating func gets the rating of a book.
+// @Description Get the average rating, rating count and rating histogram of a book, computed from its published reviews, in the organization named by the X-Tenant header (the default organization without it).
+// @Summary get book rating
+// @Tags Review
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param X-Tenant header string false "Organization slug"
+// @Success 200 {object} models.BookRating
+// @Router /v1/books/{id}/rating [get]
+func GetBookRating(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	tenantID, err := publicTenant(c, db)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	if _, err := db.GetBook(tenantID, id); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	rating, err := db.GetBookRating(tenantID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		rating = models.BookRating{BookID: id}
+	} else if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", rating)
+}
+
+// CreateReview func reviews a book.
+// @Description Rate a book of the current organization from 1 to 10 and review it. Every user reviews a book once, later changes edit the review.
+// @Summary review a book
+// @Tags Review
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param request body models.ReviewUpdate true "Review"
+// @Success 200 {object} models.Review
+// @Security ApiKeyAuth
+// @Router /v1/books/{id}/reviews [post]
+func CreateReview(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, "")
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	if err := requireTenant(claims); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", err)
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	reviewUpdate := &models.ReviewUpdate{}
+	if err := c.BodyParser(reviewUpdate); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(reviewUpdate); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if _, err := db.GetBook(claims.TenantID, id); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	now := time.Now()
+	review := models.Review{
+		ID:           uuid.New(),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		BookID:       id,
+		UserID:       claims.UserID,
+		Rating:       reviewUpdate.Rating,
+		Body:         reviewUpdate.Body,
+		ReviewStatus: repository.ReviewStatusPublished,
+	}
+
+	// The book was found above, so no row means the user reviewed it already.
+	err = db.CreateReview(claims.TenantID, &review)
+	if errors.Is(err, sql.ErrNoRows) {
+		return wrapper.ErrorResponse(c, fiber.StatusConflict, "", errors.New(repository.AlreadyReviewedErrorMessage))
+	}
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", review)
+}
+
+// UpdateReview func edits a review.
+// @Description Change the rating and text of a review. Only its author may edit it; a hidden review stays hidden.
+// @Summary edit a review
+// @Tags Review
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param review_id path string true "Review ID"
+// @Param request body models.ReviewUpdate true "Review"
+// @Success 200 {object} models.Review
+// @Security ApiKeyAuth
+// @Router /v1/books/{id}/reviews/{review_id} [put]
+func UpdateReview(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, "")
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	reviewUpdate := &models.ReviewUpdate{}
+	if err := c.BodyParser(reviewUpdate); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(reviewUpdate); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	review, status, err := pathReview(c, db, claims)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	if review.UserID != claims.UserID {
+		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", errors.New(repository.ForbiddenDataModificationErrorMessage))
+	}
+
+	review.UpdatedAt = time.Now()
+	review.Rating = reviewUpdate.Rating
+	review.Body = reviewUpdate.Body
+
+	if err := db.UpdateReview(claims.TenantID, &review); err != nil {
+		status, err := reviewWriteError(err)
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", review)
+}
+
+// ModerateReview func hides or publishes a review.
+// @Description Hide a review, which removes it from the listing and the rating of the book, or publish it again. Requires review:moderate.
+// @Summary moderate a review
+// @Tags Review
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param review_id path string true "Review ID"
+// @Param request body models.ReviewModeration true "Status"
+// @Success 200 {object} models.Review
+// @Security ApiKeyAuth
+// @Router /v1/books/{id}/reviews/{review_id}/status [put]
+func ModerateReview(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, repository.ReviewModerateCredential)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	moderation := &models.ReviewModeration{}
+	if err := c.BodyParser(moderation); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(moderation); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	review, status, err := pathReview(c, db, claims)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	now := time.Now()
+	review.ReviewStatus = moderation.ReviewStatus
+	review.ModeratedBy = &claims.UserID
+	review.ModeratedAt = &now
+
+	if err := db.ModerateReview(claims.TenantID, &review); err != nil {
+		status, err := reviewWriteError(err)
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", review)
+}
+
+// DeleteReview func deletes a review.
+// @Description Delete a review. Open to its author and holders of review:moderate.
+// @Summary delete a review
+// @Tags Review
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param review_id path string true "Review ID"
+// @Success 200 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/books/{id}/reviews/{review_id} [delete]
+func DeleteReview(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, "")
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	review, status, err := pathReview(c, db, claims)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	if review.UserID != claims.UserID && !claims.Credentials[repository.ReviewModerateCredential] {
+		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", errors.New(repository.ForbiddenErrorMessage))
+	}
+
+	if err := db.DeleteReview(claims.TenantID, review.BookID, review.ID); err != nil {
+		status, err := reviewWriteError(err)
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", "ok")
+}
+
+// pathReview loads the review named in the path from the organization of
+// the token.
+func pathReview(c *fiber.Ctx, db *database.Queries, claims *models.TokenMetadata) (models.Review, int, error) {
+	if err := requireTenant(claims); err != nil {
+		return models.Review{}, fiber.StatusForbidden, err
+	}
+
+	bookID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return models.Review{}, fiber.StatusBadRequest, err
+	}
+
+	id, err := uuid.Parse(c.Params("review_id"))
+	if err != nil {
+		return models.Review{}, fiber.StatusBadRequest, err
+	}
+
+	review, err := db.GetReview(claims.TenantID, bookID, id)
+	if err != nil {
+		return models.Review{}, fiber.StatusNotFound, errors.New(repository.NotFoundErrorMessage)
+	}
+
+	return review, 0, nil
+}
+
+// reviewWriteError maps a failed review write to the response: either the
+// review was deleted meanwhile or the database failed.
+func reviewWriteError(err error) (int, error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		return fiber.StatusNotFound, errors.New(repository.NotFoundErrorMessage)
+	}
+
+	return fiber.StatusInternalServerError, err
+}
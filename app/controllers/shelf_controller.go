@@ -0,0 +1,481 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/validator"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/wrapper"
+	"github.com/create-go-app/fiber-go-template/platform/database"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// GetUserShelves func gets the shelves of a user.
+// @Description Get the shelves of a user in the organization named by the X-Tenant header (the default organization without it), the built-in ones first. Users signed in to the organization see their private shelves too, everyone else only public ones.
+// @Summary list shelves of a user
+// @Tags Shelf
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param X-Tenant header string false "Organization slug"
+// @Success 200 {array} models.Shelf
+// @Router /v1/users/{id}/shelves [get]
+func GetUserShelves(c *fiber.Ctx) error {
+	claims, status, err := optionalClaims(c)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	tenantID, err := publicTenant(c, db)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	owner := claims != nil && claims.UserID == userID && claims.TenantID == tenantID
+	if owner {
+		if err := db.EnsureShelves(tenantID, userID); err != nil {
+			return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+		}
+	}
+
+	shelves, err := db.GetUserShelves(tenantID, userID, !owner)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", shelves)
+}
+
+// GetShelfBooks func gets the books of a shelf.
+// @Description Get the books of a shelf in their order, in the organization named by the X-Tenant header (the default organization without it). Private shelves are only found by their owner.
+// @Summary list books of a shelf
+// @Tags Shelf
+// @Accept json
+// @Produce json
+// @Param id path string true "Shelf ID"
+// @Param X-Tenant header string false "Organization slug"
+// @Success 200 {array} models.Book
+// @Router /v1/shelves/{id}/books [get]
+func GetShelfBooks(c *fiber.Ctx) error {
+	claims, status, err := optionalClaims(c)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	tenantID, err := publicTenant(c, db)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	shelf, err := db.GetShelf(tenantID, id)
+	if err != nil || (!shelf.IsPublic && (claims == nil || claims.UserID != shelf.UserID)) {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	books, err := db.GetShelfBooks(tenantID, shelf.ID)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", books)
+}
+
+// CreateShelf func creates a shelf.
+// @Description Create a custom shelf of the current user in the current organization. Shelf names are unique per user regardless of case, the built-in "Want to read" and "Favorites" included.
+// @Summary create a shelf
+// @Tags Shelf
+// @Accept json
+// @Produce json
+// @Param request body models.ShelfUpdate true "Shelf"
+// @Success 200 {object} models.Shelf
+// @Security ApiKeyAuth
+// @Router /v1/shelves [post]
+func CreateShelf(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, "")
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	if err := requireTenant(claims); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", err)
+	}
+
+	shelfUpdate, err := parseShelfUpdate(c)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	// The built-in shelves take their names first.
+	if err := db.EnsureShelves(claims.TenantID, claims.UserID); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	now := time.Now()
+	shelf := models.Shelf{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		TenantID:  claims.TenantID,
+		UserID:    claims.UserID,
+		Name:      shelfUpdate.Name,
+		Kind:      repository.ShelfKindCustom,
+		IsPublic:  shelfUpdate.IsPublic,
+	}
+
+	err = db.CreateShelf(&shelf)
+	if errors.Is(err, sql.ErrNoRows) {
+		return wrapper.ErrorResponse(c, fiber.StatusConflict, "", errors.New(repository.ShelfExistsErrorMessage))
+	}
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", shelf)
+}
+
+// UpdateShelf func renames a shelf and sets its visibility.
+// @Description Rename a shelf of the current user or make it public or private. Built-in shelves keep their name.
+// @Summary update a shelf
+// @Tags Shelf
+// @Accept json
+// @Produce json
+// @Param id path string true "Shelf ID"
+// @Param request body models.ShelfUpdate true "Shelf"
+// @Success 200 {object} models.Shelf
+// @Security ApiKeyAuth
+// @Router /v1/shelves/{id} [put]
+func UpdateShelf(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, "")
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	shelfUpdate, err := parseShelfUpdate(c)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	shelf, status, err := ownShelf(c, db, claims)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	if shelf.Kind != repository.ShelfKindCustom && shelfUpdate.Name != shelf.Name {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(repository.BuiltInShelfErrorMessage))
+	}
+
+	shelf.UpdatedAt = time.Now()
+	shelf.Name = shelfUpdate.Name
+	shelf.IsPublic = shelfUpdate.IsPublic
+
+	// The shelf was found above, so no row means the name is taken.
+	err = db.UpdateShelf(&shelf)
+	if errors.Is(err, sql.ErrNoRows) {
+		return wrapper.ErrorResponse(c, fiber.StatusConflict, "", errors.New(repository.ShelfExistsErrorMessage))
+	}
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", shelf)
+}
+
+// DeleteShelf func deletes a shelf.
+// @Description Delete a custom shelf of the current user. The books on it are left alone.
+// @Summary delete a shelf
+// @Tags Shelf
+// @Accept json
+// @Produce json
+// @Param id path string true "Shelf ID"
+// @Success 200 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/shelves/{id} [delete]
+func DeleteShelf(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, "")
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	shelf, status, err := ownShelf(c, db, claims)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	if shelf.Kind != repository.ShelfKindCustom {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(repository.BuiltInShelfErrorMessage))
+	}
+
+	err = db.DeleteShelf(claims.TenantID, shelf.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", "ok")
+}
+
+// AddShelfBook func puts a book on a shelf.
+// @Description Put a book of the current organization on a shelf of the current user, at the given position counted from 1, or last without one.
+// @Summary add a book to a shelf
+// @Tags Shelf
+// @Accept json
+// @Produce json
+// @Param id path string true "Shelf ID"
+// @Param request body models.ShelfBookAdd true "Book"
+// @Success 200 {array} models.Book
+// @Security ApiKeyAuth
+// @Router /v1/shelves/{id}/books [post]
+func AddShelfBook(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, "")
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	add := models.ShelfBookAdd{}
+	if err := c.BodyParser(&add); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(add); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	shelf, status, err := ownShelf(c, db, claims)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	if _, err := db.GetBook(claims.TenantID, add.BookID); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	err = db.AddShelfBook(claims.TenantID, shelf.ID, add.BookID, add.Position, time.Now())
+	if errors.Is(err, sql.ErrNoRows) {
+		return wrapper.ErrorResponse(c, fiber.StatusConflict, "", errors.New(repository.BookOnShelfErrorMessage))
+	}
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return shelfBooksResponse(c, db, shelf)
+}
+
+// RemoveShelfBook func takes a book off a shelf.
+// @Description Take a book off a shelf of the current user. The books after it move up.
+// @Summary remove a book from a shelf
+// @Tags Shelf
+// @Accept json
+// @Produce json
+// @Param id path string true "Shelf ID"
+// @Param book_id path string true "Book ID"
+// @Success 200 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/shelves/{id}/books/{book_id} [delete]
+func RemoveShelfBook(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, "")
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	bookID, err := uuid.Parse(c.Params("book_id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	shelf, status, err := ownShelf(c, db, claims)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	err = db.RemoveShelfBook(claims.TenantID, shelf.ID, bookID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", "ok")
+}
+
+// ReorderShelfBooks func changes the order of the books of a shelf.
+// @Description Order the books of a shelf of the current user as listed. The list must hold every book of the shelf once.
+// @Summary reorder the books of a shelf
+// @Tags Shelf
+// @Accept json
+// @Produce json
+// @Param id path string true "Shelf ID"
+// @Param request body models.ShelfOrder true "Order"
+// @Success 200 {array} models.Book
+// @Security ApiKeyAuth
+// @Router /v1/shelves/{id}/books/order [put]
+func ReorderShelfBooks(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, "")
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	order := models.ShelfOrder{}
+	if err := c.BodyParser(&order); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(order); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	shelf, status, err := ownShelf(c, db, claims)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	// The shelf was found above, so no row means the list does not match.
+	err = db.ReorderShelfBooks(claims.TenantID, shelf.ID, order.BookIDs)
+	if errors.Is(err, sql.ErrNoRows) {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(repository.ShelfOrderErrorMessage))
+	}
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return shelfBooksResponse(c, db, shelf)
+}
+
+func parseShelfUpdate(c *fiber.Ctx) (*models.ShelfUpdate, error) {
+	shelfUpdate := &models.ShelfUpdate{}
+	if err := c.BodyParser(shelfUpdate); err != nil {
+		return nil, err
+	}
+	shelfUpdate.Name = strings.TrimSpace(shelfUpdate.Name)
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(shelfUpdate); err != nil {
+		return nil, errors.New(validator.ValidatorErrors(err))
+	}
+
+	return shelfUpdate, nil
+}
+
+// ownShelf loads the shelf named by the id path parameter in the current
+// organization. Shelves of other users are reported as not found.
+func ownShelf(c *fiber.Ctx, db *database.Queries, claims *models.TokenMetadata) (models.Shelf, int, error) {
+	if err := requireTenant(claims); err != nil {
+		return models.Shelf{}, fiber.StatusForbidden, err
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return models.Shelf{}, fiber.StatusBadRequest, err
+	}
+
+	shelf, err := db.GetShelf(claims.TenantID, id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && shelf.UserID != claims.UserID) {
+		return shelf, fiber.StatusNotFound, errors.New(repository.NotFoundErrorMessage)
+	}
+	if err != nil {
+		return shelf, fiber.StatusInternalServerError, err
+	}
+
+	return shelf, 0, nil
+}
+
+func shelfBooksResponse(c *fiber.Ctx, db *database.Queries, shelf models.Shelf) error {
+	books, err := db.GetShelfBooks(shelf.TenantID, shelf.ID)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", books)
+}
+
+// withShelves fills in the shelves of the caller holding each of the books
+// when the request asks for them with include=shelves. Books on none of them,
+// and all books of anonymous callers, are left as they are. The answer then
+// depends on the credentials, which shared caches are told.
+func withShelves(c *fiber.Ctx, db *database.Queries, tenantID uuid.UUID, books []models.Book) (int, error) {
+	if c.Query("include") != "shelves" {
+		return 0, nil
+	}
+	c.Vary(fiber.HeaderAuthorization, fiber.HeaderCookie)
+
+	claims, status, err := optionalClaims(c)
+	if err != nil || claims == nil {
+		return status, err
+	}
+
+	ids := make([]uuid.UUID, len(books))
+	for i := range books {
+		ids[i] = books[i].ID
+	}
+
+	shelves, err := db.GetBookShelves(tenantID, claims.UserID, ids)
+	if err != nil {
+		return fiber.StatusInternalServerError, err
+	}
+
+	for i := range books {
+		books[i].Shelves = shelves[books[i].ID]
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/book_policy"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/validator"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/wrapper"
+	"github.com/create-go-app/fiber-go-template/platform/database"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// GetTags func gets the tags of the organization.
+// @Description Get the tags of the organization named by the X-Tenant header (the default organization without it) with their number of books, most used first. With a prefix, it completes tags being typed.
+// @Summary list tags
+// @Tags Tag
+// @Accept json
+// @Produce json
+// @Param X-Tenant header string false "Organization slug"
+// @Param prefix query string false "Start of the tag"
+// @Param limit query int false "Number of tags, 20 by default"
+// @Success 200 {array} models.TagCount
+// @Router /v1/tags [get]
+func GetTags(c *fiber.Ctx) error {
+	filter := models.TagFilter{}
+	if err := c.QueryParser(&filter); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+	filter.Prefix = normalizeTag(filter.Prefix)
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(filter); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = 20
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	tenantID, err := publicTenant(c, db)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	tags, err := db.GetTags(tenantID, filter.Prefix, filter.Limit)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", tags)
+}
+
+// GetBookTags func gets the tags of a book.
+// @Description Get the tags of a book in the organization named by the X-Tenant header (the default organization without it).
+// @Summary get book tags
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param X-Tenant header string false "Organization slug"
+// @Success 200 {array} string
+// @Router /v1/books/{id}/tags [get]
+func GetBookTags(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	tenantID, err := publicTenant(c, db)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	if _, err := db.GetBook(tenantID, id); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	tags, err := db.GetBookTags(tenantID, id)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", tags)
+}
+
+// SetBookTags func replaces the tags of a book.
+// @Description Replace the tags of a book. Tags are free-form, trimmed and lowercased. Credentials are checked as for replacing the book.
+// @Summary set book tags
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param request body models.BookTagsUpdate true "Tags"
+// @Success 200 {array} string
+// @Security ApiKeyAuth
+// @Router /v1/books/{id}/tags [put]
+func SetBookTags(c *fiber.Ctx) error {
+	claims, status, err := authorizeBook(c, book_policy.Update)
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	tagsUpdate := &models.BookTagsUpdate{}
+	if err := c.BodyParser(tagsUpdate); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+	tagsUpdate.Tags = normalizeTags(tagsUpdate.Tags)
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(tagsUpdate); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if _, _, status, err := authorizedBook(db, claims, id, book_policy.Update); err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	err = db.SetBookTags(claims.TenantID, id, tagsUpdate.Tags)
+	if errors.Is(err, sql.ErrNoRows) {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	tags, err := db.GetBookTags(claims.TenantID, id)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", tags)
+}
+
+// normalizeTag trims and lowercases a tag and collapses its inner spacing.
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.Join(strings.Fields(tag), " "))
+}
+
+// normalizeTags normalizes the tags, dropping repeated ones. An empty tag is
+// kept for validation to reject.
+func normalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = normalizeTag(tag)
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	return normalized
+}
@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/roles_credentials"
+	"github.com/create-go-app/fiber-go-template/platform/database"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// selectMembership returns the membership to act in: the preferred
+// organization when the user belongs to it, otherwise the oldest membership.
+// It returns nil when the user belongs to no organization.
+func selectMembership(db *database.Queries, userID, preferred uuid.UUID) (*models.Membership, error) {
+	memberships, err := db.GetMemberships(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(memberships) == 0 {
+		return nil, nil
+	}
+
+	for i := range memberships {
+		if memberships[i].OrganizationID == preferred {
+			return &memberships[i], nil
+		}
+	}
+
+	return &memberships[0], nil
+}
+
+// sessionTenant picks the tenant for new tokens of the user and the
+// credentials they hold in it.
+func sessionTenant(db *database.Queries, user models.User, preferred uuid.UUID) (uuid.UUID, []string, error) {
+	membership, err := selectMembership(db, user.ID, preferred)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+
+	tenantID, memberRole := uuid.Nil, ""
+	if membership != nil {
+		tenantID, memberRole = membership.OrganizationID, membership.MemberRole
+	}
+
+	credentials, err := roles_credentials.TenantCredentials(user.UserRole, memberRole)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+
+	return tenantID, credentials, nil
+}
+
+// isMember reports whether the user belongs to the organization.
+func isMember(db *database.Queries, userID, organizationID uuid.UUID) (bool, error) {
+	memberships, err := db.GetMemberships(userID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, membership := range memberships {
+		if membership.OrganizationID == organizationID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// requireTenant refuses tokens of users that belong to no organization.
+func requireTenant(claims *models.TokenMetadata) error {
+	if claims.TenantID == uuid.Nil {
+		return errors.New(repository.NoTenantErrorMessage)
+	}
+
+	return nil
+}
+
+// publicTenant resolves the organization of an unauthenticated request from
+// the X-Tenant header, falling back to the default organization.
+func publicTenant(c *fiber.Ctx, db *database.Queries) (uuid.UUID, error) {
+	slug := c.Get(repository.TenantHeaderName, repository.DefaultOrganizationSlug)
+
+	organization, err := db.GetOrganizationBySlug(slug)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return organization.ID, nil
+}
@@ -0,0 +1,50 @@
+package controllers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetBookByISBN_NotModified(t *testing.T) {
+	app, mock := newApp(t)
+	tenantID, bookID := uuid.New(), uuid.New()
+	path := "/v1/books/isbn/0-306-40615-2"
+
+	expectBook := func() {
+		expectTenant(mock, tenantID)
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM books WHERE tenant_id = $1 AND isbn = $2`)).
+			WithArgs(tenantID, "9780306406157").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id", "isbn", "version"}).AddRow(bookID, tenantID, "9780306406157", 3))
+	}
+
+	expectBook()
+	resp, err := request(app, http.MethodGet, path, "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	tag := resp.Header.Get(fiber.HeaderETag)
+	assert.NotEmpty(t, tag)
+
+	req := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+	req.Header.Set(fiber.HeaderIfNoneMatch, tag)
+	expectBook()
+	resp, err = app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusNotModified, resp.StatusCode)
+
+	// the shelves of the caller are not covered by the version
+	req = httptest.NewRequest(http.MethodGet, path+"?include=shelves", http.NoBody)
+	req.Header.Set(fiber.HeaderIfNoneMatch, tag)
+	expectBook()
+	resp, err = app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get(fiber.HeaderETag))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,246 @@
+package controllers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/create-go-app/fiber-go-template/app/controllers"
+	"github.com/create-go-app/fiber-go-template/app/queries"
+	"github.com/create-go-app/fiber-go-template/pkg/middleware"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/jwt"
+	"github.com/create-go-app/fiber-go-template/platform/database"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+var shelfColumns = []string{"id", "created_at", "updated_at", "tenant_id", "user_id", "name", "kind", "is_public"}
+
+// newApp serves the shelf routes, and the book routes listing shelves, on a
+// mocked database.
+func newApp(t *testing.T) (*fiber.App, sqlmock.Sqlmock) {
+	t.Setenv("JWT_SECRET_KEY", "testsecret")
+	t.Setenv("JWT_SECRET_KEY_EXPIRE_MINUTES_COUNT", "15")
+	t.Setenv("JWT_REFRESH_KEY", "testrefresh")
+	t.Setenv("AUTH_COOKIE_MODE", "")
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	db := sqlx.NewDb(sqlDB, "sqlmock")
+
+	open := database.OpenDBConnection
+	database.OpenDBConnection = func() (*database.Queries, error) {
+		return &database.Queries{
+			BookQueries:         &queries.BookQueries{DB: db},
+			OrganizationQueries: &queries.OrganizationQueries{DB: db},
+		}, nil
+	}
+	t.Cleanup(func() { database.OpenDBConnection = open })
+
+	app := fiber.New()
+	app.Get("/v1/book/:id", middleware.JWTOptional(), controllers.GetBook)
+	app.Get("/v1/books/isbn/:isbn", middleware.JWTOptional(), controllers.GetBookByISBN)
+	app.Get("/v1/users/:id/shelves", middleware.JWTOptional(), controllers.GetUserShelves)
+	app.Get("/v1/shelves/:id/books", middleware.JWTOptional(), controllers.GetShelfBooks)
+	app.Put("/v1/shelves/:id", middleware.JWTProtected(), controllers.UpdateShelf)
+	app.Delete("/v1/shelves/:id", middleware.JWTProtected(), controllers.DeleteShelf)
+
+	return app, mock
+}
+
+func token(t *testing.T, userID, tenantID uuid.UUID) string {
+	t.Helper()
+
+	tokens, err := jwt.GenerateNewTokens(userID.String(), tenantID, []string{})
+	if err != nil {
+		t.Fatalf("failed to generate tokens: %v", err)
+	}
+
+	return tokens.Access
+}
+
+func request(app *fiber.App, method, path, accessToken, body string) (*http.Response, error) {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	if accessToken != "" {
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+accessToken)
+	}
+
+	return app.Test(req)
+}
+
+func expectTenant(mock sqlmock.Sqlmock, tenantID uuid.UUID) {
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM organizations WHERE slug = $1`)).
+		WithArgs("default").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at", "name", "slug"}).
+			AddRow(tenantID, time.Now(), time.Now(), "Default", "default"))
+}
+
+func expectShelf(mock sqlmock.Sqlmock, tenantID, userID, shelfID uuid.UUID, kind string, public bool) {
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM shelves WHERE tenant_id = $1 AND id = $2`)).
+		WithArgs(tenantID, shelfID).
+		WillReturnRows(sqlmock.NewRows(shelfColumns).
+			AddRow(shelfID, time.Now(), time.Now(), tenantID, userID, "Favorites", kind, public))
+}
+
+func TestGetUserShelves(t *testing.T) {
+	app, mock := newApp(t)
+	tenantID, ownerID := uuid.New(), uuid.New()
+	path := "/v1/users/" + ownerID.String() + "/shelves"
+
+	cases := []struct {
+		name        string
+		accessToken string
+	}{
+		{"anonymous", ""},
+		{"another user", token(t, uuid.New(), tenantID)},
+		{"owner of another organization", token(t, ownerID, uuid.New())},
+	}
+
+	for _, tc := range cases {
+		expectTenant(mock, tenantID)
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM shelves WHERE tenant_id = $1 AND user_id = $2 AND (is_public OR NOT $3)`)).
+			WithArgs(tenantID, ownerID, true).
+			WillReturnRows(sqlmock.NewRows(shelfColumns))
+
+		resp, err := request(app, http.MethodGet, path, tc.accessToken, "")
+		assert.NoError(t, err, tc.name)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode, tc.name)
+	}
+
+	// the owner sees the private shelves too
+	expectTenant(mock, tenantID)
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO shelves`)).
+		WithArgs(tenantID, ownerID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM shelves WHERE tenant_id = $1 AND user_id = $2`)).
+		WithArgs(tenantID, ownerID, false).
+		WillReturnRows(sqlmock.NewRows(shelfColumns))
+
+	resp, err := request(app, http.MethodGet, path, token(t, ownerID, tenantID), "")
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetShelfBooks_Private(t *testing.T) {
+	app, mock := newApp(t)
+	tenantID, ownerID, shelfID := uuid.New(), uuid.New(), uuid.New()
+	path := "/v1/shelves/" + shelfID.String() + "/books"
+
+	for _, accessToken := range []string{"", token(t, uuid.New(), tenantID)} {
+		expectTenant(mock, tenantID)
+		expectShelf(mock, tenantID, ownerID, shelfID, "favorites", false)
+
+		resp, err := request(app, http.MethodGet, path, accessToken, "")
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	}
+
+	expectTenant(mock, tenantID)
+	expectShelf(mock, tenantID, ownerID, shelfID, "favorites", false)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT b.* FROM shelf_books sb JOIN books b ON b.id = sb.book_id`)).
+		WithArgs(tenantID, shelfID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	resp, err := request(app, http.MethodGet, path, token(t, ownerID, tenantID), "")
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateShelf_OtherUser(t *testing.T) {
+	app, mock := newApp(t)
+	tenantID, shelfID := uuid.New(), uuid.New()
+	expectShelf(mock, tenantID, uuid.New(), shelfID, "custom", true)
+
+	resp, err := request(app, http.MethodPut, "/v1/shelves/"+shelfID.String(), token(t, uuid.New(), tenantID), `{"name":"Mine now"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteShelf_OtherUser(t *testing.T) {
+	app, mock := newApp(t)
+	tenantID, shelfID := uuid.New(), uuid.New()
+	expectShelf(mock, tenantID, uuid.New(), shelfID, "custom", true)
+
+	resp, err := request(app, http.MethodDelete, "/v1/shelves/"+shelfID.String(), token(t, uuid.New(), tenantID), "")
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateShelf_BuiltIn(t *testing.T) {
+	app, mock := newApp(t)
+	tenantID, userID, shelfID := uuid.New(), uuid.New(), uuid.New()
+	expectShelf(mock, tenantID, userID, shelfID, "favorites", false)
+
+	resp, err := request(app, http.MethodPut, "/v1/shelves/"+shelfID.String(), token(t, userID, tenantID), `{"name":"Loved"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	// its visibility can change
+	expectShelf(mock, tenantID, userID, shelfID, "favorites", false)
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE shelves SET updated_at = $3, name = $4, is_public = $5`)).
+		WithArgs(tenantID, shelfID, sqlmock.AnyArg(), "Favorites", true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	resp, err = request(app, http.MethodPut, "/v1/shelves/"+shelfID.String(), token(t, userID, tenantID), `{"name":"Favorites","is_public":true}`)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteShelf_BuiltIn(t *testing.T) {
+	app, mock := newApp(t)
+	tenantID, userID, shelfID := uuid.New(), uuid.New(), uuid.New()
+	expectShelf(mock, tenantID, userID, shelfID, "want_to_read", false)
+
+	resp, err := request(app, http.MethodDelete, "/v1/shelves/"+shelfID.String(), token(t, userID, tenantID), "")
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetBook_IncludeShelves(t *testing.T) {
+	app, mock := newApp(t)
+	tenantID, userID, bookID := uuid.New(), uuid.New(), uuid.New()
+	path := "/v1/book/" + bookID.String()
+
+	expectBook := func() {
+		expectTenant(mock, tenantID)
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM books WHERE tenant_id = $1 AND id = $2`)).
+			WithArgs(tenantID, bookID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id", "version"}).AddRow(bookID, tenantID, 3))
+	}
+
+	// the version alone answers plain requests
+	expectBook()
+	resp, err := request(app, http.MethodGet, path, "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, `"3"`, strings.TrimPrefix(resp.Header.Get(fiber.HeaderETag), "W/"))
+
+	req := httptest.NewRequest(http.MethodGet, path+"?include=shelves", http.NoBody)
+	req.Header.Set(fiber.HeaderIfNoneMatch, resp.Header.Get(fiber.HeaderETag))
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token(t, userID, tenantID))
+	expectBook()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT sb.book_id, s.id, s.name, s.kind FROM shelf_books sb`)).
+		WithArgs(tenantID, userID, bookID).
+		WillReturnRows(sqlmock.NewRows([]string{"book_id", "id", "name", "kind"}).AddRow(bookID, uuid.New(), "Favorites", "favorites"))
+
+	resp, err = app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get(fiber.HeaderETag))
+	assert.Equal(t, "Authorization, Cookie", resp.Header.Get(fiber.HeaderVary))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
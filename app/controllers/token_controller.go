@@ -8,84 +8,113 @@ import (
 	"github.com/create-go-app/fiber-go-template/app/models"
 	"github.com/create-go-app/fiber-go-template/pkg/repository"
 	"github.com/create-go-app/fiber-go-template/pkg/utils/jwt"
-	"github.com/create-go-app/fiber-go-template/pkg/utils/roles_credentials"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/refresh_session"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/session_cookie"
 	"github.com/create-go-app/fiber-go-template/pkg/utils/wrapper"
-	"github.com/create-go-app/fiber-go-template/platform/cache"
 	"github.com/create-go-app/fiber-go-template/platform/database"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 // RenewTokens method for renew access and refresh tokens.
-// @Description Renew access and refresh tokens.
+// @Description Renew access and refresh tokens with the refresh token alone, from the body or, in cookie session mode, the refresh cookie plus the X-CSRF-Token header. The access token may have expired.
 // @Summary renew access and refresh tokens
 // @Tags Token
 // @Accept json
 // @Produce json
 // @Param request body models.Renew true "Renew Request"
 // @Success 200 {object} models.TokenResponse
-// @Security ApiKeyAuth
 // @Router /v1/token/renew [post]
 func RenewTokens(c *fiber.Ctx) error {
-	now := time.Now().Unix()
+	renew := &models.Renew{}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(renew); err != nil {
+			return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+		}
+	}
 
-	claims, err := jwt.ExtractTokenMetadata(c)
+	// Browser clients in cookie session mode never see the refresh token.
+	if cookieConfig := session_cookie.ConfigFromEnv(); renew.RefreshToken == "" && cookieConfig.Enabled {
+		if err := session_cookie.VerifyCSRF(c); err != nil {
+			return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", err)
+		}
+		renew.RefreshToken = c.Cookies(session_cookie.RefreshCookieName)
+	}
+
+	store, err := refresh_session.NewDefault()
 	if err != nil {
 		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
 	}
 
-	expiresAccessToken := claims.Expires
-	if now > expiresAccessToken {
-		return wrapper.ErrorResponse(c, fiber.StatusUnauthorized, "", errors.New(repository.UnauthorizedErrorMessage))
+	session, err := refresh_session.Redeem(context.Background(), store, renew.RefreshToken, time.Now())
+	if errors.Is(err, refresh_session.ErrInvalidToken) {
+		return wrapper.ErrorResponse(c, fiber.StatusUnauthorized, "", err)
+	}
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
 	}
 
-	renew := &models.Renew{}
-	if err := c.BodyParser(renew); err != nil {
-		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
 	}
 
-	expiresRefreshToken, err := jwt.ParseRefreshToken(renew.RefreshToken)
+	foundedUser, err := db.GetUserByID(session.UserID)
 	if err != nil {
-		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
 	}
 
-	if now < expiresRefreshToken {
-		userID := claims.UserID
-		db, err := database.OpenDBConnection()
-		if err != nil {
-			return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
-		}
+	if foundedUser.UserStatus != 1 {
+		return wrapper.ErrorResponse(c, fiber.StatusUnauthorized, "", errors.New(repository.UnauthorizedErrorMessage))
+	}
 
-		foundedUser, err := db.GetUserByID(userID)
-		if err != nil {
-			return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
-		}
+	// Renewed tokens stay in the organization of the session, unless the user
+	// has left it meanwhile.
+	tenantID, credentials, err := sessionTenant(db, foundedUser, session.TenantID)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
 
-		credentials, err := roles_credentials.GetCredentialsByRole(foundedUser.UserRole)
-		if err != nil {
-			return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
-		}
+	return startSession(c, store, foundedUser.ID, tenantID, credentials)
+}
 
-		tokens, err := jwt.GenerateNewTokens(userID.String(), credentials)
-		if err != nil {
-			return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
-		}
+// startSession issues tokens for a new session, stores its refresh token hash
+// and returns the tokens.
+func startSession(c *fiber.Ctx, store refresh_session.Store, userID, tenantID uuid.UUID, credentials []string) error {
+	tokens, err := jwt.GenerateNewTokens(userID.String(), tenantID, credentials)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
 
-		connRedis, err := cache.RedisConnection()
-		if err != nil {
-			return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
-		}
+	if err := refresh_session.Start(context.Background(), store, userID, tenantID, tokens); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
 
-		errRedis := connRedis.Set(context.Background(), userID.String(), tokens.Refresh, 0).Err()
-		if errRedis != nil {
-			return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", errRedis)
-		}
+	return tokenResponse(c, tokens)
+}
 
+// tokenResponse returns freshly issued tokens. In cookie session mode the
+// tokens are set as cookies and only the ones not set that way, plus the CSRF
+// token, are returned in the body.
+func tokenResponse(c *fiber.Ctx, tokens *models.Tokens) error {
+	cookieConfig := session_cookie.ConfigFromEnv()
+	if !cookieConfig.Enabled {
 		return wrapper.SuccessResponse(c, "", models.TokenResponse{
 			Access:  tokens.Access,
 			Refresh: tokens.Refresh,
 		})
-	} else {
-		return wrapper.ErrorResponse(c, fiber.StatusUnauthorized, "", errors.New(repository.UnauthorizedErrorMessage))
 	}
+
+	csrfToken, err := session_cookie.SetTokens(c, cookieConfig, tokens)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	response := models.TokenResponse{CSRF: csrfToken}
+	if !cookieConfig.AccessToken {
+		response.Access = tokens.Access
+	}
+
+	return wrapper.SuccessResponse(c, "", response)
 }
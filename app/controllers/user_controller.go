@@ -0,0 +1,500 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/account_purge"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/jwt"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/mailer"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/password_generator"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/random_token"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/refresh_session"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/roles_credentials"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/validator"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/wrapper"
+	"github.com/create-go-app/fiber-go-template/platform/database"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// GetCurrentUser method to get the profile of the current user.
+// @Description Get the profile, role and credentials of the current user.
+// @Summary get the current user
+// @Tags User
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.UserProfile
+// @Security ApiKeyAuth
+// @Router /v1/user/me [get]
+func GetCurrentUser(c *fiber.Ctx) error {
+	now := time.Now().Unix()
+
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if now > claims.Expires {
+		return wrapper.ErrorResponse(c, fiber.StatusUnauthorized, "", errors.New(repository.UnauthorizedErrorMessage))
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	foundedUser, err := db.GetUserByID(claims.UserID)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	credentials, err := roles_credentials.GetCredentialsByRole(foundedUser.UserRole)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", models.NewUserProfile(foundedUser, credentials))
+}
+
+// UpdateCurrentUser method to update the profile of the current user.
+// @Description Update profile fields of the current user. A new email is applied only after it has been verified.
+// @Summary update the current user
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param request body models.UserProfileUpdate true "Profile"
+// @Success 200 {object} models.UserProfile
+// @Security ApiKeyAuth
+// @Router /v1/user/me [patch]
+func UpdateCurrentUser(c *fiber.Ctx) error {
+	now := time.Now()
+
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if now.Unix() > claims.Expires {
+		return wrapper.ErrorResponse(c, fiber.StatusUnauthorized, "", errors.New(repository.UnauthorizedErrorMessage))
+	}
+
+	profileUpdate := &models.UserProfileUpdate{}
+	if err := c.BodyParser(profileUpdate); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(profileUpdate); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	}
+
+	// The email address is the way back into the account, so support staff
+	// must not be able to change it.
+	if profileUpdate.Email != nil && claims.Impersonated() {
+		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", errors.New(repository.ImpersonationNotAllowedErrorMessage))
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	foundedUser, err := db.GetUserByID(claims.UserID)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	if profileUpdate.DisplayName != nil {
+		foundedUser.DisplayName = *profileUpdate.DisplayName
+	}
+	if profileUpdate.AvatarURL != nil {
+		foundedUser.AvatarURL = *profileUpdate.AvatarURL
+	}
+	if profileUpdate.Locale != nil {
+		foundedUser.Locale = *profileUpdate.Locale
+	}
+	if profileUpdate.Timezone != nil {
+		foundedUser.Timezone = *profileUpdate.Timezone
+	}
+
+	if err := db.UpdateUserProfile(foundedUser.ID, &foundedUser); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	message := ""
+	if profileUpdate.Email != nil && !strings.EqualFold(*profileUpdate.Email, foundedUser.Email) {
+		if err := requestEmailChange(db, foundedUser, *profileUpdate.Email, now); err != nil {
+			return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+		}
+		message = repository.EmailVerificationSentMessage
+	}
+
+	credentials, err := roles_credentials.GetCredentialsByRole(foundedUser.UserRole)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, message, models.NewUserProfile(foundedUser, credentials))
+}
+
+// requestEmailChange stores a pending verification and mails the token to the
+// new address. The answer to the client does not depend on whether the
+// address is taken; that is checked when the token is redeemed.
+func requestEmailChange(db *database.Queries, user models.User, newEmail string, now time.Time) error {
+	token, hash, err := random_token.Generate()
+	if err != nil {
+		return err
+	}
+
+	if err := db.DeleteEmailVerifications(user.ID); err != nil {
+		return err
+	}
+
+	hoursCount, err := strconv.Atoi(os.Getenv("EMAIL_VERIFICATION_EXPIRE_HOURS"))
+	if err != nil || hoursCount <= 0 {
+		hoursCount = 24
+	}
+
+	verification := &models.EmailVerification{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UserID:    user.ID,
+		NewEmail:  newEmail,
+		TokenHash: hash,
+		ExpiresAt: now.Add(time.Hour * time.Duration(hoursCount)),
+	}
+
+	if err := db.CreateEmailVerification(verification); err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf(
+		"Confirm your new email address with this token (valid for %d hours):\n\n%s\n",
+		hoursCount, token,
+	)
+
+	return mailer.NewMailer().Send(newEmail, "Confirm your new email address", body)
+}
+
+// VerifyEmail method to confirm a pending email change.
+// @Description Confirm a pending email change with the token sent to the new address.
+// @Summary confirm email change
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param request body models.EmailVerify true "Verification token"
+// @Success 200 {string} status "ok"
+// @Router /v1/user/email/verify [post]
+func VerifyEmail(c *fiber.Ctx) error {
+	emailVerify := &models.EmailVerify{}
+	if err := c.BodyParser(emailVerify); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(emailVerify); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	verification, err := db.GetEmailVerificationByHash(random_token.Hash(emailVerify.Token))
+	if err != nil || time.Now().After(verification.ExpiresAt) {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(repository.InvalidVerificationTokenErrorMessage))
+	}
+
+	if _, err := db.GetUserByEmail(verification.NewEmail); err == nil {
+		return wrapper.ErrorResponse(c, fiber.StatusConflict, "", errors.New(repository.EmailUnavailableErrorMessage))
+	}
+
+	if err := db.UpdateUserEmail(verification.UserID, verification.NewEmail); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if err := db.DeleteEmailVerifications(verification.UserID); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", "ok")
+}
+
+// ExportCurrentUser method to download all personal data of the current user.
+// @Description Export the profile, memberships, books, reviews, shelves, API keys, active sessions and sign in audit entries of the current user as a ZIP (default) or JSON document.
+// @Summary export data of the current user
+// @Tags User
+// @Accept json
+// @Produce json,application/zip
+// @Param format query string false "zip or json"
+// @Success 200 {object} models.AccountExport
+// @Security ApiKeyAuth
+// @Router /v1/user/me/export [get]
+func ExportCurrentUser(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, "")
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	if err := requirePersonalSession(claims); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", err)
+	}
+
+	format := c.Query("format", "zip")
+	if format != "zip" && format != "json" {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(repository.UnsupportedFormatErrorMessage))
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	export, err := buildAccountExport(db, claims.UserID)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if format == "json" {
+		return wrapper.SuccessResponse(c, "", export)
+	}
+
+	archive, err := accountExportArchive(export)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="account-export.zip"`)
+
+	return c.Send(archive)
+}
+
+func buildAccountExport(db *database.Queries, userID uuid.UUID) (models.AccountExport, error) {
+	export := models.AccountExport{ExportedAt: time.Now()}
+
+	foundedUser, err := db.GetUserByID(userID)
+	if err != nil {
+		return export, err
+	}
+	credentials, _ := roles_credentials.GetCredentialsByRole(foundedUser.UserRole)
+	export.Profile = models.NewUserProfile(foundedUser, credentials)
+
+	// Books, reviews and shelves are read tenant by tenant, from every
+	// organization the user is a member of.
+	memberships, err := db.GetMemberships(userID)
+	if err != nil {
+		return export, err
+	}
+
+	export.Books = []models.Book{}
+	export.Reviews = []models.Review{}
+	export.Shelves = []models.AccountShelf{}
+	for _, membership := range memberships {
+		books, err := db.GetBooksByUserID(membership.OrganizationID, userID)
+		if err != nil {
+			return export, err
+		}
+		export.Books = append(export.Books, books...)
+
+		reviews, err := db.GetReviewsByUserID(membership.OrganizationID, userID)
+		if err != nil {
+			return export, err
+		}
+		export.Reviews = append(export.Reviews, reviews...)
+
+		shelves, err := db.GetUserShelves(membership.OrganizationID, userID, false)
+		if err != nil {
+			return export, err
+		}
+		for _, shelf := range shelves {
+			shelfBooks, err := db.GetShelfBooks(membership.OrganizationID, shelf.ID)
+			if err != nil {
+				return export, err
+			}
+
+			accountShelf := models.AccountShelf{Shelf: shelf, BookIDs: make([]uuid.UUID, len(shelfBooks))}
+			for i, book := range shelfBooks {
+				accountShelf.BookIDs[i] = book.ID
+			}
+			export.Shelves = append(export.Shelves, accountShelf)
+		}
+	}
+	export.Memberships = memberships
+
+	if export.APIKeys, err = db.GetAPIKeysByUserID(userID); err != nil {
+		return export, err
+	}
+
+	store, err := refresh_session.NewDefault()
+	if err != nil {
+		return export, err
+	}
+
+	sessions, err := store.List(context.Background(), userID)
+	if err != nil {
+		return export, err
+	}
+
+	export.Sessions = make([]models.Session, len(sessions))
+	for i, session := range sessions {
+		export.Sessions[i] = models.Session{ID: session.ID, ExpiresAt: session.ExpiresAt}
+	}
+
+	if export.LoginAttempts, err = db.GetLoginAttemptsByUserID(userID); err != nil {
+		return export, err
+	}
+
+	return export, nil
+}
+
+// accountExportArchive writes every part of the export as its own JSON file.
+func accountExportArchive(export models.AccountExport) ([]byte, error) {
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", export.Profile},
+		{"memberships.json", export.Memberships},
+		{"books.json", export.Books},
+		{"reviews.json", export.Reviews},
+		{"shelves.json", export.Shelves},
+		{"api_keys.json", export.APIKeys},
+		{"sessions.json", export.Sessions},
+		{"login_attempts.json", export.LoginAttempts},
+	}
+
+	buffer := &bytes.Buffer{}
+	archive := zip.NewWriter(buffer)
+	for _, file := range files {
+		writer, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: export.ExportedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// DeleteCurrentUser method to schedule deletion of the current account.
+// @Description Schedule the current account for anonymization or deletion after the grace period. Requires the password and signs out every session.
+// @Summary delete the current account
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param request body models.AccountDeletion true "Account Deletion Request"
+// @Success 200 {object} models.UserProfile
+// @Security ApiKeyAuth
+// @Router /v1/user/me [delete]
+func DeleteCurrentUser(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, "")
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	if err := requirePersonalSession(claims); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", err)
+	}
+
+	deletion := &models.AccountDeletion{}
+	if err := c.BodyParser(deletion); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", err)
+	}
+
+	validate := validator.NewValidator()
+	if err := validate.Struct(deletion); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusBadRequest, "", errors.New(validator.ValidatorErrors(err)))
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	foundedUser, err := db.GetUserByID(claims.UserID)
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusNotFound, "", errors.New(repository.NotFoundErrorMessage))
+	}
+
+	if !password_generator.ComparePasswords(foundedUser.PasswordHash, deletion.Password) {
+		return invalidCredentialsResponse(c)
+	}
+
+	now := time.Now()
+	scheduledAt := now.Add(account_purge.GracePeriod())
+	if err := db.ScheduleUserDeletion(foundedUser.ID, &scheduledAt, deletion.Mode); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if err := db.RevokeAPIKeys(foundedUser.ID, now); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if err := revokeRefreshToken(foundedUser.ID); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	foundedUser.DeletionScheduledAt = &scheduledAt
+	foundedUser.DeletionMode = deletion.Mode
+	credentials, _ := roles_credentials.GetCredentialsByRole(foundedUser.UserRole)
+
+	return wrapper.SuccessResponse(c, repository.AccountDeletionScheduledMessage, models.NewUserProfile(foundedUser, credentials))
+}
+
+// CancelCurrentUserDeletion method to cancel a scheduled deletion of the current account.
+// @Description Cancel a scheduled deletion of the current account during the grace period.
+// @Summary cancel deletion of the current account
+// @Tags User
+// @Accept json
+// @Produce json
+// @Success 200 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/user/me/deletion/cancel [post]
+func CancelCurrentUserDeletion(c *fiber.Ctx) error {
+	claims, status, err := authorize(c, "")
+	if err != nil {
+		return wrapper.ErrorResponse(c, status, "", err)
+	}
+
+	if err := requirePersonalSession(claims); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusForbidden, "", err)
+	}
+
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	if err := db.ScheduleUserDeletion(claims.UserID, nil, ""); err != nil {
+		return wrapper.ErrorResponse(c, fiber.StatusInternalServerError, "", err)
+	}
+
+	return wrapper.SuccessResponse(c, "", "ok")
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccountDeletion asks for the current account to be removed after the grace
+// period. "anonymize" keeps the books under a scrubbed account, "delete"
+// removes the account and everything it owns.
+type AccountDeletion struct {
+	Password string `json:"password" validate:"required,lte=255"`
+	Mode     string `json:"mode" validate:"required,oneof=anonymize delete"`
+}
+
+// AccountExport holds all personal data kept about a user.
+type AccountExport struct {
+	ExportedAt    time.Time      `json:"exported_at"`
+	Profile       UserProfile    `json:"profile"`
+	Memberships   []Membership   `json:"memberships"`
+	Books         []Book         `json:"books"`
+	Reviews       []Review       `json:"reviews"`
+	Shelves       []AccountShelf `json:"shelves"`
+	APIKeys       []APIKey       `json:"api_keys"`
+	Sessions      []Session      `json:"sessions"`
+	LoginAttempts []LoginAttempt `json:"login_attempts"`
+}
+
+// AccountShelf is a shelf of the user with its books in order, as listed in
+// the export.
+type AccountShelf struct {
+	Shelf
+	BookIDs []uuid.UUID `json:"book_ids"`
+}
+
+// Session is a signed in session of the user, as listed in the export. The
+// refresh token behind it is never shown.
+type Session struct {
+	ID        uuid.UUID `json:"id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type APIKeyCreate struct {
+	Name          string   `json:"name" validate:"required,lte=100"`
+	Credentials   []string `json:"credentials" validate:"required,min=1,dive,required"`
+	ExpiresInDays int      `json:"expires_in_days" validate:"required,min=1,max=365"`
+}
+
+type APIKey struct {
+	ID          uuid.UUID         `db:"id" json:"id" validate:"required,uuid"`
+	CreatedAt   time.Time         `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time         `db:"updated_at" json:"updated_at"`
+	UserID      uuid.UUID         `db:"user_id" json:"user_id" validate:"required,uuid"`
+	Name        string            `db:"name" json:"name" validate:"required,lte=100"`
+	KeyPrefix   string            `db:"key_prefix" json:"key_prefix"`
+	KeyHash     string            `db:"key_hash" json:"-"`
+	Credentials APIKeyCredentials `db:"credentials" json:"credentials"`
+	ExpiresAt   time.Time         `db:"expires_at" json:"expires_at"`
+	LastUsedAt  *time.Time        `db:"last_used_at" json:"last_used_at"`
+	RevokedAt   *time.Time        `db:"revoked_at" json:"revoked_at"`
+	TenantID    uuid.UUID         `db:"tenant_id" json:"tenant_id"`
+}
+
+// APIKeyCreated is returned only once, right after the key is minted.
+type APIKeyCreated struct {
+	APIKey
+	Key string `json:"key" example:"fgt_..."`
+}
+
+type APIKeyCredentials []string
+
+func (a APIKeyCredentials) Value() (driver.Value, error) {
+	return json.Marshal(a)
+}
+
+func (a *APIKeyCredentials) Scan(value interface{}) error {
+	j, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(j, &a)
+}
@@ -2,8 +2,12 @@ package models
 
 type SignUp struct {
 	Email    string `json:"email" validate:"required,email,lte=255"`
-	Password string `json:"password" validate:"required,lte=255"`
-	UserRole string `json:"user_role" validate:"required,lte=25"`
+	Password string `json:"password" validate:"required,password"`
+}
+
+type SignUpResponse struct {
+	Email    string `json:"email"`
+	UserRole string `json:"user_role"`
 }
 
 type SignIn struct {
@@ -11,7 +15,16 @@ type SignIn struct {
 	Password string `json:"password" validate:"required,lte=255"`
 }
 
+// TokenResponse carries the issued tokens. In cookie session mode the tokens
+// set as cookies are left out and CSRF holds the token for the X-CSRF-Token
+// header.
 type TokenResponse struct {
-	Access  string `json:"access" example:"access-token"`
-	Refresh string `json:"refresh" example:"refresh-token"`
+	Access  string `json:"access,omitempty" example:"access-token"`
+	Refresh string `json:"refresh,omitempty" example:"refresh-token"`
+	CSRF    string `json:"csrf,omitempty" example:"csrf-token"`
+}
+
+type PasswordChange struct {
+	CurrentPassword string `json:"current_password" validate:"required,lte=255"`
+	NewPassword     string `json:"new_password" validate:"required,password,nefield=CurrentPassword"`
 }
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Author is a person books of an organization are credited to. NameKey is
+// the name without punctuation, spacing and case, the organization has one
+// author per key.
+type Author struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+	TenantID  uuid.UUID `db:"tenant_id" json:"tenant_id"`
+	Name      string    `db:"name" json:"name"`
+	NameKey   string    `db:"name_key" json:"-"`
+}
+
+type AuthorUpdate struct {
+	Name string `json:"name" validate:"required,lte=255"`
+}
+
+type AuthorFilter struct {
+	Name  string `query:"name" validate:"lte=255"`
+	Page  int    `query:"page" validate:"omitempty,min=1"`
+	Limit int    `query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+// LimitOffset returns the pagination of a validated filter, 20 authors per
+// page by default.
+func (f AuthorFilter) LimitOffset() (int, int) {
+	limit := f.Limit
+	if limit == 0 {
+		limit = 20
+	}
+
+	offset := 0
+	if f.Page > 1 {
+		offset = (f.Page - 1) * limit
+	}
+
+	return limit, offset
+}
+
+type AuthorPage struct {
+	Authors []Author `json:"authors"`
+	Total   int      `json:"total"`
+	Page    int      `json:"page"`
+	Limit   int      `json:"limit"`
+}
+
+// BookAuthor credits an author of a book with a role. Position orders the
+// credits of the book, from 1.
+type BookAuthor struct {
+	AuthorID   uuid.UUID `db:"author_id" json:"author_id"`
+	Name       string    `db:"name" json:"name"`
+	AuthorRole string    `db:"author_role" json:"author_role"`
+	Position   int       `db:"position" json:"position"`
+}
+
+type BookAuthorUpdate struct {
+	AuthorID   uuid.UUID `json:"author_id" validate:"required"`
+	AuthorRole string    `json:"author_role" validate:"required,oneof=author editor translator"`
+}
+
+// BookAuthorsUpdate replaces the credits of a book, in the order given.
+type BookAuthorsUpdate struct {
+	Authors []BookAuthorUpdate `json:"authors" validate:"lte=50,dive"`
+}
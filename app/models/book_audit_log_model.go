@@ -0,0 +1,28 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BookAuditLog records a change made to a book by someone who does not own
+// it. Before and After hold the book as JSON, After is empty for deletions.
+type BookAuditLog struct {
+	ID        uuid.UUID       `db:"id" json:"id"`
+	CreatedAt time.Time       `db:"created_at" json:"created_at"`
+	TenantID  uuid.UUID       `db:"tenant_id" json:"tenant_id"`
+	BookID    uuid.UUID       `db:"book_id" json:"book_id"`
+	OwnerID   *uuid.UUID      `db:"owner_id" json:"owner_id"`
+	ActorID   *uuid.UUID      `db:"actor_id" json:"actor_id"`
+	Action    string          `db:"action" json:"action"`
+	Before    json.RawMessage `db:"before" json:"before"`
+	After     json.RawMessage `db:"after" json:"after,omitempty"`
+}
+
+type BookAuditLogFilter struct {
+	BookID  string `query:"book_id" validate:"omitempty,uuid"`
+	ActorID string `query:"actor_id" validate:"omitempty,uuid"`
+	Limit   int    `query:"limit" validate:"omitempty,min=1,max=500"`
+}
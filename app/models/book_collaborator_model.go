@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BookCollaborator is a user the owner shared a book with.
+type BookCollaborator struct {
+	BookID           uuid.UUID `db:"book_id" json:"book_id"`
+	UserID           uuid.UUID `db:"user_id" json:"user_id"`
+	CollaboratorRole string    `db:"collaborator_role" json:"collaborator_role"`
+	CreatedAt        time.Time `db:"created_at" json:"created_at"`
+}
+
+type BookCollaboratorUpdate struct {
+	UserID           uuid.UUID `json:"user_id" validate:"required"`
+	CollaboratorRole string    `json:"collaborator_role" validate:"required,oneof=editor viewer"`
+}
+
+// BookTransfer is an ownership transfer waiting for the recipient to accept
+// it.
+type BookTransfer struct {
+	BookID     uuid.UUID `db:"book_id" json:"book_id"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+	FromUserID uuid.UUID `db:"from_user_id" json:"from_user_id"`
+	ToUserID   uuid.UUID `db:"to_user_id" json:"to_user_id"`
+	ExpiresAt  time.Time `db:"expires_at" json:"expires_at"`
+}
+
+type BookTransferCreate struct {
+	UserID uuid.UUID `json:"user_id" validate:"required"`
+}
+
+// BookSharing lists who a book is shared with and its pending transfer.
+type BookSharing struct {
+	Collaborators []BookCollaborator `json:"collaborators"`
+	Transfer      *BookTransfer      `json:"transfer"`
+}
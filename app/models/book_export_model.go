@@ -0,0 +1,51 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BookExportFilter selects the books to export. Empty fields match every
+// book, the dates bound created_at and are RFC 3339 timestamps.
+type BookExportFilter struct {
+	Author      string `query:"author" json:"author,omitempty" validate:"lte=255"`
+	BookStatus  string `query:"book_status" json:"book_status,omitempty" validate:"omitempty,oneof=0 1"`
+	UserID      string `query:"user_id" json:"user_id,omitempty" validate:"omitempty,uuid"`
+	CreatedFrom string `query:"created_from" json:"created_from,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	CreatedTo   string `query:"created_to" json:"created_to,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+}
+
+func (f BookExportFilter) Value() (driver.Value, error) {
+	return json.Marshal(f)
+}
+
+func (f *BookExportFilter) Scan(value interface{}) error {
+	j, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(j, &f)
+}
+
+// BookExportJob tracks an export too large to be streamed in the response.
+// Its file can be downloaded from DownloadURL once the job is done.
+type BookExportJob struct {
+	ID           uuid.UUID        `db:"id" json:"id"`
+	CreatedAt    time.Time        `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time        `db:"updated_at" json:"updated_at"`
+	TenantID     uuid.UUID        `db:"tenant_id" json:"tenant_id"`
+	UserID       uuid.UUID        `db:"user_id" json:"user_id"`
+	Format       string           `db:"format" json:"format"`
+	Filter       BookExportFilter `db:"filter" json:"filter"`
+	Status       string           `db:"status" json:"status"`
+	TotalRows    int              `db:"total_rows" json:"total_rows"`
+	ExportedRows int              `db:"exported_rows" json:"exported_rows"`
+	Error        string           `db:"error" json:"error,omitempty"`
+	FinishedAt   *time.Time       `db:"finished_at" json:"finished_at"`
+	DownloadURL  string           `db:"-" json:"download_url,omitempty"`
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BookImportJob tracks the import of an uploaded file of books. Dry runs
+// validate every row without writing any book.
+type BookImportJob struct {
+	ID             uuid.UUID                `db:"id" json:"id"`
+	CreatedAt      time.Time                `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time                `db:"updated_at" json:"updated_at"`
+	TenantID       uuid.UUID                `db:"tenant_id" json:"tenant_id"`
+	UserID         uuid.UUID                `db:"user_id" json:"user_id"`
+	Format         string                   `db:"format" json:"format"`
+	DryRun         bool                     `db:"dry_run" json:"dry_run"`
+	Status         string                   `db:"status" json:"status"`
+	TotalRows      int                      `db:"total_rows" json:"total_rows"`
+	ProcessedRows  int                      `db:"processed_rows" json:"processed_rows"`
+	ImportedRows   int                      `db:"imported_rows" json:"imported_rows"`
+	RowErrors      BookImportRowErrors      `db:"row_errors" json:"row_errors"`
+	FinishedAt     *time.Time               `db:"finished_at" json:"finished_at"`
+	UnmappedFields BookImportUnmappedFields `db:"unmapped_fields" json:"unmapped_fields"`
+}
+
+// BookImportRowError reports why the row on Line of the file was not
+// imported.
+type BookImportRowError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+type BookImportRowErrors []BookImportRowError
+
+func (e BookImportRowErrors) Value() (driver.Value, error) {
+	if e == nil {
+		return []byte("[]"), nil
+	}
+
+	return json.Marshal(e)
+}
+
+func (e *BookImportRowErrors) Scan(value interface{}) error {
+	j, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(j, &e)
+}
+
+// BookImportUnmappedFields counts, per field of the file, the records where
+// it was found but has nowhere to go in a book.
+type BookImportUnmappedFields map[string]int
+
+func (f BookImportUnmappedFields) Value() (driver.Value, error) {
+	if f == nil {
+		return []byte("{}"), nil
+	}
+
+	return json.Marshal(f)
+}
+
+func (f *BookImportUnmappedFields) Scan(value interface{}) error {
+	j, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(j, &f)
+}
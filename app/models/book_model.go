@@ -4,6 +4,7 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,6 +19,7 @@ type BookCreate struct {
 	Author     string    `db:"author" json:"author" validate:"required,lte=255"`
 	BookStatus int       `db:"book_status" json:"book_status" validate:"oneof=0 1"`
 	BookAttrs  BookAttrs `db:"book_attrs" json:"book_attrs" validate:"required"`
+	BookMetadata
 }
 
 type BookUpdate struct {
@@ -26,6 +28,25 @@ type BookUpdate struct {
 	Author     string    `db:"author" json:"author" validate:"required,lte=255"`
 	BookStatus int       `db:"book_status" json:"book_status" validate:"oneof=0 1"`
 	BookAttrs  BookAttrs `db:"book_attrs" json:"book_attrs" validate:"required"`
+	BookMetadata
+}
+
+// BookReplace holds the fields of a book its editors may change. It is also
+// the document PATCH requests are applied to.
+type BookReplace struct {
+	Title      string    `json:"title" validate:"required,lte=255"`
+	Author     string    `json:"author" validate:"required,lte=255"`
+	BookStatus int       `json:"book_status" validate:"oneof=0 1"`
+	BookAttrs  BookAttrs `json:"book_attrs" validate:"required"`
+	BookMetadata
+}
+
+// BookListFilter narrows and orders the book list. Category matches its
+// subcategories too.
+type BookListFilter struct {
+	Tag      string `query:"tag" validate:"lte=50"`
+	Category string `query:"category" validate:"omitempty,uuid"`
+	Sort     string `query:"sort" validate:"omitempty,oneof=rating"`
 }
 
 type Book struct {
@@ -37,12 +58,84 @@ type Book struct {
 	Author     string    `db:"author" json:"author" validate:"required,lte=255"`
 	BookStatus int       `db:"book_status" json:"book_status" validate:"oneof=0 1"`
 	BookAttrs  BookAttrs `db:"book_attrs" json:"book_attrs" validate:"required"`
+	TenantID   uuid.UUID `db:"tenant_id" json:"tenant_id"`
+	Version    int       `db:"version" json:"version"`
+	BookMetadata
+	// Shelves lists the shelves of the current user holding the book. It is
+	// only filled in when asked for with include=shelves, and left out when
+	// the book is on none of them.
+	Shelves []ShelfRef `db:"-" json:"shelves,omitempty"`
+}
+
+// Editable returns the fields of the book its editors may change.
+func (b Book) Editable() BookReplace {
+	return BookReplace{
+		Title:        b.Title,
+		Author:       b.Author,
+		BookStatus:   b.BookStatus,
+		BookAttrs:    b.BookAttrs,
+		BookMetadata: b.BookMetadata,
+	}
+}
+
+// BookMetadata holds the bibliographic fields of a book, all optional. ISBNs
+// are stored as ISBN-13 without hyphens and are unique in an organization.
+type BookMetadata struct {
+	ISBN            string `db:"isbn" json:"isbn" validate:"omitempty,isbn"`
+	Publisher       string `db:"publisher" json:"publisher" validate:"lte=255"`
+	PublicationDate *Date  `db:"publication_date" json:"publication_date"`
+	Language        string `db:"language" json:"language" validate:"omitempty,bcp47,lte=35"`
+	PageCount       int    `db:"page_count" json:"page_count" validate:"min=0,max=100000"`
+	Edition         string `db:"edition" json:"edition" validate:"lte=100"`
+	BookFormat      string `db:"book_format" json:"book_format" validate:"omitempty,oneof=hardcover paperback ebook audiobook"`
+}
+
+// Date is a calendar day, written as YYYY-MM-DD.
+type Date struct {
+	time.Time
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Format(time.DateOnly))
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return fmt.Errorf("date %q is not in the YYYY-MM-DD format", s)
+	}
+	d.Time = t
+
+	return nil
+}
+
+func (d Date) Value() (driver.Value, error) {
+	return d.Format(time.DateOnly), nil
+}
+
+func (d *Date) Scan(value interface{}) error {
+	t, ok := value.(time.Time)
+	if !ok {
+		return errors.New("type assertion to time.Time failed")
+	}
+	d.Time = t
+
+	return nil
 }
 
+// BookAttrs.Picture holds the URL of the cover and Thumbnails the URLs of
+// its scaled down copies by width, once a cover is uploaded. A Rating of 0
+// means unrated, which only books imported from records can be.
 type BookAttrs struct {
-	Picture     string `json:"picture"`
-	Description string `json:"description"`
-	Rating      int    `json:"rating" validate:"min=1,max=10"`
+	Picture     string            `json:"picture"`
+	Thumbnails  map[string]string `json:"thumbnails,omitempty"`
+	Description string            `json:"description"`
+	Rating      int               `json:"rating" validate:"min=1,max=10"`
 }
 
 func (b BookAttrs) Value() (driver.Value, error) {
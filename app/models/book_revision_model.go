@@ -0,0 +1,77 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BookRevision is one change to a book, numbered from 1 per book. Snapshot
+// holds the book row as JSON after the change, or as it was when deleted.
+// RestoredFrom is the revision the change brought back, if any.
+type BookRevision struct {
+	ID           uuid.UUID        `db:"id" json:"id"`
+	CreatedAt    time.Time        `db:"created_at" json:"created_at"`
+	TenantID     uuid.UUID        `db:"tenant_id" json:"tenant_id"`
+	BookID       uuid.UUID        `db:"book_id" json:"book_id"`
+	Revision     int              `db:"revision" json:"revision"`
+	Action       string           `db:"action" json:"action"`
+	ActorID      *uuid.UUID       `db:"actor_id" json:"actor_id"`
+	RestoredFrom *int             `db:"restored_from" json:"restored_from"`
+	Snapshot     json.RawMessage  `db:"snapshot" json:"snapshot"`
+	Diff         BookRevisionDiff `db:"diff" json:"diff"`
+}
+
+// BookFieldChange is the value of a field before and after a change, null
+// when the book did not exist.
+type BookFieldChange struct {
+	From json.RawMessage `json:"from"`
+	To   json.RawMessage `json:"to"`
+}
+
+// BookRevisionDiff maps the fields that changed, with the book_attrs fields
+// named like "book_attrs.rating", to their change.
+type BookRevisionDiff map[string]BookFieldChange
+
+func (d BookRevisionDiff) Value() (driver.Value, error) {
+	if d == nil {
+		return []byte("{}"), nil
+	}
+
+	return json.Marshal(d)
+}
+
+func (d *BookRevisionDiff) Scan(value interface{}) error {
+	j, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(j, &d)
+}
+
+// BookRevisionSnapshot is the part of a snapshot a restore reads back.
+type BookRevisionSnapshot struct {
+	BookReplace
+	CreatedAt time.Time `json:"created_at"`
+	UserID    uuid.UUID `json:"user_id"`
+	Version   int       `json:"version"`
+}
+
+// BookChange tells who makes a change to a book, for the revision it is
+// recorded as. RestoredFrom is the revision a restore brings back, 0
+// otherwise, and Audit the record of a change made by a moderator, nil
+// otherwise.
+type BookChange struct {
+	ActorID      uuid.UUID
+	RestoredFrom int
+	Audit        *BookAuditLog
+}
+
+type BookRevisionDiffFilter struct {
+	From int `query:"from" validate:"required,min=1"`
+	To   int `query:"to" validate:"required,min=1"`
+}
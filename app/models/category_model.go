@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Category is a node of the category tree of an organization. ParentID is
+// nil for top-level categories.
+type Category struct {
+	ID        uuid.UUID  `db:"id" json:"id"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at" json:"updated_at"`
+	TenantID  uuid.UUID  `db:"tenant_id" json:"tenant_id"`
+	ParentID  *uuid.UUID `db:"parent_id" json:"parent_id"`
+	Name      string     `db:"name" json:"name"`
+}
+
+type CategoryUpdate struct {
+	Name     string     `json:"name" validate:"required,lte=100"`
+	ParentID *uuid.UUID `json:"parent_id"`
+}
+
+// CategoryNode is a category with its subcategories.
+type CategoryNode struct {
+	Category
+	Children []CategoryNode `json:"children"`
+}
+
+// CategoryTree arranges categories into trees, keeping their order among
+// siblings. Categories whose parent is missing become roots.
+func CategoryTree(categories []Category) []CategoryNode {
+	known := map[uuid.UUID]bool{}
+	for _, category := range categories {
+		known[category.ID] = true
+	}
+
+	children := map[uuid.UUID][]Category{}
+	roots := []Category{}
+	for _, category := range categories {
+		if category.ParentID != nil && known[*category.ParentID] {
+			children[*category.ParentID] = append(children[*category.ParentID], category)
+		} else {
+			roots = append(roots, category)
+		}
+	}
+
+	var build func([]Category) []CategoryNode
+	build = func(categories []Category) []CategoryNode {
+		nodes := make([]CategoryNode, 0, len(categories))
+		for _, category := range categories {
+			nodes = append(nodes, CategoryNode{Category: category, Children: build(children[category.ID])})
+		}
+		return nodes
+	}
+
+	return build(roots)
+}
+
+// BookCategoriesUpdate replaces the categories of a book.
+type BookCategoriesUpdate struct {
+	CategoryIDs []uuid.UUID `json:"category_ids" validate:"lte=20"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type EmailVerification struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UserID    uuid.UUID `db:"user_id" json:"user_id"`
+	NewEmail  string    `db:"new_email" json:"new_email"`
+	TokenHash string    `db:"token_hash" json:"-"`
+	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
+}
+
+type EmailVerify struct {
+	Token string `json:"token" validate:"required,lte=255"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ImpersonationLog is one request made by an administrator acting as a user.
+type ImpersonationLog struct {
+	ID        uuid.UUID  `db:"id" json:"id"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	ActorID   *uuid.UUID `db:"actor_id" json:"actor_id"`
+	UserID    *uuid.UUID `db:"user_id" json:"user_id"`
+	Method    string     `db:"method" json:"method"`
+	Path      string     `db:"path" json:"path"`
+	Status    int        `db:"status" json:"status"` // 0 until the request is answered
+	IPAddress string     `db:"ip_address" json:"ip_address"`
+}
+
+type ImpersonationLogFilter struct {
+	ActorID string `query:"actor_id" validate:"omitempty,uuid"`
+	UserID  string `query:"user_id" validate:"omitempty,uuid"`
+	Limit   int    `query:"limit" validate:"omitempty,min=1,max=500"`
+}
+
+type ImpersonationResponse struct {
+	Access    string    `json:"access"`
+	ExpiresAt time.Time `json:"expires_at"`
+	UserID    uuid.UUID `json:"user_id"`
+	ActorID   uuid.UUID `json:"actor_id"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type LoginAttempt struct {
+	ID        uuid.UUID  `db:"id" json:"id"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	Email     string     `db:"email" json:"email"`
+	UserID    *uuid.UUID `db:"user_id" json:"user_id"`
+	IPAddress string     `db:"ip_address" json:"ip_address"`
+	UserAgent string     `db:"user_agent" json:"user_agent"`
+	Succeeded bool       `db:"succeeded" json:"succeeded"`
+	Reason    string     `db:"reason" json:"reason"`
+}
+
+type LoginAttemptFilter struct {
+	Email     string `query:"email"`
+	IPAddress string `query:"ip_address"`
+	Limit     int    `query:"limit" validate:"omitempty,min=1,max=500"`
+}
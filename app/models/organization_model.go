@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Organization is a tenant: every book belongs to exactly one.
+type Organization struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+	Name      string    `db:"name" json:"name" validate:"required,lte=255"`
+	Slug      string    `db:"slug" json:"slug" validate:"required,lte=63"`
+}
+
+type OrganizationCreate struct {
+	Name string `json:"name" validate:"required,lte=255"`
+	Slug string `json:"slug" validate:"required,lte=63,hostname_rfc1123,lowercase"`
+}
+
+// OrganizationMember grants a user a role inside one organization.
+type OrganizationMember struct {
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	UserID         uuid.UUID `db:"user_id" json:"user_id"`
+	MemberRole     string    `db:"member_role" json:"member_role"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+}
+
+type OrganizationMemberUpdate struct {
+	UserID     uuid.UUID `json:"user_id" validate:"required"`
+	MemberRole string    `json:"member_role" validate:"required,lte=25"`
+}
+
+// Membership is an organization as seen by one of its members.
+type Membership struct {
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	Name           string    `db:"name" json:"name"`
+	Slug           string    `db:"slug" json:"slug"`
+	MemberRole     string    `db:"member_role" json:"member_role"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+}
+
+type TenantSwitch struct {
+	OrganizationID uuid.UUID `json:"organization_id" validate:"required"`
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Review is the rating and opinion of one user on a book.
+type Review struct {
+	ID           uuid.UUID  `db:"id" json:"id"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time  `db:"updated_at" json:"updated_at"`
+	BookID       uuid.UUID  `db:"book_id" json:"book_id"`
+	UserID       uuid.UUID  `db:"user_id" json:"user_id"`
+	Rating       int        `db:"rating" json:"rating"`
+	Body         string     `db:"body" json:"body"`
+	ReviewStatus string     `db:"review_status" json:"review_status"`
+	ModeratedBy  *uuid.UUID `db:"moderated_by" json:"moderated_by"`
+	ModeratedAt  *time.Time `db:"moderated_at" json:"moderated_at"`
+}
+
+// ReviewUpdate holds the fields of a review its author may write.
+type ReviewUpdate struct {
+	Rating int    `json:"rating" validate:"min=1,max=10"`
+	Body   string `json:"body" validate:"lte=5000"`
+}
+
+type ReviewModeration struct {
+	ReviewStatus string `json:"review_status" validate:"required,oneof=published hidden"`
+}
+
+type ReviewFilter struct {
+	Page  int `query:"page" validate:"omitempty,min=1"`
+	Limit int `query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+// LimitOffset returns the pagination of a validated filter, 20 reviews per
+// page by default.
+func (f ReviewFilter) LimitOffset() (int, int) {
+	limit := f.Limit
+	if limit == 0 {
+		limit = 20
+	}
+
+	offset := 0
+	if f.Page > 1 {
+		offset = (f.Page - 1) * limit
+	}
+
+	return limit, offset
+}
+
+type ReviewPage struct {
+	Reviews []Review `json:"reviews"`
+	Total   int      `json:"total"`
+	Page    int      `json:"page"`
+	Limit   int      `json:"limit"`
+}
+
+// BookRating aggregates the published reviews of a book.
+type BookRating struct {
+	BookID    uuid.UUID       `db:"book_id" json:"book_id"`
+	Count     int             `db:"rating_count" json:"count"`
+	Sum       int             `db:"rating_sum" json:"-"`
+	Histogram RatingHistogram `db:"histogram" json:"histogram"`
+	Average   float64         `db:"rating_average" json:"average"`
+}
+
+// RatingHistogram counts the reviews by rating: the first element is the
+// number of reviews rating the book 1, the last the number rating it 10. It
+// is stored as a PostgreSQL integer array.
+type RatingHistogram [10]int
+
+func (h RatingHistogram) Value() (driver.Value, error) {
+	fields := make([]string, len(h))
+	for i, count := range h {
+		fields[i] = strconv.Itoa(count)
+	}
+
+	return "{" + strings.Join(fields, ",") + "}", nil
+}
+
+func (h *RatingHistogram) Scan(value interface{}) error {
+	var text string
+	switch v := value.(type) {
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	default:
+		return fmt.Errorf("cannot scan %T into a rating histogram", value)
+	}
+
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "{") || !strings.HasSuffix(text, "}") {
+		return fmt.Errorf("invalid rating histogram %q", text)
+	}
+
+	fields := strings.Split(text[1:len(text)-1], ",")
+	if len(fields) != len(h) {
+		return fmt.Errorf("rating histogram has %d elements, want %d", len(fields), len(h))
+	}
+
+	for i, field := range fields {
+		count, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return fmt.Errorf("invalid rating histogram %q", text)
+		}
+		h[i] = count
+	}
+
+	return nil
+}
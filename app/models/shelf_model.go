@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Shelf is a named, ordered list of books kept by a user. Private shelves
+// are only seen by their owner.
+type Shelf struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+	TenantID  uuid.UUID `db:"tenant_id" json:"tenant_id"`
+	UserID    uuid.UUID `db:"user_id" json:"user_id"`
+	Name      string    `db:"name" json:"name"`
+	Kind      string    `db:"kind" json:"kind"`
+	IsPublic  bool      `db:"is_public" json:"is_public"`
+}
+
+// ShelfUpdate holds the fields of a shelf its owner may write. Built-in
+// shelves keep their name.
+type ShelfUpdate struct {
+	Name     string `json:"name" validate:"required,lte=100"`
+	IsPublic bool   `json:"is_public"`
+}
+
+// ShelfBookAdd puts a book on a shelf at Position, counted from 1, or last
+// without one.
+type ShelfBookAdd struct {
+	BookID   uuid.UUID `json:"book_id" validate:"required"`
+	Position int       `json:"position" validate:"min=0"`
+}
+
+// ShelfOrder lists every book of a shelf once, in their new order.
+type ShelfOrder struct {
+	BookIDs []uuid.UUID `json:"book_ids" validate:"required,unique"`
+}
+
+// ShelfRef names a shelf holding a book, in book responses.
+type ShelfRef struct {
+	ID   uuid.UUID `db:"id" json:"id"`
+	Name string    `db:"name" json:"name"`
+	Kind string    `db:"kind" json:"kind"`
+}
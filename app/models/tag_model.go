@@ -0,0 +1,19 @@
+package models
+
+// TagCount is a tag with the number of books of the organization carrying
+// it.
+type TagCount struct {
+	Name      string `db:"name" json:"name"`
+	BookCount int    `db:"book_count" json:"book_count"`
+}
+
+type TagFilter struct {
+	Prefix string `query:"prefix" validate:"lte=50"`
+	Limit  int    `query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+// BookTagsUpdate replaces the tags of a book. Tags are trimmed and
+// lowercased before they are stored.
+type BookTagsUpdate struct {
+	Tags []string `json:"tags" validate:"lte=20,dive,required,lte=50"`
+}
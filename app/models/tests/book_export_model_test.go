@@ -0,0 +1,21 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookExportFilter_ValueScan(t *testing.T) {
+	filter := models.BookExportFilter{Author: "Rob", BookStatus: "1", CreatedFrom: "2025-01-01T00:00:00Z"}
+
+	val, err := filter.Value()
+	assert.NoError(t, err)
+
+	var scanned models.BookExportFilter
+	assert.NoError(t, scanned.Scan(val))
+	assert.Equal(t, filter, scanned)
+
+	assert.Error(t, scanned.Scan("not bytes"))
+}
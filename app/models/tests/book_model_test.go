@@ -3,6 +3,7 @@ package models_test
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/create-go-app/fiber-go-template/app/models"
 	"github.com/stretchr/testify/assert"
@@ -55,3 +56,26 @@ func TestBookAttrs_Scan_InvalidJSON(t *testing.T) {
 	err := scanned.Scan(invalidJSON)
 	assert.Error(t, err)
 }
+
+func TestDate_JSON(t *testing.T) {
+	book := models.BookReplace{}
+	err := json.Unmarshal([]byte(`{"title": "Go", "publication_date": "1994-03-01"}`), &book)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(1994, 3, 1, 0, 0, 0, 0, time.UTC), book.PublicationDate.Time)
+
+	data, err := json.Marshal(book.BookMetadata)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"publication_date":"1994-03-01"`)
+
+	err = json.Unmarshal([]byte(`{"publication_date": "March 1994"}`), &book)
+	assert.Error(t, err)
+}
+
+func TestDate_Value(t *testing.T) {
+	value, err := models.Date{Time: time.Date(1994, 3, 1, 0, 0, 0, 0, time.UTC)}.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "1994-03-01", value)
+
+	var d models.Date
+	assert.Error(t, d.Scan("1994-03-01"))
+}
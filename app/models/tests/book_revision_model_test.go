@@ -0,0 +1,44 @@
+package models_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookRevisionSnapshot_JSON(t *testing.T) {
+	// as written by to_jsonb, updated_at has no time zone
+	data := []byte(`{"id": "0b4f1c6e-2d3a-4b5c-8d9e-0f1a2b3c4d5e", "created_at": "2025-10-19T10:00:00.123456+00:00",
+		"updated_at": "2025-10-19T11:00:00.5", "user_id": "6f7e8d9c-0b1a-4c3d-9e8f-7a6b5c4d3e2f", "title": "Go",
+		"author": "Rob Pike", "book_status": 1, "book_attrs": {"picture": "", "description": "", "rating": 8},
+		"version": 3, "isbn": "9780306406157", "publication_date": "1994-03-01", "page_count": 412, "book_format": "hardcover"}`)
+
+	snapshot := models.BookRevisionSnapshot{}
+	assert.NoError(t, json.Unmarshal(data, &snapshot))
+	assert.Equal(t, "Go", snapshot.Title)
+	assert.Equal(t, 8, snapshot.BookAttrs.Rating)
+	assert.Equal(t, "9780306406157", snapshot.ISBN)
+	assert.Equal(t, time.Date(1994, time.March, 1, 0, 0, 0, 0, time.UTC), snapshot.PublicationDate.Time)
+	assert.Equal(t, "6f7e8d9c-0b1a-4c3d-9e8f-7a6b5c4d3e2f", snapshot.UserID.String())
+	assert.Equal(t, 3, snapshot.Version)
+	assert.Equal(t, 2025, snapshot.CreatedAt.Year())
+}
+
+func TestBookRevisionDiff_ValueScan(t *testing.T) {
+	diff := models.BookRevisionDiff{"title": {From: []byte(`"Go"`), To: []byte(`"Go 2"`)}}
+
+	value, err := diff.Value()
+	assert.NoError(t, err)
+
+	var scanned models.BookRevisionDiff
+	assert.NoError(t, scanned.Scan(value))
+	assert.Equal(t, diff, scanned)
+
+	value, err = models.BookRevisionDiff(nil).Value()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("{}"), value)
+	assert.Error(t, scanned.Scan("{}"))
+}
@@ -0,0 +1,28 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCategoryTree(t *testing.T) {
+	fiction := models.Category{ID: uuid.New(), Name: "Fiction"}
+	fantasy := models.Category{ID: uuid.New(), ParentID: &fiction.ID, Name: "Fantasy"}
+	epic := models.Category{ID: uuid.New(), ParentID: &fantasy.ID, Name: "Epic"}
+	history := models.Category{ID: uuid.New(), Name: "History"}
+	missingParent := uuid.New()
+	orphan := models.Category{ID: uuid.New(), ParentID: &missingParent, Name: "Orphan"}
+
+	tree := models.CategoryTree([]models.Category{epic, fantasy, fiction, history, orphan})
+
+	assert.Len(t, tree, 3)
+	assert.Equal(t, "Fiction", tree[0].Name)
+	assert.Equal(t, "Fantasy", tree[0].Children[0].Name)
+	assert.Equal(t, "Epic", tree[0].Children[0].Children[0].Name)
+	assert.Empty(t, tree[0].Children[0].Children[0].Children)
+	assert.Equal(t, "History", tree[1].Name)
+	assert.Equal(t, "Orphan", tree[2].Name)
+}
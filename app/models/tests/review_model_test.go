@@ -0,0 +1,38 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRatingHistogram_ValueScan(t *testing.T) {
+	histogram := models.RatingHistogram{0, 1, 0, 0, 2, 0, 0, 7, 0, 3}
+
+	val, err := histogram.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "{0,1,0,0,2,0,0,7,0,3}", val)
+
+	var scanned models.RatingHistogram
+	assert.NoError(t, scanned.Scan(val))
+	assert.Equal(t, histogram, scanned)
+
+	assert.NoError(t, scanned.Scan([]byte("{1,0,0,0,0,0,0,0,0,0}")))
+	assert.Equal(t, models.RatingHistogram{1}, scanned)
+
+	assert.Error(t, scanned.Scan("{1,2,3}"))
+	assert.Error(t, scanned.Scan("{a,0,0,0,0,0,0,0,0,0}"))
+	assert.Error(t, scanned.Scan("1,0,0,0,0,0,0,0,0,0"))
+	assert.Error(t, scanned.Scan(42))
+}
+
+func TestReviewFilter_LimitOffset(t *testing.T) {
+	limit, offset := models.ReviewFilter{}.LimitOffset()
+	assert.Equal(t, 20, limit)
+	assert.Equal(t, 0, offset)
+
+	limit, offset = models.ReviewFilter{Page: 3, Limit: 10}.LimitOffset()
+	assert.Equal(t, 10, limit)
+	assert.Equal(t, 20, offset)
+}
@@ -0,0 +1,32 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserFilter_Search(t *testing.T) {
+	search := models.UserFilter{}.Search()
+	assert.Equal(t, 20, search.Limit)
+	assert.Zero(t, search.Offset)
+	assert.Nil(t, search.UserStatus)
+	assert.Nil(t, search.CreatedFrom)
+	assert.Nil(t, search.CreatedTo)
+
+	search = models.UserFilter{
+		Email:       "example",
+		UserStatus:  "0",
+		CreatedFrom: "2025-01-01",
+		CreatedTo:   "2025-01-31",
+		Page:        3,
+		Limit:       10,
+	}.Search()
+	assert.Equal(t, "example", search.Email)
+	assert.Equal(t, 20, search.Offset)
+	assert.Equal(t, 0, *search.UserStatus)
+	assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), *search.CreatedFrom)
+	assert.Equal(t, time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), *search.CreatedTo)
+}
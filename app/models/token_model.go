@@ -1,18 +1,34 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type Renew struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
 type Tokens struct {
-	Access  string
-	Refresh string
+	Access           string
+	Refresh          string
+	SessionID        uuid.UUID
+	RefreshHash      string
+	RefreshExpiresAt time.Time
 }
 
 type TokenMetadata struct {
 	UserID      uuid.UUID
 	Credentials map[string]bool
 	Expires     int64
+	APIKeyID    uuid.UUID // uuid.Nil unless the request was authenticated with an API key
+	SessionID   uuid.UUID // uuid.Nil for API keys, impersonation and tokens issued before sessions
+	ActorID     uuid.UUID // the administrator acting as UserID, uuid.Nil unless impersonating
+	TenantID    uuid.UUID // the organization the token acts in, uuid.Nil if the user belongs to none
+}
+
+// Impersonated reports whether an administrator is acting as the user.
+func (m *TokenMetadata) Impersonated() bool {
+	return m.ActorID != uuid.Nil
 }
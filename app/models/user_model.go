@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,4 +15,128 @@ type User struct {
 	PasswordHash string    `db:"password_hash" json:"password_hash,omitempty" validate:"required,lte=255"`
 	UserStatus   int       `db:"user_status" json:"user_status" validate:"required,len=1"`
 	UserRole     string    `db:"user_role" json:"user_role" validate:"required,lte=25"`
+	DisplayName  string    `db:"display_name" json:"display_name" validate:"lte=100"`
+	AvatarURL    string    `db:"avatar_url" json:"avatar_url" validate:"lte=2048"`
+	Locale       string    `db:"locale" json:"locale" validate:"lte=35"`
+	Timezone     string    `db:"timezone" json:"timezone" validate:"lte=64"`
+
+	DeletionScheduledAt *time.Time `db:"deletion_scheduled_at" json:"deletion_scheduled_at"`
+	DeletionMode        string     `db:"deletion_mode" json:"deletion_mode"`
+}
+
+// UserProfile is the current user as returned by the API, without secrets.
+type UserProfile struct {
+	ID          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Email       string    `json:"email"`
+	UserStatus  int       `json:"user_status"`
+	UserRole    string    `json:"user_role"`
+	DisplayName string    `json:"display_name"`
+	AvatarURL   string    `json:"avatar_url"`
+	Locale      string    `json:"locale"`
+	Timezone    string    `json:"timezone"`
+	Credentials []string  `json:"credentials"`
+
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+	DeletionMode        string     `json:"deletion_mode,omitempty"`
+}
+
+// UserProfileUpdate only changes the fields present in the request. A new
+// email is applied after it has been verified.
+type UserProfileUpdate struct {
+	DisplayName *string `json:"display_name" validate:"omitempty,lte=100"`
+	AvatarURL   *string `json:"avatar_url" validate:"omitempty,url,lte=2048"`
+	Locale      *string `json:"locale" validate:"omitempty,bcp47_language_tag,lte=35"`
+	Timezone    *string `json:"timezone" validate:"omitempty,timezone,lte=64"`
+	Email       *string `json:"email" validate:"omitempty,email,lte=255"`
+}
+
+func NewUserProfile(u User, credentials []string) UserProfile {
+	return UserProfile{
+		ID:          u.ID,
+		CreatedAt:   u.CreatedAt,
+		UpdatedAt:   u.UpdatedAt,
+		Email:       u.Email,
+		UserStatus:  u.UserStatus,
+		UserRole:    u.UserRole,
+		DisplayName: u.DisplayName,
+		AvatarURL:   u.AvatarURL,
+		Locale:      u.Locale,
+		Timezone:    u.Timezone,
+		Credentials: credentials,
+
+		DeletionScheduledAt: u.DeletionScheduledAt,
+		DeletionMode:        u.DeletionMode,
+	}
+}
+
+type UserFilter struct {
+	Email       string `query:"email" validate:"lte=255"`
+	UserRole    string `query:"user_role" validate:"lte=25"`
+	UserStatus  string `query:"user_status" validate:"omitempty,oneof=0 1"`
+	CreatedFrom string `query:"created_from" validate:"omitempty,datetime=2006-01-02"`
+	CreatedTo   string `query:"created_to" validate:"omitempty,datetime=2006-01-02"`
+	Page        int    `query:"page" validate:"omitempty,min=1"`
+	Limit       int    `query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+// UserSearch is the typed form of UserFilter used by the queries. Nil and
+// empty fields match every user.
+type UserSearch struct {
+	Email       string
+	UserRole    string
+	UserStatus  *int
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Limit       int
+	Offset      int
+}
+
+// Search converts a validated filter, applying pagination defaults. The
+// created_to day is inclusive.
+func (f UserFilter) Search() UserSearch {
+	search := UserSearch{
+		Email:    f.Email,
+		UserRole: f.UserRole,
+		Limit:    f.Limit,
+	}
+
+	if search.Limit == 0 {
+		search.Limit = 20
+	}
+	if f.Page > 1 {
+		search.Offset = (f.Page - 1) * search.Limit
+	}
+
+	if status, err := strconv.Atoi(f.UserStatus); err == nil {
+		search.UserStatus = &status
+	}
+	if from, err := time.Parse(time.DateOnly, f.CreatedFrom); err == nil {
+		search.CreatedFrom = &from
+	}
+	if to, err := time.Parse(time.DateOnly, f.CreatedTo); err == nil {
+		to = to.AddDate(0, 0, 1)
+		search.CreatedTo = &to
+	}
+
+	return search
+}
+
+type UserPage struct {
+	Users []UserProfile `json:"users"`
+	Total int           `json:"total"`
+	Page  int           `json:"page"`
+	Limit int           `json:"limit"`
+}
+
+type UserDetails struct {
+	UserProfile
+	BookCount   int          `json:"book_count"`
+	Memberships []Membership `json:"memberships"`
+}
+
+type UserAdminUpdate struct {
+	UserRole   *string `json:"user_role" validate:"omitempty,lte=25"`
+	UserStatus *int    `json:"user_status" validate:"omitempty,oneof=0 1"`
 }
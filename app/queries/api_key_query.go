@@ -0,0 +1,98 @@
+package queries
+
+import (
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type APIKeyQueries struct {
+	*sqlx.DB
+}
+
+func (q *APIKeyQueries) GetAPIKeysByUserID(userID uuid.UUID) ([]models.APIKey, error) {
+	keys := []models.APIKey{}
+	query := `SELECT * FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC`
+
+	err := q.Select(&keys, query, userID)
+	if err != nil {
+		return keys, err
+	}
+
+	return keys, nil
+}
+
+func (q *APIKeyQueries) GetAPIKeyByHash(hash string) (models.APIKey, error) {
+	key := models.APIKey{}
+	query := `SELECT * FROM api_keys WHERE key_hash = $1`
+
+	err := q.Get(&key, query, hash)
+	if err != nil {
+		return key, err
+	}
+
+	return key, nil
+}
+
+func (q *APIKeyQueries) CreateAPIKey(k *models.APIKey) error {
+	query := `INSERT INTO api_keys VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULL, NULL, $10)`
+
+	_, err := q.Exec(
+		query,
+		k.ID, k.CreatedAt, k.UpdatedAt, k.UserID, k.Name, k.KeyPrefix, k.KeyHash, k.Credentials, k.ExpiresAt, k.TenantID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q *APIKeyQueries) TouchAPIKey(id uuid.UUID, usedAt time.Time) error {
+	query := `UPDATE api_keys SET last_used_at = $2 WHERE id = $1`
+
+	_, err := q.Exec(query, id, usedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RevokeAPIKey returns sql.ErrNoRows when the key does not exist, belongs to
+// another user or has already been revoked.
+func (q *APIKeyQueries) RevokeAPIKey(id, userID uuid.UUID, revokedAt time.Time) error {
+	query := `UPDATE api_keys SET revoked_at = $3 WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+
+	result, err := q.Exec(query, id, userID, revokedAt)
+	if err != nil {
+		return err
+	}
+
+	return checkRowsAffected(result)
+}
+
+// RevokeAPIKeys revokes every active key of the user.
+func (q *APIKeyQueries) RevokeAPIKeys(userID uuid.UUID, revokedAt time.Time) error {
+	query := `UPDATE api_keys SET revoked_at = $2 WHERE user_id = $1 AND revoked_at IS NULL`
+
+	_, err := q.Exec(query, userID, revokedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q *APIKeyQueries) DeleteAPIKeys(userID uuid.UUID) error {
+	query := `DELETE FROM api_keys WHERE user_id = $1`
+
+	_, err := q.Exec(query, userID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package queries
+
+import (
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// AuthorQueries computes name keys with the author_name_key database
+// function, so they match those of the authors created for new books.
+type AuthorQueries struct {
+	*sqlx.DB
+}
+
+// GetAuthors returns one page of the authors of the tenant whose name
+// contains name, by name, and the total number of matches.
+func (q *AuthorQueries) GetAuthors(tenantID uuid.UUID, name string, limit, offset int) ([]models.Author, int, error) {
+	authors := []models.Author{}
+	total := 0
+	condition := `FROM authors WHERE tenant_id = $1 AND ($2 = '' OR position(lower($2) in lower(name)) > 0)`
+
+	if err := q.Get(&total, `SELECT COUNT(*) `+condition, tenantID, name); err != nil {
+		return authors, 0, err
+	}
+
+	query := `SELECT * ` + condition + ` ORDER BY name, id LIMIT $3 OFFSET $4`
+	if err := q.Select(&authors, query, tenantID, name, limit, offset); err != nil {
+		return authors, 0, err
+	}
+
+	return authors, total, nil
+}
+
+func (q *AuthorQueries) GetAuthor(tenantID, id uuid.UUID) (models.Author, error) {
+	author := models.Author{}
+	query := `SELECT * FROM authors WHERE tenant_id = $1 AND id = $2`
+
+	err := q.Get(&author, query, tenantID, id)
+	if err != nil {
+		return author, err
+	}
+
+	return author, nil
+}
+
+// GetAuthorByName returns the author of the tenant with the same name key as
+// name, or sql.ErrNoRows.
+func (q *AuthorQueries) GetAuthorByName(tenantID uuid.UUID, name string) (models.Author, error) {
+	author := models.Author{}
+	query := `SELECT * FROM authors WHERE tenant_id = $1 AND name_key = author_name_key($2)`
+
+	err := q.Get(&author, query, tenantID, name)
+	if err != nil {
+		return author, err
+	}
+
+	return author, nil
+}
+
+// CreateAuthor returns sql.ErrNoRows when the tenant already has an author
+// with the same name key.
+func (q *AuthorQueries) CreateAuthor(a *models.Author) error {
+	query := `INSERT INTO authors VALUES ($1, $2, $3, $4, $5, author_name_key($5))
+		ON CONFLICT (tenant_id, name_key) DO NOTHING`
+
+	result, err := q.Exec(query, a.ID, a.CreatedAt, a.UpdatedAt, a.TenantID, a.Name)
+	if err != nil {
+		return err
+	}
+
+	return checkRowsAffected(result)
+}
+
+// UpdateAuthor renames the author. It returns sql.ErrNoRows when the author
+// is gone.
+func (q *AuthorQueries) UpdateAuthor(a *models.Author) error {
+	query := `UPDATE authors SET updated_at = $3, name = $4, name_key = author_name_key($4)
+		WHERE tenant_id = $1 AND id = $2`
+
+	result, err := q.Exec(query, a.TenantID, a.ID, a.UpdatedAt, a.Name)
+	if err != nil {
+		return err
+	}
+
+	return checkRowsAffected(result)
+}
+
+// DeleteAuthor only deletes authors no book is credited to. It returns
+// sql.ErrNoRows when the author is gone or still credited.
+func (q *AuthorQueries) DeleteAuthor(tenantID, id uuid.UUID) error {
+	query := `DELETE FROM authors WHERE tenant_id = $1 AND id = $2
+		AND NOT EXISTS (SELECT 1 FROM book_authors WHERE author_id = $2)`
+
+	result, err := q.Exec(query, tenantID, id)
+	if err != nil {
+		return err
+	}
+
+	return checkRowsAffected(result)
+}
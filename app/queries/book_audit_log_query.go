@@ -0,0 +1,43 @@
+package queries
+
+import (
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type BookAuditLogQueries struct {
+	*sqlx.DB
+}
+
+// createBookAuditLog is run by BookQueries in the transaction of the change
+// it records.
+func createBookAuditLog(db sqlx.Execer, l *models.BookAuditLog) error {
+	query := `INSERT INTO book_audit_logs VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	_, err := db.Exec(
+		query,
+		l.ID, l.CreatedAt, l.TenantID, l.BookID, l.OwnerID, l.ActorID, l.Action, l.Before, l.After,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetBookAuditLogs returns the newest entries of the organization first.
+// Empty filter fields match everything.
+func (q *BookAuditLogQueries) GetBookAuditLogs(tenantID uuid.UUID, f models.BookAuditLogFilter) ([]models.BookAuditLog, error) {
+	logs := []models.BookAuditLog{}
+	query := `SELECT * FROM book_audit_logs
+		WHERE tenant_id = $1 AND ($2 = '' OR book_id::text = $2) AND ($3 = '' OR actor_id::text = $3)
+		ORDER BY created_at DESC LIMIT $4`
+
+	err := q.Select(&logs, query, tenantID, f.BookID, f.ActorID, f.Limit)
+	if err != nil {
+		return logs, err
+	}
+
+	return logs, nil
+}
@@ -0,0 +1,72 @@
+package queries
+
+import (
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// GetBookAuthors returns the credits of the book in order.
+func (q *BookQueries) GetBookAuthors(tenantID, bookID uuid.UUID) ([]models.BookAuthor, error) {
+	authors := []models.BookAuthor{}
+	query := `SELECT ba.author_id, a.name, ba.author_role, ba.position
+		FROM book_authors ba JOIN authors a ON a.id = ba.author_id JOIN books b ON b.id = ba.book_id
+		WHERE b.tenant_id = $1 AND ba.book_id = $2 ORDER BY ba.position, a.name`
+
+	err := q.inTenant(tenantID, func(db sqlx.Ext) error {
+		return sqlx.Select(db, &authors, query, tenantID, bookID)
+	})
+	if err != nil {
+		return authors, err
+	}
+
+	return authors, nil
+}
+
+// SetBookAuthors replaces the credits of the book in one transaction,
+// numbering them in the order given. It returns sql.ErrNoRows when the book
+// or one of the authors is not in the tenant.
+func (q *BookQueries) SetBookAuthors(tenantID, bookID uuid.UUID, authors []models.BookAuthorUpdate) error {
+	return q.inTenantTx(tenantID, func(db sqlx.Ext) error {
+		if err := lockBook(db, tenantID, bookID); err != nil {
+			return err
+		}
+
+		if _, err := db.Exec(`DELETE FROM book_authors WHERE book_id = $1`, bookID); err != nil {
+			return err
+		}
+
+		for i, author := range authors {
+			result, err := db.Exec(
+				`INSERT INTO book_authors SELECT $2, $3, $4, $5 WHERE EXISTS (SELECT 1 FROM authors WHERE tenant_id = $1 AND id = $3)`,
+				tenantID, bookID, author.AuthorID, author.AuthorRole, i+1,
+			)
+			if err != nil {
+				return err
+			}
+			if err := checkRowsAffected(result); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetBooksByAuthorID returns the books of the tenant credited to the author
+// in any role, by title.
+func (q *BookQueries) GetBooksByAuthorID(tenantID, authorID uuid.UUID) ([]models.Book, error) {
+	books := []models.Book{}
+	query := `SELECT * FROM books b WHERE b.tenant_id = $1
+		AND EXISTS (SELECT 1 FROM book_authors ba WHERE ba.book_id = b.id AND ba.author_id = $2)
+		ORDER BY b.title, b.id`
+
+	err := q.inTenant(tenantID, func(db sqlx.Ext) error {
+		return sqlx.Select(db, &books, query, tenantID, authorID)
+	})
+	if err != nil {
+		return books, err
+	}
+
+	return books, nil
+}
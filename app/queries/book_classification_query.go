@@ -0,0 +1,98 @@
+package queries
+
+import (
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// GetBookTags returns the tags of the book by name.
+func (q *BookQueries) GetBookTags(tenantID, bookID uuid.UUID) ([]string, error) {
+	tags := []string{}
+	query := `SELECT t.name FROM book_tags bt JOIN tags t ON t.id = bt.tag_id JOIN books b ON b.id = bt.book_id
+		WHERE b.tenant_id = $1 AND bt.book_id = $2 ORDER BY t.name`
+
+	err := q.inTenant(tenantID, func(db sqlx.Ext) error {
+		return sqlx.Select(db, &tags, query, tenantID, bookID)
+	})
+	if err != nil {
+		return tags, err
+	}
+
+	return tags, nil
+}
+
+// SetBookTags replaces the tags of the book in one transaction, creating the
+// tags the tenant does not have yet. It returns sql.ErrNoRows when the book
+// is not in the tenant.
+func (q *BookQueries) SetBookTags(tenantID, bookID uuid.UUID, tags []string) error {
+	return q.inTenantTx(tenantID, func(db sqlx.Ext) error {
+		if err := lockBook(db, tenantID, bookID); err != nil {
+			return err
+		}
+
+		if _, err := db.Exec(`DELETE FROM book_tags WHERE book_id = $1`, bookID); err != nil {
+			return err
+		}
+
+		for _, tag := range tags {
+			_, err := db.Exec(`INSERT INTO tags (tenant_id, name) VALUES ($1, $2) ON CONFLICT (tenant_id, name) DO NOTHING`, tenantID, tag)
+			if err != nil {
+				return err
+			}
+
+			_, err = db.Exec(`INSERT INTO book_tags SELECT $2, id FROM tags WHERE tenant_id = $1 AND name = $3`, tenantID, bookID, tag)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetBookCategories returns the categories of the book by name.
+func (q *BookQueries) GetBookCategories(tenantID, bookID uuid.UUID) ([]models.Category, error) {
+	categories := []models.Category{}
+	query := `SELECT c.* FROM book_categories bc JOIN categories c ON c.id = bc.category_id JOIN books b ON b.id = bc.book_id
+		WHERE b.tenant_id = $1 AND bc.book_id = $2 ORDER BY c.name`
+
+	err := q.inTenant(tenantID, func(db sqlx.Ext) error {
+		return sqlx.Select(db, &categories, query, tenantID, bookID)
+	})
+	if err != nil {
+		return categories, err
+	}
+
+	return categories, nil
+}
+
+// SetBookCategories replaces the categories of the book in one transaction.
+// It returns sql.ErrNoRows when the book or one of the categories is not in
+// the tenant.
+func (q *BookQueries) SetBookCategories(tenantID, bookID uuid.UUID, categoryIDs []uuid.UUID) error {
+	return q.inTenantTx(tenantID, func(db sqlx.Ext) error {
+		if err := lockBook(db, tenantID, bookID); err != nil {
+			return err
+		}
+
+		if _, err := db.Exec(`DELETE FROM book_categories WHERE book_id = $1`, bookID); err != nil {
+			return err
+		}
+
+		for _, categoryID := range categoryIDs {
+			result, err := db.Exec(
+				`INSERT INTO book_categories SELECT $2, $3 WHERE EXISTS (SELECT 1 FROM categories WHERE tenant_id = $1 AND id = $3)`,
+				tenantID, bookID, categoryID,
+			)
+			if err != nil {
+				return err
+			}
+			if err := checkRowsAffected(result); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
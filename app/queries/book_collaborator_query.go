@@ -0,0 +1,178 @@
+package queries
+
+import (
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// GetCollaboratorRole returns the role of the user on the book, or
+// sql.ErrNoRows when the book is not shared with them.
+func (q *BookQueries) GetCollaboratorRole(tenantID, bookID, userID uuid.UUID) (string, error) {
+	role := ""
+	query := `SELECT c.collaborator_role FROM book_collaborators c JOIN books b ON b.id = c.book_id
+		WHERE b.tenant_id = $1 AND c.book_id = $2 AND c.user_id = $3`
+
+	err := q.inTenant(tenantID, func(db sqlx.Ext) error {
+		return sqlx.Get(db, &role, query, tenantID, bookID, userID)
+	})
+	if err != nil {
+		return role, err
+	}
+
+	return role, nil
+}
+
+func (q *BookQueries) GetBookCollaborators(tenantID, bookID uuid.UUID) ([]models.BookCollaborator, error) {
+	collaborators := []models.BookCollaborator{}
+	query := `SELECT c.* FROM book_collaborators c JOIN books b ON b.id = c.book_id
+		WHERE b.tenant_id = $1 AND c.book_id = $2 ORDER BY c.created_at`
+
+	err := q.inTenant(tenantID, func(db sqlx.Ext) error {
+		return sqlx.Select(db, &collaborators, query, tenantID, bookID)
+	})
+	if err != nil {
+		return collaborators, err
+	}
+
+	return collaborators, nil
+}
+
+// SaveBookCollaborator shares the book with the user or changes their role.
+// It returns sql.ErrNoRows when the book is not in the tenant.
+func (q *BookQueries) SaveBookCollaborator(tenantID uuid.UUID, c *models.BookCollaborator) error {
+	query := `INSERT INTO book_collaborators
+		SELECT $2, $3, $4, $5 WHERE EXISTS (SELECT 1 FROM books WHERE tenant_id = $1 AND id = $2)
+		ON CONFLICT (book_id, user_id) DO UPDATE SET collaborator_role = EXCLUDED.collaborator_role`
+
+	return q.inTenant(tenantID, func(db sqlx.Ext) error {
+		result, err := db.Exec(query, tenantID, c.BookID, c.UserID, c.CollaboratorRole, c.CreatedAt)
+		if err != nil {
+			return err
+		}
+		return checkRowsAffected(result)
+	})
+}
+
+// DeleteBookCollaborator returns sql.ErrNoRows when the book is not shared
+// with the user.
+func (q *BookQueries) DeleteBookCollaborator(tenantID, bookID, userID uuid.UUID) error {
+	query := `DELETE FROM book_collaborators c USING books b
+		WHERE b.id = c.book_id AND b.tenant_id = $1 AND c.book_id = $2 AND c.user_id = $3`
+
+	return q.inTenant(tenantID, func(db sqlx.Ext) error {
+		result, err := db.Exec(query, tenantID, bookID, userID)
+		if err != nil {
+			return err
+		}
+		return checkRowsAffected(result)
+	})
+}
+
+// GetSharedBooks returns the books of the tenant shared with the user.
+func (q *BookQueries) GetSharedBooks(tenantID, userID uuid.UUID) ([]models.Book, error) {
+	books := []models.Book{}
+	query := `SELECT b.* FROM books b JOIN book_collaborators c ON c.book_id = b.id
+		WHERE b.tenant_id = $1 AND c.user_id = $2 ORDER BY b.title`
+
+	err := q.inTenant(tenantID, func(db sqlx.Ext) error {
+		return sqlx.Select(db, &books, query, tenantID, userID)
+	})
+	if err != nil {
+		return books, err
+	}
+
+	return books, nil
+}
+
+// SaveBookTransfer offers the book to a new owner, replacing any pending
+// offer. It returns sql.ErrNoRows when the book is not in the tenant.
+func (q *BookQueries) SaveBookTransfer(tenantID uuid.UUID, t *models.BookTransfer) error {
+	query := `INSERT INTO book_transfers
+		SELECT $2, $3, $4, $5, $6 WHERE EXISTS (SELECT 1 FROM books WHERE tenant_id = $1 AND id = $2)
+		ON CONFLICT (book_id) DO UPDATE SET created_at = EXCLUDED.created_at, from_user_id = EXCLUDED.from_user_id,
+			to_user_id = EXCLUDED.to_user_id, expires_at = EXCLUDED.expires_at`
+
+	return q.inTenant(tenantID, func(db sqlx.Ext) error {
+		result, err := db.Exec(query, tenantID, t.BookID, t.CreatedAt, t.FromUserID, t.ToUserID, t.ExpiresAt)
+		if err != nil {
+			return err
+		}
+		return checkRowsAffected(result)
+	})
+}
+
+func (q *BookQueries) GetBookTransfer(tenantID, bookID uuid.UUID) (models.BookTransfer, error) {
+	transfer := models.BookTransfer{}
+	query := `SELECT t.* FROM book_transfers t JOIN books b ON b.id = t.book_id
+		WHERE b.tenant_id = $1 AND t.book_id = $2`
+
+	err := q.inTenant(tenantID, func(db sqlx.Ext) error {
+		return sqlx.Get(db, &transfer, query, tenantID, bookID)
+	})
+	if err != nil {
+		return transfer, err
+	}
+
+	return transfer, nil
+}
+
+// GetBookTransfersTo returns the transfers of the tenant offered to the user
+// that have not expired.
+func (q *BookQueries) GetBookTransfersTo(tenantID, userID uuid.UUID, now time.Time) ([]models.BookTransfer, error) {
+	transfers := []models.BookTransfer{}
+	query := `SELECT t.* FROM book_transfers t JOIN books b ON b.id = t.book_id
+		WHERE b.tenant_id = $1 AND t.to_user_id = $2 AND t.expires_at > $3 ORDER BY t.created_at`
+
+	err := q.inTenant(tenantID, func(db sqlx.Ext) error {
+		return sqlx.Select(db, &transfers, query, tenantID, userID, now)
+	})
+	if err != nil {
+		return transfers, err
+	}
+
+	return transfers, nil
+}
+
+// DeleteBookTransfer returns sql.ErrNoRows when no transfer is pending.
+func (q *BookQueries) DeleteBookTransfer(tenantID, bookID uuid.UUID) error {
+	query := `DELETE FROM book_transfers t USING books b
+		WHERE b.id = t.book_id AND b.tenant_id = $1 AND t.book_id = $2`
+
+	return q.inTenant(tenantID, func(db sqlx.Ext) error {
+		result, err := db.Exec(query, tenantID, bookID)
+		if err != nil {
+			return err
+		}
+		return checkRowsAffected(result)
+	})
+}
+
+// AcceptBookTransfer makes the recipient the owner of the book, as long as
+// the offering user still owns it, and closes the transfer. The recipient
+// stops being a collaborator. It returns sql.ErrNoRows when the book changed
+// hands meanwhile.
+func (q *BookQueries) AcceptBookTransfer(tenantID uuid.UUID, t models.BookTransfer, now time.Time) error {
+	return q.changeInTenant(tenantID, models.BookChange{ActorID: t.ToUserID}, func(db sqlx.Ext) error {
+		result, err := db.Exec(
+			`UPDATE books SET user_id = $4, updated_at = $5, version = version + 1
+			WHERE tenant_id = $1 AND id = $2 AND user_id = $3`,
+			tenantID, t.BookID, t.FromUserID, t.ToUserID, now,
+		)
+		if err != nil {
+			return err
+		}
+		if err := checkRowsAffected(result); err != nil {
+			return err
+		}
+
+		if _, err := db.Exec(`DELETE FROM book_collaborators WHERE book_id = $1 AND user_id = $2`, t.BookID, t.ToUserID); err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`DELETE FROM book_transfers WHERE book_id = $1`, t.BookID)
+		return err
+	})
+}
@@ -0,0 +1,102 @@
+package queries
+
+import (
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// bookExportConditions are shared by the count and the export, so both see
+// the same books. $2 to $6 are the fields of models.BookExportFilter.
+const bookExportConditions = `tenant_id = $1 AND ($2 = '' OR author = $2) AND ($3 = '' OR book_status::text = $3)
+	AND ($4 = '' OR user_id::text = $4) AND ($5 = '' OR created_at >= $5::timestamptz)
+	AND ($6 = '' OR created_at < $6::timestamptz)`
+
+func bookExportArgs(tenantID uuid.UUID, f models.BookExportFilter) []any {
+	return []any{tenantID, f.Author, f.BookStatus, f.UserID, f.CreatedFrom, f.CreatedTo}
+}
+
+func (q *BookQueries) CountBooksForExport(tenantID uuid.UUID, f models.BookExportFilter) (int, error) {
+	count := 0
+	query := `SELECT COUNT(*) FROM books WHERE ` + bookExportConditions
+
+	err := q.inTenant(tenantID, func(db sqlx.Ext) error {
+		return sqlx.Get(db, &count, query, bookExportArgs(tenantID, f)...)
+	})
+	if err != nil {
+		return count, err
+	}
+
+	return count, nil
+}
+
+// StreamBooks calls fn with every book matching the filter, oldest first,
+// as the rows are read from the database instead of loading them all. It
+// stops at the first error of fn.
+func (q *BookQueries) StreamBooks(tenantID uuid.UUID, f models.BookExportFilter, fn func(models.Book) error) error {
+	query := `SELECT * FROM books WHERE ` + bookExportConditions + ` ORDER BY created_at, id`
+
+	return q.inTenant(tenantID, func(db sqlx.Ext) error {
+		rows, err := db.Queryx(query, bookExportArgs(tenantID, f)...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			book := models.Book{}
+			if err := rows.StructScan(&book); err != nil {
+				return err
+			}
+			if err := fn(book); err != nil {
+				return err
+			}
+		}
+
+		return rows.Err()
+	})
+}
+
+type BookExportJobQueries struct {
+	*sqlx.DB
+}
+
+func (q *BookExportJobQueries) CreateBookExportJob(j *models.BookExportJob) error {
+	query := `INSERT INTO book_export_jobs VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+
+	_, err := q.Exec(
+		query,
+		j.ID, j.CreatedAt, j.UpdatedAt, j.TenantID, j.UserID, j.Format, j.Filter, j.Status,
+		j.TotalRows, j.ExportedRows, j.Error, j.FinishedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateBookExportJob stores the progress of the job.
+func (q *BookExportJobQueries) UpdateBookExportJob(j *models.BookExportJob) error {
+	query := `UPDATE book_export_jobs SET updated_at = $2, status = $3, exported_rows = $4, error = $5, finished_at = $6
+		WHERE id = $1`
+
+	result, err := q.Exec(query, j.ID, j.UpdatedAt, j.Status, j.ExportedRows, j.Error, j.FinishedAt)
+	if err != nil {
+		return err
+	}
+
+	return checkRowsAffected(result)
+}
+
+func (q *BookExportJobQueries) GetBookExportJob(tenantID, id uuid.UUID) (models.BookExportJob, error) {
+	job := models.BookExportJob{}
+	query := `SELECT * FROM book_export_jobs WHERE tenant_id = $1 AND id = $2`
+
+	err := q.Get(&job, query, tenantID, id)
+	if err != nil {
+		return job, err
+	}
+
+	return job, nil
+}
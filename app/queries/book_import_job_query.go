@@ -0,0 +1,71 @@
+package queries
+
+import (
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type BookImportJobQueries struct {
+	*sqlx.DB
+}
+
+func (q *BookImportJobQueries) CreateBookImportJob(j *models.BookImportJob) error {
+	query := `INSERT INTO book_import_jobs VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`
+
+	_, err := q.Exec(
+		query,
+		j.ID, j.CreatedAt, j.UpdatedAt, j.TenantID, j.UserID, j.Format, j.DryRun, j.Status,
+		j.TotalRows, j.ProcessedRows, j.ImportedRows, j.RowErrors, j.FinishedAt,
+		j.UnmappedFields,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateBookImportJob stores the progress of the job.
+func (q *BookImportJobQueries) UpdateBookImportJob(j *models.BookImportJob) error {
+	query := `UPDATE book_import_jobs SET updated_at = $2, status = $3, processed_rows = $4, imported_rows = $5,
+		row_errors = $6, finished_at = $7 WHERE id = $1`
+
+	result, err := q.Exec(query, j.ID, j.UpdatedAt, j.Status, j.ProcessedRows, j.ImportedRows, j.RowErrors, j.FinishedAt)
+	if err != nil {
+		return err
+	}
+
+	return checkRowsAffected(result)
+}
+
+func (q *BookImportJobQueries) GetBookImportJob(tenantID, id uuid.UUID) (models.BookImportJob, error) {
+	job := models.BookImportJob{}
+	query := `SELECT * FROM book_import_jobs WHERE tenant_id = $1 AND id = $2`
+
+	err := q.Get(&job, query, tenantID, id)
+	if err != nil {
+		return job, err
+	}
+
+	return job, nil
+}
+
+// FailBookImportJobs marks every job in one of the given statuses as failed
+// and returns how many there were.
+func (q *BookImportJobQueries) FailBookImportJobs(failed string, statuses []string, now time.Time) (int64, error) {
+	query, args, err := sqlx.In(`UPDATE book_import_jobs SET status = ?, updated_at = ?, finished_at = ? WHERE status IN (?)`,
+		failed, now, now, statuses)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := q.Exec(q.Rebind(query), args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -1,20 +1,98 @@
 package queries
 
 import (
+	"strconv"
+
 	"github.com/create-go-app/fiber-go-template/app/models"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 )
 
+// BookQueries scopes every statement to one tenant. With RowLevelSecurity
+// set, statements also run in a transaction that sets app.tenant_id for the
+// books_tenant_isolation policy. What belongs to books, like reviews,
+// credits, tags, revisions, shelves and collaborators, is queried through it
+// too, to share that scoping.
 type BookQueries struct {
 	*sqlx.DB
+	RowLevelSecurity bool
+}
+
+// inTenant runs fn against the database, or against a transaction bound to
+// the tenant when row-level security is enabled.
+func (q *BookQueries) inTenant(tenantID uuid.UUID, fn func(db sqlx.Ext) error) error {
+	if !q.RowLevelSecurity {
+		return fn(q.DB)
+	}
+
+	return q.inTenantTx(tenantID, fn)
+}
+
+// inTenantTx runs fn in a transaction, bound to the tenant when row-level
+// security is enabled.
+func (q *BookQueries) inTenantTx(tenantID uuid.UUID, fn func(db sqlx.Ext) error) error {
+	tx, err := q.Beginx()
+	if err != nil {
+		return err
+	}
+
+	if q.RowLevelSecurity {
+		if _, err := tx.Exec(`SELECT set_config('app.tenant_id', $1, true)`, tenantID.String()); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (q *BookQueries) GetBooks() ([]models.Book, error) {
+// changeInTenant runs fn in a transaction like inTenantTx. The actor of the
+// change, and the revision it restores, are set for the trigger recording
+// book revisions, and the audit entry, if any, is written along, so a change
+// is never stored without its records.
+func (q *BookQueries) changeInTenant(tenantID uuid.UUID, change models.BookChange, fn func(db sqlx.Ext) error) error {
+	return q.inTenantTx(tenantID, func(db sqlx.Ext) error {
+		if err := setBookChange(db, change); err != nil {
+			return err
+		}
+		if err := fn(db); err != nil {
+			return err
+		}
+		if change.Audit != nil {
+			return createBookAuditLog(db, change.Audit)
+		}
+		return nil
+	})
+}
+
+// setBookChange sets app.actor_id and app.restored_from, read by the
+// record_book_revision trigger, until the end of the transaction.
+func setBookChange(db sqlx.Execer, change models.BookChange) error {
+	actorID, restoredFrom := "", ""
+	if change.ActorID != uuid.Nil {
+		actorID = change.ActorID.String()
+	}
+	if change.RestoredFrom > 0 {
+		restoredFrom = strconv.Itoa(change.RestoredFrom)
+	}
+
+	_, err := db.Exec(`SELECT set_config('app.actor_id', $1, true), set_config('app.restored_from', $2, true)`, actorID, restoredFrom)
+
+	return err
+}
+
+func (q *BookQueries) GetBooks(tenantID uuid.UUID) ([]models.Book, error) {
 	books := []models.Book{}
-	query := `SELECT * FROM books`
+	query := `SELECT * FROM books WHERE tenant_id = $1`
 
-	err := q.Select(&books, query)
+	err := q.inTenant(tenantID, func(db sqlx.Ext) error {
+		return sqlx.Select(db, &books, query, tenantID)
+	})
 	if err != nil {
 		return books, err
 	}
@@ -22,11 +100,13 @@ func (q *BookQueries) GetBooks() ([]models.Book, error) {
 	return books, nil
 }
 
-func (q *BookQueries) GetBooksByAuthor(author string) ([]models.Book, error) {
+func (q *BookQueries) GetBooksByAuthor(tenantID uuid.UUID, author string) ([]models.Book, error) {
 	books := []models.Book{}
-	query := `SELECT * FROM books WHERE author = $1`
+	query := `SELECT * FROM books WHERE tenant_id = $1 AND author = $2`
 
-	err := q.Select(&books, query, author)
+	err := q.inTenant(tenantID, func(db sqlx.Ext) error {
+		return sqlx.Select(db, &books, query, tenantID, author)
+	})
 	if err != nil {
 		return books, err
 	}
@@ -34,11 +114,49 @@ func (q *BookQueries) GetBooksByAuthor(author string) ([]models.Book, error) {
 	return books, nil
 }
 
-func (q *BookQueries) GetBook(id uuid.UUID) (models.Book, error) {
+// bookListQuery selects the books of the tenant ($1) carrying the tag ($2)
+// and in the category ($3) or its subcategories, each filter applying when
+// set.
+const bookListQuery = `WITH RECURSIVE subtree AS (
+		SELECT id FROM categories WHERE tenant_id = $1 AND id = NULLIF($3, '')::uuid
+		UNION
+		SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+	)
+	SELECT b.* FROM books b LEFT JOIN book_ratings r ON r.book_id = b.id
+	WHERE b.tenant_id = $1
+		AND ($2 = '' OR EXISTS (SELECT 1 FROM book_tags bt JOIN tags t ON t.id = bt.tag_id
+			WHERE bt.book_id = b.id AND t.tenant_id = $1 AND t.name = $2))
+		AND ($3 = '' OR EXISTS (SELECT 1 FROM book_categories bc
+			WHERE bc.book_id = b.id AND bc.category_id IN (SELECT id FROM subtree)))`
+
+// SearchBooks returns the books of the tenant matching the filter. Sorted by
+// rating, the best rated books come first and those without published
+// reviews last.
+func (q *BookQueries) SearchBooks(tenantID uuid.UUID, f models.BookListFilter) ([]models.Book, error) {
+	books := []models.Book{}
+	query := bookListQuery + ` ORDER BY b.created_at, b.id`
+	if f.Sort == "rating" {
+		query = bookListQuery + `
+	ORDER BY COALESCE(r.rating_average, 0) DESC, COALESCE(r.rating_count, 0) DESC, b.created_at, b.id`
+	}
+
+	err := q.inTenant(tenantID, func(db sqlx.Ext) error {
+		return sqlx.Select(db, &books, query, tenantID, f.Tag, f.Category)
+	})
+	if err != nil {
+		return books, err
+	}
+
+	return books, nil
+}
+
+func (q *BookQueries) GetBook(tenantID, id uuid.UUID) (models.Book, error) {
 	book := models.Book{}
-	query := `SELECT * FROM books WHERE id = $1`
+	query := `SELECT * FROM books WHERE tenant_id = $1 AND id = $2`
 
-	err := q.Get(&book, query, id)
+	err := q.inTenant(tenantID, func(db sqlx.Ext) error {
+		return sqlx.Get(db, &book, query, tenantID, id)
+	})
 	if err != nil {
 		return book, err
 	}
@@ -46,35 +164,122 @@ func (q *BookQueries) GetBook(id uuid.UUID) (models.Book, error) {
 	return book, nil
 }
 
-func (q *BookQueries) CreateBook(b *models.Book) error {
-	query := `INSERT INTO books VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+// GetBookByISBN looks a book up by its normalized ISBN.
+func (q *BookQueries) GetBookByISBN(tenantID uuid.UUID, isbn string) (models.Book, error) {
+	book := models.Book{}
+	query := `SELECT * FROM books WHERE tenant_id = $1 AND isbn = $2`
 
-	_, err := q.Exec(query, b.ID, b.CreatedAt, b.UpdatedAt, b.UserID, b.Title, b.Author, b.BookStatus, b.BookAttrs)
+	err := q.inTenant(tenantID, func(db sqlx.Ext) error {
+		return sqlx.Get(db, &book, query, tenantID, isbn)
+	})
 	if err != nil {
-		return err
+		return book, err
 	}
 
-	return nil
+	return book, nil
 }
 
-func (q *BookQueries) UpdateBook(id uuid.UUID, b *models.Book) error {
-	query := `UPDATE books SET updated_at = $2, title = $3, author = $4, book_status = $5, book_attrs = $6 WHERE id = $1`
+// GetTakenISBNs returns which of the ISBNs books of the tenant already have.
+func (q *BookQueries) GetTakenISBNs(tenantID uuid.UUID, isbns []string) ([]string, error) {
+	taken := []string{}
+	query, args, err := sqlx.In(`SELECT isbn FROM books WHERE tenant_id = ? AND isbn IN (?)`, tenantID, isbns)
+	if err != nil {
+		return taken, err
+	}
 
-	_, err := q.Exec(query, id, b.UpdatedAt, b.Title, b.Author, b.BookStatus, b.BookAttrs)
+	err = q.inTenant(tenantID, func(db sqlx.Ext) error {
+		return sqlx.Select(db, &taken, db.Rebind(query), args...)
+	})
 	if err != nil {
+		return taken, err
+	}
+
+	return taken, nil
+}
+
+// CreateBook inserts the book, recorded as made by the actor of the change.
+func (q *BookQueries) CreateBook(b *models.Book, change models.BookChange) error {
+	query := `INSERT INTO books VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)`
+
+	return q.changeInTenant(b.TenantID, change, func(db sqlx.Ext) error {
+		_, err := db.Exec(query, b.ID, b.CreatedAt, b.UpdatedAt, b.UserID, b.Title, b.Author, b.BookStatus, b.BookAttrs, b.TenantID, b.Version,
+			b.ISBN, b.Publisher, b.PublicationDate, b.Language, b.PageCount, b.Edition, b.BookFormat)
 		return err
+	})
+}
+
+// CreateBooks inserts the books of the tenant in one transaction, so either
+// all of them are stored or none.
+func (q *BookQueries) CreateBooks(tenantID uuid.UUID, books []models.Book, change models.BookChange) error {
+	query := `INSERT INTO books VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)`
+
+	return q.changeInTenant(tenantID, change, func(db sqlx.Ext) error {
+		for _, b := range books {
+			_, err := db.Exec(query, b.ID, b.CreatedAt, b.UpdatedAt, b.UserID, b.Title, b.Author, b.BookStatus, b.BookAttrs, tenantID, b.Version,
+				b.ISBN, b.Publisher, b.PublicationDate, b.Language, b.PageCount, b.Edition, b.BookFormat)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// UpdateBook only writes when the stored version still is b.Version, and
+// bumps it. It returns sql.ErrNoRows when the book changed or is gone.
+func (q *BookQueries) UpdateBook(tenantID, id uuid.UUID, b *models.Book, change models.BookChange) error {
+	query := `UPDATE books SET updated_at = $3, title = $4, author = $5, book_status = $6, book_attrs = $7, version = version + 1,
+		isbn = $9, publisher = $10, publication_date = $11, language = $12, page_count = $13, edition = $14, book_format = $15
+		WHERE tenant_id = $1 AND id = $2 AND version = $8`
+
+	return q.changeInTenant(tenantID, change, func(db sqlx.Ext) error {
+		result, err := db.Exec(query, tenantID, id, b.UpdatedAt, b.Title, b.Author, b.BookStatus, b.BookAttrs, b.Version,
+			b.ISBN, b.Publisher, b.PublicationDate, b.Language, b.PageCount, b.Edition, b.BookFormat)
+		if err != nil {
+			return err
+		}
+		return checkRowsAffected(result)
+	})
+}
+
+// DeleteBook only deletes the given version of the book. It returns
+// sql.ErrNoRows when the book changed or is gone.
+func (q *BookQueries) DeleteBook(tenantID, id uuid.UUID, version int, change models.BookChange) error {
+	query := `DELETE FROM books WHERE tenant_id = $1 AND id = $2 AND version = $3`
+
+	return q.changeInTenant(tenantID, change, func(db sqlx.Ext) error {
+		result, err := db.Exec(query, tenantID, id, version)
+		if err != nil {
+			return err
+		}
+		return checkRowsAffected(result)
+	})
+}
+
+func (q *BookQueries) CountBooksByUserID(tenantID, userID uuid.UUID) (int, error) {
+	count := 0
+	query := `SELECT COUNT(*) FROM books WHERE tenant_id = $1 AND user_id = $2`
+
+	err := q.inTenant(tenantID, func(db sqlx.Ext) error {
+		return sqlx.Get(db, &count, query, tenantID, userID)
+	})
+	if err != nil {
+		return count, err
 	}
 
-	return nil
+	return count, nil
 }
 
-func (q *BookQueries) DeleteBook(id uuid.UUID) error {
-	query := `DELETE FROM books WHERE id = $1`
+func (q *BookQueries) GetBooksByUserID(tenantID, userID uuid.UUID) ([]models.Book, error) {
+	books := []models.Book{}
+	query := `SELECT * FROM books WHERE tenant_id = $1 AND user_id = $2`
 
-	_, err := q.Exec(query, id)
+	err := q.inTenant(tenantID, func(db sqlx.Ext) error {
+		return sqlx.Select(db, &books, query, tenantID, userID)
+	})
 	if err != nil {
-		return err
+		return books, err
 	}
 
-	return nil
+	return books, nil
 }
@@ -0,0 +1,76 @@
+package queries
+
+import (
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Book revisions are written by the record_book_revision trigger on books,
+// with the actor set by changeInTenant, and outlive deleted books.
+
+// GetBookRevisions returns the revisions of the book, newest first.
+func (q *BookQueries) GetBookRevisions(tenantID, bookID uuid.UUID) ([]models.BookRevision, error) {
+	revisions := []models.BookRevision{}
+	query := `SELECT * FROM book_revisions WHERE tenant_id = $1 AND book_id = $2 ORDER BY revision DESC`
+
+	err := q.inTenant(tenantID, func(db sqlx.Ext) error {
+		return sqlx.Select(db, &revisions, query, tenantID, bookID)
+	})
+	if err != nil {
+		return revisions, err
+	}
+
+	return revisions, nil
+}
+
+// GetBookRevision returns sql.ErrNoRows when the book has no such revision.
+func (q *BookQueries) GetBookRevision(tenantID, bookID uuid.UUID, revision int) (models.BookRevision, error) {
+	bookRevision := models.BookRevision{}
+	query := `SELECT * FROM book_revisions WHERE tenant_id = $1 AND book_id = $2 AND revision = $3`
+
+	err := q.inTenant(tenantID, func(db sqlx.Ext) error {
+		return sqlx.Get(db, &bookRevision, query, tenantID, bookID, revision)
+	})
+	if err != nil {
+		return bookRevision, err
+	}
+
+	return bookRevision, nil
+}
+
+// GetLatestBookRevision returns the last revision of the book, the one
+// holding it as it was deleted when it is gone. It returns sql.ErrNoRows when
+// the book has no revision.
+func (q *BookQueries) GetLatestBookRevision(tenantID, bookID uuid.UUID) (models.BookRevision, error) {
+	bookRevision := models.BookRevision{}
+	query := `SELECT * FROM book_revisions WHERE tenant_id = $1 AND book_id = $2 ORDER BY revision DESC LIMIT 1`
+
+	err := q.inTenant(tenantID, func(db sqlx.Ext) error {
+		return sqlx.Get(db, &bookRevision, query, tenantID, bookID)
+	})
+	if err != nil {
+		return bookRevision, err
+	}
+
+	return bookRevision, nil
+}
+
+// DiffBookRevisions compares the snapshots of two revisions of the book with
+// the same book_revision_diff function the trigger uses. It returns
+// sql.ErrNoRows when either revision does not exist.
+func (q *BookQueries) DiffBookRevisions(tenantID, bookID uuid.UUID, from, to int) (models.BookRevisionDiff, error) {
+	diff := models.BookRevisionDiff{}
+	query := `SELECT book_revision_diff(f.snapshot, t.snapshot) FROM book_revisions f
+		JOIN book_revisions t ON t.tenant_id = f.tenant_id AND t.book_id = f.book_id AND t.revision = $4
+		WHERE f.tenant_id = $1 AND f.book_id = $2 AND f.revision = $3`
+
+	err := q.inTenant(tenantID, func(db sqlx.Ext) error {
+		return sqlx.Get(db, &diff, query, tenantID, bookID, from, to)
+	})
+	if err != nil {
+		return diff, err
+	}
+
+	return diff, nil
+}
@@ -0,0 +1,116 @@
+package queries
+
+import (
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type CategoryQueries struct {
+	*sqlx.DB
+}
+
+// GetCategories returns every category of the tenant by name.
+func (q *CategoryQueries) GetCategories(tenantID uuid.UUID) ([]models.Category, error) {
+	categories := []models.Category{}
+	query := `SELECT * FROM categories WHERE tenant_id = $1 ORDER BY lower(name), id`
+
+	err := q.Select(&categories, query, tenantID)
+	if err != nil {
+		return categories, err
+	}
+
+	return categories, nil
+}
+
+func (q *CategoryQueries) GetCategory(tenantID, id uuid.UUID) (models.Category, error) {
+	category := models.Category{}
+	query := `SELECT * FROM categories WHERE tenant_id = $1 AND id = $2`
+
+	err := q.Get(&category, query, tenantID, id)
+	if err != nil {
+		return category, err
+	}
+
+	return category, nil
+}
+
+// CategoryNameTaken reports whether the parent, or the top level for a nil
+// parent, has another category with the name, whatever its case.
+func (q *CategoryQueries) CategoryNameTaken(tenantID uuid.UUID, parentID *uuid.UUID, name string, id uuid.UUID) (bool, error) {
+	taken := false
+	query := `SELECT EXISTS (SELECT 1 FROM categories WHERE tenant_id = $1 AND parent_id IS NOT DISTINCT FROM $2
+		AND lower(name) = lower($3) AND id <> $4)`
+
+	err := q.Get(&taken, query, tenantID, parentID, name, id)
+	if err != nil {
+		return taken, err
+	}
+
+	return taken, nil
+}
+
+// CreateCategory returns sql.ErrNoRows when the parent is not in the tenant.
+func (q *CategoryQueries) CreateCategory(c *models.Category) error {
+	query := `INSERT INTO categories
+		SELECT $1, $2, $3, $4, $5, $6 WHERE $5::uuid IS NULL OR EXISTS (SELECT 1 FROM categories WHERE tenant_id = $4 AND id = $5)`
+
+	result, err := q.Exec(query, c.ID, c.CreatedAt, c.UpdatedAt, c.TenantID, c.ParentID, c.Name)
+	if err != nil {
+		return err
+	}
+
+	return checkRowsAffected(result)
+}
+
+// UpdateCategory renames or moves the category. It returns sql.ErrNoRows
+// when the category is gone, the parent is not in the tenant or the parent
+// is the category itself or one of its subcategories. The categories of the
+// tenant are locked first, so two moves cannot build a cycle together.
+func (q *CategoryQueries) UpdateCategory(c *models.Category) error {
+	query := `WITH RECURSIVE subtree AS (
+			SELECT id FROM categories WHERE tenant_id = $1 AND id = $2
+			UNION
+			SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+		)
+		UPDATE categories SET updated_at = $3, parent_id = $4, name = $5
+		WHERE tenant_id = $1 AND id = $2
+		AND ($4::uuid IS NULL OR EXISTS (SELECT 1 FROM categories WHERE tenant_id = $1 AND id = $4)
+			AND NOT EXISTS (SELECT 1 FROM subtree WHERE id = $4))`
+
+	tx, err := q.Beginx()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`SELECT 1 FROM categories WHERE tenant_id = $1 FOR UPDATE`, c.TenantID); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	result, err := tx.Exec(query, c.TenantID, c.ID, c.UpdatedAt, c.ParentID, c.Name)
+	if err == nil {
+		err = checkRowsAffected(result)
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteCategory only deletes categories without subcategories, books lose
+// it. It returns sql.ErrNoRows when the category is gone or has
+// subcategories.
+func (q *CategoryQueries) DeleteCategory(tenantID, id uuid.UUID) error {
+	query := `DELETE FROM categories WHERE tenant_id = $1 AND id = $2
+		AND NOT EXISTS (SELECT 1 FROM categories WHERE parent_id = $2)`
+
+	result, err := q.Exec(query, tenantID, id)
+	if err != nil {
+		return err
+	}
+
+	return checkRowsAffected(result)
+}
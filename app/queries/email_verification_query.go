@@ -0,0 +1,47 @@
+package queries
+
+import (
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type EmailVerificationQueries struct {
+	*sqlx.DB
+}
+
+func (q *EmailVerificationQueries) GetEmailVerificationByHash(hash string) (models.EmailVerification, error) {
+	verification := models.EmailVerification{}
+	query := `SELECT * FROM email_verifications WHERE token_hash = $1`
+
+	err := q.Get(&verification, query, hash)
+	if err != nil {
+		return verification, err
+	}
+
+	return verification, nil
+}
+
+func (q *EmailVerificationQueries) CreateEmailVerification(v *models.EmailVerification) error {
+	query := `INSERT INTO email_verifications VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err := q.Exec(query, v.ID, v.CreatedAt, v.UserID, v.NewEmail, v.TokenHash, v.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteEmailVerifications removes all pending verifications of the user, so
+// only the latest requested address can be confirmed.
+func (q *EmailVerificationQueries) DeleteEmailVerifications(userID uuid.UUID) error {
+	query := `DELETE FROM email_verifications WHERE user_id = $1`
+
+	_, err := q.Exec(query, userID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
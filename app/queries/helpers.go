@@ -0,0 +1,41 @@
+package queries
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jmoiron/sqlx"
+)
+
+func checkRowsAffected(result sql.Result) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// IsISBNTaken reports whether err is the unique index on ISBNs refusing a
+// book whose ISBN another book of the organization has.
+func IsISBNTaken(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "books_tenant_isbn_key"
+}
+
+// lockBook locks the book against concurrent changes until the end of the
+// transaction. It returns sql.ErrNoRows when the book is not in the tenant.
+func lockBook(db sqlx.Ext, tenantID, bookID uuid.UUID) error {
+	result, err := db.Exec(`SELECT 1 FROM books WHERE tenant_id = $1 AND id = $2 FOR UPDATE`, tenantID, bookID)
+	if err != nil {
+		return err
+	}
+
+	return checkRowsAffected(result)
+}
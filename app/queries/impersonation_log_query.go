@@ -0,0 +1,54 @@
+package queries
+
+import (
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type ImpersonationLogQueries struct {
+	*sqlx.DB
+}
+
+func (q *ImpersonationLogQueries) CreateImpersonationLog(l *models.ImpersonationLog) error {
+	query := `INSERT INTO impersonation_logs VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	_, err := q.Exec(
+		query,
+		l.ID, l.CreatedAt, l.ActorID, l.UserID, l.Method, l.Path, l.Status, l.IPAddress,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetImpersonationLogStatus records the status a logged request was answered
+// with.
+func (q *ImpersonationLogQueries) SetImpersonationLogStatus(id uuid.UUID, status int) error {
+	query := `UPDATE impersonation_logs SET status = $2 WHERE id = $1`
+
+	_, err := q.Exec(query, id, status)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetImpersonationLogs returns the newest entries first. Empty filter fields
+// match everything.
+func (q *ImpersonationLogQueries) GetImpersonationLogs(f models.ImpersonationLogFilter) ([]models.ImpersonationLog, error) {
+	logs := []models.ImpersonationLog{}
+	query := `SELECT * FROM impersonation_logs
+		WHERE ($1 = '' OR actor_id::text = $1) AND ($2 = '' OR user_id::text = $2)
+		ORDER BY created_at DESC LIMIT $3`
+
+	err := q.Select(&logs, query, f.ActorID, f.UserID, f.Limit)
+	if err != nil {
+		return logs, err
+	}
+
+	return logs, nil
+}
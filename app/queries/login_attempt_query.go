@@ -0,0 +1,67 @@
+package queries
+
+import (
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type LoginAttemptQueries struct {
+	*sqlx.DB
+}
+
+func (q *LoginAttemptQueries) CreateLoginAttempt(a *models.LoginAttempt) error {
+	query := `INSERT INTO login_attempts VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	_, err := q.Exec(
+		query,
+		a.ID, a.CreatedAt, a.Email, a.UserID, a.IPAddress, a.UserAgent, a.Succeeded, a.Reason,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetLoginAttempts returns the newest attempts first. Empty filter fields
+// match everything.
+func (q *LoginAttemptQueries) GetLoginAttempts(f models.LoginAttemptFilter) ([]models.LoginAttempt, error) {
+	attempts := []models.LoginAttempt{}
+	query := `SELECT * FROM login_attempts
+		WHERE ($1 = '' OR email = $1) AND ($2 = '' OR ip_address = $2)
+		ORDER BY created_at DESC LIMIT $3`
+
+	err := q.Select(&attempts, query, f.Email, f.IPAddress, f.Limit)
+	if err != nil {
+		return attempts, err
+	}
+
+	return attempts, nil
+}
+
+func (q *LoginAttemptQueries) GetLoginAttemptsByUserID(userID uuid.UUID) ([]models.LoginAttempt, error) {
+	attempts := []models.LoginAttempt{}
+	query := `SELECT * FROM login_attempts WHERE user_id = $1 ORDER BY created_at DESC`
+
+	err := q.Select(&attempts, query, userID)
+	if err != nil {
+		return attempts, err
+	}
+
+	return attempts, nil
+}
+
+// AnonymizeLoginAttempts replaces the email and clears the IP address and
+// user agent on every attempt made for the user, including attempts recorded
+// before the account could be matched.
+func (q *LoginAttemptQueries) AnonymizeLoginAttempts(userID uuid.UUID, oldEmail, newEmail string) error {
+	query := `UPDATE login_attempts SET email = $3, ip_address = '', user_agent = '' WHERE user_id = $1 OR email = $2`
+
+	_, err := q.Exec(query, userID, oldEmail, newEmail)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package queries
+
+import (
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type OrganizationQueries struct {
+	*sqlx.DB
+}
+
+func (q *OrganizationQueries) GetOrganizations() ([]models.Organization, error) {
+	organizations := []models.Organization{}
+	query := `SELECT * FROM organizations ORDER BY name`
+
+	err := q.Select(&organizations, query)
+	if err != nil {
+		return organizations, err
+	}
+
+	return organizations, nil
+}
+
+func (q *OrganizationQueries) GetOrganization(id uuid.UUID) (models.Organization, error) {
+	organization := models.Organization{}
+	query := `SELECT * FROM organizations WHERE id = $1`
+
+	err := q.Get(&organization, query, id)
+	if err != nil {
+		return organization, err
+	}
+
+	return organization, nil
+}
+
+func (q *OrganizationQueries) GetOrganizationBySlug(slug string) (models.Organization, error) {
+	organization := models.Organization{}
+	query := `SELECT * FROM organizations WHERE slug = $1`
+
+	err := q.Get(&organization, query, slug)
+	if err != nil {
+		return organization, err
+	}
+
+	return organization, nil
+}
+
+func (q *OrganizationQueries) CreateOrganization(o *models.Organization) error {
+	query := `INSERT INTO organizations VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := q.Exec(query, o.ID, o.CreatedAt, o.UpdatedAt, o.Name, o.Slug)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetMemberships returns the organizations of the user, oldest membership
+// first.
+func (q *OrganizationQueries) GetMemberships(userID uuid.UUID) ([]models.Membership, error) {
+	memberships := []models.Membership{}
+	query := `SELECT m.organization_id, o.name, o.slug, m.member_role, m.created_at
+		FROM organization_members m JOIN organizations o ON o.id = m.organization_id
+		WHERE m.user_id = $1 ORDER BY m.created_at, o.name`
+
+	err := q.Select(&memberships, query, userID)
+	if err != nil {
+		return memberships, err
+	}
+
+	return memberships, nil
+}
+
+func (q *OrganizationQueries) GetOrganizationMembers(organizationID uuid.UUID) ([]models.OrganizationMember, error) {
+	members := []models.OrganizationMember{}
+	query := `SELECT * FROM organization_members WHERE organization_id = $1 ORDER BY created_at`
+
+	err := q.Select(&members, query, organizationID)
+	if err != nil {
+		return members, err
+	}
+
+	return members, nil
+}
+
+// SaveOrganizationMember adds the user to the organization or changes their
+// role in it.
+func (q *OrganizationQueries) SaveOrganizationMember(m *models.OrganizationMember) error {
+	query := `INSERT INTO organization_members VALUES ($1, $2, $3, $4)
+		ON CONFLICT (organization_id, user_id) DO UPDATE SET member_role = EXCLUDED.member_role`
+
+	_, err := q.Exec(query, m.OrganizationID, m.UserID, m.MemberRole, m.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteOrganizationMember returns sql.ErrNoRows when the user is not a
+// member.
+func (q *OrganizationQueries) DeleteOrganizationMember(organizationID, userID uuid.UUID) error {
+	query := `DELETE FROM organization_members WHERE organization_id = $1 AND user_id = $2`
+
+	result, err := q.Exec(query, organizationID, userID)
+	if err != nil {
+		return err
+	}
+
+	return checkRowsAffected(result)
+}
@@ -0,0 +1,45 @@
+package queries
+
+import (
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type PasswordResetQueries struct {
+	*sqlx.DB
+}
+
+func (q *PasswordResetQueries) GetPasswordResetByHash(hash string) (models.PasswordReset, error) {
+	reset := models.PasswordReset{}
+	query := `SELECT * FROM password_resets WHERE token_hash = $1`
+
+	err := q.Get(&reset, query, hash)
+	if err != nil {
+		return reset, err
+	}
+
+	return reset, nil
+}
+
+func (q *PasswordResetQueries) CreatePasswordReset(r *models.PasswordReset) error {
+	query := `INSERT INTO password_resets VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := q.Exec(query, r.ID, r.CreatedAt, r.UserID, r.TokenHash, r.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q *PasswordResetQueries) DeletePasswordResets(userID uuid.UUID) error {
+	query := `DELETE FROM password_resets WHERE user_id = $1`
+
+	_, err := q.Exec(query, userID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
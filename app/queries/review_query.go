@@ -0,0 +1,141 @@
+package queries
+
+import (
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// The book_ratings aggregates are maintained by a trigger on reviews.
+
+// GetReviews returns a page of the published reviews of the book, newest
+// first, and how many there are in total.
+func (q *BookQueries) GetReviews(tenantID, bookID uuid.UUID, limit, offset int) ([]models.Review, int, error) {
+	reviews := []models.Review{}
+	total := 0
+	condition := `FROM reviews r JOIN books b ON b.id = r.book_id
+		WHERE b.tenant_id = $1 AND r.book_id = $2 AND r.review_status = 'published'`
+
+	err := q.inTenant(tenantID, func(db sqlx.Ext) error {
+		if err := sqlx.Get(db, &total, `SELECT COUNT(*) `+condition, tenantID, bookID); err != nil {
+			return err
+		}
+		return sqlx.Select(db, &reviews, `SELECT r.* `+condition+` ORDER BY r.created_at DESC LIMIT $3 OFFSET $4`,
+			tenantID, bookID, limit, offset)
+	})
+	if err != nil {
+		return reviews, 0, err
+	}
+
+	return reviews, total, nil
+}
+
+func (q *BookQueries) GetReview(tenantID, bookID, id uuid.UUID) (models.Review, error) {
+	review := models.Review{}
+	query := `SELECT r.* FROM reviews r JOIN books b ON b.id = r.book_id
+		WHERE b.tenant_id = $1 AND r.book_id = $2 AND r.id = $3`
+
+	err := q.inTenant(tenantID, func(db sqlx.Ext) error {
+		return sqlx.Get(db, &review, query, tenantID, bookID, id)
+	})
+	if err != nil {
+		return review, err
+	}
+
+	return review, nil
+}
+
+// GetReviewsByUserID returns every review the user wrote in the tenant,
+// hidden ones included, oldest first.
+func (q *BookQueries) GetReviewsByUserID(tenantID, userID uuid.UUID) ([]models.Review, error) {
+	reviews := []models.Review{}
+	query := `SELECT r.* FROM reviews r JOIN books b ON b.id = r.book_id
+		WHERE b.tenant_id = $1 AND r.user_id = $2 ORDER BY r.created_at`
+
+	err := q.inTenant(tenantID, func(db sqlx.Ext) error {
+		return sqlx.Select(db, &reviews, query, tenantID, userID)
+	})
+	if err != nil {
+		return reviews, err
+	}
+
+	return reviews, nil
+}
+
+// CreateReview returns sql.ErrNoRows when the book is not in the tenant or
+// the user already reviewed it.
+func (q *BookQueries) CreateReview(tenantID uuid.UUID, r *models.Review) error {
+	query := `INSERT INTO reviews
+		SELECT $2, $3, $4, $5, $6, $7, $8, $9, $10, $11 WHERE EXISTS (SELECT 1 FROM books WHERE tenant_id = $1 AND id = $5)
+		ON CONFLICT (book_id, user_id) DO NOTHING`
+
+	return q.inTenant(tenantID, func(db sqlx.Ext) error {
+		result, err := db.Exec(query, tenantID, r.ID, r.CreatedAt, r.UpdatedAt, r.BookID, r.UserID, r.Rating, r.Body,
+			r.ReviewStatus, r.ModeratedBy, r.ModeratedAt)
+		if err != nil {
+			return err
+		}
+		return checkRowsAffected(result)
+	})
+}
+
+// UpdateReview writes the rating and body of the review. It returns
+// sql.ErrNoRows when the review is gone.
+func (q *BookQueries) UpdateReview(tenantID uuid.UUID, r *models.Review) error {
+	query := `UPDATE reviews r SET updated_at = $4, rating = $5, body = $6 FROM books b
+		WHERE b.id = r.book_id AND b.tenant_id = $1 AND r.book_id = $2 AND r.id = $3`
+
+	return q.inTenant(tenantID, func(db sqlx.Ext) error {
+		result, err := db.Exec(query, tenantID, r.BookID, r.ID, r.UpdatedAt, r.Rating, r.Body)
+		if err != nil {
+			return err
+		}
+		return checkRowsAffected(result)
+	})
+}
+
+// ModerateReview writes the status of the review and who set it. It returns
+// sql.ErrNoRows when the review is gone.
+func (q *BookQueries) ModerateReview(tenantID uuid.UUID, r *models.Review) error {
+	query := `UPDATE reviews r SET review_status = $4, moderated_by = $5, moderated_at = $6 FROM books b
+		WHERE b.id = r.book_id AND b.tenant_id = $1 AND r.book_id = $2 AND r.id = $3`
+
+	return q.inTenant(tenantID, func(db sqlx.Ext) error {
+		result, err := db.Exec(query, tenantID, r.BookID, r.ID, r.ReviewStatus, r.ModeratedBy, r.ModeratedAt)
+		if err != nil {
+			return err
+		}
+		return checkRowsAffected(result)
+	})
+}
+
+// DeleteReview returns sql.ErrNoRows when the review is gone.
+func (q *BookQueries) DeleteReview(tenantID, bookID, id uuid.UUID) error {
+	query := `DELETE FROM reviews r USING books b
+		WHERE b.id = r.book_id AND b.tenant_id = $1 AND r.book_id = $2 AND r.id = $3`
+
+	return q.inTenant(tenantID, func(db sqlx.Ext) error {
+		result, err := db.Exec(query, tenantID, bookID, id)
+		if err != nil {
+			return err
+		}
+		return checkRowsAffected(result)
+	})
+}
+
+// GetBookRating returns the rating aggregates of the book, or sql.ErrNoRows
+// when it was never reviewed.
+func (q *BookQueries) GetBookRating(tenantID, bookID uuid.UUID) (models.BookRating, error) {
+	rating := models.BookRating{}
+	query := `SELECT r.* FROM book_ratings r JOIN books b ON b.id = r.book_id
+		WHERE b.tenant_id = $1 AND r.book_id = $2`
+
+	err := q.inTenant(tenantID, func(db sqlx.Ext) error {
+		return sqlx.Get(db, &rating, query, tenantID, bookID)
+	})
+	if err != nil {
+		return rating, err
+	}
+
+	return rating, nil
+}
@@ -0,0 +1,242 @@
+package queries
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// EnsureShelves creates the built-in shelves of the user, private, unless
+// they exist.
+func (q *BookQueries) EnsureShelves(tenantID, userID uuid.UUID) error {
+	query := `INSERT INTO shelves (tenant_id, user_id, name, kind, updated_at)
+		VALUES ($1, $2, 'Want to read', 'want_to_read', NOW()), ($1, $2, 'Favorites', 'favorites', NOW())
+		ON CONFLICT DO NOTHING`
+
+	return q.inTenant(tenantID, func(db sqlx.Ext) error {
+		_, err := db.Exec(query, tenantID, userID)
+		return err
+	})
+}
+
+// GetUserShelves returns the shelves of the user, the built-in ones first.
+// With publicOnly, private shelves are left out.
+func (q *BookQueries) GetUserShelves(tenantID, userID uuid.UUID, publicOnly bool) ([]models.Shelf, error) {
+	shelves := []models.Shelf{}
+	query := `SELECT * FROM shelves WHERE tenant_id = $1 AND user_id = $2 AND (is_public OR NOT $3)
+		ORDER BY kind = 'custom', kind = 'favorites', created_at, name`
+
+	err := q.inTenant(tenantID, func(db sqlx.Ext) error {
+		return sqlx.Select(db, &shelves, query, tenantID, userID, publicOnly)
+	})
+	if err != nil {
+		return shelves, err
+	}
+
+	return shelves, nil
+}
+
+func (q *BookQueries) GetShelf(tenantID, id uuid.UUID) (models.Shelf, error) {
+	shelf := models.Shelf{}
+	query := `SELECT * FROM shelves WHERE tenant_id = $1 AND id = $2`
+
+	err := q.inTenant(tenantID, func(db sqlx.Ext) error {
+		return sqlx.Get(db, &shelf, query, tenantID, id)
+	})
+	if err != nil {
+		return shelf, err
+	}
+
+	return shelf, nil
+}
+
+// CreateShelf returns sql.ErrNoRows when the user already has a shelf with
+// the name.
+func (q *BookQueries) CreateShelf(s *models.Shelf) error {
+	query := `INSERT INTO shelves VALUES ($1, $2, $3, $4, $5, $6, $7, $8) ON CONFLICT DO NOTHING`
+
+	return q.inTenant(s.TenantID, func(db sqlx.Ext) error {
+		result, err := db.Exec(query, s.ID, s.CreatedAt, s.UpdatedAt, s.TenantID, s.UserID, s.Name, s.Kind, s.IsPublic)
+		if err != nil {
+			return err
+		}
+		return checkRowsAffected(result)
+	})
+}
+
+// UpdateShelf renames the shelf and sets its visibility. It returns
+// sql.ErrNoRows when the shelf is gone or the user already has another
+// shelf with the name.
+func (q *BookQueries) UpdateShelf(s *models.Shelf) error {
+	query := `UPDATE shelves SET updated_at = $3, name = $4, is_public = $5
+		WHERE tenant_id = $1 AND id = $2 AND NOT EXISTS (SELECT 1 FROM shelves o
+			WHERE o.tenant_id = $1 AND o.user_id = shelves.user_id AND o.id <> $2 AND lower(o.name) = lower($4))`
+
+	return q.inTenant(s.TenantID, func(db sqlx.Ext) error {
+		result, err := db.Exec(query, s.TenantID, s.ID, s.UpdatedAt, s.Name, s.IsPublic)
+		if err != nil {
+			return err
+		}
+		return checkRowsAffected(result)
+	})
+}
+
+// DeleteShelf returns sql.ErrNoRows when the shelf is gone.
+func (q *BookQueries) DeleteShelf(tenantID, id uuid.UUID) error {
+	query := `DELETE FROM shelves WHERE tenant_id = $1 AND id = $2`
+
+	return q.inTenant(tenantID, func(db sqlx.Ext) error {
+		result, err := db.Exec(query, tenantID, id)
+		if err != nil {
+			return err
+		}
+		return checkRowsAffected(result)
+	})
+}
+
+// GetShelfBooks returns the books of the shelf in their order.
+func (q *BookQueries) GetShelfBooks(tenantID, shelfID uuid.UUID) ([]models.Book, error) {
+	books := []models.Book{}
+	query := `SELECT b.* FROM shelf_books sb JOIN books b ON b.id = sb.book_id
+		WHERE b.tenant_id = $1 AND sb.shelf_id = $2 ORDER BY sb.position`
+
+	err := q.inTenant(tenantID, func(db sqlx.Ext) error {
+		return sqlx.Select(db, &books, query, tenantID, shelfID)
+	})
+	if err != nil {
+		return books, err
+	}
+
+	return books, nil
+}
+
+// lockShelf locks the shelf against concurrent changes of its books until
+// the end of the transaction. It returns sql.ErrNoRows when the shelf is not
+// in the tenant.
+func lockShelf(db sqlx.Ext, tenantID, shelfID uuid.UUID) error {
+	result, err := db.Exec(`SELECT 1 FROM shelves WHERE tenant_id = $1 AND id = $2 FOR UPDATE`, tenantID, shelfID)
+	if err != nil {
+		return err
+	}
+
+	return checkRowsAffected(result)
+}
+
+// AddShelfBook puts the book on the shelf at position, or last when
+// position is 0 or past the end. It returns sql.ErrNoRows when the book
+// already is on the shelf.
+func (q *BookQueries) AddShelfBook(tenantID, shelfID, bookID uuid.UUID, position int, addedAt time.Time) error {
+	return q.inTenantTx(tenantID, func(db sqlx.Ext) error {
+		if err := lockShelf(db, tenantID, shelfID); err != nil {
+			return err
+		}
+
+		result, err := db.Exec(
+			`INSERT INTO shelf_books SELECT $1, $2, COALESCE(MAX(position), 0) + 1, $3 FROM shelf_books WHERE shelf_id = $1
+			ON CONFLICT DO NOTHING`,
+			shelfID, bookID, addedAt,
+		)
+		if err != nil {
+			return err
+		}
+		if err := checkRowsAffected(result); err != nil {
+			return err
+		}
+
+		if position == 0 {
+			return nil
+		}
+
+		// The book was added last, move it up and the books from position
+		// down by one.
+		_, err = db.Exec(
+			`UPDATE shelf_books SET position = CASE WHEN book_id = $2 THEN $3 ELSE position + 1 END
+			WHERE shelf_id = $1 AND position >= $3`,
+			shelfID, bookID, position,
+		)
+		return err
+	})
+}
+
+// RemoveShelfBook takes the book off the shelf; the compact_shelf_books
+// trigger closes the gap. It returns sql.ErrNoRows when the book is not on
+// the shelf.
+func (q *BookQueries) RemoveShelfBook(tenantID, shelfID, bookID uuid.UUID) error {
+	return q.inTenantTx(tenantID, func(db sqlx.Ext) error {
+		if err := lockShelf(db, tenantID, shelfID); err != nil {
+			return err
+		}
+
+		result, err := db.Exec(`DELETE FROM shelf_books WHERE shelf_id = $1 AND book_id = $2`, shelfID, bookID)
+		if err != nil {
+			return err
+		}
+		return checkRowsAffected(result)
+	})
+}
+
+// ReorderShelfBooks numbers the books of the shelf in the order of bookIDs.
+// It returns sql.ErrNoRows, changing nothing, unless bookIDs lists every
+// book of the shelf once.
+func (q *BookQueries) ReorderShelfBooks(tenantID, shelfID uuid.UUID, bookIDs []uuid.UUID) error {
+	return q.inTenantTx(tenantID, func(db sqlx.Ext) error {
+		if err := lockShelf(db, tenantID, shelfID); err != nil {
+			return err
+		}
+
+		count := 0
+		if err := sqlx.Get(db, &count, `SELECT COUNT(*) FROM shelf_books WHERE shelf_id = $1`, shelfID); err != nil {
+			return err
+		}
+		if count != len(bookIDs) {
+			return sql.ErrNoRows
+		}
+
+		for i, bookID := range bookIDs {
+			result, err := db.Exec(`UPDATE shelf_books SET position = $3 WHERE shelf_id = $1 AND book_id = $2`, shelfID, bookID, i+1)
+			if err != nil {
+				return err
+			}
+			if err := checkRowsAffected(result); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetBookShelves returns, for each of the books, the shelves of the user
+// holding it.
+func (q *BookQueries) GetBookShelves(tenantID, userID uuid.UUID, bookIDs []uuid.UUID) (map[uuid.UUID][]models.ShelfRef, error) {
+	shelves := map[uuid.UUID][]models.ShelfRef{}
+	if len(bookIDs) == 0 {
+		return shelves, nil
+	}
+
+	rows := []struct {
+		BookID uuid.UUID `db:"book_id"`
+		models.ShelfRef
+	}{}
+	query, args, err := sqlx.In(`SELECT sb.book_id, s.id, s.name, s.kind FROM shelf_books sb JOIN shelves s ON s.id = sb.shelf_id
+		WHERE s.tenant_id = ? AND s.user_id = ? AND sb.book_id IN (?)
+		ORDER BY s.kind = 'custom', s.kind = 'favorites', s.created_at, s.name`, tenantID, userID, bookIDs)
+	if err != nil {
+		return shelves, err
+	}
+
+	err = q.inTenant(tenantID, func(db sqlx.Ext) error {
+		return sqlx.Select(db, &rows, db.Rebind(query), args...)
+	})
+	if err != nil {
+		return shelves, err
+	}
+
+	for _, row := range rows {
+		shelves[row.BookID] = append(shelves[row.BookID], row.ShelfRef)
+	}
+
+	return shelves, nil
+}
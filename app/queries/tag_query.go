@@ -0,0 +1,27 @@
+package queries
+
+import (
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type TagQueries struct {
+	*sqlx.DB
+}
+
+// GetTags returns the tags of the tenant starting with prefix that some book
+// carries, most used first.
+func (q *TagQueries) GetTags(tenantID uuid.UUID, prefix string, limit int) ([]models.TagCount, error) {
+	tags := []models.TagCount{}
+	query := `SELECT t.name, COUNT(*) AS book_count FROM tags t JOIN book_tags bt ON bt.tag_id = t.id
+		WHERE t.tenant_id = $1 AND left(t.name, length($2)) = $2
+		GROUP BY t.name ORDER BY book_count DESC, t.name LIMIT $3`
+
+	err := q.Select(&tags, query, tenantID, prefix, limit)
+	if err != nil {
+		return tags, err
+	}
+
+	return tags, nil
+}
@@ -0,0 +1,180 @@
+package queries_test
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/app/queries"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var apiKeyColumns = []string{
+	"id", "created_at", "updated_at", "user_id", "name", "key_prefix", "key_hash",
+	"credentials", "expires_at", "last_used_at", "revoked_at",
+}
+
+func TestAPIKeyQueries_GetAPIKeysByUserID(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.APIKeyQueries{DB: db}
+	userID := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC`)).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows(apiKeyColumns).
+			AddRow(uuid.New(), time.Now(), time.Now(), userID, "ci", "fgt_abcdefgh", "hash", []byte(`["book:create"]`), time.Now().Add(time.Hour), nil, nil))
+
+	keys, err := q.GetAPIKeysByUserID(userID)
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+	assert.Equal(t, models.APIKeyCredentials{"book:create"}, keys[0].Credentials)
+	assert.Nil(t, keys[0].RevokedAt)
+
+	// error case
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC`)).
+		WithArgs(userID).
+		WillReturnError(errors.New("db error"))
+	_, err = q.GetAPIKeysByUserID(userID)
+	assert.Error(t, err)
+}
+
+func TestAPIKeyQueries_GetAPIKeyByHash(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.APIKeyQueries{DB: db}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM api_keys WHERE key_hash = $1`)).
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows(apiKeyColumns).
+			AddRow(uuid.New(), time.Now(), time.Now(), uuid.New(), "ci", "fgt_abcdefgh", "hash", []byte(`[]`), time.Now(), time.Now(), nil))
+
+	key, err := q.GetAPIKeyByHash("hash")
+	assert.NoError(t, err)
+	assert.Equal(t, "hash", key.KeyHash)
+	assert.NotNil(t, key.LastUsedAt)
+
+	// error case
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM api_keys WHERE key_hash = $1`)).
+		WithArgs("hash").
+		WillReturnError(sql.ErrNoRows)
+	_, err = q.GetAPIKeyByHash("hash")
+	assert.Error(t, err)
+}
+
+func TestAPIKeyQueries_CreateAPIKey(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.APIKeyQueries{DB: db}
+
+	k := &models.APIKey{
+		ID:          uuid.New(),
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+		UserID:      uuid.New(),
+		Name:        "ci",
+		KeyPrefix:   "fgt_abcdefgh",
+		KeyHash:     "hash",
+		Credentials: models.APIKeyCredentials{"book:create"},
+		ExpiresAt:   time.Now().Add(time.Hour),
+		TenantID:    uuid.New(),
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO api_keys VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULL, NULL, $10)`)).
+		WithArgs(k.ID, k.CreatedAt, k.UpdatedAt, k.UserID, k.Name, k.KeyPrefix, k.KeyHash, k.Credentials, k.ExpiresAt, k.TenantID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := q.CreateAPIKey(k)
+	assert.NoError(t, err)
+
+	// error case
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO api_keys VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULL, NULL, $10)`)).
+		WithArgs(k.ID, k.CreatedAt, k.UpdatedAt, k.UserID, k.Name, k.KeyPrefix, k.KeyHash, k.Credentials, k.ExpiresAt, k.TenantID).
+		WillReturnError(errors.New("insert error"))
+	err = q.CreateAPIKey(k)
+	assert.Error(t, err)
+}
+
+func TestAPIKeyQueries_TouchAPIKey(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.APIKeyQueries{DB: db}
+	id := uuid.New()
+	usedAt := time.Now()
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE api_keys SET last_used_at = $2 WHERE id = $1`)).
+		WithArgs(id, usedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := q.TouchAPIKey(id, usedAt)
+	assert.NoError(t, err)
+}
+
+func TestAPIKeyQueries_RevokeAPIKey(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.APIKeyQueries{DB: db}
+	id, userID := uuid.New(), uuid.New()
+	revokedAt := time.Now()
+	query := regexp.QuoteMeta(`UPDATE api_keys SET revoked_at = $3 WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`)
+
+	mock.ExpectExec(query).
+		WithArgs(id, userID, revokedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, q.RevokeAPIKey(id, userID, revokedAt))
+
+	// not found or already revoked
+	mock.ExpectExec(query).
+		WithArgs(id, userID, revokedAt).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, q.RevokeAPIKey(id, userID, revokedAt), sql.ErrNoRows)
+
+	// error case
+	mock.ExpectExec(query).
+		WithArgs(id, userID, revokedAt).
+		WillReturnError(errors.New("update error"))
+	assert.Error(t, q.RevokeAPIKey(id, userID, revokedAt))
+}
+
+func TestAPIKeyQueries_RevokeAPIKeys(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.APIKeyQueries{DB: db}
+	userID := uuid.New()
+	revokedAt := time.Now()
+	query := regexp.QuoteMeta(`UPDATE api_keys SET revoked_at = $2 WHERE user_id = $1 AND revoked_at IS NULL`)
+
+	mock.ExpectExec(query).
+		WithArgs(userID, revokedAt).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	assert.NoError(t, q.RevokeAPIKeys(userID, revokedAt))
+
+	// nothing to revoke is not an error
+	mock.ExpectExec(query).
+		WithArgs(userID, revokedAt).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.NoError(t, q.RevokeAPIKeys(userID, revokedAt))
+
+	// error case
+	mock.ExpectExec(query).
+		WithArgs(userID, revokedAt).
+		WillReturnError(errors.New("update error"))
+	assert.Error(t, q.RevokeAPIKeys(userID, revokedAt))
+}
+
+func TestAPIKeyQueries_DeleteAPIKeys(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.APIKeyQueries{DB: db}
+	userID := uuid.New()
+	query := regexp.QuoteMeta(`DELETE FROM api_keys WHERE user_id = $1`)
+
+	mock.ExpectExec(query).
+		WithArgs(userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, q.DeleteAPIKeys(userID))
+
+	// error case
+	mock.ExpectExec(query).
+		WithArgs(userID).
+		WillReturnError(errors.New("delete error"))
+	assert.Error(t, q.DeleteAPIKeys(userID))
+}
@@ -0,0 +1,97 @@
+package queries_test
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/app/queries"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var authorColumns = []string{"id", "created_at", "updated_at", "tenant_id", "name", "name_key"}
+
+func TestAuthorQueries_GetAuthors(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.AuthorQueries{DB: db}
+	tenantID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM authors WHERE tenant_id = $1`)).
+		WithArgs(tenantID, "tolk").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM authors WHERE tenant_id = $1`)+`.*`+regexp.QuoteMeta(`ORDER BY name, id LIMIT $3 OFFSET $4`)).
+		WithArgs(tenantID, "tolk", 20, 0).
+		WillReturnRows(sqlmock.NewRows(authorColumns).AddRow(uuid.New(), now, now, tenantID, "J.R.R. Tolkien", "jrrtolkien"))
+
+	authors, total, err := q.GetAuthors(tenantID, "tolk", 20, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Len(t, authors, 1)
+	assert.Equal(t, "J.R.R. Tolkien", authors[0].Name)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuthorQueries_GetAuthorByName(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.AuthorQueries{DB: db}
+	tenantID := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM authors WHERE tenant_id = $1 AND name_key = author_name_key($2)`)).
+		WithArgs(tenantID, "Tolkien, J. R. R.").
+		WillReturnError(sql.ErrNoRows)
+
+	_, err := q.GetAuthorByName(tenantID, "Tolkien, J. R. R.")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuthorQueries_CreateAuthor(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.AuthorQueries{DB: db}
+	now := time.Now()
+	a := &models.Author{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, TenantID: uuid.New(), Name: "Ursula K. Le Guin"}
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO authors VALUES ($1, $2, $3, $4, $5, author_name_key($5))
+		ON CONFLICT (tenant_id, name_key) DO NOTHING`)).
+		WithArgs(a.ID, a.CreatedAt, a.UpdatedAt, a.TenantID, a.Name).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, q.CreateAuthor(a))
+
+	// same name key
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO authors`)).
+		WithArgs(a.ID, a.CreatedAt, a.UpdatedAt, a.TenantID, a.Name).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, q.CreateAuthor(a), sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuthorQueries_UpdateAuthor(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.AuthorQueries{DB: db}
+	a := &models.Author{ID: uuid.New(), UpdatedAt: time.Now(), TenantID: uuid.New(), Name: "Le Guin, Ursula K."}
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE authors SET updated_at = $3, name = $4, name_key = author_name_key($4)`)).
+		WithArgs(a.TenantID, a.ID, a.UpdatedAt, a.Name).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, q.UpdateAuthor(a))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuthorQueries_DeleteAuthor(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.AuthorQueries{DB: db}
+	tenantID, id := uuid.New(), uuid.New()
+
+	// still credited
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM authors WHERE tenant_id = $1 AND id = $2
+		AND NOT EXISTS (SELECT 1 FROM book_authors WHERE author_id = $2)`)).
+		WithArgs(tenantID, id).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, q.DeleteAuthor(tenantID, id), sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
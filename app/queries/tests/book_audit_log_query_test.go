@@ -0,0 +1,39 @@
+package queries_test
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/app/queries"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookAuditLogQueries_GetBookAuditLogs(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookAuditLogQueries{DB: db}
+	tenantID, bookID, actorID := uuid.New(), uuid.New(), uuid.New()
+	filter := models.BookAuditLogFilter{BookID: bookID.String(), Limit: 10}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM book_audit_logs`)).
+		WithArgs(tenantID, filter.BookID, "", 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "tenant_id", "book_id", "owner_id", "actor_id", "action", "before", "after"}).
+			AddRow(uuid.New(), time.Now(), tenantID, bookID, nil, actorID, "update", []byte(`{"title":"Go"}`), []byte(`{"title":"Go 2"}`)))
+
+	logs, err := q.GetBookAuditLogs(tenantID, filter)
+	assert.NoError(t, err)
+	assert.Len(t, logs, 1)
+	assert.Equal(t, &actorID, logs[0].ActorID)
+	assert.Nil(t, logs[0].OwnerID)
+	assert.JSONEq(t, `{"title":"Go 2"}`, string(logs[0].After))
+
+	// error case
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM book_audit_logs`)).
+		WillReturnError(errors.New("db error"))
+	_, err = q.GetBookAuditLogs(tenantID, filter)
+	assert.Error(t, err)
+}
@@ -0,0 +1,84 @@
+package queries_test
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/app/queries"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookQueries_GetBookAuthors(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID, bookID, authorID := uuid.New(), uuid.New(), uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT ba.author_id, a.name, ba.author_role, ba.position`)).
+		WithArgs(tenantID, bookID).
+		WillReturnRows(sqlmock.NewRows([]string{"author_id", "name", "author_role", "position"}).
+			AddRow(authorID, "Homer", "author", 1))
+
+	authors, err := q.GetBookAuthors(tenantID, bookID)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.BookAuthor{{AuthorID: authorID, Name: "Homer", AuthorRole: "author", Position: 1}}, authors)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookQueries_SetBookAuthors(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID, bookID := uuid.New(), uuid.New()
+	authors := []models.BookAuthorUpdate{
+		{AuthorID: uuid.New(), AuthorRole: "author"},
+		{AuthorID: uuid.New(), AuthorRole: "translator"},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT 1 FROM books WHERE tenant_id = $1 AND id = $2 FOR UPDATE`)).
+		WithArgs(tenantID, bookID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM book_authors WHERE book_id = $1`)).
+		WithArgs(bookID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	for i, author := range authors {
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO book_authors SELECT $2, $3, $4, $5`)).
+			WithArgs(tenantID, bookID, author.AuthorID, author.AuthorRole, i+1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+	assert.NoError(t, q.SetBookAuthors(tenantID, bookID, authors))
+
+	// an author of another tenant rolls everything back
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT 1 FROM books`)).
+		WithArgs(tenantID, bookID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM book_authors`)).
+		WithArgs(bookID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO book_authors`)).
+		WithArgs(tenantID, bookID, authors[0].AuthorID, authors[0].AuthorRole, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	assert.ErrorIs(t, q.SetBookAuthors(tenantID, bookID, authors), sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookQueries_GetBooksByAuthorID(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID, authorID := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`AND EXISTS (SELECT 1 FROM book_authors ba WHERE ba.book_id = b.id AND ba.author_id = $2)`)).
+		WithArgs(tenantID, authorID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow(uuid.New(), "The Odyssey"))
+
+	books, err := q.GetBooksByAuthorID(tenantID, authorID)
+	assert.NoError(t, err)
+	assert.Len(t, books, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,91 @@
+package queries_test
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/app/queries"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookQueries_SearchBooks(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID, categoryID := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`WITH RECURSIVE subtree AS (`)+`.*`+regexp.QuoteMeta(`ORDER BY b.created_at, b.id`)).
+		WithArgs(tenantID, "fantasy", categoryID.String()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow(uuid.New(), "The Hobbit"))
+
+	books, err := q.SearchBooks(tenantID, models.BookListFilter{Tag: "fantasy", Category: categoryID.String()})
+	assert.NoError(t, err)
+	assert.Len(t, books, 1)
+
+	// sorted by rating, unfiltered
+	mock.ExpectQuery(regexp.QuoteMeta(`ORDER BY COALESCE(r.rating_average, 0) DESC, COALESCE(r.rating_count, 0) DESC`)).
+		WithArgs(tenantID, "", "").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow(uuid.New(), "Best").AddRow(uuid.New(), "Unrated"))
+
+	books, err = q.SearchBooks(tenantID, models.BookListFilter{Sort: "rating"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Best", books[0].Title)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookQueries_SetBookTags(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID, bookID := uuid.New(), uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT 1 FROM books WHERE tenant_id = $1 AND id = $2 FOR UPDATE`)).
+		WithArgs(tenantID, bookID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM book_tags WHERE book_id = $1`)).
+		WithArgs(bookID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	for _, tag := range []string{"fantasy", "classic"} {
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO tags (tenant_id, name) VALUES ($1, $2) ON CONFLICT (tenant_id, name) DO NOTHING`)).
+			WithArgs(tenantID, tag).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO book_tags SELECT $2, id FROM tags WHERE tenant_id = $1 AND name = $3`)).
+			WithArgs(tenantID, bookID, tag).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+	assert.NoError(t, q.SetBookTags(tenantID, bookID, []string{"fantasy", "classic"}))
+
+	// book of another tenant
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT 1 FROM books`)).
+		WithArgs(tenantID, bookID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	assert.ErrorIs(t, q.SetBookTags(tenantID, bookID, []string{"fantasy"}), sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookQueries_SetBookCategories(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID, bookID, categoryID := uuid.New(), uuid.New(), uuid.New()
+
+	// unknown category
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT 1 FROM books`)).
+		WithArgs(tenantID, bookID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM book_categories WHERE book_id = $1`)).
+		WithArgs(bookID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO book_categories SELECT $2, $3 WHERE EXISTS (SELECT 1 FROM categories WHERE tenant_id = $1 AND id = $3)`)).
+		WithArgs(tenantID, bookID, categoryID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	assert.ErrorIs(t, q.SetBookCategories(tenantID, bookID, []uuid.UUID{categoryID}), sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,116 @@
+package queries_test
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/app/queries"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookQueries_GetCollaboratorRole(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID, bookID, userID := uuid.New(), uuid.New(), uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT c.collaborator_role FROM book_collaborators c JOIN books b ON b.id = c.book_id`)).
+		WithArgs(tenantID, bookID, userID).
+		WillReturnRows(sqlmock.NewRows([]string{"collaborator_role"}).AddRow("editor"))
+
+	role, err := q.GetCollaboratorRole(tenantID, bookID, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, "editor", role)
+
+	// not shared
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM book_collaborators c`)).
+		WithArgs(tenantID, bookID, userID).
+		WillReturnError(sql.ErrNoRows)
+	_, err = q.GetCollaboratorRole(tenantID, bookID, userID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestBookQueries_SaveBookCollaborator(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID := uuid.New()
+	c := &models.BookCollaborator{BookID: uuid.New(), UserID: uuid.New(), CollaboratorRole: "viewer", CreatedAt: time.Now()}
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO book_collaborators
+		SELECT $2, $3, $4, $5 WHERE EXISTS (SELECT 1 FROM books WHERE tenant_id = $1 AND id = $2)`)).
+		WithArgs(tenantID, c.BookID, c.UserID, c.CollaboratorRole, c.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, q.SaveBookCollaborator(tenantID, c))
+
+	// book of another tenant
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO book_collaborators`)).
+		WithArgs(tenantID, c.BookID, c.UserID, c.CollaboratorRole, c.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, q.SaveBookCollaborator(tenantID, c), sql.ErrNoRows)
+}
+
+func TestBookQueries_DeleteBookCollaborator(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID, bookID, userID := uuid.New(), uuid.New(), uuid.New()
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM book_collaborators c USING books b`)).
+		WithArgs(tenantID, bookID, userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, q.DeleteBookCollaborator(tenantID, bookID, userID))
+
+	// not a collaborator
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM book_collaborators c USING books b`)).
+		WithArgs(tenantID, bookID, userID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, q.DeleteBookCollaborator(tenantID, bookID, userID), sql.ErrNoRows)
+}
+
+func TestBookQueries_AcceptBookTransfer(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID, now := uuid.New(), time.Now()
+	transfer := models.BookTransfer{BookID: uuid.New(), FromUserID: uuid.New(), ToUserID: uuid.New()}
+
+	mock.ExpectBegin()
+	expectBookChange(mock, transfer.ToUserID.String(), "")
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE books SET user_id = $4, updated_at = $5, version = version + 1`)).
+		WithArgs(tenantID, transfer.BookID, transfer.FromUserID, transfer.ToUserID, now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM book_collaborators WHERE book_id = $1 AND user_id = $2`)).
+		WithArgs(transfer.BookID, transfer.ToUserID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM book_transfers WHERE book_id = $1`)).
+		WithArgs(transfer.BookID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	assert.NoError(t, q.AcceptBookTransfer(tenantID, transfer, now))
+
+	// the book changed hands since the offer
+	mock.ExpectBegin()
+	expectBookChange(mock, transfer.ToUserID.String(), "")
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE books SET user_id = $4`)).
+		WithArgs(tenantID, transfer.BookID, transfer.FromUserID, transfer.ToUserID, now).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	assert.ErrorIs(t, q.AcceptBookTransfer(tenantID, transfer, now), sql.ErrNoRows)
+
+	// error case
+	mock.ExpectBegin()
+	expectBookChange(mock, transfer.ToUserID.String(), "")
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE books SET user_id = $4`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM book_collaborators`)).
+		WillReturnError(errors.New("delete error"))
+	mock.ExpectRollback()
+
+	assert.Error(t, q.AcceptBookTransfer(tenantID, transfer, now))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,112 @@
+package queries_test
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/app/queries"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookQueries_CountBooksForExport(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID := uuid.New()
+	f := models.BookExportFilter{Author: "Rob", CreatedFrom: "2025-01-01T00:00:00Z"}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM books WHERE tenant_id = $1 AND ($2 = '' OR author = $2)`)).
+		WithArgs(tenantID, "Rob", "", "", "2025-01-01T00:00:00Z", "").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
+
+	count, err := q.CountBooksForExport(tenantID, f)
+	assert.NoError(t, err)
+	assert.Equal(t, 42, count)
+
+	// error case
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM books`)).
+		WillReturnError(errors.New("db error"))
+	_, err = q.CountBooksForExport(tenantID, f)
+	assert.Error(t, err)
+}
+
+func TestBookQueries_StreamBooks(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID := uuid.New()
+	f := models.BookExportFilter{BookStatus: "1"}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM books WHERE tenant_id = $1`)+`.*`+regexp.QuoteMeta(`ORDER BY created_at, id`)).
+		WithArgs(tenantID, "", "1", "", "", "").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).
+			AddRow(uuid.New(), "Go").
+			AddRow(uuid.New(), "Rust"))
+
+	titles := []string{}
+	err := q.StreamBooks(tenantID, f, func(b models.Book) error {
+		titles = append(titles, b.Title)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Go", "Rust"}, titles)
+
+	// the callback stops the stream
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM books`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).
+			AddRow(uuid.New(), "Go").
+			AddRow(uuid.New(), "Rust"))
+
+	calls := 0
+	err = q.StreamBooks(tenantID, f, func(b models.Book) error {
+		calls++
+		return errors.New("client gone")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestBookExportJobQueries_CreateBookExportJob(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookExportJobQueries{DB: db}
+	j := &models.BookExportJob{
+		ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), TenantID: uuid.New(), UserID: uuid.New(),
+		Format: "xlsx", Filter: models.BookExportFilter{Author: "Rob"}, Status: "queued", TotalRows: 20000,
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO book_export_jobs VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`)).
+		WithArgs(j.ID, j.CreatedAt, j.UpdatedAt, j.TenantID, j.UserID, "xlsx", j.Filter, "queued", 20000, 0, "", j.FinishedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, q.CreateBookExportJob(j))
+
+	// error case
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO book_export_jobs`)).
+		WillReturnError(errors.New("insert error"))
+	assert.Error(t, q.CreateBookExportJob(j))
+}
+
+func TestBookExportJobQueries_GetBookExportJob(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookExportJobQueries{DB: db}
+	tenantID, id := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM book_export_jobs WHERE tenant_id = $1 AND id = $2`)).
+		WithArgs(tenantID, id).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "filter"}).
+			AddRow(id, "done", []byte(`{"author":"Rob"}`)))
+
+	job, err := q.GetBookExportJob(tenantID, id)
+	assert.NoError(t, err)
+	assert.Equal(t, "Rob", job.Filter.Author)
+
+	// error case
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM book_export_jobs`)).
+		WithArgs(tenantID, id).
+		WillReturnError(sql.ErrNoRows)
+	_, err = q.GetBookExportJob(tenantID, id)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,93 @@
+package queries_test
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/app/queries"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookImportJobQueries_CreateBookImportJob(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookImportJobQueries{DB: db}
+	j := &models.BookImportJob{
+		ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), TenantID: uuid.New(), UserID: uuid.New(),
+		Format: "csv", Status: "queued", TotalRows: 3, RowErrors: models.BookImportRowErrors{},
+		UnmappedFields: models.BookImportUnmappedFields{"650": 2},
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO book_import_jobs VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`)).
+		WithArgs(j.ID, j.CreatedAt, j.UpdatedAt, j.TenantID, j.UserID, "csv", false, "queued", 3, 0, 0, j.RowErrors, j.FinishedAt, j.UnmappedFields).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, q.CreateBookImportJob(j))
+
+	// error case
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO book_import_jobs`)).
+		WillReturnError(errors.New("insert error"))
+	assert.Error(t, q.CreateBookImportJob(j))
+}
+
+func TestBookImportJobQueries_UpdateBookImportJob(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookImportJobQueries{DB: db}
+	finishedAt := time.Now()
+	j := &models.BookImportJob{
+		ID: uuid.New(), UpdatedAt: finishedAt, Status: "done", ProcessedRows: 3, ImportedRows: 2,
+		RowErrors: models.BookImportRowErrors{{Line: 3, Error: "Author: required"}}, FinishedAt: &finishedAt,
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE book_import_jobs SET updated_at = $2, status = $3, processed_rows = $4, imported_rows = $5,`)).
+		WithArgs(j.ID, j.UpdatedAt, "done", 3, 2, j.RowErrors, j.FinishedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, q.UpdateBookImportJob(j))
+
+	// job is gone
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE book_import_jobs`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, q.UpdateBookImportJob(j), sql.ErrNoRows)
+}
+
+func TestBookImportJobQueries_GetBookImportJob(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookImportJobQueries{DB: db}
+	tenantID, id := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM book_import_jobs WHERE tenant_id = $1 AND id = $2`)).
+		WithArgs(tenantID, id).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id", "status", "row_errors"}).
+			AddRow(id, tenantID, "done", []byte(`[{"line":2,"error":"Title: required"}]`)))
+
+	job, err := q.GetBookImportJob(tenantID, id)
+	assert.NoError(t, err)
+	assert.Equal(t, "done", job.Status)
+	assert.Equal(t, models.BookImportRowErrors{{Line: 2, Error: "Title: required"}}, job.RowErrors)
+
+	// error case
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM book_import_jobs`)).
+		WithArgs(tenantID, id).
+		WillReturnError(sql.ErrNoRows)
+	_, err = q.GetBookImportJob(tenantID, id)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestBookImportJobQueries_FailBookImportJobs(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookImportJobQueries{DB: db}
+	now := time.Now()
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE book_import_jobs SET status = ?, updated_at = ?, finished_at = ? WHERE status IN (?, ?)`)).
+		WithArgs("failed", now, now, "queued", "running").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	n, err := q.FailBookImportJobs("failed", []string{"queued", "running"}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -1,6 +1,7 @@
 package queries_test
 
 import (
+	"database/sql"
 	"errors"
 	"regexp"
 	"testing"
@@ -22,71 +23,122 @@ func newMock(t *testing.T) (*sqlx.DB, sqlmock.Sqlmock) {
 	return sqlx.NewDb(db, "sqlmock"), mock
 }
 
+// expectBookChange expects the settings the book revision trigger reads.
+func expectBookChange(mock sqlmock.Sqlmock, actorID, restoredFrom string) {
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT set_config('app.actor_id', $1, true), set_config('app.restored_from', $2, true)`)).
+		WithArgs(actorID, restoredFrom).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
 func TestBookQueries_GetBooks(t *testing.T) {
 	db, mock := newMock(t)
 	q := &queries.BookQueries{DB: db}
+	tenantID := uuid.New()
 
-	columns := []string{"id", "created_at", "updated_at", "user_id", "title", "author", "book_status", "book_attrs"}
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM books`)).
+	columns := []string{"id", "created_at", "updated_at", "user_id", "title", "author", "book_status", "book_attrs", "tenant_id"}
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM books WHERE tenant_id = $1`)).
+		WithArgs(tenantID).
 		WillReturnRows(sqlmock.NewRows(columns).
-			AddRow(uuid.New(), time.Now(), time.Now(), uuid.New(), "Title1", "Author1", 1, []byte(`{"picture": "picture1", "description": "description1", "rating": 5}`)))
+			AddRow(uuid.New(), time.Now(), time.Now(), uuid.New(), "Title1", "Author1", 1, []byte(`{"picture": "picture1", "description": "description1", "rating": 5}`), tenantID))
 
-	books, err := q.GetBooks()
+	books, err := q.GetBooks(tenantID)
 	assert.NoError(t, err)
 	assert.Len(t, books, 1)
 
 	// error case
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM books`)).
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM books WHERE tenant_id = $1`)).
+		WithArgs(tenantID).
 		WillReturnError(errors.New("db error"))
-	_, err = q.GetBooks()
+	_, err = q.GetBooks(tenantID)
 	assert.Error(t, err)
 }
 
 func TestBookQueries_GetBooksByAuthor(t *testing.T) {
 	db, mock := newMock(t)
 	q := &queries.BookQueries{DB: db}
+	tenantID := uuid.New()
 
-	columns := []string{"id", "created_at", "updated_at", "user_id", "title", "author", "book_status", "book_attrs"}
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM books WHERE author = $1`)).
-		WithArgs("Author1").
+	columns := []string{"id", "created_at", "updated_at", "user_id", "title", "author", "book_status", "book_attrs", "tenant_id"}
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM books WHERE tenant_id = $1 AND author = $2`)).
+		WithArgs(tenantID, "Author1").
 		WillReturnRows(sqlmock.NewRows(columns).
-			AddRow(uuid.New(), time.Now(), time.Now(), uuid.New(), "Title1", "Author1", 1, []byte(`{"picture": "picture1", "description": "description1", "rating": 5}`)))
+			AddRow(uuid.New(), time.Now(), time.Now(), uuid.New(), "Title1", "Author1", 1, []byte(`{"picture": "picture1", "description": "description1", "rating": 5}`), tenantID))
 
-	books, err := q.GetBooksByAuthor("Author1")
+	books, err := q.GetBooksByAuthor(tenantID, "Author1")
 	assert.NoError(t, err)
 	assert.Len(t, books, 1)
 
 	// error case
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM books WHERE author = $1`)).
-		WithArgs("Author1").
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM books WHERE tenant_id = $1 AND author = $2`)).
+		WithArgs(tenantID, "Author1").
 		WillReturnError(errors.New("db error"))
-	_, err = q.GetBooksByAuthor("Author1")
+	_, err = q.GetBooksByAuthor(tenantID, "Author1")
 	assert.Error(t, err)
 }
 
 func TestBookQueries_GetBook(t *testing.T) {
 	db, mock := newMock(t)
 	q := &queries.BookQueries{DB: db}
-	id := uuid.New()
+	tenantID, id := uuid.New(), uuid.New()
 
-	columns := []string{"id", "created_at", "updated_at", "user_id", "title", "author", "book_status", "book_attrs"}
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM books WHERE id = $1`)).
-		WithArgs(id).
+	columns := []string{"id", "created_at", "updated_at", "user_id", "title", "author", "book_status", "book_attrs", "tenant_id"}
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM books WHERE tenant_id = $1 AND id = $2`)).
+		WithArgs(tenantID, id).
 		WillReturnRows(sqlmock.NewRows(columns).
-			AddRow(id, time.Now(), time.Now(), uuid.New(), "Title1", "Author1", 1, []byte(`{"picture": "picture1", "description": "description1", "rating": 5}`)))
+			AddRow(id, time.Now(), time.Now(), uuid.New(), "Title1", "Author1", 1, []byte(`{"picture": "picture1", "description": "description1", "rating": 5}`), tenantID))
 
-	book, err := q.GetBook(id)
+	book, err := q.GetBook(tenantID, id)
 	assert.NoError(t, err)
 	assert.Equal(t, id, book.ID)
 
 	// error case
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM books WHERE id = $1`)).
-		WithArgs(id).
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM books WHERE tenant_id = $1 AND id = $2`)).
+		WithArgs(tenantID, id).
 		WillReturnError(errors.New("db error"))
-	_, err = q.GetBook(id)
+	_, err = q.GetBook(tenantID, id)
 	assert.Error(t, err)
 }
 
+func TestBookQueries_GetBookByISBN(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID, id := uuid.New(), uuid.New()
+	published := time.Date(1994, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	columns := []string{"id", "title", "tenant_id", "isbn", "publication_date", "page_count"}
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM books WHERE tenant_id = $1 AND isbn = $2`)).
+		WithArgs(tenantID, "9780306406157").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(id, "Title1", tenantID, "9780306406157", published, 412))
+
+	book, err := q.GetBookByISBN(tenantID, "9780306406157")
+	assert.NoError(t, err)
+	assert.Equal(t, id, book.ID)
+	assert.Equal(t, "1994-03-01", book.PublicationDate.Format(time.DateOnly))
+	assert.Equal(t, 412, book.PageCount)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM books WHERE tenant_id = $1 AND isbn = $2`)).
+		WithArgs(tenantID, "9780306406164").
+		WillReturnError(sql.ErrNoRows)
+	_, err = q.GetBookByISBN(tenantID, "9780306406164")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookQueries_GetTakenISBNs(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT isbn FROM books WHERE tenant_id = ? AND isbn IN (?, ?)`)).
+		WithArgs(tenantID, "9780306406157", "9781402894626").
+		WillReturnRows(sqlmock.NewRows([]string{"isbn"}).AddRow("9781402894626"))
+
+	taken, err := q.GetTakenISBNs(tenantID, []string{"9780306406157", "9781402894626"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"9781402894626"}, taken)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestBookQueries_CreateBook(t *testing.T) {
 	db, mock := newMock(t)
 	q := &queries.BookQueries{DB: db}
@@ -104,27 +156,84 @@ func TestBookQueries_CreateBook(t *testing.T) {
 			Description: "Description1",
 			Rating:      5,
 		},
+		TenantID: uuid.New(),
+		Version:  1,
+		BookMetadata: models.BookMetadata{
+			ISBN:            "9780306406157",
+			Publisher:       "Plenum",
+			PublicationDate: &models.Date{Time: time.Date(1994, 3, 1, 0, 0, 0, 0, time.UTC)},
+			Language:        "en",
+			PageCount:       412,
+			BookFormat:      "hardcover",
+		},
 	}
 
-	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO books VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`)).
-		WithArgs(b.ID, b.CreatedAt, b.UpdatedAt, b.UserID, b.Title, b.Author, b.BookStatus, b.BookAttrs).
+	change := models.BookChange{ActorID: b.UserID}
+
+	mock.ExpectBegin()
+	expectBookChange(mock, b.UserID.String(), "")
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO books VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)`)).
+		WithArgs(b.ID, b.CreatedAt, b.UpdatedAt, b.UserID, b.Title, b.Author, b.BookStatus, b.BookAttrs, b.TenantID, b.Version,
+			b.ISBN, b.Publisher, b.PublicationDate, b.Language, b.PageCount, b.Edition, b.BookFormat).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
-	err := q.CreateBook(b)
+	err := q.CreateBook(b, change)
 	assert.NoError(t, err)
 
 	// error case
-	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO books VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`)).
-		WithArgs(b.ID, b.CreatedAt, b.UpdatedAt, b.UserID, b.Title, b.Author, b.BookStatus, b.BookAttrs).
+	mock.ExpectBegin()
+	expectBookChange(mock, b.UserID.String(), "")
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO books VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)`)).
+		WithArgs(b.ID, b.CreatedAt, b.UpdatedAt, b.UserID, b.Title, b.Author, b.BookStatus, b.BookAttrs, b.TenantID, b.Version,
+			b.ISBN, b.Publisher, b.PublicationDate, b.Language, b.PageCount, b.Edition, b.BookFormat).
 		WillReturnError(errors.New("insert error"))
-	err = q.CreateBook(b)
+	mock.ExpectRollback()
+	err = q.CreateBook(b, change)
 	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookQueries_CreateBooks(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID := uuid.New()
+	books := []models.Book{
+		{ID: uuid.New(), UserID: uuid.New(), Title: "Title1", Author: "Author1", BookStatus: 1, TenantID: tenantID, Version: 1},
+		{ID: uuid.New(), UserID: uuid.New(), Title: "Title2", Author: "Author2", BookStatus: 0, TenantID: tenantID, Version: 1},
+	}
+
+	actorID := uuid.New()
+
+	mock.ExpectBegin()
+	expectBookChange(mock, actorID.String(), "")
+	for _, b := range books {
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO books VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)`)).
+			WithArgs(b.ID, b.CreatedAt, b.UpdatedAt, b.UserID, b.Title, b.Author, b.BookStatus, b.BookAttrs, tenantID, b.Version,
+				b.ISBN, b.Publisher, b.PublicationDate, b.Language, b.PageCount, b.Edition, b.BookFormat).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	mock.ExpectCommit()
+
+	assert.NoError(t, q.CreateBooks(tenantID, books, models.BookChange{ActorID: actorID}))
+
+	// one failing insert rolls the whole batch back
+	mock.ExpectBegin()
+	expectBookChange(mock, actorID.String(), "")
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO books`)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO books`)).
+		WillReturnError(errors.New("insert error"))
+	mock.ExpectRollback()
+
+	assert.Error(t, q.CreateBooks(tenantID, books, models.BookChange{ActorID: actorID}))
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestBookQueries_UpdateBook(t *testing.T) {
 	db, mock := newMock(t)
 	q := &queries.BookQueries{DB: db}
-	id := uuid.New()
+	tenantID, id := uuid.New(), uuid.New()
 	b := &models.Book{
 		UpdatedAt:  time.Now(),
 		Title:      "UpdatedTitle",
@@ -135,39 +244,222 @@ func TestBookQueries_UpdateBook(t *testing.T) {
 			Description: "UpdatedDescription",
 			Rating:      5,
 		},
+		Version: 3,
 	}
+	query := regexp.QuoteMeta(`UPDATE books SET updated_at = $3, title = $4, author = $5, book_status = $6, book_attrs = $7, version = version + 1,
+		isbn = $9, publisher = $10, publication_date = $11, language = $12, page_count = $13, edition = $14, book_format = $15
+		WHERE tenant_id = $1 AND id = $2 AND version = $8`)
+	actorID := uuid.New()
+	change := models.BookChange{ActorID: actorID}
 
-	mock.ExpectExec(regexp.QuoteMeta(`UPDATE books SET updated_at = $2, title = $3, author = $4, book_status = $5, book_attrs = $6 WHERE id = $1`)).
-		WithArgs(id, b.UpdatedAt, b.Title, b.Author, b.BookStatus, b.BookAttrs).
+	mock.ExpectBegin()
+	expectBookChange(mock, actorID.String(), "")
+	mock.ExpectExec(query).
+		WithArgs(tenantID, id, b.UpdatedAt, b.Title, b.Author, b.BookStatus, b.BookAttrs, b.Version,
+			b.ISBN, b.Publisher, b.PublicationDate, b.Language, b.PageCount, b.Edition, b.BookFormat).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
-	err := q.UpdateBook(id, b)
+	err := q.UpdateBook(tenantID, id, b, change)
 	assert.NoError(t, err)
 
+	// a restore names the restored revision
+	mock.ExpectBegin()
+	expectBookChange(mock, actorID.String(), "2")
+	mock.ExpectExec(query).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err = q.UpdateBook(tenantID, id, b, models.BookChange{ActorID: actorID, RestoredFrom: 2})
+	assert.NoError(t, err)
+
+	// stale version
+	mock.ExpectBegin()
+	expectBookChange(mock, actorID.String(), "")
+	mock.ExpectExec(query).
+		WithArgs(tenantID, id, b.UpdatedAt, b.Title, b.Author, b.BookStatus, b.BookAttrs, b.Version,
+			b.ISBN, b.Publisher, b.PublicationDate, b.Language, b.PageCount, b.Edition, b.BookFormat).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	err = q.UpdateBook(tenantID, id, b, change)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
 	// error case
-	mock.ExpectExec(regexp.QuoteMeta(`UPDATE books SET updated_at = $2, title = $3, author = $4, book_status = $5, book_attrs = $6 WHERE id = $1`)).
-		WithArgs(id, b.UpdatedAt, b.Title, b.Author, b.BookStatus, b.BookAttrs).
+	mock.ExpectBegin()
+	expectBookChange(mock, actorID.String(), "")
+	mock.ExpectExec(query).
+		WithArgs(tenantID, id, b.UpdatedAt, b.Title, b.Author, b.BookStatus, b.BookAttrs, b.Version,
+			b.ISBN, b.Publisher, b.PublicationDate, b.Language, b.PageCount, b.Edition, b.BookFormat).
 		WillReturnError(errors.New("update error"))
-	err = q.UpdateBook(id, b)
+	mock.ExpectRollback()
+	err = q.UpdateBook(tenantID, id, b, change)
 	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestBookQueries_DeleteBook(t *testing.T) {
 	db, mock := newMock(t)
 	q := &queries.BookQueries{DB: db}
-	id := uuid.New()
+	tenantID, id := uuid.New(), uuid.New()
+	change := models.BookChange{}
 
-	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM books WHERE id = $1`)).
-		WithArgs(id).
+	mock.ExpectBegin()
+	expectBookChange(mock, "", "")
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM books WHERE tenant_id = $1 AND id = $2 AND version = $3`)).
+		WithArgs(tenantID, id, 2).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
-	err := q.DeleteBook(id)
+	err := q.DeleteBook(tenantID, id, 2, change)
 	assert.NoError(t, err)
 
+	// stale version
+	mock.ExpectBegin()
+	expectBookChange(mock, "", "")
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM books WHERE tenant_id = $1 AND id = $2 AND version = $3`)).
+		WithArgs(tenantID, id, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	err = q.DeleteBook(tenantID, id, 1, change)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
 	// error case
-	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM books WHERE id = $1`)).
-		WithArgs(id).
+	mock.ExpectBegin()
+	expectBookChange(mock, "", "")
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM books WHERE tenant_id = $1 AND id = $2 AND version = $3`)).
+		WithArgs(tenantID, id, 2).
 		WillReturnError(errors.New("delete error"))
-	err = q.DeleteBook(id)
+	mock.ExpectRollback()
+	err = q.DeleteBook(tenantID, id, 2, change)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookQueries_CountBooksByUserID(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID, userID := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM books WHERE tenant_id = $1 AND user_id = $2`)).
+		WithArgs(tenantID, userID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	count, err := q.CountBooksByUserID(tenantID, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	// error case
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM books WHERE tenant_id = $1 AND user_id = $2`)).
+		WithArgs(tenantID, userID).
+		WillReturnError(errors.New("db error"))
+	_, err = q.CountBooksByUserID(tenantID, userID)
+	assert.Error(t, err)
+}
+
+func TestBookQueries_GetBooksByUserID(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID, userID := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM books WHERE tenant_id = $1 AND user_id = $2`)).
+		WithArgs(tenantID, userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "title"}).
+			AddRow(uuid.New(), userID, "Go"))
+
+	books, err := q.GetBooksByUserID(tenantID, userID)
+	assert.NoError(t, err)
+	assert.Len(t, books, 1)
+	assert.Equal(t, "Go", books[0].Title)
+
+	// error case
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM books WHERE tenant_id = $1 AND user_id = $2`)).
+		WithArgs(tenantID, userID).
+		WillReturnError(errors.New("db error"))
+	_, err = q.GetBooksByUserID(tenantID, userID)
+	assert.Error(t, err)
+}
+
+func TestBookQueries_RowLevelSecurity(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db, RowLevelSecurity: true}
+	tenantID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT set_config('app.tenant_id', $1, true)`)).
+		WithArgs(tenantID.String()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM books WHERE tenant_id = $1`)).
+		WithArgs(tenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "tenant_id"}).
+			AddRow(uuid.New(), "Go", tenantID))
+	mock.ExpectCommit()
+
+	books, err := q.GetBooks(tenantID)
+	assert.NoError(t, err)
+	assert.Len(t, books, 1)
+
+	// error case rolls the transaction back
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT set_config('app.tenant_id', $1, true)`)).
+		WithArgs(tenantID.String()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM books WHERE tenant_id = $1`)).
+		WithArgs(tenantID).
+		WillReturnError(errors.New("db error"))
+	mock.ExpectRollback()
+
+	_, err = q.GetBooks(tenantID)
 	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookQueries_DeleteBook_Audited(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID, id, actorID := uuid.New(), uuid.New(), uuid.New()
+	audit := &models.BookAuditLog{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		TenantID:  tenantID,
+		BookID:    id,
+		ActorID:   &actorID,
+		Action:    "delete",
+		Before:    []byte(`{"title":"Go"}`),
+	}
+	change := models.BookChange{ActorID: actorID, Audit: audit}
+
+	mock.ExpectBegin()
+	expectBookChange(mock, actorID.String(), "")
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM books WHERE tenant_id = $1 AND id = $2 AND version = $3`)).
+		WithArgs(tenantID, id, 2).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO book_audit_logs VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`)).
+		WithArgs(audit.ID, audit.CreatedAt, tenantID, id, audit.OwnerID, audit.ActorID, "delete", audit.Before, audit.After).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	assert.NoError(t, q.DeleteBook(tenantID, id, 2, change))
+
+	// a stale version is neither deleted nor recorded
+	mock.ExpectBegin()
+	expectBookChange(mock, actorID.String(), "")
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM books WHERE tenant_id = $1 AND id = $2 AND version = $3`)).
+		WithArgs(tenantID, id, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	assert.ErrorIs(t, q.DeleteBook(tenantID, id, 1, change), sql.ErrNoRows)
+
+	// the change is rolled back when it cannot be recorded
+	mock.ExpectBegin()
+	expectBookChange(mock, actorID.String(), "")
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM books`)).
+		WithArgs(tenantID, id, 2).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO book_audit_logs`)).
+		WillReturnError(errors.New("insert error"))
+	mock.ExpectRollback()
+
+	assert.Error(t, q.DeleteBook(tenantID, id, 2, change))
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -0,0 +1,99 @@
+package queries_test
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/app/queries"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookQueries_GetBookRevisions(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID, bookID := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM book_revisions WHERE tenant_id = $1 AND book_id = $2 ORDER BY revision DESC`)).
+		WithArgs(tenantID, bookID).
+		WillReturnRows(sqlmock.NewRows([]string{"revision", "action", "snapshot", "diff"}).
+			AddRow(2, "update", []byte(`{"title":"Go 2"}`), []byte(`{"title":{"from":"Go","to":"Go 2"}}`)).
+			AddRow(1, "create", []byte(`{"title":"Go"}`), []byte(`{"title":{"from":null,"to":"Go"}}`)))
+
+	revisions, err := q.GetBookRevisions(tenantID, bookID)
+	assert.NoError(t, err)
+	assert.Len(t, revisions, 2)
+	assert.Equal(t, 2, revisions[0].Revision)
+	assert.JSONEq(t, `"Go 2"`, string(revisions[0].Diff["title"].To))
+	assert.JSONEq(t, `null`, string(revisions[1].Diff["title"].From))
+
+	// error case
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM book_revisions`)).
+		WillReturnError(errors.New("db error"))
+	_, err = q.GetBookRevisions(tenantID, bookID)
+	assert.Error(t, err)
+}
+
+func TestBookQueries_GetBookRevision(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID, bookID := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM book_revisions WHERE tenant_id = $1 AND book_id = $2 AND revision = $3`)).
+		WithArgs(tenantID, bookID, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"revision", "action", "restored_from", "snapshot", "diff"}).
+			AddRow(3, "update", 1, []byte(`{"title":"Go"}`), []byte(`{}`)))
+
+	revision, err := q.GetBookRevision(tenantID, bookID, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, *revision.RestoredFrom)
+
+	// no such revision
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM book_revisions`)).
+		WillReturnRows(sqlmock.NewRows([]string{"revision"}))
+	_, err = q.GetBookRevision(tenantID, bookID, 9)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestBookQueries_GetLatestBookRevision(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID, bookID := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM book_revisions WHERE tenant_id = $1 AND book_id = $2 ORDER BY revision DESC LIMIT 1`)).
+		WithArgs(tenantID, bookID).
+		WillReturnRows(sqlmock.NewRows([]string{"revision", "action", "snapshot", "diff"}).
+			AddRow(4, "delete", []byte(`{"title":"Go"}`), []byte(`{}`)))
+
+	revision, err := q.GetLatestBookRevision(tenantID, bookID)
+	assert.NoError(t, err)
+	assert.Equal(t, "delete", revision.Action)
+}
+
+func TestBookQueries_DiffBookRevisions(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID, bookID := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT book_revision_diff(f.snapshot, t.snapshot) FROM book_revisions f`)).
+		WithArgs(tenantID, bookID, 1, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"book_revision_diff"}).
+			AddRow([]byte(`{"book_attrs.rating":{"from":5,"to":8}}`)))
+
+	diff, err := q.DiffBookRevisions(tenantID, bookID, 1, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, models.BookRevisionDiff{
+		"book_attrs.rating": {From: []byte(`5`), To: []byte(`8`)},
+	}, diff)
+
+	// either revision is missing
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT book_revision_diff`)).
+		WillReturnRows(sqlmock.NewRows([]string{"book_revision_diff"}))
+	_, err = q.DiffBookRevisions(tenantID, bookID, 1, 9)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
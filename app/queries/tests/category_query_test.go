@@ -0,0 +1,94 @@
+package queries_test
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/app/queries"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCategoryQueries_CategoryNameTaken(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.CategoryQueries{DB: db}
+	tenantID, id := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS (SELECT 1 FROM categories WHERE tenant_id = $1 AND parent_id IS NOT DISTINCT FROM $2`)).
+		WithArgs(tenantID, nil, "Fiction", id).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	taken, err := q.CategoryNameTaken(tenantID, nil, "Fiction", id)
+	assert.NoError(t, err)
+	assert.True(t, taken)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCategoryQueries_CreateCategory(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.CategoryQueries{DB: db}
+	now := time.Now()
+	parentID := uuid.New()
+	c := &models.Category{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, TenantID: uuid.New(), ParentID: &parentID, Name: "Fantasy"}
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO categories
+		SELECT $1, $2, $3, $4, $5, $6 WHERE $5::uuid IS NULL OR EXISTS (SELECT 1 FROM categories WHERE tenant_id = $4 AND id = $5)`)).
+		WithArgs(c.ID, c.CreatedAt, c.UpdatedAt, c.TenantID, c.ParentID, c.Name).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, q.CreateCategory(c))
+
+	// parent of another tenant
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO categories`)).
+		WithArgs(c.ID, c.CreatedAt, c.UpdatedAt, c.TenantID, c.ParentID, c.Name).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, q.CreateCategory(c), sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCategoryQueries_UpdateCategory(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.CategoryQueries{DB: db}
+	parentID := uuid.New()
+	c := &models.Category{ID: uuid.New(), UpdatedAt: time.Now(), TenantID: uuid.New(), ParentID: &parentID, Name: "Fantasy"}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT 1 FROM categories WHERE tenant_id = $1 FOR UPDATE`)).
+		WithArgs(c.TenantID).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(regexp.QuoteMeta(`UNION SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id ) UPDATE categories SET updated_at = $3, parent_id = $4, name = $5`)+
+		`.*`+regexp.QuoteMeta(`AND NOT EXISTS (SELECT 1 FROM subtree WHERE id = $4)`)).
+		WithArgs(c.TenantID, c.ID, c.UpdatedAt, c.ParentID, c.Name).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	assert.NoError(t, q.UpdateCategory(c))
+
+	// parent inside the category
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT 1 FROM categories`)).
+		WithArgs(c.TenantID).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE categories`)).
+		WithArgs(c.TenantID, c.ID, c.UpdatedAt, c.ParentID, c.Name).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	assert.ErrorIs(t, q.UpdateCategory(c), sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCategoryQueries_DeleteCategory(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.CategoryQueries{DB: db}
+	tenantID, id := uuid.New(), uuid.New()
+
+	// has subcategories
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM categories WHERE tenant_id = $1 AND id = $2
+		AND NOT EXISTS (SELECT 1 FROM categories WHERE parent_id = $2)`)).
+		WithArgs(tenantID, id).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, q.DeleteCategory(tenantID, id), sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,85 @@
+package queries_test
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/app/queries"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailVerificationQueries_GetEmailVerificationByHash(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.EmailVerificationQueries{DB: db}
+
+	columns := []string{"id", "created_at", "user_id", "new_email", "token_hash", "expires_at"}
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM email_verifications WHERE token_hash = $1`)).
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(uuid.New(), time.Now(), uuid.New(), "new@example.com", "hash", time.Now().Add(time.Hour)))
+
+	verification, err := q.GetEmailVerificationByHash("hash")
+	assert.NoError(t, err)
+	assert.Equal(t, "new@example.com", verification.NewEmail)
+
+	// error case
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM email_verifications WHERE token_hash = $1`)).
+		WithArgs("hash").
+		WillReturnError(sql.ErrNoRows)
+	_, err = q.GetEmailVerificationByHash("hash")
+	assert.Error(t, err)
+}
+
+func TestEmailVerificationQueries_CreateEmailVerification(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.EmailVerificationQueries{DB: db}
+
+	v := &models.EmailVerification{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UserID:    uuid.New(),
+		NewEmail:  "new@example.com",
+		TokenHash: "hash",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO email_verifications VALUES ($1, $2, $3, $4, $5, $6)`)).
+		WithArgs(v.ID, v.CreatedAt, v.UserID, v.NewEmail, v.TokenHash, v.ExpiresAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := q.CreateEmailVerification(v)
+	assert.NoError(t, err)
+
+	// error case
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO email_verifications VALUES ($1, $2, $3, $4, $5, $6)`)).
+		WithArgs(v.ID, v.CreatedAt, v.UserID, v.NewEmail, v.TokenHash, v.ExpiresAt).
+		WillReturnError(errors.New("insert error"))
+	err = q.CreateEmailVerification(v)
+	assert.Error(t, err)
+}
+
+func TestEmailVerificationQueries_DeleteEmailVerifications(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.EmailVerificationQueries{DB: db}
+	userID := uuid.New()
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM email_verifications WHERE user_id = $1`)).
+		WithArgs(userID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	err := q.DeleteEmailVerifications(userID)
+	assert.NoError(t, err)
+
+	// error case
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM email_verifications WHERE user_id = $1`)).
+		WithArgs(userID).
+		WillReturnError(errors.New("delete error"))
+	err = q.DeleteEmailVerifications(userID)
+	assert.Error(t, err)
+}
@@ -0,0 +1,78 @@
+package queries_test
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/app/queries"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImpersonationLogQueries_CreateImpersonationLog(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.ImpersonationLogQueries{DB: db}
+	actorID, userID := uuid.New(), uuid.New()
+	entry := &models.ImpersonationLog{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		ActorID:   &actorID,
+		UserID:    &userID,
+		Method:    "GET",
+		Path:      "/api/v1/user/me",
+		Status:    200,
+		IPAddress: "10.0.0.1",
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO impersonation_logs VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`)).
+		WithArgs(entry.ID, entry.CreatedAt, entry.ActorID, entry.UserID, entry.Method, entry.Path, entry.Status, entry.IPAddress).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, q.CreateImpersonationLog(entry))
+
+	// error case
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO impersonation_logs`)).
+		WillReturnError(errors.New("insert error"))
+	assert.Error(t, q.CreateImpersonationLog(entry))
+}
+
+func TestImpersonationLogQueries_SetImpersonationLogStatus(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.ImpersonationLogQueries{DB: db}
+	id := uuid.New()
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE impersonation_logs SET status = $2 WHERE id = $1`)).
+		WithArgs(id, 204).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, q.SetImpersonationLogStatus(id, 204))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestImpersonationLogQueries_GetImpersonationLogs(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.ImpersonationLogQueries{DB: db}
+	actorID := uuid.New()
+	filter := models.ImpersonationLogFilter{ActorID: actorID.String(), Limit: 10}
+
+	columns := []string{"id", "created_at", "actor_id", "user_id", "method", "path", "status", "ip_address"}
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM impersonation_logs`)).
+		WithArgs(filter.ActorID, filter.UserID, filter.Limit).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(uuid.New(), time.Now(), actorID, nil, "DELETE", "/api/v1/book", 403, "10.0.0.1"))
+
+	logs, err := q.GetImpersonationLogs(filter)
+	assert.NoError(t, err)
+	assert.Len(t, logs, 1)
+	assert.Equal(t, actorID, *logs[0].ActorID)
+	assert.Nil(t, logs[0].UserID)
+
+	// error case
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM impersonation_logs`)).
+		WithArgs(filter.ActorID, filter.UserID, filter.Limit).
+		WillReturnError(errors.New("db error"))
+	_, err = q.GetImpersonationLogs(filter)
+	assert.Error(t, err)
+}
@@ -0,0 +1,111 @@
+package queries_test
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/app/queries"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginAttemptQueries_CreateLoginAttempt(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.LoginAttemptQueries{DB: db}
+	userID := uuid.New()
+
+	a := &models.LoginAttempt{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		Email:     "test@example.com",
+		UserID:    &userID,
+		IPAddress: "10.0.0.1",
+		UserAgent: "curl/8.0",
+		Succeeded: false,
+		Reason:    repository.LoginAttemptWrongPassword,
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO login_attempts VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`)).
+		WithArgs(a.ID, a.CreatedAt, a.Email, a.UserID, a.IPAddress, a.UserAgent, a.Succeeded, a.Reason).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := q.CreateLoginAttempt(a)
+	assert.NoError(t, err)
+
+	// error case
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO login_attempts VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`)).
+		WithArgs(a.ID, a.CreatedAt, a.Email, a.UserID, a.IPAddress, a.UserAgent, a.Succeeded, a.Reason).
+		WillReturnError(errors.New("insert error"))
+	err = q.CreateLoginAttempt(a)
+	assert.Error(t, err)
+}
+
+func TestLoginAttemptQueries_GetLoginAttempts(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.LoginAttemptQueries{DB: db}
+	filter := models.LoginAttemptFilter{Email: "test@example.com", Limit: 10}
+
+	columns := []string{"id", "created_at", "email", "user_id", "ip_address", "user_agent", "succeeded", "reason"}
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM login_attempts`)).
+		WithArgs(filter.Email, filter.IPAddress, filter.Limit).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(uuid.New(), time.Now(), filter.Email, nil, "10.0.0.1", "curl/8.0", false, repository.LoginAttemptUnknownEmail))
+
+	attempts, err := q.GetLoginAttempts(filter)
+	assert.NoError(t, err)
+	assert.Len(t, attempts, 1)
+	assert.Nil(t, attempts[0].UserID)
+
+	// error case
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM login_attempts`)).
+		WithArgs(filter.Email, filter.IPAddress, filter.Limit).
+		WillReturnError(errors.New("db error"))
+	_, err = q.GetLoginAttempts(filter)
+	assert.Error(t, err)
+}
+
+func TestLoginAttemptQueries_GetLoginAttemptsByUserID(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.LoginAttemptQueries{DB: db}
+	userID := uuid.New()
+
+	columns := []string{"id", "created_at", "email", "user_id", "ip_address", "user_agent", "succeeded", "reason"}
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM login_attempts WHERE user_id = $1 ORDER BY created_at DESC`)).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(uuid.New(), time.Now(), "test@example.com", userID, "10.0.0.1", "curl/8.0", true, repository.LoginAttemptSucceeded))
+
+	attempts, err := q.GetLoginAttemptsByUserID(userID)
+	assert.NoError(t, err)
+	assert.Len(t, attempts, 1)
+
+	// error case
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM login_attempts WHERE user_id = $1`)).
+		WithArgs(userID).
+		WillReturnError(errors.New("db error"))
+	_, err = q.GetLoginAttemptsByUserID(userID)
+	assert.Error(t, err)
+}
+
+func TestLoginAttemptQueries_AnonymizeLoginAttempts(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.LoginAttemptQueries{DB: db}
+	userID := uuid.New()
+	query := regexp.QuoteMeta(`UPDATE login_attempts SET email = $3, ip_address = '', user_agent = '' WHERE user_id = $1 OR email = $2`)
+
+	mock.ExpectExec(query).
+		WithArgs(userID, "old@example.com", "deleted@invalid").
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	assert.NoError(t, q.AnonymizeLoginAttempts(userID, "old@example.com", "deleted@invalid"))
+
+	// error case
+	mock.ExpectExec(query).
+		WithArgs(userID, "old@example.com", "deleted@invalid").
+		WillReturnError(errors.New("db error"))
+	assert.Error(t, q.AnonymizeLoginAttempts(userID, "old@example.com", "deleted@invalid"))
+}
@@ -0,0 +1,111 @@
+package queries_test
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/app/queries"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrganizationQueries_GetOrganizationBySlug(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.OrganizationQueries{DB: db}
+	id := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM organizations WHERE slug = $1`)).
+		WithArgs("acme").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at", "name", "slug"}).
+			AddRow(id, time.Now(), time.Now(), "Acme", "acme"))
+
+	organization, err := q.GetOrganizationBySlug("acme")
+	assert.NoError(t, err)
+	assert.Equal(t, id, organization.ID)
+
+	// error case
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM organizations WHERE slug = $1`)).
+		WithArgs("missing").
+		WillReturnError(sql.ErrNoRows)
+	_, err = q.GetOrganizationBySlug("missing")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestOrganizationQueries_CreateOrganization(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.OrganizationQueries{DB: db}
+	o := &models.Organization{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), Name: "Acme", Slug: "acme"}
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO organizations VALUES ($1, $2, $3, $4, $5)`)).
+		WithArgs(o.ID, o.CreatedAt, o.UpdatedAt, o.Name, o.Slug).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, q.CreateOrganization(o))
+
+	// error case
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO organizations`)).
+		WillReturnError(errors.New("insert error"))
+	assert.Error(t, q.CreateOrganization(o))
+}
+
+func TestOrganizationQueries_GetMemberships(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.OrganizationQueries{DB: db}
+	userID, organizationID := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM organization_members m JOIN organizations o ON o.id = m.organization_id`)).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"organization_id", "name", "slug", "member_role", "created_at"}).
+			AddRow(organizationID, "Acme", "acme", "moderator", time.Now()))
+
+	memberships, err := q.GetMemberships(userID)
+	assert.NoError(t, err)
+	assert.Len(t, memberships, 1)
+	assert.Equal(t, organizationID, memberships[0].OrganizationID)
+	assert.Equal(t, "moderator", memberships[0].MemberRole)
+
+	// error case
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM organization_members m`)).
+		WithArgs(userID).
+		WillReturnError(errors.New("db error"))
+	_, err = q.GetMemberships(userID)
+	assert.Error(t, err)
+}
+
+func TestOrganizationQueries_SaveOrganizationMember(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.OrganizationQueries{DB: db}
+	m := &models.OrganizationMember{OrganizationID: uuid.New(), UserID: uuid.New(), MemberRole: "user", CreatedAt: time.Now()}
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO organization_members VALUES ($1, $2, $3, $4)
+		ON CONFLICT (organization_id, user_id) DO UPDATE SET member_role = EXCLUDED.member_role`)).
+		WithArgs(m.OrganizationID, m.UserID, m.MemberRole, m.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, q.SaveOrganizationMember(m))
+
+	// error case
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO organization_members`)).
+		WillReturnError(errors.New("insert error"))
+	assert.Error(t, q.SaveOrganizationMember(m))
+}
+
+func TestOrganizationQueries_DeleteOrganizationMember(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.OrganizationQueries{DB: db}
+	organizationID, userID := uuid.New(), uuid.New()
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM organization_members WHERE organization_id = $1 AND user_id = $2`)).
+		WithArgs(organizationID, userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, q.DeleteOrganizationMember(organizationID, userID))
+
+	// not a member
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM organization_members`)).
+		WithArgs(organizationID, userID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, q.DeleteOrganizationMember(organizationID, userID), sql.ErrNoRows)
+}
@@ -0,0 +1,85 @@
+package queries_test
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/app/queries"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordResetQueries_GetPasswordResetByHash(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.PasswordResetQueries{DB: db}
+	userID := uuid.New()
+
+	columns := []string{"id", "created_at", "user_id", "token_hash", "expires_at"}
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM password_resets WHERE token_hash = $1`)).
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(uuid.New(), time.Now(), userID, "hash", time.Now().Add(time.Hour)))
+
+	reset, err := q.GetPasswordResetByHash("hash")
+	assert.NoError(t, err)
+	assert.Equal(t, userID, reset.UserID)
+
+	// error case
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM password_resets WHERE token_hash = $1`)).
+		WithArgs("hash").
+		WillReturnError(sql.ErrNoRows)
+	_, err = q.GetPasswordResetByHash("hash")
+	assert.Error(t, err)
+}
+
+func TestPasswordResetQueries_CreatePasswordReset(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.PasswordResetQueries{DB: db}
+
+	r := &models.PasswordReset{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UserID:    uuid.New(),
+		TokenHash: "hash",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO password_resets VALUES ($1, $2, $3, $4, $5)`)).
+		WithArgs(r.ID, r.CreatedAt, r.UserID, r.TokenHash, r.ExpiresAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := q.CreatePasswordReset(r)
+	assert.NoError(t, err)
+
+	// error case
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO password_resets VALUES ($1, $2, $3, $4, $5)`)).
+		WithArgs(r.ID, r.CreatedAt, r.UserID, r.TokenHash, r.ExpiresAt).
+		WillReturnError(errors.New("insert error"))
+	err = q.CreatePasswordReset(r)
+	assert.Error(t, err)
+}
+
+func TestPasswordResetQueries_DeletePasswordResets(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.PasswordResetQueries{DB: db}
+	userID := uuid.New()
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM password_resets WHERE user_id = $1`)).
+		WithArgs(userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := q.DeletePasswordResets(userID)
+	assert.NoError(t, err)
+
+	// error case
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM password_resets WHERE user_id = $1`)).
+		WithArgs(userID).
+		WillReturnError(errors.New("delete error"))
+	err = q.DeletePasswordResets(userID)
+	assert.Error(t, err)
+}
@@ -0,0 +1,137 @@
+package queries_test
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/app/queries"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var reviewColumns = []string{"id", "created_at", "updated_at", "book_id", "user_id", "rating", "body", "review_status", "moderated_by", "moderated_at"}
+
+func TestBookQueries_GetReviews(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID, bookID := uuid.New(), uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM reviews r JOIN books b ON b.id = r.book_id`)).
+		WithArgs(tenantID, bookID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(21))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT r.* FROM reviews r`)+`.*`+regexp.QuoteMeta(`ORDER BY r.created_at DESC LIMIT $3 OFFSET $4`)).
+		WithArgs(tenantID, bookID, 20, 20).
+		WillReturnRows(sqlmock.NewRows(reviewColumns).
+			AddRow(uuid.New(), now, now, bookID, uuid.New(), 8, "Great", "published", nil, nil))
+
+	reviews, total, err := q.GetReviews(tenantID, bookID, 20, 20)
+	assert.NoError(t, err)
+	assert.Equal(t, 21, total)
+	assert.Len(t, reviews, 1)
+	assert.Equal(t, 8, reviews[0].Rating)
+	assert.Nil(t, reviews[0].ModeratedBy)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookQueries_GetReviewsByUserID(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID, userID := uuid.New(), uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT r.* FROM reviews r JOIN books b ON b.id = r.book_id
+		WHERE b.tenant_id = $1 AND r.user_id = $2 ORDER BY r.created_at`)).
+		WithArgs(tenantID, userID).
+		WillReturnRows(sqlmock.NewRows(reviewColumns).
+			AddRow(uuid.New(), now, now, uuid.New(), userID, 3, "Dull", "hidden", uuid.New(), now))
+
+	reviews, err := q.GetReviewsByUserID(tenantID, userID)
+	assert.NoError(t, err)
+	assert.Len(t, reviews, 1)
+	assert.Equal(t, "hidden", reviews[0].ReviewStatus)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookQueries_CreateReview(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID := uuid.New()
+	now := time.Now()
+	r := &models.Review{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, BookID: uuid.New(), UserID: uuid.New(), Rating: 7, Body: "Good", ReviewStatus: "published"}
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO reviews
+		SELECT $2, $3, $4, $5, $6, $7, $8, $9, $10, $11 WHERE EXISTS (SELECT 1 FROM books WHERE tenant_id = $1 AND id = $5)
+		ON CONFLICT (book_id, user_id) DO NOTHING`)).
+		WithArgs(tenantID, r.ID, r.CreatedAt, r.UpdatedAt, r.BookID, r.UserID, r.Rating, r.Body, r.ReviewStatus, r.ModeratedBy, r.ModeratedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, q.CreateReview(tenantID, r))
+
+	// already reviewed
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO reviews`)).
+		WithArgs(tenantID, r.ID, r.CreatedAt, r.UpdatedAt, r.BookID, r.UserID, r.Rating, r.Body, r.ReviewStatus, r.ModeratedBy, r.ModeratedAt).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, q.CreateReview(tenantID, r), sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookQueries_UpdateAndModerateReview(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID, moderatorID := uuid.New(), uuid.New()
+	now := time.Now()
+	r := &models.Review{ID: uuid.New(), UpdatedAt: now, BookID: uuid.New(), Rating: 3, Body: "Meh"}
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE reviews r SET updated_at = $4, rating = $5, body = $6 FROM books b`)).
+		WithArgs(tenantID, r.BookID, r.ID, r.UpdatedAt, r.Rating, r.Body).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, q.UpdateReview(tenantID, r))
+
+	r.ReviewStatus, r.ModeratedBy, r.ModeratedAt = "hidden", &moderatorID, &now
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE reviews r SET review_status = $4, moderated_by = $5, moderated_at = $6 FROM books b`)).
+		WithArgs(tenantID, r.BookID, r.ID, r.ReviewStatus, r.ModeratedBy, r.ModeratedAt).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, q.ModerateReview(tenantID, r), sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookQueries_DeleteReview(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID, bookID, id := uuid.New(), uuid.New(), uuid.New()
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM reviews r USING books b`)).
+		WithArgs(tenantID, bookID, id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, q.DeleteReview(tenantID, bookID, id))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookQueries_GetBookRating(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID, bookID := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT r.* FROM book_ratings r JOIN books b ON b.id = r.book_id`)).
+		WithArgs(tenantID, bookID).
+		WillReturnRows(sqlmock.NewRows([]string{"book_id", "rating_count", "rating_sum", "histogram", "rating_average"}).
+			AddRow(bookID, 3, 24, "{0,0,0,0,0,0,0,1,1,1}", 8.0))
+
+	rating, err := q.GetBookRating(tenantID, bookID)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, rating.Count)
+	assert.Equal(t, 8.0, rating.Average)
+	assert.Equal(t, models.RatingHistogram{0, 0, 0, 0, 0, 0, 0, 1, 1, 1}, rating.Histogram)
+
+	// never reviewed
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM book_ratings r`)).
+		WithArgs(tenantID, bookID).
+		WillReturnError(sql.ErrNoRows)
+	_, err = q.GetBookRating(tenantID, bookID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,193 @@
+package queries_test
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/app/queries"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var shelfColumns = []string{"id", "created_at", "updated_at", "tenant_id", "user_id", "name", "kind", "is_public"}
+
+func TestBookQueries_EnsureShelves(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID, userID := uuid.New(), uuid.New()
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO shelves (tenant_id, user_id, name, kind, updated_at)`)+`.*`+regexp.QuoteMeta(`ON CONFLICT DO NOTHING`)).
+		WithArgs(tenantID, userID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, q.EnsureShelves(tenantID, userID))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookQueries_GetUserShelves(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID, userID := uuid.New(), uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM shelves WHERE tenant_id = $1 AND user_id = $2 AND (is_public OR NOT $3)`)).
+		WithArgs(tenantID, userID, true).
+		WillReturnRows(sqlmock.NewRows(shelfColumns).
+			AddRow(uuid.New(), now, now, tenantID, userID, "Sci-fi", "custom", true))
+
+	shelves, err := q.GetUserShelves(tenantID, userID, true)
+	assert.NoError(t, err)
+	assert.Len(t, shelves, 1)
+	assert.Equal(t, "Sci-fi", shelves[0].Name)
+	assert.True(t, shelves[0].IsPublic)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookQueries_CreateShelf(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	now := time.Now()
+	s := &models.Shelf{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, TenantID: uuid.New(), UserID: uuid.New(), Name: "Sci-fi", Kind: "custom"}
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO shelves VALUES ($1, $2, $3, $4, $5, $6, $7, $8) ON CONFLICT DO NOTHING`)).
+		WithArgs(s.ID, s.CreatedAt, s.UpdatedAt, s.TenantID, s.UserID, s.Name, s.Kind, s.IsPublic).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, q.CreateShelf(s))
+
+	// same name
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO shelves`)).
+		WithArgs(s.ID, s.CreatedAt, s.UpdatedAt, s.TenantID, s.UserID, s.Name, s.Kind, s.IsPublic).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, q.CreateShelf(s), sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookQueries_UpdateShelf(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	s := &models.Shelf{ID: uuid.New(), UpdatedAt: time.Now(), TenantID: uuid.New(), Name: "Favorites", IsPublic: true}
+
+	// another shelf of the user is named so
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE shelves SET updated_at = $3, name = $4, is_public = $5`)).
+		WithArgs(s.TenantID, s.ID, s.UpdatedAt, s.Name, s.IsPublic).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, q.UpdateShelf(s), sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookQueries_AddShelfBook(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID, shelfID, bookID := uuid.New(), uuid.New(), uuid.New()
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT 1 FROM shelves WHERE tenant_id = $1 AND id = $2 FOR UPDATE`)).
+		WithArgs(tenantID, shelfID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO shelf_books SELECT $1, $2, COALESCE(MAX(position), 0) + 1, $3 FROM shelf_books WHERE shelf_id = $1`)).
+		WithArgs(shelfID, bookID, now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE shelf_books SET position = CASE WHEN book_id = $2 THEN $3 ELSE position + 1 END`)).
+		WithArgs(shelfID, bookID, 1).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+	assert.NoError(t, q.AddShelfBook(tenantID, shelfID, bookID, 1, now))
+
+	// already on the shelf
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT 1 FROM shelves`)).
+		WithArgs(tenantID, shelfID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO shelf_books`)).
+		WithArgs(shelfID, bookID, now).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	assert.ErrorIs(t, q.AddShelfBook(tenantID, shelfID, bookID, 0, now), sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookQueries_RemoveShelfBook(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID, shelfID, bookID := uuid.New(), uuid.New(), uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT 1 FROM shelves`)).
+		WithArgs(tenantID, shelfID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM shelf_books WHERE shelf_id = $1 AND book_id = $2`)).
+		WithArgs(shelfID, bookID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	assert.NoError(t, q.RemoveShelfBook(tenantID, shelfID, bookID))
+
+	// not on the shelf
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT 1 FROM shelves`)).
+		WithArgs(tenantID, shelfID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM shelf_books`)).
+		WithArgs(shelfID, bookID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	assert.ErrorIs(t, q.RemoveShelfBook(tenantID, shelfID, bookID), sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookQueries_ReorderShelfBooks(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID, shelfID := uuid.New(), uuid.New()
+	bookIDs := []uuid.UUID{uuid.New(), uuid.New()}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT 1 FROM shelves`)).
+		WithArgs(tenantID, shelfID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM shelf_books WHERE shelf_id = $1`)).
+		WithArgs(shelfID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	for i, bookID := range bookIDs {
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE shelf_books SET position = $3 WHERE shelf_id = $1 AND book_id = $2`)).
+			WithArgs(shelfID, bookID, i+1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+	assert.NoError(t, q.ReorderShelfBooks(tenantID, shelfID, bookIDs))
+
+	// a book is missing from the list
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT 1 FROM shelves`)).
+		WithArgs(tenantID, shelfID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM shelf_books`)).
+		WithArgs(shelfID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectRollback()
+	assert.ErrorIs(t, q.ReorderShelfBooks(tenantID, shelfID, bookIDs), sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookQueries_GetBookShelves(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.BookQueries{DB: db}
+	tenantID, userID := uuid.New(), uuid.New()
+	bookIDs := []uuid.UUID{uuid.New(), uuid.New()}
+	shelfID := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`WHERE s.tenant_id = ? AND s.user_id = ? AND sb.book_id IN (?, ?)`)).
+		WithArgs(tenantID, userID, bookIDs[0], bookIDs[1]).
+		WillReturnRows(sqlmock.NewRows([]string{"book_id", "id", "name", "kind"}).
+			AddRow(bookIDs[0], shelfID, "Favorites", "favorites"))
+
+	shelves, err := q.GetBookShelves(tenantID, userID, bookIDs)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ShelfRef{{ID: shelfID, Name: "Favorites", Kind: "favorites"}}, shelves[bookIDs[0]])
+	assert.Empty(t, shelves[bookIDs[1]])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
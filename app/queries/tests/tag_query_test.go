@@ -0,0 +1,29 @@
+package queries_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/app/queries"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagQueries_GetTags(t *testing.T) {
+	db, mock := newMock(t)
+	q := &queries.TagQueries{DB: db}
+	tenantID := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT t.name, COUNT(*) AS book_count FROM tags t JOIN book_tags bt ON bt.tag_id = t.id
+		WHERE t.tenant_id = $1 AND left(t.name, length($2)) = $2
+		GROUP BY t.name ORDER BY book_count DESC, t.name LIMIT $3`)).
+		WithArgs(tenantID, "fan", 10).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "book_count"}).AddRow("fantasy", 12).AddRow("fanfiction", 2))
+
+	tags, err := q.GetTags(tenantID, "fan", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.TagCount{{Name: "fantasy", BookCount: 12}, {Name: "fanfiction", BookCount: 2}}, tags)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
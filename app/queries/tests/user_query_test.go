@@ -138,6 +138,255 @@ func TestCreateUser(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestUpdateUserPassword(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	q := &queries.UserQueries{DB: db}
+	id := uuid.New()
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE users SET password_hash = $2 WHERE id = $1`)).
+		WithArgs(id, "new-hash").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := q.UpdateUserPassword(id, "new-hash")
+	assert.NoError(t, err)
+
+	// Test Exec error
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE users SET password_hash = $2 WHERE id = $1`)).
+		WithArgs(id, "new-hash").
+		WillReturnError(assert.AnError)
+
+	err = q.UpdateUserPassword(id, "new-hash")
+	assert.Error(t, err)
+}
+
+func TestUpdateUserProfile(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	q := &queries.UserQueries{DB: db}
+	id := uuid.New()
+	user := &models.User{
+		DisplayName: "Jane",
+		AvatarURL:   "https://example.com/jane.png",
+		Locale:      "en-GB",
+		Timezone:    "Europe/London",
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE users SET display_name = $2, avatar_url = $3, locale = $4, timezone = $5 WHERE id = $1`)).
+		WithArgs(id, user.DisplayName, user.AvatarURL, user.Locale, user.Timezone).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := q.UpdateUserProfile(id, user)
+	assert.NoError(t, err)
+
+	// Test Exec error
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE users SET display_name = $2, avatar_url = $3, locale = $4, timezone = $5 WHERE id = $1`)).
+		WithArgs(id, user.DisplayName, user.AvatarURL, user.Locale, user.Timezone).
+		WillReturnError(assert.AnError)
+
+	err = q.UpdateUserProfile(id, user)
+	assert.Error(t, err)
+}
+
+func TestUpdateUserEmail(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	q := &queries.UserQueries{DB: db}
+	id := uuid.New()
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE users SET email = $2 WHERE id = $1`)).
+		WithArgs(id, "new@example.com").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := q.UpdateUserEmail(id, "new@example.com")
+	assert.NoError(t, err)
+
+	// Test Exec error
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE users SET email = $2 WHERE id = $1`)).
+		WithArgs(id, "new@example.com").
+		WillReturnError(assert.AnError)
+
+	err = q.UpdateUserEmail(id, "new@example.com")
+	assert.Error(t, err)
+}
+
+func TestGetUsers(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	q := &queries.UserQueries{DB: db}
+	status := 1
+	search := models.UserSearch{Email: "example", UserStatus: &status, Limit: 20, Offset: 20}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM users WHERE`)).
+		WithArgs(search.Email, search.UserRole, search.UserStatus, search.CreatedFrom, search.CreatedTo).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(21))
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM users WHERE`)).
+		WithArgs(search.Email, search.UserRole, search.UserStatus, search.CreatedFrom, search.CreatedTo, search.Limit, search.Offset).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "created_at", "updated_at", "email", "password_hash", "user_status", "user_role",
+		}).AddRow(uuid.New(), time.Now(), time.Now(), "test@example.com", "hashed", 1, repository.UserRoleName))
+
+	users, total, err := q.GetUsers(search)
+	assert.NoError(t, err)
+	assert.Equal(t, 21, total)
+	assert.Len(t, users, 1)
+
+	// Test count error
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM users WHERE`)).
+		WillReturnError(assert.AnError)
+
+	_, _, err = q.GetUsers(search)
+	assert.Error(t, err)
+
+	// Test select error
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM users WHERE`)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM users WHERE`)).
+		WillReturnError(assert.AnError)
+
+	_, _, err = q.GetUsers(search)
+	assert.Error(t, err)
+}
+
+func TestUpdateUserRoleAndStatus(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	q := &queries.UserQueries{DB: db}
+	id := uuid.New()
+	query := regexp.QuoteMeta(`UPDATE organization_members SET member_role = $2
+			WHERE user_id = $1 AND organization_id = $4`) + `.*` + regexp.QuoteMeta(`UPDATE users SET user_role = $2, user_status = $3 WHERE id = $1`)
+
+	mock.ExpectExec(query).
+		WithArgs(id, repository.ModeratorRoleName, 0, repository.DefaultOrganizationID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := q.UpdateUserRoleAndStatus(id, repository.ModeratorRoleName, 0, repository.DefaultOrganizationID)
+	assert.NoError(t, err)
+
+	// Test Exec error
+	mock.ExpectExec(query).
+		WithArgs(id, repository.ModeratorRoleName, 0, repository.DefaultOrganizationID).
+		WillReturnError(assert.AnError)
+
+	err = q.UpdateUserRoleAndStatus(id, repository.ModeratorRoleName, 0, repository.DefaultOrganizationID)
+	assert.Error(t, err)
+}
+
+func TestDeleteUser(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	q := &queries.UserQueries{DB: db}
+	id := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM users WHERE id = $1`)).
+		WithArgs(id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM book_revisions WHERE book_id IN (`)).
+		WithArgs(id).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE book_revisions SET`)).
+		WithArgs(id).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	err := q.DeleteUser(id)
+	assert.NoError(t, err)
+
+	// Test Exec error
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM users WHERE id = $1`)).
+		WithArgs(id).
+		WillReturnError(assert.AnError)
+	mock.ExpectRollback()
+
+	err = q.DeleteUser(id)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestScheduleUserDeletion(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	q := &queries.UserQueries{DB: db}
+	id := uuid.New()
+	at := time.Now().Add(time.Hour)
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE users SET deletion_scheduled_at = $2, deletion_mode = $3 WHERE id = $1`)).
+		WithArgs(id, &at, repository.AccountDeletionModeDelete).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := q.ScheduleUserDeletion(id, &at, repository.AccountDeletionModeDelete)
+	assert.NoError(t, err)
+
+	// Test Exec error
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE users SET deletion_scheduled_at = $2, deletion_mode = $3 WHERE id = $1`)).
+		WithArgs(id, nil, "").
+		WillReturnError(assert.AnError)
+
+	err = q.ScheduleUserDeletion(id, nil, "")
+	assert.Error(t, err)
+}
+
+func TestGetUsersDueForDeletion(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	q := &queries.UserQueries{DB: db}
+	now := time.Now()
+	id := uuid.New()
+
+	rows := sqlmock.NewRows([]string{"id", "email", "deletion_mode"}).
+		AddRow(id, "gone@example.com", repository.AccountDeletionModeAnonymize)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM users WHERE deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= $1`)).
+		WithArgs(now).
+		WillReturnRows(rows)
+
+	got, err := q.GetUsersDueForDeletion(now)
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+	assert.Equal(t, id, got[0].ID)
+	assert.Equal(t, repository.AccountDeletionModeAnonymize, got[0].DeletionMode)
+
+	// Test Select error
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM users WHERE deletion_scheduled_at IS NOT NULL`)).
+		WillReturnError(assert.AnError)
+
+	_, err = q.GetUsersDueForDeletion(now)
+	assert.Error(t, err)
+}
+
+func TestAnonymizeUser(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	q := &queries.UserQueries{DB: db}
+	id := uuid.New()
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE users SET email = $2, password_hash = $3, user_status = 0`)).
+		WithArgs(id, "deleted@invalid", "hash").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := q.AnonymizeUser(id, "deleted@invalid", "hash")
+	assert.NoError(t, err)
+
+	// Test Exec error
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE users SET email = $2`)).
+		WillReturnError(assert.AnError)
+
+	err = q.AnonymizeUser(id, "deleted@invalid", "hash")
+	assert.Error(t, err)
+}
+
 func TestBookAttrs_Scan_Success(t *testing.T) {
 	original := models.BookAttrs{
 		Picture:     "image.png",
@@ -1,6 +1,8 @@
 package queries
 
 import (
+	"time"
+
 	"github.com/create-go-app/fiber-go-template/app/models"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -47,3 +49,151 @@ func (q *UserQueries) CreateUser(u *models.User) error {
 
 	return nil
 }
+
+func (q *UserQueries) UpdateUserPassword(id uuid.UUID, passwordHash string) error {
+	query := `UPDATE users SET password_hash = $2 WHERE id = $1`
+
+	_, err := q.Exec(query, id, passwordHash)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q *UserQueries) UpdateUserProfile(id uuid.UUID, u *models.User) error {
+	query := `UPDATE users SET display_name = $2, avatar_url = $3, locale = $4, timezone = $5 WHERE id = $1`
+
+	_, err := q.Exec(query, id, u.DisplayName, u.AvatarURL, u.Locale, u.Timezone)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q *UserQueries) UpdateUserEmail(id uuid.UUID, email string) error {
+	query := `UPDATE users SET email = $2 WHERE id = $1`
+
+	_, err := q.Exec(query, id, email)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+const userSearchCondition = `($1 = '' OR position(lower($1) in lower(email)) > 0)
+		AND ($2 = '' OR user_role = $2)
+		AND ($3::int IS NULL OR user_status = $3)
+		AND ($4::timestamptz IS NULL OR created_at >= $4)
+		AND ($5::timestamptz IS NULL OR created_at < $5)`
+
+// GetUsers returns one page of users matching the search, newest first, and
+// the total number of matches.
+func (q *UserQueries) GetUsers(s models.UserSearch) ([]models.User, int, error) {
+	users := []models.User{}
+	total := 0
+	args := []interface{}{s.Email, s.UserRole, s.UserStatus, s.CreatedFrom, s.CreatedTo}
+
+	countQuery := `SELECT COUNT(*) FROM users WHERE ` + userSearchCondition
+	if err := q.Get(&total, countQuery, args...); err != nil {
+		return users, 0, err
+	}
+
+	query := `SELECT * FROM users WHERE ` + userSearchCondition + `
+		ORDER BY created_at DESC LIMIT $6 OFFSET $7`
+	if err := q.Select(&users, query, append(args, s.Limit, s.Offset)...); err != nil {
+		return users, 0, err
+	}
+
+	return users, total, nil
+}
+
+// UpdateUserRoleAndStatus sets the role and status of the user. Book
+// credentials come from the role in each organization, so a change of role
+// is also applied to the membership of the user in defaultOrganizationID, in
+// the same statement. Roles in other organizations are left to them.
+func (q *UserQueries) UpdateUserRoleAndStatus(id uuid.UUID, role string, status int, defaultOrganizationID uuid.UUID) error {
+	query := `WITH memberships AS (
+			UPDATE organization_members SET member_role = $2
+			WHERE user_id = $1 AND organization_id = $4 AND EXISTS (SELECT 1 FROM users WHERE id = $1 AND user_role <> $2)
+		)
+		UPDATE users SET user_role = $2, user_status = $3 WHERE id = $1`
+
+	_, err := q.Exec(query, id, role, status, defaultOrganizationID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteUser deletes the user, with their books, and the revisions that
+// would keep them around: the history of the deleted books they owned last
+// is dropped, and they are scrubbed from the revisions of books others own.
+func (q *UserQueries) DeleteUser(id uuid.UUID) error {
+	queries := []string{
+		`DELETE FROM users WHERE id = $1`,
+		`DELETE FROM book_revisions WHERE book_id IN (
+			SELECT r.book_id FROM book_revisions r WHERE r.action = 'delete' AND r.snapshot ->> 'user_id' = $1::text
+			AND NOT EXISTS (SELECT 1 FROM books b WHERE b.id = r.book_id))`,
+		`UPDATE book_revisions SET
+			snapshot = CASE WHEN snapshot ->> 'user_id' = $1::text THEN jsonb_set(snapshot, '{user_id}', 'null') ELSE snapshot END,
+			diff = CASE WHEN diff ->> 'user_id' LIKE '%' || $1::text || '%' THEN diff - 'user_id' ELSE diff END
+			WHERE snapshot ->> 'user_id' = $1::text OR diff ->> 'user_id' LIKE '%' || $1::text || '%'`,
+	}
+
+	tx, err := q.Beginx()
+	if err != nil {
+		return err
+	}
+
+	for _, query := range queries {
+		if _, err := tx.Exec(query, id); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ScheduleUserDeletion sets or, with a nil time, cancels a pending deletion.
+func (q *UserQueries) ScheduleUserDeletion(id uuid.UUID, at *time.Time, mode string) error {
+	query := `UPDATE users SET deletion_scheduled_at = $2, deletion_mode = $3 WHERE id = $1`
+
+	_, err := q.Exec(query, id, at, mode)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q *UserQueries) GetUsersDueForDeletion(now time.Time) ([]models.User, error) {
+	users := []models.User{}
+	query := `SELECT * FROM users WHERE deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= $1`
+
+	err := q.Select(&users, query, now)
+	if err != nil {
+		return users, err
+	}
+
+	return users, nil
+}
+
+// AnonymizeUser scrubs every personal field of the user and blocks the
+// account. Rows referencing the user keep pointing at the scrubbed account.
+func (q *UserQueries) AnonymizeUser(id uuid.UUID, email, passwordHash string) error {
+	query := `UPDATE users SET email = $2, password_hash = $3, user_status = 0,
+		display_name = '', avatar_url = '', locale = 'en', timezone = 'UTC',
+		deletion_scheduled_at = NULL, deletion_mode = '' WHERE id = $1`
+
+	_, err := q.Exec(query, id, email, passwordHash)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
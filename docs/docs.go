@@ -196,12 +196,7 @@ const docTemplate = `{
         },
         "/v1/token/renew": {
             "post": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "Renew access and refresh tokens.",
+                "description": "Renew access and refresh tokens with the refresh token alone, from the body or, in cookie session mode, the refresh cookie plus the X-CSRF-Token header. The access token may have expired.",
                 "consumes": [
                     "application/json"
                 ],
@@ -489,8 +484,7 @@ const docTemplate = `{
             "type": "object",
             "required": [
                 "email",
-                "password",
-                "user_role"
+                "password"
             ],
             "properties": {
                 "email": {
@@ -500,10 +494,6 @@ const docTemplate = `{
                 "password": {
                     "type": "string",
                     "maxLength": 255
-                },
-                "user_role": {
-                    "type": "string",
-                    "maxLength": 25
                 }
             }
         },
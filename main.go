@@ -1,11 +1,16 @@
 package main
 
 import (
+	"log"
 	"os"
 
 	"github.com/create-go-app/fiber-go-template/pkg/configs"
 	"github.com/create-go-app/fiber-go-template/pkg/middleware"
 	"github.com/create-go-app/fiber-go-template/pkg/routes"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/account_purge"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/book_export"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/book_import"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/password_policy"
 	"github.com/create-go-app/fiber-go-template/pkg/utils/start_server"
 
 	"github.com/gofiber/fiber/v2"
@@ -28,6 +33,10 @@ import (
 // @in header
 // @name Authorization
 func main() {
+	if err := password_policy.PolicyFromEnv().Preload(); err != nil {
+		log.Fatalf("Oops... Breached password list is not loaded! Reason: %v", err)
+	}
+
 	config := configs.FiberConfig()
 	app := fiber.New(config)
 	middleware.FiberMiddleware(app)
@@ -37,8 +46,16 @@ func main() {
 	}
 	routes.PublicRoutes(app)
 	routes.PrivateRoutes(app)
+	routes.StorageRoute(app)
 	routes.NotFoundRoute(app)
 
+	account_purge.Start()
+	book_export.StartSweep()
+
+	if err := book_import.FailInterrupted(); err != nil {
+		log.Printf("Oops... Interrupted book imports are not cleaned up! Reason: %v", err)
+	}
+
 	if os.Getenv("STAGE_STATUS") == "dev" {
 		start_server.StartServer(app)
 	} else {
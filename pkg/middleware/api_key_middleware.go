@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"errors"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/api_key"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/jwt"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/roles_credentials"
+	"github.com/create-go-app/fiber-go-template/platform/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// apiKeyLookupFunc resolves a key hash to the stored key, its owner and the
+// owner's role in the key's organization, empty once they left it. Tests can
+// swap it to avoid a database.
+var apiKeyLookupFunc = func(hash string) (models.APIKey, models.User, string, error) {
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return models.APIKey{}, models.User{}, "", err
+	}
+
+	key, err := db.GetAPIKeyByHash(hash)
+	if err != nil {
+		return key, models.User{}, "", err
+	}
+
+	user, err := db.GetUserByID(key.UserID)
+	if err != nil {
+		return key, user, "", err
+	}
+
+	memberships, err := db.GetMemberships(user.ID)
+	if err != nil {
+		return key, user, "", err
+	}
+
+	memberRole := ""
+	for _, membership := range memberships {
+		if membership.OrganizationID == key.TenantID {
+			memberRole = membership.MemberRole
+		}
+	}
+
+	// Usage tracking is best effort and must not block the request.
+	_ = db.TouchAPIKey(key.ID, time.Now())
+
+	return key, user, memberRole, nil
+}
+
+func apiKeyAuth(c *fiber.Ctx, key string, onError fiber.ErrorHandler) error {
+	storedKey, user, memberRole, err := apiKeyLookupFunc(api_key.HashAPIKey(key))
+	if err != nil {
+		return onError(c, errors.New(repository.InvalidAPIKeyErrorMessage))
+	}
+
+	if storedKey.RevokedAt != nil || time.Now().After(storedKey.ExpiresAt) || user.UserStatus != 1 {
+		return onError(c, errors.New(repository.InvalidAPIKeyErrorMessage))
+	}
+
+	roleCredentials, err := roles_credentials.TenantCredentials(user.UserRole, memberRole)
+	if err != nil {
+		return onError(c, err)
+	}
+
+	credentials := map[string]bool{}
+	for _, credential := range roles_credentials.AllCredentials() {
+		credentials[credential] = false
+	}
+	for _, credential := range api_key.FilterCredentials(storedKey.Credentials, roleCredentials) {
+		credentials[credential] = true
+	}
+
+	c.Locals(jwt.TokenMetadataKey, &models.TokenMetadata{
+		UserID:      user.ID,
+		Credentials: credentials,
+		Expires:     storedKey.ExpiresAt.Unix(),
+		APIKeyID:    storedKey.ID,
+		TenantID:    storedKey.TenantID,
+	})
+
+	return c.Next()
+}
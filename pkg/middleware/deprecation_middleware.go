@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Deprecated marks every response of a route kept only for compatibility
+// with a Deprecation header (RFC 9745) giving when it was deprecated and a
+// Sunset header (RFC 8594) giving when it will be removed.
+func Deprecated(deprecatedAt, sunset time.Time) fiber.Handler {
+	deprecation := "@" + strconv.FormatInt(deprecatedAt.Unix(), 10)
+	sunsetAt := sunset.UTC().Format(http.TimeFormat)
+
+	return func(c *fiber.Ctx) error {
+		c.Set("Deprecation", deprecation)
+		c.Set("Sunset", sunsetAt)
+
+		return c.Next()
+	}
+}
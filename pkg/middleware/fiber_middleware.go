@@ -1,11 +1,34 @@
 package middleware
 
 import (
+	"os"
+
+	"github.com/create-go-app/fiber-go-template/pkg/utils/session_cookie"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 )
 
 func FiberMiddleware(a *fiber.App) {
-	a.Use(cors.New(), logger.New())
+	a.Use(cors.New(corsConfig()), logger.New())
+}
+
+// corsConfig allows credentialed requests from CORS_ALLOW_ORIGINS so a
+// browser client on another origin can use cookie session mode. Credentials
+// are never allowed together with the "*" wildcard. ETag and the deprecation
+// headers are readable by browser clients of any origin.
+func corsConfig() cors.Config {
+	config := cors.ConfigDefault
+	config.ExposeHeaders = "ETag, Deprecation, Sunset"
+
+	origins := os.Getenv("CORS_ALLOW_ORIGINS")
+	if origins == "" || origins == "*" {
+		return config
+	}
+
+	config.AllowOrigins = origins
+	config.AllowCredentials = true
+	config.AllowHeaders = "Origin, Content-Type, Accept, Authorization, If-Match, If-None-Match, " + session_cookie.CSRFHeaderName
+
+	return config
 }
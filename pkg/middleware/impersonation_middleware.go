@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/jwt"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/text"
+	"github.com/create-go-app/fiber-go-template/platform/database"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// impersonationLogFunc persists one impersonation log entry and
+// impersonationStatusFunc records the status the request was answered with.
+// Tests can swap them to avoid a database.
+var (
+	impersonationLogFunc = func(entry *models.ImpersonationLog) error {
+		db, err := database.OpenDBConnection()
+		if err != nil {
+			return err
+		}
+
+		return db.CreateImpersonationLog(entry)
+	}
+	impersonationStatusFunc = func(id uuid.UUID, status int) error {
+		db, err := database.OpenDBConnection()
+		if err != nil {
+			return err
+		}
+
+		return db.SetImpersonationLogStatus(id, status)
+	}
+)
+
+// impersonationAudit runs after a JWT was accepted. Requests made with an
+// impersonation token are recorded before the handler runs, and refused when
+// they cannot be, so no impersonated action goes unrecorded. The status is
+// filled in once the handler has answered.
+func impersonationAudit(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil || !claims.Impersonated() {
+		return c.Next()
+	}
+
+	actorID, userID := claims.ActorID, claims.UserID
+	entry := &models.ImpersonationLog{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		ActorID:   &actorID,
+		UserID:    &userID,
+		Method:    c.Method(),
+		Path:      text.Truncate(c.Path(), 255),
+		IPAddress: c.IP(),
+	}
+
+	if err := impersonationLogFunc(entry); err != nil {
+		log.Printf("impersonation audit: failed to record request: %v", err)
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": true,
+			"msg":   repository.ImpersonationAuditErrorMessage,
+		})
+	}
+
+	handlerErr := c.Next()
+
+	status := c.Response().StatusCode()
+	if handlerErr != nil {
+		status = fiber.StatusInternalServerError
+		var fiberErr *fiber.Error
+		if errors.As(handlerErr, &fiberErr) {
+			status = fiberErr.Code
+		}
+	}
+
+	if err := impersonationStatusFunc(entry.ID, status); err != nil {
+		log.Printf("impersonation audit: failed to record status: %v", err)
+	}
+
+	return handlerErr
+}
@@ -5,19 +5,74 @@ import (
 	"os"
 	"strings"
 
+	"github.com/create-go-app/fiber-go-template/pkg/utils/api_key"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/jwt"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/session_cookie"
 	"github.com/gofiber/fiber/v2"
 
 	jwtMiddleware "github.com/gofiber/contrib/jwt"
 )
 
+// JWTProtected accepts either a signed JWT or an API key as the bearer
+// credential. In cookie session mode a JWT in the access token cookie is
+// accepted as well, provided unsafe requests pass the CSRF check. Requests
+// made with an impersonation token are recorded in the impersonation log.
 func JWTProtected() func(*fiber.Ctx) error {
+	return authenticate(jwtError, csrfError)
+}
+
+// JWTOptional lets requests through anonymously unless they carry valid
+// credentials, checked like JWTProtected, for public routes that tailor
+// their answer to the caller. Invalid or expired credentials are ignored
+// rather than refused, like no credentials at all.
+func JWTOptional() func(*fiber.Ctx) error {
+	anonymous := func(c *fiber.Ctx, _ error) error {
+		return c.Next()
+	}
+
+	return authenticate(anonymous, anonymous)
+}
+
+// authenticate checks the credentials of the request, answering with
+// onError when they are invalid and with onCSRFError when a cookie session
+// request fails the CSRF check.
+func authenticate(onError, onCSRFError fiber.ErrorHandler) func(*fiber.Ctx) error {
 	config := jwtMiddleware.Config{
-		SigningKey:   jwtMiddleware.SigningKey{Key: []byte(os.Getenv("JWT_SECRET_KEY"))},
-		ContextKey:   "jwt",
-		ErrorHandler: jwtError,
+		SigningKey:     jwtMiddleware.SigningKey{Key: []byte(os.Getenv("JWT_SECRET_KEY"))},
+		ContextKey:     "jwt",
+		ErrorHandler:   onError,
+		SuccessHandler: impersonationAudit,
 	}
 
-	return jwtMiddleware.New(config)
+	jwtHandler := jwtMiddleware.New(config)
+
+	config.TokenLookup = "cookie:" + session_cookie.AccessCookieName
+	cookieHandler := jwtMiddleware.New(config)
+	cookieMode := session_cookie.ConfigFromEnv().Enabled
+
+	return func(c *fiber.Ctx) error {
+		token := jwt.ExtractBearerToken(c)
+		if api_key.IsAPIKey(token) {
+			return apiKeyAuth(c, token, onError)
+		}
+
+		if token == "" && cookieMode && c.Cookies(session_cookie.AccessCookieName) != "" {
+			if err := session_cookie.VerifyCSRF(c); err != nil {
+				return onCSRFError(c, err)
+			}
+
+			return cookieHandler(c)
+		}
+
+		return jwtHandler(c)
+	}
+}
+
+func csrfError(c *fiber.Ctx, err error) error {
+	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+		"error": true,
+		"msg":   err.Error(),
+	})
 }
 
 func jwtError(c *fiber.Ctx, err error) error {
@@ -0,0 +1,33 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/create-go-app/fiber-go-template/pkg/middleware"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/api_key"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestJWTProtected_UnknownAPIKey(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+	os.Setenv("DB_TYPE", "unsupported")
+	defer os.Unsetenv("DB_TYPE")
+
+	app := fiber.New()
+	app.Use(middleware.JWTProtected())
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("OK")
+	})
+
+	req := httptest.NewRequest("GET", "/", http.NoBody)
+	req.Header.Set("Authorization", "Bearer "+api_key.Prefix+"unknown")
+	resp, _ := app.Test(req)
+
+	if resp.StatusCode != fiber.StatusUnauthorized {
+		t.Errorf("expected %d, got %d", fiber.StatusUnauthorized, resp.StatusCode)
+	}
+}
@@ -0,0 +1,47 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/pkg/middleware"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/jwt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func TestJWTProtected_ImpersonationToken(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+	// The audit entry cannot be written without a database, so the request
+	// must be refused before the handler runs.
+	os.Setenv("DB_TYPE", "unsupported")
+	defer os.Unsetenv("DB_TYPE")
+
+	userID, actorID := uuid.New(), uuid.New()
+	token, err := jwt.GenerateImpersonationToken(userID.String(), actorID.String(), uuid.Nil, nil, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	app := fiber.New()
+	app.Use(middleware.JWTProtected())
+	handled := false
+	app.Get("/", func(c *fiber.Ctx) error {
+		handled = true
+		return c.SendString("OK")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, _ := app.Test(req)
+
+	if resp.StatusCode != fiber.StatusServiceUnavailable {
+		t.Errorf("expected %d, got %d", fiber.StatusServiceUnavailable, resp.StatusCode)
+	}
+	if handled {
+		t.Error("expected the handler not to run")
+	}
+}
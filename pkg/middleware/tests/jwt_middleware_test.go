@@ -2,13 +2,17 @@ package middleware_test
 
 import (
 	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"testing"
 
 	"github.com/create-go-app/fiber-go-template/pkg/middleware"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/jwt"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/session_cookie"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 func callJwtError(app *fiber.App, path string, err error) int {
@@ -85,3 +89,126 @@ func TestJWTProtected_MissingOrMalformed(t *testing.T) {
 		t.Errorf("expected %d, got %d", fiber.StatusBadRequest, resp.StatusCode)
 	}
 }
+
+func TestJWTProtected_CookieSession(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+	os.Setenv("JWT_SECRET_KEY_EXPIRE_MINUTES_COUNT", "15")
+	os.Setenv("JWT_REFRESH_KEY", "testrefresh")
+	os.Setenv("AUTH_COOKIE_MODE", "true")
+	defer os.Unsetenv("AUTH_COOKIE_MODE")
+
+	tokens, err := jwt.GenerateNewTokens(uuid.NewString(), uuid.Nil, []string{})
+	if err != nil {
+		t.Fatalf("failed to generate tokens: %v", err)
+	}
+
+	app := fiber.New()
+	app.Use(middleware.JWTProtected())
+	app.All("/", func(c *fiber.Ctx) error {
+		return c.SendString("OK")
+	})
+
+	cases := []struct {
+		name   string
+		method string
+		csrf   string
+		want   int
+	}{
+		{"safe method needs no CSRF token", http.MethodGet, "", fiber.StatusOK},
+		{"unsafe method without CSRF token", http.MethodPost, "", fiber.StatusForbidden},
+		{"unsafe method with wrong CSRF token", http.MethodPost, "other", fiber.StatusForbidden},
+		{"unsafe method with matching CSRF token", http.MethodPost, "csrf", fiber.StatusOK},
+	}
+
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, "/", http.NoBody)
+		req.AddCookie(&http.Cookie{Name: session_cookie.AccessCookieName, Value: tokens.Access})
+		req.AddCookie(&http.Cookie{Name: session_cookie.CSRFCookieName, Value: "csrf"})
+		if tc.csrf != "" {
+			req.Header.Set(session_cookie.CSRFHeaderName, tc.csrf)
+		}
+
+		resp, _ := app.Test(req)
+		if resp.StatusCode != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.want, resp.StatusCode)
+		}
+	}
+}
+
+func TestJWTProtected_CookieIgnoredOutsideCookieMode(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+	os.Setenv("JWT_REFRESH_KEY", "testrefresh")
+	os.Unsetenv("AUTH_COOKIE_MODE")
+
+	tokens, err := jwt.GenerateNewTokens(uuid.NewString(), uuid.Nil, []string{})
+	if err != nil {
+		t.Fatalf("failed to generate tokens: %v", err)
+	}
+
+	app := fiber.New()
+	app.Use(middleware.JWTProtected())
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("OK")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+	req.AddCookie(&http.Cookie{Name: session_cookie.AccessCookieName, Value: tokens.Access})
+	resp, _ := app.Test(req)
+
+	if resp.StatusCode != fiber.StatusBadRequest {
+		t.Errorf("expected %d, got %d", fiber.StatusBadRequest, resp.StatusCode)
+	}
+}
+
+func TestJWTOptional(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+	os.Setenv("JWT_SECRET_KEY_EXPIRE_MINUTES_COUNT", "15")
+	os.Setenv("JWT_REFRESH_KEY", "testrefresh")
+	os.Unsetenv("AUTH_COOKIE_MODE")
+
+	tokens, err := jwt.GenerateNewTokens(uuid.NewString(), uuid.Nil, []string{})
+	if err != nil {
+		t.Fatalf("failed to generate tokens: %v", err)
+	}
+
+	os.Setenv("JWT_SECRET_KEY_EXPIRE_MINUTES_COUNT", "-1")
+	expired, err := jwt.GenerateNewTokens(uuid.NewString(), uuid.Nil, []string{})
+	os.Setenv("JWT_SECRET_KEY_EXPIRE_MINUTES_COUNT", "15")
+	if err != nil {
+		t.Fatalf("failed to generate tokens: %v", err)
+	}
+
+	app := fiber.New()
+	app.Use(middleware.JWTOptional())
+	app.Get("/", func(c *fiber.Ctx) error {
+		if c.Locals("jwt") == nil {
+			return c.SendString("anonymous")
+		}
+		return c.SendString("signed in")
+	})
+
+	cases := []struct {
+		name          string
+		authorization string
+		want          string
+	}{
+		{"no credentials", "", "anonymous"},
+		{"valid token", "Bearer " + tokens.Access, "signed in"},
+		{"invalid token", "Bearer badtoken", "anonymous"},
+		{"expired token", "Bearer " + expired.Access, "anonymous"},
+		{"malformed header", "Basic abc", "anonymous"},
+	}
+
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+		if tc.authorization != "" {
+			req.Header.Set("Authorization", tc.authorization)
+		}
+
+		resp, _ := app.Test(req)
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != fiber.StatusOK || string(body) != tc.want {
+			t.Errorf("%s: expected 200 %q, got %d %q", tc.name, tc.want, resp.StatusCode, body)
+		}
+	}
+}
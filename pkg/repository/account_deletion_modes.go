@@ -0,0 +1,6 @@
+package repository
+
+const (
+	AccountDeletionModeAnonymize string = "anonymize"
+	AccountDeletionModeDelete    string = "delete"
+)
@@ -0,0 +1,8 @@
+package repository
+
+// Roles a book credits its authors with.
+const (
+	AuthorRoleAuthor     string = "author"
+	AuthorRoleEditor     string = "editor"
+	AuthorRoleTranslator string = "translator"
+)
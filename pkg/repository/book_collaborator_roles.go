@@ -0,0 +1,6 @@
+package repository
+
+const (
+	EditorCollaboratorRole string = "editor"
+	ViewerCollaboratorRole string = "viewer"
+)
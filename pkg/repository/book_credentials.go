@@ -1,7 +1,11 @@
 package repository
 
+// The plain update and delete credentials cover the books a user created,
+// the ":any" ones cover every book of the organization.
 const (
-	BookCreateCredential string = "book:create"
-	BookUpdateCredential string = "book:update"
-	BookDeleteCredential string = "book:delete"
+	BookCreateCredential    string = "book:create"
+	BookUpdateCredential    string = "book:update"
+	BookDeleteCredential    string = "book:delete"
+	BookUpdateAnyCredential string = "book:update:any"
+	BookDeleteAnyCredential string = "book:delete:any"
 )
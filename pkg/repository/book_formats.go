@@ -0,0 +1,9 @@
+package repository
+
+// Book formats, the binding or medium of an edition.
+const (
+	BookFormatHardcover string = "hardcover"
+	BookFormatPaperback string = "paperback"
+	BookFormatEbook     string = "ebook"
+	BookFormatAudiobook string = "audiobook"
+)
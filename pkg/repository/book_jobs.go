@@ -0,0 +1,34 @@
+package repository
+
+// MARC is MARC 21 in ISO 2709 transmission format, ONIX is ONIX for Books
+// 3.0 with reference tags.
+const (
+	BookImportFormatCSV     string = "csv"
+	BookImportFormatNDJSON  string = "ndjson"
+	BookImportFormatMARC    string = "marc"
+	BookImportFormatMARCXML string = "marcxml"
+	BookImportFormatONIX    string = "onix"
+	BookImportFormatBibTeX  string = "bibtex"
+	BookImportFormatRIS     string = "ris"
+)
+
+const (
+	BookExportFormatCSV     string = "csv"
+	BookExportFormatNDJSON  string = "ndjson"
+	BookExportFormatXLSX    string = "xlsx"
+	BookExportFormatMARC    string = "marc"
+	BookExportFormatMARCXML string = "marcxml"
+	BookExportFormatONIX    string = "onix"
+	BookExportFormatBibTeX  string = "bibtex"
+	BookExportFormatRIS     string = "ris"
+)
+
+// Statuses of the background import and export jobs.
+const (
+	JobStatusQueued  string = "queued"
+	JobStatusRunning string = "running"
+	// JobStatusDone is set once the job went through every row, even when an
+	// import rejected some of them.
+	JobStatusDone   string = "done"
+	JobStatusFailed string = "failed"
+)
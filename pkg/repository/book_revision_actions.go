@@ -0,0 +1,10 @@
+package repository
+
+// A revision records the creation, an update or the deletion of a book.
+// Restores are recorded as updates, or creations for deleted books, with
+// the restored revision.
+const (
+	BookRevisionActionCreate string = "create"
+	BookRevisionActionUpdate string = "update"
+	BookRevisionActionDelete string = "delete"
+)
@@ -0,0 +1,5 @@
+package repository
+
+const (
+	CategoryManageCredential string = "category:manage"
+)
@@ -5,4 +5,45 @@ const (
 	ForbiddenErrorMessage                 string = "permission denied, check credentials of your token"
 	ForbiddenDataModificationErrorMessage string = "permission denied, only the creator can action their data"
 	NotFoundErrorMessage                  string = "data not found"
+	SelfModificationErrorMessage          string = "permission denied, administrators cannot change or delete their own account here"
+	InternalServerErrorMessage            string = "internal server error"
+	InvalidCredentialsErrorMessage        string = "invalid credentials"
+	TooManyAttemptsErrorMessage           string = "too many failed sign in attempts, try again later"
+	InvalidAPIKeyErrorMessage             string = "unauthorized, API key is invalid, expired or revoked"
+	APIKeyNotAllowedErrorMessage          string = "permission denied, API keys cannot manage API keys"
+	ImpersonationNotAllowedErrorMessage   string = "permission denied, not allowed while impersonating a user"
+	ImpersonationAuditErrorMessage        string = "impersonated requests are refused while they cannot be recorded"
+	ImpersonationTargetErrorMessage       string = "permission denied, this account cannot be impersonated"
+	InvalidVerificationTokenErrorMessage  string = "verification token is invalid or expired"
+	EmailUnavailableErrorMessage          string = "email address cannot be used"
+	InvalidRefreshTokenErrorMessage       string = "unauthorized, refresh token is invalid, expired or revoked"
+	InvalidCSRFTokenErrorMessage          string = "permission denied, CSRF token is missing or invalid"
+	NoTenantErrorMessage                  string = "permission denied, you are not a member of any organization"
+	NotMemberErrorMessage                 string = "permission denied, you are not a member of this organization"
+	SlugTakenErrorMessage                 string = "organization slug is already taken"
+	BookIDMismatchErrorMessage            string = "book ID in the body does not match the path"
+	UnsupportedPatchErrorMessage          string = "unsupported patch format, use application/merge-patch+json or application/json-patch+json"
+	PreconditionFailedErrorMessage        string = "the book has changed since it was read, fetch it again and retry"
+	CollaboratorTargetErrorMessage        string = "books can only be shared with other members of the organization"
+	TransferTargetErrorMessage            string = "books can only be transferred to other members of the organization"
+	UnsupportedFormatErrorMessage         string = "unsupported format"
+	CoverTooLargeErrorMessage             string = "cover image file is too large"
+	UnsupportedImportErrorMessage         string = "unsupported import format, upload CSV, JSON Lines, MARC 21, MARCXML, ONIX 3.0, BibTeX or RIS"
+	AlreadyReviewedErrorMessage           string = "you have already reviewed this book, edit your review instead"
+	AuthorExistsErrorMessage              string = "the organization already has an author with this name"
+	AuthorInUseErrorMessage               string = "the author is credited on books, remove the credits first"
+	BookAuthorsErrorMessage               string = "credits must name authors of the organization, each once per role"
+	CategoryExistsErrorMessage            string = "the parent category already has a subcategory with this name"
+	CategoryParentErrorMessage            string = "the parent must be another category of the organization, outside the subcategories of this one"
+	CategoryInUseErrorMessage             string = "the category has subcategories, move or delete them first"
+	BookCategoriesErrorMessage            string = "categories must be categories of the organization"
+	ISBNExistsErrorMessage                string = "the organization already has a book with this ISBN"
+	InvalidISBNErrorMessage               string = "ISBN is not a valid ISBN-10 or ISBN-13"
+	InvalidRevisionErrorMessage           string = "revision must be a positive number"
+	BookOwnerGoneErrorMessage             string = "the last owner of the deleted book no longer exists"
+	ShelfExistsErrorMessage               string = "you already have a shelf with this name"
+	BuiltInShelfErrorMessage              string = "built-in shelves cannot be renamed or deleted"
+	ShelfOrderErrorMessage                string = "book_ids must list every book of the shelf once"
+	BookOnShelfErrorMessage               string = "the book is already on the shelf"
+	APIKeyCredentialsErrorMessage         string = "permission denied, API key credentials must be a subset of your role credentials"
 )
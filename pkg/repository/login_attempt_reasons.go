@@ -0,0 +1,9 @@
+package repository
+
+const (
+	LoginAttemptSucceeded     string = "succeeded"
+	LoginAttemptUnknownEmail  string = "unknown_email"
+	LoginAttemptWrongPassword string = "wrong_password"
+	LoginAttemptThrottled     string = "throttled"
+	LoginAttemptBlocked       string = "blocked"
+)
@@ -0,0 +1,14 @@
+package repository
+
+import "github.com/google/uuid"
+
+// DefaultOrganizationID is the organization that existed data was moved to
+// and that new users join when they sign up.
+var DefaultOrganizationID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+// DefaultOrganizationSlug names the tenant of public requests without an
+// X-Tenant header.
+const DefaultOrganizationSlug = "default"
+
+// TenantHeaderName selects the organization, by slug, for public requests.
+const TenantHeaderName = "X-Tenant"
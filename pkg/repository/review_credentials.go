@@ -0,0 +1,5 @@
+package repository
+
+const (
+	ReviewModerateCredential string = "review:moderate"
+)
@@ -0,0 +1,7 @@
+package repository
+
+// Hidden reviews are left out of the listings and of the rating of the book.
+const (
+	ReviewStatusPublished string = "published"
+	ReviewStatusHidden    string = "hidden"
+)
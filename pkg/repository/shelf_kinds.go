@@ -0,0 +1,9 @@
+package repository
+
+// Every user has one shelf of each built-in kind, which cannot be renamed or
+// deleted, and any number of custom shelves.
+const (
+	ShelfKindWantToRead string = "want_to_read"
+	ShelfKindFavorites  string = "favorites"
+	ShelfKindCustom     string = "custom"
+)
@@ -0,0 +1,7 @@
+package repository
+
+const (
+	SignUpAcceptedMessage           string = "sign up accepted, if this email was not registered yet the account has been created"
+	EmailVerificationSentMessage    string = "profile updated, confirm the new email address with the token sent to it"
+	AccountDeletionScheduledMessage string = "account deletion scheduled, sign in and cancel it before the date shown to keep the account"
+)
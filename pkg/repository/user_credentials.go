@@ -0,0 +1,5 @@
+package repository
+
+const (
+	UserManageCredential string = "user:manage"
+)
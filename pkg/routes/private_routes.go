@@ -1,19 +1,95 @@
 package routes
 
 import (
+	"time"
+
 	"github.com/create-go-app/fiber-go-template/app/controllers"
 	"github.com/create-go-app/fiber-go-template/pkg/middleware"
 	"github.com/gofiber/fiber/v2"
 )
 
+// The book routes taking the ID from the body are replaced by /books/:id.
+var (
+	legacyBookRoutesDeprecatedAt = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	legacyBookRoutesSunset       = time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)
+)
+
 func PrivateRoutes(a *fiber.App) {
+	deprecated := middleware.Deprecated(legacyBookRoutesDeprecatedAt, legacyBookRoutesSunset)
+
 	route := a.Group("/api/v1")
 
 	route.Post("/book", middleware.JWTProtected(), controllers.CreateBook)
 	route.Post("/user/sign/out", middleware.JWTProtected(), controllers.UserSignOut)
-	route.Post("/token/renew", middleware.JWTProtected(), controllers.RenewTokens)
+	route.Post("/user/api-keys", middleware.JWTProtected(), controllers.CreateAPIKey)
+	route.Post("/user/me/deletion/cancel", middleware.JWTProtected(), controllers.CancelCurrentUserDeletion)
+	route.Post("/admin/users/:id/unlock", middleware.JWTProtected(), controllers.UnlockUser)
+	route.Post("/admin/users/:id/password-reset", middleware.JWTProtected(), controllers.ForceUserPasswordReset)
+	route.Post("/admin/users/:id/impersonate", middleware.JWTProtected(), controllers.ImpersonateUser)
+	route.Post("/user/tenant", middleware.JWTProtected(), controllers.SwitchTenant)
+	route.Post("/admin/organizations", middleware.JWTProtected(), controllers.CreateOrganization)
+	route.Post("/books/import", middleware.JWTProtected(), controllers.ImportBooks)
+	route.Post("/books/:id/cover", middleware.JWTProtected(), controllers.UploadBookCover)
+	route.Post("/books/:id/transfer", middleware.JWTProtected(), controllers.TransferBook)
+	route.Post("/books/:id/transfer/accept", middleware.JWTProtected(), controllers.AcceptBookTransfer)
+	route.Post("/books/:id/reviews", middleware.JWTProtected(), controllers.CreateReview)
+	route.Post("/authors", middleware.JWTProtected(), controllers.CreateAuthor)
+	route.Post("/categories", middleware.JWTProtected(), controllers.CreateCategory)
+	route.Post("/books/:id/revisions/:rev/restore", middleware.JWTProtected(), controllers.RestoreBookRevision)
+	route.Post("/shelves", middleware.JWTProtected(), controllers.CreateShelf)
+	route.Post("/shelves/:id/books", middleware.JWTProtected(), controllers.AddShelfBook)
+
+	route.Get("/user/me", middleware.JWTProtected(), controllers.GetCurrentUser)
+	route.Get("/user/me/export", middleware.JWTProtected(), controllers.ExportCurrentUser)
+	route.Get("/user/api-keys", middleware.JWTProtected(), controllers.GetAPIKeys)
+	route.Get("/admin/login-attempts", middleware.JWTProtected(), controllers.GetLoginAttempts)
+	route.Get("/admin/impersonation-logs", middleware.JWTProtected(), controllers.GetImpersonationLogs)
+	route.Get("/admin/book-audit-logs", middleware.JWTProtected(), controllers.GetBookAuditLogs)
+	route.Get("/admin/users", middleware.JWTProtected(), controllers.GetUsers)
+	route.Get("/admin/users/:id", middleware.JWTProtected(), controllers.GetUser)
+	route.Get("/user/organizations", middleware.JWTProtected(), controllers.GetCurrentUserOrganizations)
+	route.Get("/admin/organizations", middleware.JWTProtected(), controllers.GetOrganizations)
+	route.Get("/admin/organizations/:id/members", middleware.JWTProtected(), controllers.GetOrganizationMembers)
+	route.Get("/books/export", middleware.JWTProtected(), controllers.ExportBooks)
+	route.Get("/books/export/:id", middleware.JWTProtected(), controllers.GetBookExportJob)
+	route.Get("/books/export/:id/download", middleware.JWTProtected(), controllers.DownloadBookExport)
+	route.Get("/books/import/:id", middleware.JWTProtected(), controllers.GetBookImportJob)
+	route.Get("/books/:id/collaborators", middleware.JWTProtected(), controllers.GetBookCollaborators)
+	route.Get("/user/books/shared", middleware.JWTProtected(), controllers.GetSharedBooks)
+	route.Get("/user/book-transfers", middleware.JWTProtected(), controllers.GetBookTransfers)
+	route.Get("/books/:id/revisions", middleware.JWTProtected(), controllers.GetBookRevisions)
+	route.Get("/books/:id/revisions/diff", middleware.JWTProtected(), controllers.DiffBookRevisions)
+
+	route.Put("/book", deprecated, middleware.JWTProtected(), controllers.UpdateBook)
+	route.Put("/books/:id", middleware.JWTProtected(), controllers.UpdateBook)
+	route.Put("/user/password", middleware.JWTProtected(), controllers.UserChangePassword)
+	route.Put("/admin/organizations/:id/members", middleware.JWTProtected(), controllers.SaveOrganizationMember)
+	route.Put("/books/:id/collaborators", middleware.JWTProtected(), controllers.SaveBookCollaborator)
+	route.Put("/books/:id/reviews/:review_id", middleware.JWTProtected(), controllers.UpdateReview)
+	route.Put("/books/:id/reviews/:review_id/status", middleware.JWTProtected(), controllers.ModerateReview)
+	route.Put("/books/:id/authors", middleware.JWTProtected(), controllers.SetBookAuthors)
+	route.Put("/authors/:id", middleware.JWTProtected(), controllers.UpdateAuthor)
+	route.Put("/books/:id/tags", middleware.JWTProtected(), controllers.SetBookTags)
+	route.Put("/books/:id/categories", middleware.JWTProtected(), controllers.SetBookCategories)
+	route.Put("/categories/:id", middleware.JWTProtected(), controllers.UpdateCategory)
+	route.Put("/shelves/:id", middleware.JWTProtected(), controllers.UpdateShelf)
+	route.Put("/shelves/:id/books/order", middleware.JWTProtected(), controllers.ReorderShelfBooks)
 
-	route.Put("/book", middleware.JWTProtected(), controllers.UpdateBook)
+	route.Patch("/books/:id", middleware.JWTProtected(), controllers.PatchBook)
+	route.Patch("/user/me", middleware.JWTProtected(), controllers.UpdateCurrentUser)
+	route.Patch("/admin/users/:id", middleware.JWTProtected(), controllers.UpdateUser)
 
-	route.Delete("/book", middleware.JWTProtected(), controllers.DeleteBook)
+	route.Delete("/book", deprecated, middleware.JWTProtected(), controllers.DeleteBook)
+	route.Delete("/books/:id", middleware.JWTProtected(), controllers.DeleteBook)
+	route.Delete("/user/me", middleware.JWTProtected(), controllers.DeleteCurrentUser)
+	route.Delete("/user/api-keys/:id", middleware.JWTProtected(), controllers.RevokeAPIKey)
+	route.Delete("/admin/users/:id", middleware.JWTProtected(), controllers.DeleteUser)
+	route.Delete("/admin/organizations/:id/members/:user_id", middleware.JWTProtected(), controllers.DeleteOrganizationMember)
+	route.Delete("/books/:id/collaborators/:user_id", middleware.JWTProtected(), controllers.DeleteBookCollaborator)
+	route.Delete("/books/:id/transfer", middleware.JWTProtected(), controllers.CancelBookTransfer)
+	route.Delete("/books/:id/reviews/:review_id", middleware.JWTProtected(), controllers.DeleteReview)
+	route.Delete("/authors/:id", middleware.JWTProtected(), controllers.DeleteAuthor)
+	route.Delete("/categories/:id", middleware.JWTProtected(), controllers.DeleteCategory)
+	route.Delete("/shelves/:id", middleware.JWTProtected(), controllers.DeleteShelf)
+	route.Delete("/shelves/:id/books/:book_id", middleware.JWTProtected(), controllers.RemoveShelfBook)
 }
@@ -2,15 +2,33 @@ package routes
 
 import (
 	"github.com/create-go-app/fiber-go-template/app/controllers"
+	"github.com/create-go-app/fiber-go-template/pkg/middleware"
 	"github.com/gofiber/fiber/v2"
 )
 
 func PublicRoutes(a *fiber.App) {
 	route := a.Group("/api/v1")
 
-	route.Get("/books", controllers.GetBooks)
-	route.Get("/book/:id", controllers.GetBook)
+	route.Get("/books", middleware.JWTOptional(), controllers.GetBooks)
+	route.Get("/books/isbn/:isbn", middleware.JWTOptional(), controllers.GetBookByISBN)
+	route.Get("/books/:id<guid>", middleware.JWTOptional(), controllers.GetBook)
+	route.Get("/book/:id", middleware.JWTOptional(), controllers.GetBook)
+	route.Get("/books/:id/reviews", controllers.GetReviews)
+	route.Get("/books/:id/rating", controllers.GetBookRating)
+	route.Get("/books/:id/authors", controllers.GetBookAuthors)
+	route.Get("/authors", controllers.GetAuthors)
+	route.Get("/authors/:id", controllers.GetAuthor)
+	route.Get("/authors/:id/books", controllers.GetAuthorBooks)
+	route.Get("/books/:id/tags", controllers.GetBookTags)
+	route.Get("/books/:id/categories", controllers.GetBookCategories)
+	route.Get("/tags", controllers.GetTags)
+	route.Get("/categories", controllers.GetCategories)
+	route.Get("/users/:id/shelves", middleware.JWTOptional(), controllers.GetUserShelves)
+	route.Get("/shelves/:id/books", middleware.JWTOptional(), controllers.GetShelfBooks)
 
 	route.Post("/user/sign/up", controllers.UserSignUp)
 	route.Post("/user/sign/in", controllers.UserSignIn)
+	route.Post("/user/email/verify", controllers.VerifyEmail)
+	route.Post("/user/password/reset", controllers.UserResetPassword)
+	route.Post("/token/renew", controllers.RenewTokens)
 }
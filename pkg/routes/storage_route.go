@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/create-go-app/fiber-go-template/platform/storage"
+	"github.com/gofiber/fiber/v2"
+)
+
+// StorageRoute serves the files of the local blob store, other backends
+// serve their files themselves.
+func StorageRoute(a *fiber.App) {
+	if storage.Backend() != "local" {
+		return
+	}
+
+	a.Static(storage.LocalURLPrefix, storage.LocalDir())
+}
@@ -0,0 +1,44 @@
+package routes_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/create-go-app/fiber-go-template/pkg/routes"
+	"github.com/create-go-app/fiber-go-template/platform/database"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublicRoutes_GetBook(t *testing.T) {
+	open := database.OpenDBConnection
+	database.OpenDBConnection = func() (*database.Queries, error) {
+		return nil, errors.New("database reached")
+	}
+	t.Cleanup(func() { database.OpenDBConnection = open })
+
+	app := fiber.New()
+	routes.PublicRoutes(app)
+	routes.PrivateRoutes(app)
+
+	// both paths reach the book
+	for _, path := range []string{"/api/v1/books/", "/api/v1/book/"} {
+		resp, err := app.Test(httptest.NewRequest("GET", path+uuid.NewString(), http.NoBody))
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode, path)
+
+		var body map[string]interface{}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, "database reached", body["details"], path)
+	}
+
+	// other routes under /books are not taken for book IDs: the export asks
+	// for a token
+	resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/books/export", http.NoBody))
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,40 @@
+package routes_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/create-go-app/fiber-go-template/pkg/routes"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorageRoute(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("STORAGE_BACKEND", "local")
+	t.Setenv("STORAGE_LOCAL_DIR", dir)
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "covers"), 0o750))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "covers", "a.jpg"), []byte("jpeg"), 0o600))
+
+	app := fiber.New()
+	routes.StorageRoute(app)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/storage/covers/a.jpg", http.NoBody))
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "jpeg", string(body))
+
+	// other backends are not served by the app
+	t.Setenv("STORAGE_BACKEND", "s3")
+	app = fiber.New()
+	routes.StorageRoute(app)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/storage/covers/a.jpg", http.NoBody))
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
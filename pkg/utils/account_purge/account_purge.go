@@ -0,0 +1,139 @@
+package account_purge
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/password_generator"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/random_token"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/refresh_session"
+	"github.com/create-go-app/fiber-go-template/platform/database"
+	"github.com/google/uuid"
+)
+
+// Store is the part of database.Queries the purge needs.
+type Store interface {
+	GetUsersDueForDeletion(now time.Time) ([]models.User, error)
+	AnonymizeUser(id uuid.UUID, email, passwordHash string) error
+	DeleteUser(id uuid.UUID) error
+	DeleteAPIKeys(userID uuid.UUID) error
+	DeleteEmailVerifications(userID uuid.UUID) error
+	DeletePasswordResets(userID uuid.UUID) error
+	AnonymizeLoginAttempts(userID uuid.UUID, oldEmail, newEmail string) error
+}
+
+// GracePeriod is how long a requested deletion can still be cancelled.
+func GracePeriod() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("ACCOUNT_DELETION_GRACE_DAYS"))
+	if err != nil || days < 0 {
+		days = 30
+	}
+
+	return time.Hour * 24 * time.Duration(days)
+}
+
+// AnonymizedEmail is the placeholder address of a scrubbed account.
+func AnonymizedEmail(id uuid.UUID) string {
+	return fmt.Sprintf("deleted-%s@invalid", id)
+}
+
+// revokeSessionFunc drops the refresh token of the user. Tests can swap it to
+// run without Redis.
+var revokeSessionFunc = func(userID uuid.UUID) error {
+	store, err := refresh_session.NewDefault()
+	if err != nil {
+		return err
+	}
+
+	return refresh_session.RevokeAll(context.Background(), store, userID)
+}
+
+// PurgeDueAccounts finalizes every deletion whose grace period is over and
+// returns how many accounts were processed. Every step is idempotent, so a
+// failed run is simply retried by the next one.
+func PurgeDueAccounts(store Store, now time.Time) (int, error) {
+	users, err := store.GetUsersDueForDeletion(now)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, user := range users {
+		if err := purgeAccount(store, user); err != nil {
+			return i, fmt.Errorf("purge of user %s failed: %w", user.ID, err)
+		}
+	}
+
+	return len(users), nil
+}
+
+func purgeAccount(store Store, user models.User) error {
+	anonymizedEmail := AnonymizedEmail(user.ID)
+
+	if err := store.AnonymizeLoginAttempts(user.ID, user.Email, anonymizedEmail); err != nil {
+		return err
+	}
+
+	if err := revokeSessionFunc(user.ID); err != nil {
+		log.Printf("account purge: failed to revoke session of user %s: %v", user.ID, err)
+	}
+
+	if user.DeletionMode == repository.AccountDeletionModeDelete {
+		return store.DeleteUser(user.ID)
+	}
+
+	unusablePassword, _, err := random_token.Generate()
+	if err != nil {
+		return err
+	}
+
+	if err := store.DeleteAPIKeys(user.ID); err != nil {
+		return err
+	}
+
+	if err := store.DeleteEmailVerifications(user.ID); err != nil {
+		return err
+	}
+
+	if err := store.DeletePasswordResets(user.ID); err != nil {
+		return err
+	}
+
+	return store.AnonymizeUser(user.ID, anonymizedEmail, password_generator.GeneratePassword(unusablePassword))
+}
+
+// Start runs the purge in the background, every ACCOUNT_PURGE_INTERVAL_MINUTES
+// (one hour by default).
+func Start() {
+	minutesCount, err := strconv.Atoi(os.Getenv("ACCOUNT_PURGE_INTERVAL_MINUTES"))
+	if err != nil || minutesCount <= 0 {
+		minutesCount = 60
+	}
+	interval := time.Minute * time.Duration(minutesCount)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			db, err := database.OpenDBConnection()
+			if err != nil {
+				log.Printf("account purge: %v", err)
+				continue
+			}
+
+			if count, err := PurgeDueAccounts(db, time.Now()); err != nil {
+				log.Printf("account purge: %v", err)
+			} else if count > 0 {
+				log.Printf("account purge: %d account(s) purged", count)
+			}
+
+			_ = db.UserQueries.Close()
+		}
+	}()
+}
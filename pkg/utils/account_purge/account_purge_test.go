@@ -0,0 +1,128 @@
+package account_purge
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStore struct {
+	users   []models.User
+	dueErr  error
+	calls   []string
+	failOn  string
+	scrubTo map[uuid.UUID]string
+}
+
+func (s *fakeStore) record(call string) error {
+	s.calls = append(s.calls, call)
+	if call == s.failOn {
+		return errors.New(call + " failed")
+	}
+	return nil
+}
+
+func (s *fakeStore) GetUsersDueForDeletion(now time.Time) ([]models.User, error) {
+	return s.users, s.dueErr
+}
+
+func (s *fakeStore) AnonymizeUser(id uuid.UUID, email, passwordHash string) error {
+	if s.scrubTo == nil {
+		s.scrubTo = map[uuid.UUID]string{}
+	}
+	s.scrubTo[id] = email
+	return s.record("AnonymizeUser")
+}
+
+func (s *fakeStore) DeleteUser(id uuid.UUID) error { return s.record("DeleteUser") }
+
+func (s *fakeStore) DeleteAPIKeys(userID uuid.UUID) error { return s.record("DeleteAPIKeys") }
+
+func (s *fakeStore) DeleteEmailVerifications(userID uuid.UUID) error {
+	return s.record("DeleteEmailVerifications")
+}
+
+func (s *fakeStore) DeletePasswordResets(userID uuid.UUID) error {
+	return s.record("DeletePasswordResets")
+}
+
+func (s *fakeStore) AnonymizeLoginAttempts(userID uuid.UUID, oldEmail, newEmail string) error {
+	return s.record("AnonymizeLoginAttempts")
+}
+
+func stubRevokeSession(t *testing.T) *[]uuid.UUID {
+	orig := revokeSessionFunc
+	t.Cleanup(func() { revokeSessionFunc = orig })
+
+	revoked := []uuid.UUID{}
+	revokeSessionFunc = func(userID uuid.UUID) error {
+		revoked = append(revoked, userID)
+		return errors.New("redis down")
+	}
+	return &revoked
+}
+
+func TestGracePeriod(t *testing.T) {
+	_ = os.Unsetenv("ACCOUNT_DELETION_GRACE_DAYS")
+	assert.Equal(t, 30*24*time.Hour, GracePeriod())
+
+	_ = os.Setenv("ACCOUNT_DELETION_GRACE_DAYS", "7")
+	defer os.Unsetenv("ACCOUNT_DELETION_GRACE_DAYS")
+	assert.Equal(t, 7*24*time.Hour, GracePeriod())
+
+	_ = os.Setenv("ACCOUNT_DELETION_GRACE_DAYS", "-1")
+	assert.Equal(t, 30*24*time.Hour, GracePeriod())
+}
+
+func TestPurgeDueAccounts_Anonymize(t *testing.T) {
+	revoked := stubRevokeSession(t)
+	id := uuid.New()
+	store := &fakeStore{users: []models.User{
+		{ID: id, Email: "gone@example.com", DeletionMode: repository.AccountDeletionModeAnonymize},
+	}}
+
+	count, err := PurgeDueAccounts(store, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, []uuid.UUID{id}, *revoked)
+	assert.Equal(t, []string{
+		"AnonymizeLoginAttempts", "DeleteAPIKeys", "DeleteEmailVerifications", "DeletePasswordResets", "AnonymizeUser",
+	}, store.calls)
+	assert.Equal(t, AnonymizedEmail(id), store.scrubTo[id])
+}
+
+func TestPurgeDueAccounts_Delete(t *testing.T) {
+	stubRevokeSession(t)
+	store := &fakeStore{users: []models.User{
+		{ID: uuid.New(), Email: "gone@example.com", DeletionMode: repository.AccountDeletionModeDelete},
+	}}
+
+	count, err := PurgeDueAccounts(store, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, []string{"AnonymizeLoginAttempts", "DeleteUser"}, store.calls)
+}
+
+func TestPurgeDueAccounts_Errors(t *testing.T) {
+	stubRevokeSession(t)
+
+	_, err := PurgeDueAccounts(&fakeStore{dueErr: errors.New("db error")}, time.Now())
+	assert.Error(t, err)
+
+	store := &fakeStore{
+		users: []models.User{
+			{ID: uuid.New(), DeletionMode: repository.AccountDeletionModeAnonymize},
+			{ID: uuid.New(), DeletionMode: repository.AccountDeletionModeAnonymize},
+		},
+		failOn: "DeleteAPIKeys",
+	}
+	count, err := PurgeDueAccounts(store, time.Now())
+	assert.Error(t, err)
+	assert.Equal(t, 0, count)
+}
@@ -0,0 +1,48 @@
+package api_key
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) { return 0, errors.New("entropy error") }
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, prefix, hash, err := GenerateAPIKey()
+	assert.NoError(t, err)
+	assert.True(t, IsAPIKey(key))
+	assert.Len(t, prefix, displayPrefixLength)
+	assert.Equal(t, key[:displayPrefixLength], prefix)
+	assert.Equal(t, HashAPIKey(key), hash)
+	assert.NotContains(t, hash, key)
+
+	other, _, _, err := GenerateAPIKey()
+	assert.NoError(t, err)
+	assert.NotEqual(t, key, other)
+}
+
+func TestGenerateAPIKey_RandError(t *testing.T) {
+	orig := randReader
+	randReader = failingReader{}
+	defer func() { randReader = orig }()
+
+	_, _, _, err := GenerateAPIKey()
+	assert.Error(t, err)
+}
+
+func TestIsAPIKey(t *testing.T) {
+	assert.True(t, IsAPIKey(Prefix+"abc"))
+	assert.False(t, IsAPIKey("eyJhbGciOiJIUzI1NiJ9.e30.sig"))
+	assert.False(t, IsAPIKey(""))
+}
+
+func TestFilterCredentials(t *testing.T) {
+	got := FilterCredentials([]string{"book:create", "book:delete"}, []string{"book:create", "book:update"})
+	assert.Equal(t, []string{"book:create"}, got)
+
+	assert.Empty(t, FilterCredentials(nil, []string{"book:create"}))
+}
@@ -0,0 +1,413 @@
+package book_export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/book_interchange"
+	"github.com/create-go-app/fiber-go-template/platform/database"
+	"github.com/google/uuid"
+)
+
+// Streamer is the part of database.Queries an export reads books from.
+type Streamer interface {
+	StreamBooks(tenantID uuid.UUID, f models.BookExportFilter, fn func(models.Book) error) error
+}
+
+// Store is the part of database.Queries a background export needs.
+type Store interface {
+	Streamer
+	UpdateBookExportJob(j *models.BookExportJob) error
+}
+
+type column struct {
+	name    string
+	numeric bool
+	value   func(b models.Book) string
+}
+
+// columns are the exported fields, with book_attrs flattened.
+var columns = []column{
+	{"id", false, func(b models.Book) string { return b.ID.String() }},
+	{"created_at", false, func(b models.Book) string { return b.CreatedAt.Format(time.RFC3339) }},
+	{"updated_at", false, func(b models.Book) string { return b.UpdatedAt.Format(time.RFC3339) }},
+	{"user_id", false, func(b models.Book) string { return b.UserID.String() }},
+	{"title", false, func(b models.Book) string { return b.Title }},
+	{"author", false, func(b models.Book) string { return b.Author }},
+	{"book_status", true, func(b models.Book) string { return strconv.Itoa(b.BookStatus) }},
+	{"picture", false, func(b models.Book) string { return b.BookAttrs.Picture }},
+	{"description", false, func(b models.Book) string { return b.BookAttrs.Description }},
+	{"rating", true, func(b models.Book) string { return strconv.Itoa(b.BookAttrs.Rating) }},
+	{"isbn", false, func(b models.Book) string { return b.ISBN }},
+	{"publisher", false, func(b models.Book) string { return b.Publisher }},
+	{"publication_date", false, func(b models.Book) string { return publicationDate(b) }},
+	{"language", false, func(b models.Book) string { return b.Language }},
+	{"page_count", true, func(b models.Book) string { return strconv.Itoa(b.PageCount) }},
+	{"edition", false, func(b models.Book) string { return b.Edition }},
+	{"book_format", false, func(b models.Book) string { return b.BookFormat }},
+}
+
+// publicationDate is the publication date of the book as YYYY-MM-DD, empty
+// when unknown.
+func publicationDate(b models.Book) string {
+	if b.PublicationDate == nil {
+		return ""
+	}
+
+	return b.PublicationDate.Format(time.DateOnly)
+}
+
+func header() []string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.name
+	}
+
+	return names
+}
+
+func record(b models.Book) []string {
+	values := make([]string, len(columns))
+	for i, c := range columns {
+		values[i] = c.value(b)
+	}
+
+	return values
+}
+
+// escapeFormula prefixes a cell that a spreadsheet would run as a formula
+// with a quote, so an opened CSV shows the text instead.
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@", rune(value[0])) {
+		return "'" + value
+	}
+
+	return value
+}
+
+// IsFormat reports whether books can be exported in format.
+func IsFormat(format string) bool {
+	switch format {
+	case repository.BookExportFormatCSV, repository.BookExportFormatNDJSON, repository.BookExportFormatXLSX:
+		return true
+	}
+
+	return book_interchange.IsFormat(format)
+}
+
+// ContentType returns the media type of an export in format.
+func ContentType(format string) string {
+	switch format {
+	case repository.BookExportFormatCSV:
+		return "text/csv; charset=utf-8"
+	case repository.BookExportFormatNDJSON:
+		return "application/x-ndjson"
+	case repository.BookExportFormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+
+	return book_interchange.ContentType(format)
+}
+
+// Extension returns the file extension of an export in format.
+func Extension(format string) string {
+	if book_interchange.IsFormat(format) {
+		return book_interchange.Extension(format)
+	}
+
+	return format
+}
+
+// Writer encodes books one at a time. Close has to be called to complete the
+// file.
+type Writer interface {
+	Write(b models.Book) error
+	Close() error
+}
+
+// NewWriter returns a Writer encoding books in format to w. CSV and XLSX
+// start with a header row, NDJSON has one flat object per book. The
+// bibliographic formats are written by book_interchange.
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case repository.BookExportFormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(header()); err != nil {
+			return nil, err
+		}
+		return &csvWriter{writer}, nil
+	case repository.BookExportFormatNDJSON:
+		return &ndjsonWriter{json.NewEncoder(w)}, nil
+	case repository.BookExportFormatXLSX:
+		writer, err := newXLSXWriter(w)
+		if err != nil {
+			return nil, err
+		}
+		if err := writer.writeRow(header(), make([]bool, len(columns))); err != nil {
+			return nil, err
+		}
+		numeric := make([]bool, len(columns))
+		for i, c := range columns {
+			numeric[i] = c.numeric
+		}
+		return &sheetWriter{writer, numeric}, nil
+	}
+
+	if book_interchange.IsFormat(format) {
+		return book_interchange.NewWriter(format, w)
+	}
+
+	return nil, fmt.Errorf("unknown export format %q", format)
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func (w *csvWriter) Write(b models.Book) error {
+	values := record(b)
+	for i, c := range columns {
+		if !c.numeric {
+			values[i] = escapeFormula(values[i])
+		}
+	}
+
+	return w.writer.Write(values)
+}
+
+func (w *csvWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+type ndjsonWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *ndjsonWriter) Write(b models.Book) error {
+	object := make(map[string]any, len(columns))
+	for _, c := range columns {
+		if c.numeric {
+			object[c.name] = json.Number(c.value(b))
+		} else {
+			object[c.name] = c.value(b)
+		}
+	}
+
+	return w.encoder.Encode(object)
+}
+
+func (w *ndjsonWriter) Close() error {
+	return nil
+}
+
+type sheetWriter struct {
+	writer  *xlsxWriter
+	numeric []bool
+}
+
+func (w *sheetWriter) Write(b models.Book) error {
+	return w.writer.writeRow(record(b), w.numeric)
+}
+
+func (w *sheetWriter) Close() error {
+	return w.writer.close()
+}
+
+// Export streams the books of the tenant matching the filter to w and
+// returns how many were written. progress, when not nil, is called with the
+// count after every book.
+func Export(store Streamer, tenantID uuid.UUID, f models.BookExportFilter, format string, w io.Writer, progress func(int) error) (int, error) {
+	writer, err := NewWriter(format, w)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = store.StreamBooks(tenantID, f, func(b models.Book) error {
+		if err := writer.Write(b); err != nil {
+			return err
+		}
+		count++
+		if progress != nil {
+			return progress(count)
+		}
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+
+	return count, writer.Close()
+}
+
+// StreamLimit is the number of books above which an export runs as a
+// background job instead of in the response, read from
+// BOOK_EXPORT_STREAM_LIMIT (10000 by default).
+func StreamLimit() int {
+	limit, err := strconv.Atoi(os.Getenv("BOOK_EXPORT_STREAM_LIMIT"))
+	if err != nil || limit < 0 {
+		limit = 10000
+	}
+
+	return limit
+}
+
+// Dir is where background exports are written, read from BOOK_EXPORT_DIR
+// (book-exports in the temporary directory by default).
+func Dir() string {
+	if dir := os.Getenv("BOOK_EXPORT_DIR"); dir != "" {
+		return dir
+	}
+
+	return filepath.Join(os.TempDir(), "book-exports")
+}
+
+// Path returns the file of a background export.
+func Path(job models.BookExportJob) string {
+	return filepath.Join(Dir(), job.ID.String()+"."+Extension(job.Format))
+}
+
+// Retention is how long the file of a background export is kept, read from
+// BOOK_EXPORT_RETENTION_HOURS (24 by default).
+func Retention() time.Duration {
+	hours, err := strconv.Atoi(os.Getenv("BOOK_EXPORT_RETENTION_HOURS"))
+	if err != nil || hours <= 0 {
+		hours = 24
+	}
+
+	return time.Hour * time.Duration(hours)
+}
+
+// Sweep deletes the files of Dir last written before the given time and
+// returns how many were deleted. Partial files of exports that never
+// finished go the same way.
+func Sweep(before time.Time) (int, error) {
+	entries, err := os.ReadDir(Dir())
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() || !info.ModTime().Before(before) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(Dir(), entry.Name())); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// StartSweep deletes expired export files in the background, every hour.
+func StartSweep() {
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if count, err := Sweep(time.Now().Add(-Retention())); err != nil {
+				log.Printf("book export sweep: %v", err)
+			} else if count > 0 {
+				log.Printf("book export sweep: %d file(s) deleted", count)
+			}
+		}
+	}()
+}
+
+// progressInterval is how many books are exported between two progress
+// updates of a background job.
+const progressInterval = 1000
+
+// Run writes the export of the job to its file. The file only appears once
+// it is complete.
+func Run(store Store, job *models.BookExportJob) error {
+	job.Status = repository.JobStatusRunning
+	job.UpdatedAt = time.Now()
+	if err := store.UpdateBookExportJob(job); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(Dir(), 0o750); err != nil {
+		return err
+	}
+
+	path := Path(*job)
+	file, err := os.CreateTemp(Dir(), job.ID.String()+".*.part")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	count, err := Export(store, job.TenantID, job.Filter, job.Format, file, func(count int) error {
+		if count%progressInterval != 0 {
+			return nil
+		}
+		job.ExportedRows = count
+		job.UpdatedAt = time.Now()
+		return store.UpdateBookExportJob(job)
+	})
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(file.Name(), path); err != nil {
+		return err
+	}
+
+	finishedAt := time.Now()
+	job.Status = repository.JobStatusDone
+	job.ExportedRows = count
+	job.UpdatedAt = finishedAt
+	job.FinishedAt = &finishedAt
+
+	return store.UpdateBookExportJob(job)
+}
+
+// Start runs the job in the background on its own database connection. A job
+// that cannot finish is marked as failed with the error.
+func Start(job models.BookExportJob) {
+	go func() {
+		db, err := database.OpenDBConnection()
+		if err != nil {
+			log.Printf("book export %s: %v", job.ID, err)
+			return
+		}
+		defer func() { _ = db.BookExportJobQueries.Close() }()
+
+		if err := Run(db, &job); err != nil {
+			log.Printf("book export %s: %v", job.ID, err)
+
+			finishedAt := time.Now()
+			job.Status = repository.JobStatusFailed
+			job.Error = err.Error()
+			job.UpdatedAt = finishedAt
+			job.FinishedAt = &finishedAt
+			if err := db.UpdateBookExportJob(&job); err != nil {
+				log.Printf("book export %s: %v", job.ID, err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,205 @@
+package book_export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStore struct {
+	books     []models.Book
+	streamErr error
+	updates   []models.BookExportJob
+}
+
+func (s *fakeStore) StreamBooks(tenantID uuid.UUID, f models.BookExportFilter, fn func(models.Book) error) error {
+	for _, b := range s.books {
+		if err := fn(b); err != nil {
+			return err
+		}
+	}
+	return s.streamErr
+}
+
+func (s *fakeStore) UpdateBookExportJob(j *models.BookExportJob) error {
+	s.updates = append(s.updates, *j)
+	return nil
+}
+
+func testBooks() []models.Book {
+	createdAt := time.Date(2025, time.October, 19, 10, 0, 0, 0, time.UTC)
+	published := models.Date{Time: time.Date(2015, time.October, 26, 0, 0, 0, 0, time.UTC)}
+	return []models.Book{
+		{ID: uuid.New(), CreatedAt: createdAt, UpdatedAt: createdAt, UserID: uuid.New(), Title: "Go, 2nd edition", Author: "Rob",
+			BookStatus: 1, BookAttrs: models.BookAttrs{Description: "<b>&</b>", Rating: 8},
+			BookMetadata: models.BookMetadata{ISBN: "9780134190440", Publisher: "Addison-Wesley", PublicationDate: &published,
+				Language: "en", PageCount: 380, Edition: "2nd", BookFormat: "paperback"}},
+		{ID: uuid.New(), CreatedAt: createdAt, UpdatedAt: createdAt, UserID: uuid.New(), Title: "Draft", Author: "Ken",
+			BookAttrs: models.BookAttrs{Rating: 3}},
+	}
+}
+
+func TestExport_CSV(t *testing.T) {
+	books := testBooks()
+	out := &bytes.Buffer{}
+
+	count, err := Export(&fakeStore{books: books}, uuid.New(), models.BookExportFilter{}, repository.BookExportFormatCSV, out, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(t, "id,created_at,updated_at,user_id,title,author,book_status,picture,description,rating,"+
+		"isbn,publisher,publication_date,language,page_count,edition,book_format", lines[0])
+	assert.Equal(t, books[0].ID.String()+",2025-10-19T10:00:00Z,2025-10-19T10:00:00Z,"+books[0].UserID.String()+
+		`,"Go, 2nd edition",Rob,1,,<b>&</b>,8,9780134190440,Addison-Wesley,2015-10-26,en,380,2nd,paperback`, lines[1])
+	assert.Len(t, lines, 3)
+}
+
+func TestExport_CSVFormula(t *testing.T) {
+	books := []models.Book{{ID: uuid.New(), Title: "=HYPERLINK(\"http://evil\")", Author: "@Rob",
+		BookAttrs: models.BookAttrs{Description: "-2+3", Rating: 5}}}
+	out := &bytes.Buffer{}
+
+	_, err := Export(&fakeStore{books: books}, uuid.New(), models.BookExportFilter{}, repository.BookExportFormatCSV, out, nil)
+	assert.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Contains(t, lines[1], `,"'=HYPERLINK(""http://evil"")",'@Rob,0,,'-2+3,5,`)
+}
+
+func TestExport_NDJSON(t *testing.T) {
+	books := testBooks()
+	out := &bytes.Buffer{}
+
+	_, err := Export(&fakeStore{books: books}, uuid.New(), models.BookExportFilter{}, repository.BookExportFormatNDJSON, out, nil)
+	assert.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 2)
+
+	object := map[string]any{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &object))
+	assert.Equal(t, "Draft", object["title"])
+	assert.Equal(t, float64(0), object["book_status"])
+	assert.Equal(t, float64(3), object["rating"])
+	assert.Equal(t, "", object["publication_date"])
+	assert.Equal(t, float64(0), object["page_count"])
+	assert.NotContains(t, object, "book_attrs")
+}
+
+func TestExport_XLSX(t *testing.T) {
+	out := &bytes.Buffer{}
+
+	_, err := Export(&fakeStore{books: testBooks()}, uuid.New(), models.BookExportFilter{}, repository.BookExportFormatXLSX, out, nil)
+	assert.NoError(t, err)
+
+	archive, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	assert.NoError(t, err)
+
+	names := []string{}
+	sheet := ""
+	for _, file := range archive.File {
+		names = append(names, file.Name)
+		if file.Name == "xl/worksheets/sheet1.xml" {
+			r, err := file.Open()
+			assert.NoError(t, err)
+			content, err := io.ReadAll(r)
+			assert.NoError(t, err)
+			sheet = string(content)
+		}
+	}
+
+	assert.ElementsMatch(t, []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"}, names)
+	assert.Equal(t, 3, strings.Count(sheet, "<row "))
+	assert.Contains(t, sheet, `<t xml:space="preserve">&lt;b&gt;&amp;&lt;/b&gt;</t>`)
+	assert.Contains(t, sheet, `<c><v>8</v></c>`)
+	assert.Contains(t, sheet, `<c><v>380</v></c>`)
+	assert.True(t, strings.HasSuffix(sheet, "</sheetData></worksheet>"))
+}
+
+func TestExport_Errors(t *testing.T) {
+	_, err := Export(&fakeStore{}, uuid.New(), models.BookExportFilter{}, "pdf", io.Discard, nil)
+	assert.Error(t, err)
+
+	count, err := Export(&fakeStore{books: testBooks(), streamErr: errors.New("db error")}, uuid.New(), models.BookExportFilter{}, repository.BookExportFormatCSV, io.Discard, nil)
+	assert.Error(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestStreamLimit(t *testing.T) {
+	_ = os.Unsetenv("BOOK_EXPORT_STREAM_LIMIT")
+	assert.Equal(t, 10000, StreamLimit())
+
+	_ = os.Setenv("BOOK_EXPORT_STREAM_LIMIT", "0")
+	defer os.Unsetenv("BOOK_EXPORT_STREAM_LIMIT")
+	assert.Equal(t, 0, StreamLimit())
+
+	_ = os.Setenv("BOOK_EXPORT_STREAM_LIMIT", "many")
+	assert.Equal(t, 10000, StreamLimit())
+}
+
+func TestRun(t *testing.T) {
+	t.Setenv("BOOK_EXPORT_DIR", t.TempDir())
+
+	store := &fakeStore{books: testBooks()}
+	job := &models.BookExportJob{ID: uuid.New(), TenantID: uuid.New(), Format: repository.BookExportFormatCSV, TotalRows: 2}
+
+	assert.NoError(t, Run(store, job))
+
+	assert.Equal(t, repository.JobStatusDone, job.Status)
+	assert.Equal(t, 2, job.ExportedRows)
+	assert.NotNil(t, job.FinishedAt)
+	assert.Equal(t, repository.JobStatusRunning, store.updates[0].Status)
+
+	content, err := os.ReadFile(Path(*job))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, strings.Count(string(content), "\n"))
+
+	entries, err := os.ReadDir(Dir())
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestRun_Failed(t *testing.T) {
+	t.Setenv("BOOK_EXPORT_DIR", t.TempDir())
+
+	store := &fakeStore{books: testBooks(), streamErr: errors.New("db error")}
+	job := &models.BookExportJob{ID: uuid.New(), Format: repository.BookExportFormatXLSX}
+
+	assert.Error(t, Run(store, job))
+
+	// neither the file nor the partial one is left behind
+	entries, err := os.ReadDir(Dir())
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestSweep(t *testing.T) {
+	t.Setenv("BOOK_EXPORT_DIR", t.TempDir())
+
+	count, err := Sweep(time.Now())
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+
+	old, fresh := Path(models.BookExportJob{ID: uuid.New(), Format: "csv"}), Path(models.BookExportJob{ID: uuid.New(), Format: "csv"})
+	assert.NoError(t, os.WriteFile(old, []byte("id\n"), 0o600))
+	assert.NoError(t, os.WriteFile(fresh, []byte("id\n"), 0o600))
+	assert.NoError(t, os.Chtimes(old, time.Now().Add(-48*time.Hour), time.Now().Add(-48*time.Hour)))
+
+	count, err = Sweep(time.Now().Add(-Retention()))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.NoFileExists(t, old)
+	assert.FileExists(t, fresh)
+}
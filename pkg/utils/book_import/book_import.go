@@ -0,0 +1,471 @@
+package book_import
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/app/queries"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/book_interchange"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/isbn"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/validator"
+	"github.com/create-go-app/fiber-go-template/platform/database"
+	"github.com/google/uuid"
+)
+
+// Columns are the CSV header names. title and author are required, the
+// book_attrs fields are flattened.
+var Columns = []string{
+	"title", "author", "book_status", "picture", "description", "rating",
+	"isbn", "publisher", "publication_date", "language", "page_count", "edition", "book_format",
+}
+
+// Store is the part of database.Queries the import needs.
+type Store interface {
+	GetTakenISBNs(tenantID uuid.UUID, isbns []string) ([]string, error)
+	CreateBooks(tenantID uuid.UUID, books []models.Book, change models.BookChange) error
+	UpdateBookImportJob(j *models.BookImportJob) error
+}
+
+// Row is a book read from the file. Err is set when the row itself could not
+// be read, the rest of the file is still imported. For the interchange
+// formats, Line is the position of the record in the file, Unmapped lists
+// the fields of the record that have no book column and Unrated is set, as
+// records carry no rating: their books are imported unrated.
+type Row struct {
+	Line     int
+	Book     models.BookCreate
+	Err      error
+	Unmapped []string
+	Unrated  bool
+}
+
+// BatchSize is how many books are inserted per transaction, read from
+// BOOK_IMPORT_BATCH_SIZE (100 by default).
+func BatchSize() int {
+	size, err := strconv.Atoi(os.Getenv("BOOK_IMPORT_BATCH_SIZE"))
+	if err != nil || size <= 0 {
+		size = 100
+	}
+
+	return size
+}
+
+// IsFormat reports whether books can be imported from format.
+func IsFormat(format string) bool {
+	switch format {
+	case repository.BookImportFormatCSV, repository.BookImportFormatNDJSON:
+		return true
+	}
+
+	return book_interchange.IsFormat(format)
+}
+
+// Parse reads all the rows of a file. It only fails when the file cannot be
+// read as a whole, like a CSV file with an unknown column.
+func Parse(format string, r io.Reader) ([]Row, error) {
+	switch format {
+	case repository.BookImportFormatCSV:
+		return parseCSV(r)
+	case repository.BookImportFormatNDJSON:
+		return parseNDJSON(r)
+	}
+
+	if book_interchange.IsFormat(format) {
+		records, err := book_interchange.Parse(format, r)
+		if err != nil {
+			return nil, err
+		}
+		rows := make([]Row, len(records))
+		for i, record := range records {
+			rows[i] = Row{Line: i + 1, Book: record.Book, Err: record.Err, Unmapped: record.Unmapped, Unrated: true}
+		}
+		return rows, nil
+	}
+
+	return nil, fmt.Errorf("unknown import format %q", format)
+}
+
+// UnmappedFields counts, per field, the rows where it was left unmapped.
+func UnmappedFields(rows []Row) models.BookImportUnmappedFields {
+	fields := models.BookImportUnmappedFields{}
+	for _, row := range rows {
+		for _, field := range row.Unmapped {
+			fields[field]++
+		}
+	}
+
+	return fields
+}
+
+// newBookCreate returns the defaults of a row. Books are active unless the
+// row says otherwise.
+func newBookCreate() models.BookCreate {
+	return models.BookCreate{BookStatus: 1}
+}
+
+func parseCSV(r io.Reader) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return []Row{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !isColumn(name) {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("duplicate column %q", name)
+		}
+		columns[name] = i
+	}
+	for _, name := range []string{"title", "author"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing column %q", name)
+		}
+	}
+
+	rows := []Row{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+
+		// Rows with the wrong number of fields are reported, anything else
+		// leaves the reader out of step with the file.
+		if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			rows = append(rows, Row{Line: line, Err: err})
+			continue
+		}
+
+		row := Row{Line: line, Book: newBookCreate()}
+		row.Err = fromRecord(&row.Book, columns, record)
+		rows = append(rows, row)
+	}
+}
+
+func isColumn(name string) bool {
+	for _, column := range Columns {
+		if column == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func fromRecord(book *models.BookCreate, columns map[string]int, record []string) error {
+	field := func(name string) (string, bool) {
+		i, ok := columns[name]
+		if !ok {
+			return "", false
+		}
+		value := strings.TrimSpace(record[i])
+		return value, value != ""
+	}
+
+	book.Title, _ = field("title")
+	book.Author, _ = field("author")
+	book.BookAttrs.Picture, _ = field("picture")
+	book.BookAttrs.Description, _ = field("description")
+	book.ISBN, _ = field("isbn")
+	book.Publisher, _ = field("publisher")
+	book.Language, _ = field("language")
+	book.Edition, _ = field("edition")
+	book.BookFormat, _ = field("book_format")
+
+	if value, ok := field("book_status"); ok {
+		status, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("book_status %q is not a number", value)
+		}
+		book.BookStatus = status
+	}
+
+	if value, ok := field("rating"); ok {
+		rating, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("rating %q is not a number", value)
+		}
+		book.BookAttrs.Rating = rating
+	}
+
+	if value, ok := field("publication_date"); ok {
+		date, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			return fmt.Errorf("publication_date %q is not in the YYYY-MM-DD format", value)
+		}
+		book.PublicationDate = &models.Date{Time: date}
+	}
+
+	if value, ok := field("page_count"); ok {
+		pageCount, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("page_count %q is not a number", value)
+		}
+		book.PageCount = pageCount
+	}
+
+	return nil
+}
+
+func parseNDJSON(r io.Reader) ([]Row, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	rows := []Row{}
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		row := Row{Line: line, Book: newBookCreate()}
+		decoder := json.NewDecoder(bytes.NewReader(text))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&row.Book); err != nil {
+			row.Err = err
+		}
+		rows = append(rows, row)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// Run validates the rows with the BookCreate rules and, unless the job is a
+// dry run, inserts the valid ones in batches of batchSize. Rows repeating the
+// ISBN of an earlier row of the file, or of a book of the organization, are
+// reported on their own, in dry runs too. When the database still rejects a
+// batch, its books are inserted one by one so only the failing rows are
+// reported. The progress is stored after every batch.
+func Run(store Store, job *models.BookImportJob, rows []Row, batchSize int) error {
+	job.Status = repository.JobStatusRunning
+	job.UpdatedAt = time.Now()
+	if err := store.UpdateBookImportJob(job); err != nil {
+		return err
+	}
+
+	validate := validator.NewValidator()
+	isbnLines := map[string]int{}
+
+	for start := 0; start < len(rows); start += batchSize {
+		batch := rows[start:min(start+batchSize, len(rows))]
+
+		books := []models.Book{}
+		lines := []int{}
+		isbns := []string{}
+		for _, row := range batch {
+			check := validate.Struct
+			if row.Unrated && row.Book.BookAttrs.Rating == 0 {
+				check = func(book any) error { return validate.StructExcept(book, "BookAttrs.Rating") }
+			}
+			if err := rowError(check, row); err != "" {
+				job.RowErrors = append(job.RowErrors, models.BookImportRowError{Line: row.Line, Error: err})
+				continue
+			}
+
+			metadata := row.Book.BookMetadata
+			metadata.ISBN = isbn.Normalize(metadata.ISBN)
+			if metadata.ISBN != "" {
+				if line, found := isbnLines[metadata.ISBN]; found {
+					job.RowErrors = append(job.RowErrors, models.BookImportRowError{
+						Line: row.Line, Error: fmt.Sprintf("ISBN: already on line %d", line),
+					})
+					continue
+				}
+				isbnLines[metadata.ISBN] = row.Line
+				isbns = append(isbns, metadata.ISBN)
+			}
+
+			now := time.Now()
+			books = append(books, models.Book{
+				ID:           uuid.New(),
+				CreatedAt:    now,
+				UpdatedAt:    now,
+				UserID:       job.UserID,
+				Title:        row.Book.Title,
+				Author:       row.Book.Author,
+				BookStatus:   row.Book.BookStatus,
+				BookAttrs:    row.Book.BookAttrs,
+				TenantID:     job.TenantID,
+				Version:      1,
+				BookMetadata: metadata,
+			})
+			lines = append(lines, row.Line)
+		}
+
+		if len(isbns) > 0 {
+			taken, err := store.GetTakenISBNs(job.TenantID, isbns)
+			if err != nil {
+				return err
+			}
+			books, lines = dropTaken(job, books, lines, taken)
+		}
+
+		if !job.DryRun && len(books) > 0 {
+			if err := store.CreateBooks(job.TenantID, books, models.BookChange{ActorID: job.UserID}); err != nil {
+				for i := range books {
+					if err := store.CreateBooks(job.TenantID, books[i:i+1], models.BookChange{ActorID: job.UserID}); err != nil {
+						job.RowErrors = append(job.RowErrors, models.BookImportRowError{Line: lines[i], Error: createError(job, err)})
+						continue
+					}
+					job.ImportedRows++
+				}
+			} else {
+				job.ImportedRows += len(books)
+			}
+		}
+
+		job.ProcessedRows += len(batch)
+		job.UpdatedAt = time.Now()
+		if err := store.UpdateBookImportJob(job); err != nil {
+			return err
+		}
+	}
+
+	finishedAt := time.Now()
+	job.Status = repository.JobStatusDone
+	job.UpdatedAt = finishedAt
+	job.FinishedAt = &finishedAt
+
+	return store.UpdateBookImportJob(job)
+}
+
+// dropTaken reports the books whose ISBN the organization already has and
+// returns the others, with their lines.
+func dropTaken(job *models.BookImportJob, books []models.Book, lines []int, taken []string) ([]models.Book, []int) {
+	if len(taken) == 0 {
+		return books, lines
+	}
+
+	isTaken := map[string]bool{}
+	for _, code := range taken {
+		isTaken[code] = true
+	}
+
+	keptBooks, keptLines := []models.Book{}, []int{}
+	for i, book := range books {
+		if isTaken[book.ISBN] {
+			job.RowErrors = append(job.RowErrors, models.BookImportRowError{Line: lines[i], Error: repository.ISBNExistsErrorMessage})
+			continue
+		}
+		keptBooks = append(keptBooks, book)
+		keptLines = append(keptLines, lines[i])
+	}
+
+	return keptBooks, keptLines
+}
+
+// createError is the row error for a book the database refused. Errors
+// other than a taken ISBN are logged, not shown.
+func createError(job *models.BookImportJob, err error) string {
+	if queries.IsISBNTaken(err) {
+		return repository.ISBNExistsErrorMessage
+	}
+
+	log.Printf("book import %s: %v", job.ID, err)
+	return repository.InternalServerErrorMessage
+}
+
+func rowError(validate func(any) error, row Row) string {
+	if row.Err != nil {
+		return row.Err.Error()
+	}
+
+	if err := validate(&row.Book); err != nil {
+		if message := validator.ValidatorErrors(err); message != "" {
+			return message
+		}
+		return err.Error()
+	}
+
+	return ""
+}
+
+// Start runs the job in the background on its own database connection. A job
+// that cannot finish is marked as failed.
+func Start(job models.BookImportJob, rows []Row) {
+	go func() {
+		db, err := database.OpenDBConnection()
+		if err != nil {
+			log.Printf("book import %s: %v", job.ID, err)
+			return
+		}
+		defer func() { _ = db.BookImportJobQueries.Close() }()
+
+		if err := runRecovered(db, &job, rows, BatchSize()); err != nil {
+			log.Printf("book import %s: %v", job.ID, err)
+
+			finishedAt := time.Now()
+			job.Status = repository.JobStatusFailed
+			job.UpdatedAt = finishedAt
+			job.FinishedAt = &finishedAt
+			if err := db.UpdateBookImportJob(&job); err != nil {
+				log.Printf("book import %s: %v", job.ID, err)
+			}
+		}
+	}()
+}
+
+// runRecovered is Run with a panic turned into an error, so a broken import
+// fails its job instead of the whole server.
+func runRecovered(store Store, job *models.BookImportJob, rows []Row, batchSize int) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return Run(store, job, rows, batchSize)
+}
+
+// FailInterrupted marks the jobs a previous process left queued or running
+// as failed. Their rows were only held in memory, so they cannot be resumed.
+func FailInterrupted() error {
+	db, err := database.OpenDBConnection()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.BookImportJobQueries.Close() }()
+
+	n, err := db.FailBookImportJobs(
+		repository.JobStatusFailed, []string{repository.JobStatusQueued, repository.JobStatusRunning}, time.Now(),
+	)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("book import: %d interrupted jobs marked as failed", n)
+	}
+
+	return nil
+}
@@ -0,0 +1,299 @@
+package book_import
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStore struct {
+	batches   [][]models.Book
+	failBatch int
+	failISBN  string
+	taken     []string
+	lookups   [][]string
+	updates   []models.BookImportJob
+}
+
+func (s *fakeStore) GetTakenISBNs(tenantID uuid.UUID, isbns []string) ([]string, error) {
+	s.lookups = append(s.lookups, isbns)
+	return s.taken, nil
+}
+
+func (s *fakeStore) CreateBooks(tenantID uuid.UUID, books []models.Book, change models.BookChange) error {
+	s.batches = append(s.batches, books)
+	if len(s.batches) == s.failBatch {
+		return errors.New("insert error")
+	}
+	for _, book := range books {
+		if book.ISBN != "" && book.ISBN == s.failISBN {
+			return &pgconn.PgError{Code: "23505", ConstraintName: "books_tenant_isbn_key"}
+		}
+	}
+	return nil
+}
+
+func (s *fakeStore) UpdateBookImportJob(j *models.BookImportJob) error {
+	s.updates = append(s.updates, *j)
+	return nil
+}
+
+func TestBatchSize(t *testing.T) {
+	_ = os.Unsetenv("BOOK_IMPORT_BATCH_SIZE")
+	assert.Equal(t, 100, BatchSize())
+
+	_ = os.Setenv("BOOK_IMPORT_BATCH_SIZE", "25")
+	defer os.Unsetenv("BOOK_IMPORT_BATCH_SIZE")
+	assert.Equal(t, 25, BatchSize())
+
+	_ = os.Setenv("BOOK_IMPORT_BATCH_SIZE", "0")
+	assert.Equal(t, 100, BatchSize())
+}
+
+func TestParse_CSV(t *testing.T) {
+	file := "\ufeffTitle,author,rating,book_status\n" +
+		"Go,Rob,8,\n" +
+		"\"Quoted, title\",Ken,5,0\n" +
+		"Short,row\n" +
+		"Bad,Rating,x,1\n"
+
+	rows, err := Parse(repository.BookImportFormatCSV, strings.NewReader(file))
+	assert.NoError(t, err)
+	assert.Len(t, rows, 4)
+
+	assert.Equal(t, 2, rows[0].Line)
+	assert.NoError(t, rows[0].Err)
+	assert.Equal(t, models.BookCreate{Title: "Go", Author: "Rob", BookStatus: 1, BookAttrs: models.BookAttrs{Rating: 8}}, rows[0].Book)
+
+	assert.Equal(t, "Quoted, title", rows[1].Book.Title)
+	assert.Equal(t, 0, rows[1].Book.BookStatus)
+
+	assert.Equal(t, 4, rows[2].Line)
+	assert.Error(t, rows[2].Err)
+	assert.Error(t, rows[3].Err)
+}
+
+func TestParse_CSVHeader(t *testing.T) {
+	for _, file := range []string{
+		"title,author,shelf\n",
+		"title,title,author\n",
+		"title,rating\n",
+		"title,author\n\"unterminated\n",
+	} {
+		_, err := Parse(repository.BookImportFormatCSV, strings.NewReader(file))
+		assert.Error(t, err, file)
+	}
+
+	rows, err := Parse(repository.BookImportFormatCSV, strings.NewReader(""))
+	assert.NoError(t, err)
+	assert.Empty(t, rows)
+}
+
+func TestParse_NDJSON(t *testing.T) {
+	file := `{"title":"Go","author":"Rob","book_attrs":{"rating":8}}
+
+{"title":"Draft","author":"Ken","book_status":0,"book_attrs":{"rating":3}}
+{"title":"Go","price":"12.50"}
+not json
+`
+
+	rows, err := Parse(repository.BookImportFormatNDJSON, strings.NewReader(file))
+	assert.NoError(t, err)
+	assert.Len(t, rows, 4)
+
+	assert.NoError(t, rows[0].Err)
+	assert.Equal(t, 1, rows[0].Book.BookStatus)
+	assert.Equal(t, 3, rows[1].Line)
+	assert.Equal(t, 0, rows[1].Book.BookStatus)
+	assert.Error(t, rows[2].Err)
+	assert.Error(t, rows[3].Err)
+
+	_, err = Parse("xml", strings.NewReader(file))
+	assert.Error(t, err)
+}
+
+func TestParse_CSVMetadata(t *testing.T) {
+	file := "title,author,rating,isbn,publisher,publication_date,language,page_count,edition,book_format\n" +
+		"Go,Rob,8,0-306-40615-2,Addison-Wesley,2015-10-26,en,380,2nd,paperback\n" +
+		"Go,Rob,8,,,26/10/2015,,,,\n" +
+		"Go,Rob,8,,,,,many,,\n"
+
+	rows, err := Parse(repository.BookImportFormatCSV, strings.NewReader(file))
+	assert.NoError(t, err)
+	assert.Len(t, rows, 3)
+
+	assert.NoError(t, rows[0].Err)
+	published := &models.Date{Time: time.Date(2015, time.October, 26, 0, 0, 0, 0, time.UTC)}
+	assert.Equal(t, models.BookMetadata{ISBN: "0-306-40615-2", Publisher: "Addison-Wesley", PublicationDate: published,
+		Language: "en", PageCount: 380, Edition: "2nd", BookFormat: "paperback"}, rows[0].Book.BookMetadata)
+	assert.EqualError(t, rows[1].Err, `publication_date "26/10/2015" is not in the YYYY-MM-DD format`)
+	assert.EqualError(t, rows[2].Err, `page_count "many" is not a number`)
+}
+
+func testRows() []Row {
+	valid := models.BookCreate{Title: "Go", Author: "Rob", BookStatus: 1, BookAttrs: models.BookAttrs{Rating: 8}}
+	return []Row{
+		{Line: 2, Book: valid},
+		{Line: 3, Book: models.BookCreate{Title: "No author", BookStatus: 1, BookAttrs: models.BookAttrs{Rating: 8}}},
+		{Line: 4, Book: valid},
+		{Line: 5, Err: errors.New("wrong number of fields")},
+		{Line: 6, Book: valid},
+	}
+}
+
+func TestRun(t *testing.T) {
+	store := &fakeStore{}
+	job := &models.BookImportJob{ID: uuid.New(), TenantID: uuid.New(), UserID: uuid.New(), TotalRows: 5}
+
+	assert.NoError(t, Run(store, job, testRows(), 2))
+
+	assert.Len(t, store.batches, 3)
+	assert.Len(t, store.batches[0], 1)
+	assert.Equal(t, job.UserID, store.batches[0][0].UserID)
+	assert.Equal(t, job.TenantID, store.batches[0][0].TenantID)
+	assert.Equal(t, 1, store.batches[0][0].Version)
+
+	assert.Equal(t, repository.JobStatusDone, job.Status)
+	assert.NotNil(t, job.FinishedAt)
+	assert.Equal(t, 5, job.ProcessedRows)
+	assert.Equal(t, 3, job.ImportedRows)
+	assert.Len(t, job.RowErrors, 2)
+	assert.Equal(t, 3, job.RowErrors[0].Line)
+	assert.Contains(t, job.RowErrors[0].Error, "Author")
+	assert.Equal(t, 5, job.RowErrors[1].Line)
+
+	// running, one update per batch, done
+	assert.Len(t, store.updates, 5)
+	assert.Equal(t, repository.JobStatusRunning, store.updates[0].Status)
+	assert.Equal(t, 2, store.updates[1].ProcessedRows)
+}
+
+func TestRun_FailedBatch(t *testing.T) {
+	store := &fakeStore{failBatch: 2}
+	job := &models.BookImportJob{ID: uuid.New(), TenantID: uuid.New(), UserID: uuid.New()}
+
+	assert.NoError(t, Run(store, job, testRows(), 2))
+
+	// the failed batch is retried book by book
+	assert.Len(t, store.batches, 4)
+	assert.Equal(t, store.batches[1], store.batches[2])
+	assert.Equal(t, 3, job.ImportedRows)
+	assert.Len(t, job.RowErrors, 2)
+}
+
+type panicStore struct{ fakeStore }
+
+func (s *panicStore) CreateBooks(tenantID uuid.UUID, books []models.Book, change models.BookChange) error {
+	panic("boom")
+}
+
+func TestRunRecovered(t *testing.T) {
+	job := &models.BookImportJob{ID: uuid.New(), TenantID: uuid.New(), UserID: uuid.New()}
+
+	err := runRecovered(&panicStore{}, job, testRows(), 2)
+	assert.EqualError(t, err, "panic: boom")
+}
+
+func TestRun_Unrated(t *testing.T) {
+	store := &fakeStore{}
+	job := &models.BookImportJob{ID: uuid.New(), TenantID: uuid.New(), UserID: uuid.New()}
+	unrated := models.BookCreate{Title: "Go", Author: "Rob", BookStatus: 1}
+	outOfRange := models.BookCreate{Title: "Go", Author: "Rob", BookStatus: 1, BookAttrs: models.BookAttrs{Rating: 11}}
+
+	// only records may leave the rating out
+	rows := []Row{{Line: 1, Book: unrated, Unrated: true}, {Line: 2, Book: unrated}, {Line: 3, Book: outOfRange, Unrated: true}}
+	assert.NoError(t, Run(store, job, rows, 10))
+
+	assert.Equal(t, 1, job.ImportedRows)
+	assert.Equal(t, 0, store.batches[0][0].BookAttrs.Rating)
+	assert.Equal(t, models.BookImportRowErrors{{Line: 2, Error: "Rating: min"}, {Line: 3, Error: "Rating: max"}}, job.RowErrors)
+}
+
+func TestRun_DryRun(t *testing.T) {
+	store := &fakeStore{}
+	job := &models.BookImportJob{ID: uuid.New(), DryRun: true}
+
+	assert.NoError(t, Run(store, job, testRows(), 2))
+
+	assert.Empty(t, store.batches)
+	assert.Equal(t, 0, job.ImportedRows)
+	assert.Equal(t, 5, job.ProcessedRows)
+	assert.Len(t, job.RowErrors, 2)
+	assert.Equal(t, repository.JobStatusDone, job.Status)
+}
+
+func TestRun_ISBN(t *testing.T) {
+	store := &fakeStore{}
+	job := &models.BookImportJob{ID: uuid.New(), TenantID: uuid.New(), UserID: uuid.New()}
+	book := models.BookCreate{Title: "Go", Author: "Rob", BookStatus: 1, BookAttrs: models.BookAttrs{Rating: 8}}
+	withISBN, withBadISBN, withSameISBN := book, book, book
+	withISBN.ISBN = "0-306-40615-2"
+	withBadISBN.ISBN = "0-306-40615-3"
+	withSameISBN.ISBN = "9780306406157"
+
+	rows := []Row{{Line: 2, Book: withISBN}, {Line: 3, Book: withBadISBN}, {Line: 4, Book: book}, {Line: 5, Book: withSameISBN}}
+	assert.NoError(t, Run(store, job, rows, 2))
+
+	assert.Equal(t, "9780306406157", store.batches[0][0].ISBN)
+	assert.Len(t, store.batches[1], 1)
+	assert.Equal(t, 2, job.ImportedRows)
+	assert.Equal(t, models.BookImportRowErrors{{Line: 3, Error: "ISBN: isbn"}, {Line: 5, Error: "ISBN: already on line 2"}}, job.RowErrors)
+	assert.Equal(t, [][]string{{"9780306406157"}}, store.lookups)
+}
+
+func TestRun_TakenISBN(t *testing.T) {
+	book := models.BookCreate{Title: "Go", Author: "Rob", BookStatus: 1, BookAttrs: models.BookAttrs{Rating: 8}}
+	taken, raced, free := book, book, book
+	taken.ISBN = "0-306-40615-2"
+	raced.ISBN = "978-1-4028-9462-6"
+	free.ISBN = "9780262033848"
+	rows := []Row{{Line: 2, Book: taken}, {Line: 3, Book: raced}, {Line: 4, Book: free}}
+
+	// books of the organization are found before inserting, in dry runs too
+	for _, dryRun := range []bool{false, true} {
+		store := &fakeStore{taken: []string{"9780306406157"}}
+		job := &models.BookImportJob{ID: uuid.New(), TenantID: uuid.New(), DryRun: dryRun}
+
+		assert.NoError(t, Run(store, job, rows[:1], 10))
+		assert.Empty(t, store.batches)
+		assert.Equal(t, models.BookImportRowErrors{{Line: 2, Error: repository.ISBNExistsErrorMessage}}, job.RowErrors)
+	}
+
+	// a book taking the ISBN meanwhile only fails its own row
+	store := &fakeStore{failISBN: "9781402894626"}
+	job := &models.BookImportJob{ID: uuid.New(), TenantID: uuid.New()}
+
+	assert.NoError(t, Run(store, job, rows[1:], 10))
+	assert.Len(t, store.batches, 3)
+	assert.Equal(t, 1, job.ImportedRows)
+	assert.Equal(t, models.BookImportRowErrors{{Line: 3, Error: repository.ISBNExistsErrorMessage}}, job.RowErrors)
+}
+
+func TestParse_Interchange(t *testing.T) {
+	file := "TY  - BOOK\nTI  - Go\nAU  - Pike, Rob\nKW  - golang\nER  - \n\nTY  - BOOK\nTI  - Rust\nKW  - rust\n"
+
+	assert.True(t, IsFormat(repository.BookImportFormatRIS))
+	assert.False(t, IsFormat("xml"))
+
+	rows, err := Parse(repository.BookImportFormatRIS, strings.NewReader(file))
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+
+	assert.Equal(t, 1, rows[0].Line)
+	assert.NoError(t, rows[0].Err)
+	assert.Equal(t, "Rob Pike", rows[0].Book.Author)
+	assert.True(t, rows[0].Unrated)
+	assert.Equal(t, []string{"KW"}, rows[0].Unmapped)
+	assert.Equal(t, 2, rows[1].Line)
+	assert.Error(t, rows[1].Err)
+
+	assert.Equal(t, models.BookImportUnmappedFields{"KW": 1}, UnmappedFields(rows))
+}
@@ -0,0 +1,437 @@
+package book_interchange
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+	"golang.org/x/text/unicode/norm"
+)
+
+// bibtexMonths are the month macros BibTeX predefines.
+var bibtexMonths = map[string]string{
+	"jan": "1", "feb": "2", "mar": "3", "apr": "4", "may": "5", "jun": "6",
+	"jul": "7", "aug": "8", "sep": "9", "oct": "10", "nov": "11", "dec": "12",
+}
+
+// bibtexParser reads the entries of a BibTeX file one at a time.
+type bibtexParser struct {
+	data    []byte
+	pos     int
+	strings map[string]string
+}
+
+// ParseBibTeX reads the entries of a BibTeX file, of any entry type. @string
+// macros are expanded, @comment and @preamble are skipped. An entry that
+// cannot be read is reported and the next one is read from the next "@".
+func ParseBibTeX(r io.Reader) ([]Record, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &bibtexParser{data: data, strings: map[string]string{}}
+	records := []Record{}
+	for {
+		start := bytes.IndexByte(p.data[p.pos:], '@')
+		if start < 0 {
+			return records, nil
+		}
+		p.pos += start + 1
+
+		entryType := strings.ToLower(p.identifier())
+		p.skipSpace()
+		if p.pos >= len(p.data) || (p.data[p.pos] != '{' && p.data[p.pos] != '(') {
+			records = append(records, Record{Err: fmt.Errorf("BibTeX entry @%s has no body", entryType)})
+			continue
+		}
+
+		switch entryType {
+		case "comment", "preamble":
+			if _, err := p.braced(); err != nil {
+				return records, nil
+			}
+		case "string":
+			p.pos++
+			if _, err := p.fields(func(name, value string) { p.strings[name] = value }); err != nil {
+				records = append(records, Record{Err: err})
+			}
+		default:
+			p.pos++
+			fields := map[string]string{}
+			var order []string
+			key, err := p.fields(func(name, value string) {
+				if _, ok := fields[name]; !ok {
+					order = append(order, name)
+				}
+				fields[name] = value
+			})
+			if err != nil {
+				records = append(records, Record{Err: fmt.Errorf("BibTeX entry %q: %w", key, err)})
+				continue
+			}
+			records = append(records, bibtexRecord(fields, order))
+		}
+	}
+}
+
+func (p *bibtexParser) skipSpace() {
+	for p.pos < len(p.data) && unicode.IsSpace(rune(p.data[p.pos])) {
+		p.pos++
+	}
+}
+
+// identifier reads an entry type, key, field or macro name.
+func (p *bibtexParser) identifier() string {
+	start := p.pos
+	for p.pos < len(p.data) && !strings.ContainsRune(" \t\r\n{}(),=#\"", rune(p.data[p.pos])) {
+		p.pos++
+	}
+
+	return string(p.data[start:p.pos])
+}
+
+// braced reads a {...} or (...) group, returning what is between the outer
+// delimiters.
+func (p *bibtexParser) braced() (string, error) {
+	open, end := p.data[p.pos], byte('}')
+	if open == '(' {
+		end = ')'
+	}
+
+	start, depth := p.pos+1, 0
+	for ; p.pos < len(p.data); p.pos++ {
+		switch c := p.data[p.pos]; {
+		case c == '\\':
+			p.pos++
+		case c == open:
+			depth++
+		case c == end:
+			depth--
+			if depth == 0 {
+				p.pos++
+				return string(p.data[start : p.pos-1]), nil
+			}
+		}
+	}
+
+	return "", io.ErrUnexpectedEOF
+}
+
+// fields reads the key, when there is one, and the name = value pairs of an
+// entry body up to its closing brace, calling field with each pair.
+func (p *bibtexParser) fields(field func(name, value string)) (string, error) {
+	key := ""
+	p.skipSpace()
+	mark := p.pos
+	name := p.identifier()
+	p.skipSpace()
+	if p.pos < len(p.data) && p.data[p.pos] == ',' {
+		key = name
+		p.pos++
+	} else {
+		p.pos = mark
+	}
+
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.data) {
+			return key, io.ErrUnexpectedEOF
+		}
+		if p.data[p.pos] == '}' || p.data[p.pos] == ')' {
+			p.pos++
+			return key, nil
+		}
+
+		name := strings.ToLower(p.identifier())
+		p.skipSpace()
+		if name == "" || p.pos >= len(p.data) || p.data[p.pos] != '=' {
+			return key, fmt.Errorf("expected a field name and \"=\" at offset %d", p.pos)
+		}
+		p.pos++
+
+		value, err := p.value()
+		if err != nil {
+			return key, err
+		}
+		field(name, value)
+
+		p.skipSpace()
+		if p.pos < len(p.data) && p.data[p.pos] == ',' {
+			p.pos++
+		}
+	}
+}
+
+// value reads a field value: braced or quoted text, a number or a macro,
+// concatenated with "#". The markup of braced text is kept.
+func (p *bibtexParser) value() (string, error) {
+	value := &strings.Builder{}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.data) {
+			return "", io.ErrUnexpectedEOF
+		}
+
+		switch c := p.data[p.pos]; {
+		case c == '{':
+			part, err := p.braced()
+			if err != nil {
+				return "", err
+			}
+			value.WriteString(part)
+		case c == '"':
+			start, depth := p.pos+1, 0
+			for p.pos++; p.pos < len(p.data) && (p.data[p.pos] != '"' || depth > 0); p.pos++ {
+				switch p.data[p.pos] {
+				case '\\':
+					p.pos++
+				case '{':
+					depth++
+				case '}':
+					depth--
+				}
+			}
+			if p.pos >= len(p.data) {
+				return "", io.ErrUnexpectedEOF
+			}
+			value.Write(p.data[start:p.pos])
+			p.pos++
+		default:
+			word := p.identifier()
+			if word == "" {
+				return "", fmt.Errorf("expected a value at offset %d", p.pos)
+			}
+			if expanded, ok := p.strings[strings.ToLower(word)]; ok {
+				word = expanded
+			} else if month, ok := bibtexMonths[strings.ToLower(word)]; ok {
+				word = month
+			}
+			value.WriteString(word)
+		}
+
+		p.skipSpace()
+		if p.pos >= len(p.data) || p.data[p.pos] != '#' {
+			return value.String(), nil
+		}
+		p.pos++
+	}
+}
+
+// bibtexAnd splits name lists on the "and" outside braces.
+var bibtexAnd = regexp.MustCompile(`(?i)\s+and\s+`)
+
+// splitNames splits a BibTeX name list, keeping braced corporate names like
+// "{Barnes and Noble}" whole.
+func splitNames(names string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(names); i++ {
+		switch names[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		}
+		if depth == 0 {
+			if match := bibtexAnd.FindStringIndex(names[i:]); match != nil && match[0] == 0 && i > start {
+				parts = append(parts, names[start:i])
+				start = i + match[1]
+				i = start - 1
+			}
+		}
+	}
+
+	return append(parts, names[start:])
+}
+
+// latexAccents are the combining marks of the LaTeX accent commands, like
+// the acute accent of {\'e}.
+var latexAccents = map[byte]rune{
+	'\'': '\u0301', '`': '\u0300', '^': '\u0302', '"': '\u0308', '~': '\u0303', '=': '\u0304', '.': '\u0307', 'c': '\u0327',
+}
+
+// latexAccent matches an accent command and the letter it applies to.
+var latexAccent = regexp.MustCompile(`^\\(?:(['` + "`" + `^"~=.])\s*(?:\{(\pL)\}|(\pL))|(c)\{(\pL)\})`)
+
+// latexText turns the LaTeX markup of a value into plain text: escaped
+// characters are unescaped, accents applied and the braces protecting case
+// dropped.
+func latexText(s string) string {
+	s = strings.ReplaceAll(s, `\textbackslash{}`, "\x00")
+	text := &strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s) && strings.ContainsRune(`&%$#_{}`, rune(s[i+1])):
+			i++
+			text.WriteByte(s[i])
+		case c == '\\' && latexAccent.MatchString(s[i:]):
+			match := latexAccent.FindStringSubmatch(s[i:])
+			text.WriteString(match[2] + match[3] + match[5])
+			text.WriteRune(latexAccents[(match[1] + match[4])[0]])
+			i += len(match[0]) - 1
+		case c == '{' || c == '}':
+		default:
+			text.WriteByte(c)
+		}
+	}
+
+	return norm.NFC.String(strings.ReplaceAll(strings.Join(strings.Fields(text.String()), " "), "\x00", `\`))
+}
+
+// latexEscape escapes the characters LaTeX gives a meaning to.
+var latexEscape = strings.NewReplacer(
+	`\`, `\textbackslash{}`, `{`, `\{`, `}`, `\}`, `&`, `\&`, `%`, `\%`, `$`, `\$`, `#`, `\#`, `_`, `\_`,
+)
+
+// bibtexRecord maps the fields of an entry onto a book.
+func bibtexRecord(fields map[string]string, order []string) Record {
+	record := newRecord()
+	book := &record.Book
+	field := func(name string) string {
+		return latexText(fields[name])
+	}
+
+	for _, name := range order {
+		switch name {
+		case "title":
+			book.Title = title(field("title"), field("subtitle"))
+		case "subtitle", "year", "month", "day":
+		case "author":
+			book.Author = byline(mapStrings(splitNames(fields["author"]), latexText))
+		case "publisher":
+			book.Publisher = field("publisher")
+		case "date":
+			parts := strings.SplitN(field("date"), "-", 3)
+			book.PublicationDate = publicationDate(parts[0], part(parts, 1), part(parts, 2))
+		case "isbn":
+			isbn, qualifier, _ := strings.Cut(field("isbn"), " ")
+			book.ISBN = isbn
+			if book.BookFormat == "" {
+				book.BookFormat = bookFormat(qualifier)
+			}
+		case "edition":
+			book.Edition = field("edition")
+		case "language", "langid":
+			if book.Language = languageName(field(name)); book.Language == "" {
+				record.unmapped(name)
+			}
+		case "pagetotal":
+			book.PageCount = pages(field("pagetotal"))
+		case "pages":
+			if count, err := strconv.Atoi(field("pages")); err == nil && book.PageCount == 0 {
+				book.PageCount = count
+			} else {
+				record.unmapped(name)
+			}
+		case "abstract":
+			book.BookAttrs.Description = field("abstract")
+		default:
+			record.unmapped(name)
+		}
+	}
+
+	if book.PublicationDate == nil {
+		book.PublicationDate = publicationDate(field("year"), field("month"), field("day"))
+	}
+
+	return record
+}
+
+func part(parts []string, i int) string {
+	if i < len(parts) {
+		return parts[i]
+	}
+
+	return ""
+}
+
+func mapStrings(values []string, fn func(string) string) []string {
+	mapped := make([]string, len(values))
+	for i, value := range values {
+		mapped[i] = fn(value)
+	}
+
+	return mapped
+}
+
+// namedLanguages are the languages whose English name, as BibTeX and RIS
+// files spell them, is recognized.
+var namedLanguages = func() map[string]string {
+	names := map[string]string{}
+	for _, tag := range []string{
+		"ar", "ca", "cs", "da", "de", "el", "en", "es", "fi", "fr", "he", "hi", "hu", "it", "ja", "ko",
+		"la", "nb", "nl", "no", "pl", "pt", "ro", "ru", "sv", "tr", "uk", "zh",
+	} {
+		names[strings.ToLower(display.English.Languages().Name(language.MustParse(tag)))] = tag
+	}
+	names["ngerman"], names["american"], names["british"], names["brazilian"] = "de", "en-US", "en-GB", "pt-BR"
+
+	return names
+}()
+
+// languageName returns the BCP 47 tag of a language given by its tag or by
+// its English name, "" when it is neither.
+func languageName(name string) string {
+	if tag, ok := namedLanguages[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return tag
+	}
+	if len(strings.TrimSpace(name)) > 8 {
+		return ""
+	}
+
+	return languageTag(name)
+}
+
+// englishName returns the English name of the language of a BCP 47 tag.
+func englishName(tag string) string {
+	base, _ := language.Make(tag).Base()
+	return display.English.Languages().Name(base)
+}
+
+type bibtexWriter struct {
+	w io.Writer
+}
+
+// Write writes the book as a @book entry keyed by its ID.
+func (w *bibtexWriter) Write(b models.Book) error {
+	buffer := bufio.NewWriter(w.w)
+	fmt.Fprintf(buffer, "@book{%s,\n", b.ID)
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(buffer, "  %s = {%s},\n", name, latexEscape.Replace(value))
+		}
+	}
+
+	field("author", b.Author)
+	field("title", b.Title)
+	field("edition", b.Edition)
+	field("publisher", b.Publisher)
+	if b.PublicationDate != nil {
+		field("year", b.PublicationDate.Format("2006"))
+		field("date", b.PublicationDate.Format("2006-01-02"))
+	}
+	field("isbn", b.ISBN)
+	if b.Language != "" {
+		field("language", strings.ToLower(englishName(b.Language)))
+	}
+	if b.PageCount > 0 {
+		field("pagetotal", strconv.Itoa(b.PageCount))
+	}
+	field("abstract", b.BookAttrs.Description)
+	buffer.WriteString("}\n\n")
+
+	return buffer.Flush()
+}
+
+func (w *bibtexWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,73 @@
+package book_interchange
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBibTeX(t *testing.T) {
+	records := parseSample(t, repository.BookImportFormatBibTeX, "sample.bib")
+	assert.Len(t, records, 3)
+
+	assert.NoError(t, records[0].Err)
+	assert.Equal(t, models.BookCreate{
+		Title:      "The Hobbit: There and Back Again",
+		Author:     "J. R. R. Tolkien",
+		BookStatus: 1,
+		BookAttrs:  models.BookAttrs{Description: "A hobbit, a wizard & thirteen dwarves go after 100% of a dragon's hoard."},
+		BookMetadata: models.BookMetadata{
+			ISBN:            "0-306-40615-2",
+			Publisher:       "Plenum Press, New York",
+			PublicationDate: date(1994, time.March, 1),
+			Language:        "en",
+			PageCount:       412,
+			Edition:         "2nd",
+		},
+	}, records[0].Book)
+	assert.Equal(t, []string{"keywords"}, records[0].Unmapped)
+
+	assert.NoError(t, records[1].Err)
+	assert.Equal(t, "Ensaio sobre a cegueira", records[1].Book.Title)
+	assert.Equal(t, "José Saramago, Caminho and Sons and Ana Pereira", records[1].Book.Author)
+	assert.Equal(t, date(1995, time.October, 25), records[1].Book.PublicationDate)
+	assert.Equal(t, "pt", records[1].Book.Language)
+	assert.Equal(t, 310, records[1].Book.PageCount)
+	assert.Equal(t, []string{"url"}, records[1].Unmapped)
+
+	assert.ErrorContains(t, records[2].Err, `"broken"`)
+}
+
+func TestLatexText(t *testing.T) {
+	assert.Equal(t, "Gödel, Escher, Bach", latexText(`G{\"o}del, Escher, Bach`))
+	assert.Equal(t, "Français à la carte", latexText("Fran\\c{c}ais \\`a la carte"))
+	assert.Equal(t, `C:\ & {x}`, latexText(`C:\textbackslash{} \& \{x\}`))
+}
+
+func TestBibTeXWriter(t *testing.T) {
+	b := testBook()
+	record := roundTrip(t, repository.BookExportFormatBibTeX, b)
+
+	assert.Equal(t, b.Title, record.Book.Title)
+	assert.Equal(t, b.Author, record.Book.Author)
+	assert.Equal(t, "Line one line two", record.Book.BookAttrs.Description)
+	assert.Equal(t, b.ISBN, record.Book.ISBN)
+	assert.Equal(t, b.Publisher, record.Book.Publisher)
+	assert.Equal(t, b.PublicationDate, record.Book.PublicationDate)
+	assert.Equal(t, "pt", record.Book.Language)
+	assert.Equal(t, b.PageCount, record.Book.PageCount)
+	assert.Equal(t, b.Edition, record.Book.Edition)
+	assert.Empty(t, record.Unmapped)
+
+	out := &bytes.Buffer{}
+	writer, _ := NewWriter(repository.BookExportFormatBibTeX, out)
+	assert.NoError(t, writer.Write(b))
+	assert.True(t, strings.HasPrefix(out.String(), "@book{"+b.ID.String()+",\n"))
+	assert.Contains(t, out.String(), `  title = {Go \& Rust: 100\% safe\_code \{v2\}},`)
+	assert.Contains(t, out.String(), "  language = {portuguese},")
+}
@@ -0,0 +1,317 @@
+package book_interchange
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"golang.org/x/text/language"
+)
+
+// Record is a book read from a library interchange file. Unmapped names the
+// fields of the record no book field holds, like MARC tags ("650"), ONIX
+// composites ("DescriptiveDetail/Subject"), BibTeX fields ("keywords") or
+// RIS tags ("KW"). Err is set when the record itself could not be read.
+type Record struct {
+	Book     models.BookCreate
+	Unmapped []string
+	Err      error
+}
+
+// unmapped adds a field to the report of the record, once.
+func (r *Record) unmapped(field string) {
+	for _, name := range r.Unmapped {
+		if name == field {
+			return
+		}
+	}
+	r.Unmapped = append(r.Unmapped, field)
+}
+
+// newRecord returns the defaults of a record. Books are active.
+func newRecord() Record {
+	return Record{Book: models.BookCreate{BookStatus: 1}}
+}
+
+// IsFormat reports whether format is one of the library interchange
+// formats.
+func IsFormat(format string) bool {
+	switch format {
+	case repository.BookImportFormatMARC, repository.BookImportFormatMARCXML, repository.BookImportFormatONIX,
+		repository.BookImportFormatBibTeX, repository.BookImportFormatRIS:
+		return true
+	}
+
+	return false
+}
+
+// Parse reads all the records of a file in format. It only fails when the
+// file cannot be read as a whole.
+func Parse(format string, r io.Reader) ([]Record, error) {
+	switch format {
+	case repository.BookImportFormatMARC:
+		return ParseMARC(r)
+	case repository.BookImportFormatMARCXML:
+		return ParseMARCXML(r)
+	case repository.BookImportFormatONIX:
+		return ParseONIX(r)
+	case repository.BookImportFormatBibTeX:
+		return ParseBibTeX(r)
+	case repository.BookImportFormatRIS:
+		return ParseRIS(r)
+	}
+
+	return nil, fmt.Errorf("unknown interchange format %q", format)
+}
+
+// SniffXML tells MARCXML and ONIX files apart by their root element. It
+// returns "" for anything else.
+func SniffXML(data []byte) string {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return ""
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			switch start.Name.Local {
+			case "collection", "record":
+				return repository.BookImportFormatMARCXML
+			case "ONIXMessage":
+				return repository.BookImportFormatONIX
+			}
+			return ""
+		}
+	}
+}
+
+// Writer encodes books one at a time. Close has to be called to complete the
+// file.
+type Writer interface {
+	Write(b models.Book) error
+	Close() error
+}
+
+// NewWriter returns a Writer encoding books in format to w.
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case repository.BookExportFormatMARC:
+		return &marcWriter{w}, nil
+	case repository.BookExportFormatMARCXML:
+		return newMARCXMLWriter(w)
+	case repository.BookExportFormatONIX:
+		return newONIXWriter(w, time.Now())
+	case repository.BookExportFormatBibTeX:
+		return &bibtexWriter{w}, nil
+	case repository.BookExportFormatRIS:
+		return &risWriter{w}, nil
+	}
+
+	return nil, fmt.Errorf("unknown interchange format %q", format)
+}
+
+// ContentType returns the media type of a file in format.
+func ContentType(format string) string {
+	switch format {
+	case repository.BookExportFormatMARC:
+		return "application/marc"
+	case repository.BookExportFormatMARCXML:
+		return "application/marcxml+xml"
+	case repository.BookExportFormatONIX:
+		return "application/xml"
+	case repository.BookExportFormatBibTeX:
+		return "application/x-bibtex; charset=utf-8"
+	case repository.BookExportFormatRIS:
+		return "application/x-research-info-systems"
+	}
+
+	return "application/octet-stream"
+}
+
+// Extension returns the usual file name extension of format.
+func Extension(format string) string {
+	switch format {
+	case repository.BookExportFormatMARC:
+		return "mrc"
+	case repository.BookExportFormatMARCXML, repository.BookExportFormatONIX:
+		return "xml"
+	case repository.BookExportFormatBibTeX:
+		return "bib"
+	}
+
+	return format
+}
+
+// byline joins author names the way a byline reads, "A", "A and B" or
+// "A, B and C", turning inverted names around first.
+func byline(names []string) string {
+	display := make([]string, 0, len(names))
+	for _, name := range names {
+		if name = displayName(name); name != "" {
+			display = append(display, name)
+		}
+	}
+
+	switch len(display) {
+	case 0:
+		return ""
+	case 1:
+		return display[0]
+	}
+
+	return strings.Join(display[:len(display)-1], ", ") + " and " + display[len(display)-1]
+}
+
+// displayName turns an inverted name like "Tolkien, J. R. R.," into
+// "J. R. R. Tolkien".
+func displayName(name string) string {
+	name = trimPunctuation(name)
+	if last, first, ok := strings.Cut(name, ","); ok && !strings.Contains(first, ",") {
+		return strings.TrimSpace(first) + " " + strings.TrimSpace(last)
+	}
+
+	return name
+}
+
+// initial matches a name that ends with an initial, whose period is kept.
+var initial = regexp.MustCompile(`(^|[\s.])\p{Lu}\.$`)
+
+// trimPunctuation drops the ISBD punctuation cataloguers end fields with,
+// like the " /" after a title or the "," after a name.
+func trimPunctuation(s string) string {
+	for {
+		s = strings.TrimSpace(s)
+		trimmed := strings.TrimRight(s, "/:;,=")
+		if strings.HasSuffix(trimmed, ".") && !initial.MatchString(trimmed) {
+			trimmed = strings.TrimSuffix(trimmed, ".")
+		}
+		if trimmed == s {
+			return s
+		}
+		s = trimmed
+	}
+}
+
+// languageTag returns the BCP 47 tag of a language code, like the ISO 639-2
+// codes of MARC and ONIX, or "" when it is not one.
+func languageTag(code string) string {
+	tag, err := language.Parse(strings.TrimSpace(code))
+	if err != nil || tag == language.Und {
+		return ""
+	}
+
+	return tag.String()
+}
+
+// bibliographicCodes are the ISO 639-2 codes MARC and ONIX use where they
+// differ from the terminology codes.
+var bibliographicCodes = map[string]string{
+	"sqi": "alb", "hye": "arm", "eus": "baq", "mya": "bur", "zho": "chi", "ces": "cze", "nld": "dut",
+	"fra": "fre", "kat": "geo", "deu": "ger", "ell": "gre", "isl": "ice", "mkd": "mac", "mri": "mao",
+	"msa": "may", "fas": "per", "ron": "rum", "slk": "slo", "bod": "tib", "cym": "wel",
+}
+
+// languageCode returns the ISO 639-2 code of a BCP 47 tag, "und" when
+// unknown.
+func languageCode(tag string) string {
+	base, confidence := language.Make(tag).Base()
+	if tag == "" || confidence == language.No {
+		return "und"
+	}
+
+	code := base.ISO3()
+	if bibliographic, ok := bibliographicCodes[code]; ok {
+		return bibliographic
+	}
+
+	return code
+}
+
+// year finds the year in a date as cataloguers write it, like "c1994." or
+// "[1994?]".
+var year = regexp.MustCompile(`(?:^|\D)(\d{4})(?:\D|$)`)
+
+// publicationDate builds a date from the year, month and day parts found in
+// a record. A missing month or day is read as the first one, a missing or
+// unreadable year gives no date.
+func publicationDate(y, m, d string) *models.Date {
+	match := year.FindStringSubmatch(y)
+	if match == nil {
+		return nil
+	}
+
+	yearNumber, _ := strconv.Atoi(match[1])
+	month := time.January
+	if number, err := strconv.Atoi(strings.TrimSpace(m)); err == nil && number >= 1 && number <= 12 {
+		month = time.Month(number)
+	} else if m = strings.ToLower(strings.TrimSpace(m)); len(m) >= 3 {
+		for candidate := time.January; candidate <= time.December; candidate++ {
+			if strings.HasPrefix(strings.ToLower(candidate.String()), m[:3]) {
+				month = candidate
+			}
+		}
+	}
+	day := 1
+	if number, err := strconv.Atoi(strings.TrimSpace(d)); err == nil && number >= 1 && number <= 31 {
+		day = number
+	}
+
+	date := time.Date(yearNumber, month, day, 0, 0, 0, 0, time.UTC)
+	if date.Month() != month {
+		date = time.Date(yearNumber, month, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	return &models.Date{Time: date}
+}
+
+// pageCount reads the number of pages of an extent like "xii, 412 p. :".
+var pageCount = regexp.MustCompile(`(\d+)\s*(p\b|pages?\b)`)
+
+func pages(extent string) int {
+	match := pageCount.FindStringSubmatch(extent)
+	if match == nil {
+		if number, err := strconv.Atoi(strings.TrimSpace(extent)); err == nil && number > 0 {
+			return number
+		}
+		return 0
+	}
+
+	number, _ := strconv.Atoi(match[1])
+	return number
+}
+
+// bookFormat reads the binding or medium of an edition from a qualifier
+// like "(pbk.)" or "hardcover", "" when it says neither.
+func bookFormat(qualifier string) string {
+	qualifier = strings.ToLower(qualifier)
+	switch {
+	case strings.Contains(qualifier, "hardcover"), strings.Contains(qualifier, "hardback"),
+		strings.Contains(qualifier, "hbk"), strings.Contains(qualifier, "cloth"):
+		return repository.BookFormatHardcover
+	case strings.Contains(qualifier, "paperback"), strings.Contains(qualifier, "pbk"):
+		return repository.BookFormatPaperback
+	case strings.Contains(qualifier, "ebook"), strings.Contains(qualifier, "e-book"):
+		return repository.BookFormatEbook
+	case strings.Contains(qualifier, "audiobook"), strings.Contains(qualifier, "audio"):
+		return repository.BookFormatAudiobook
+	}
+
+	return ""
+}
+
+// title joins a title and its subtitle.
+func title(main, subtitle string) string {
+	main, subtitle = trimPunctuation(main), trimPunctuation(subtitle)
+	if subtitle == "" {
+		return main
+	}
+
+	return main + ": " + subtitle
+}
@@ -0,0 +1,120 @@
+package book_interchange
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseSample parses a file of testdata in format.
+func parseSample(t *testing.T, format, name string) []Record {
+	t.Helper()
+
+	file, err := os.Open(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	defer file.Close()
+
+	records, err := Parse(format, file)
+	require.NoError(t, err)
+
+	return records
+}
+
+func date(y int, m time.Month, d int) *models.Date {
+	return &models.Date{Time: time.Date(y, m, d, 0, 0, 0, 0, time.UTC)}
+}
+
+func testBook() models.Book {
+	return models.Book{
+		ID:         uuid.New(),
+		CreatedAt:  time.Date(2025, time.October, 19, 10, 0, 0, 0, time.UTC),
+		Title:      "Go & Rust: 100% safe_code {v2}",
+		Author:     "Rob Pike and Ken Thompson",
+		BookStatus: 1,
+		BookAttrs:  models.BookAttrs{Description: "Line one\nline two"},
+		BookMetadata: models.BookMetadata{
+			ISBN:            "9780306406157",
+			Publisher:       "Plenum",
+			PublicationDate: date(1994, time.March, 1),
+			Language:        "pt-BR",
+			PageCount:       412,
+			Edition:         "2nd",
+			BookFormat:      repository.BookFormatHardcover,
+		},
+	}
+}
+
+// roundTrip writes the book in format and reads it back.
+func roundTrip(t *testing.T, format string, b models.Book) Record {
+	t.Helper()
+
+	out := &bytes.Buffer{}
+	writer, err := NewWriter(format, out)
+	require.NoError(t, err)
+	require.NoError(t, writer.Write(b))
+	require.NoError(t, writer.Close())
+
+	records, err := Parse(format, out)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.NoError(t, records[0].Err)
+
+	return records[0]
+}
+
+func TestSniffXML(t *testing.T) {
+	marcXML, _ := os.ReadFile(filepath.Join("testdata", "sample_marcxml.xml"))
+	onix, _ := os.ReadFile(filepath.Join("testdata", "sample_onix.xml"))
+
+	assert.Equal(t, repository.BookImportFormatMARCXML, SniffXML(marcXML))
+	assert.Equal(t, repository.BookImportFormatONIX, SniffXML(onix))
+	assert.Equal(t, "", SniffXML([]byte(`<?xml version="1.0"?><rss/>`)))
+	assert.Equal(t, "", SniffXML([]byte(`title,author`)))
+}
+
+func TestByline(t *testing.T) {
+	assert.Equal(t, "", byline(nil))
+	assert.Equal(t, "J. R. R. Tolkien", byline([]string{"Tolkien, J. R. R.,"}))
+	assert.Equal(t, "A and Bob", byline([]string{"A", "Bob."}))
+	assert.Equal(t, "A, B and C", byline([]string{"A", "", "B", "C"}))
+}
+
+func TestTrimPunctuation(t *testing.T) {
+	assert.Equal(t, "The hobbit", trimPunctuation("The hobbit :"))
+	assert.Equal(t, "or, There and back again", trimPunctuation("or, There and back again /"))
+	assert.Equal(t, "Plenum Press", trimPunctuation("Plenum Press,"))
+	assert.Equal(t, "2nd ed", trimPunctuation("2nd ed."))
+	assert.Equal(t, "Tolkien, J. R. R.", trimPunctuation("Tolkien, J. R. R.,"))
+}
+
+func TestPublicationDate(t *testing.T) {
+	assert.Equal(t, date(1994, time.January, 1), publicationDate("c1994.", "", ""))
+	assert.Equal(t, date(1994, time.March, 1), publicationDate("[1994?]", "Mar", ""))
+	assert.Equal(t, date(1995, time.October, 25), publicationDate("1995", "10", "25"))
+	assert.Equal(t, date(1995, time.February, 1), publicationDate("1995", "2", "30"))
+	assert.Nil(t, publicationDate("n.d.", "", ""))
+}
+
+func TestLanguageCode(t *testing.T) {
+	assert.Equal(t, "eng", languageCode("en-GB"))
+	assert.Equal(t, "fre", languageCode("fr"))
+	assert.Equal(t, "und", languageCode(""))
+	assert.Equal(t, "en", languageTag("eng"))
+	assert.Equal(t, "de", languageTag("ger"))
+	assert.Equal(t, "", languageTag("und"))
+}
+
+func TestExtension(t *testing.T) {
+	assert.Equal(t, "mrc", Extension(repository.BookExportFormatMARC))
+	assert.Equal(t, "xml", Extension(repository.BookExportFormatONIX))
+	assert.Equal(t, "bib", Extension(repository.BookExportFormatBibTeX))
+	assert.Equal(t, "ris", Extension(repository.BookExportFormatRIS))
+}
@@ -0,0 +1,487 @@
+package book_interchange
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/text"
+)
+
+// ISO 2709 separators.
+const (
+	subfieldDelimiter = 0x1f
+	fieldTerminator   = 0x1e
+	recordTerminator  = 0x1d
+)
+
+type marcRecord struct {
+	leader string
+	fields []marcField
+}
+
+// marcField is a control field (001 to 009), holding value, or a data field
+// with indicators and subfields.
+type marcField struct {
+	tag       string
+	ind1      byte
+	ind2      byte
+	value     string
+	subfields []marcSubfield
+}
+
+type marcSubfield struct {
+	code  byte
+	value string
+}
+
+func (f marcField) isControl() bool {
+	return f.tag < "010"
+}
+
+// subfield returns the first subfield with the code.
+func (f marcField) subfield(code byte) string {
+	for _, s := range f.subfields {
+		if s.code == code {
+			return s.value
+		}
+	}
+
+	return ""
+}
+
+// recordControlTags describe the record rather than the book, so they are
+// left out of the report of unmapped fields.
+var recordControlTags = map[string]bool{"001": true, "003": true, "005": true}
+
+// toRecord maps a MARC 21 bibliographic record onto a book. The first field
+// with each mapped tag is read, repeated ones are reported as unmapped.
+func (m marcRecord) toRecord() Record {
+	record := newRecord()
+	book := &record.Book
+	seen := map[string]bool{}
+	var authors []string
+	var date, fixedLanguage string
+	published := false
+
+	if len(m.leader) > 6 && m.leader[6] == 'i' {
+		book.BookFormat = repository.BookFormatAudiobook
+	}
+
+	for _, f := range m.fields {
+		first := !seen[f.tag]
+		seen[f.tag] = true
+
+		switch {
+		case recordControlTags[f.tag]:
+		case f.tag == "008" && first:
+			if len(f.value) >= 38 {
+				date = strings.Trim(f.value[7:11], " u|")
+				fixedLanguage = strings.TrimSpace(f.value[35:38])
+				if (f.value[23] == 'o' || f.value[23] == 's') && book.BookFormat == "" {
+					book.BookFormat = repository.BookFormatEbook
+				}
+			}
+		case f.tag == "020" && first && f.subfield('a') != "":
+			isbn, qualifier, _ := strings.Cut(strings.TrimSpace(f.subfield('a')), " ")
+			book.ISBN = isbn
+			if format := bookFormat(qualifier + " " + f.subfield('q')); format != "" && book.BookFormat == "" {
+				book.BookFormat = format
+			}
+		case f.tag == "041" && first && f.subfield('a') != "":
+			book.Language = languageTag(f.subfield('a'))
+		case (f.tag == "100" || f.tag == "110") && len(authors) == 0:
+			authors = append(authors, f.subfield('a'))
+		case f.tag == "700" && isAuthorRelator(f.subfield('e'), f.subfield('4')):
+			authors = append(authors, f.subfield('a'))
+		case f.tag == "245" && first:
+			book.Title = title(f.subfield('a'), f.subfield('b'))
+		case f.tag == "250" && first:
+			book.Edition = trimPunctuation(f.subfield('a'))
+		case (f.tag == "264" && f.ind2 == '1' || f.tag == "260") && !published:
+			published = true
+			book.Publisher = trimPunctuation(f.subfield('b'))
+			if c := f.subfield('c'); c != "" {
+				date = c
+			}
+		case f.tag == "300" && first:
+			book.PageCount = pages(f.subfield('a'))
+		case f.tag == "520" && first:
+			book.BookAttrs.Description = strings.TrimSpace(f.subfield('a'))
+		default:
+			record.unmapped(f.tag)
+		}
+	}
+
+	book.Author = byline(authors)
+	book.PublicationDate = publicationDate(date, "", "")
+	if book.Language == "" {
+		book.Language = languageTag(fixedLanguage)
+	}
+
+	return record
+}
+
+// isAuthorRelator reports whether an added entry credits an author: it has
+// no relator term or code, or they say author.
+func isAuthorRelator(term, code string) bool {
+	if term == "" && code == "" {
+		return true
+	}
+
+	return strings.HasPrefix(strings.ToLower(trimPunctuation(term)), "author") || code == "aut"
+}
+
+// marcRecordOf maps a book onto a MARC 21 bibliographic record.
+func marcRecordOf(b models.Book) marcRecord {
+	leader := []byte("     nam a22     7c 4500")
+	if b.BookFormat == repository.BookFormatAudiobook {
+		leader[6] = 'i'
+	}
+
+	// The fixed-length data elements of books: date entered, type of date
+	// and dates, place, form of item, language and cataloging source.
+	fixed := bytes.Repeat([]byte{' '}, 40)
+	copy(fixed[0:6], b.CreatedAt.Format("060102"))
+	copy(fixed[6:15], "nuuuuuuuu")
+	if b.PublicationDate != nil {
+		copy(fixed[6:11], "s"+b.PublicationDate.Format("2006"))
+	}
+	copy(fixed[15:18], "xx ")
+	if b.BookFormat == repository.BookFormatEbook {
+		fixed[23] = 'o'
+	}
+	copy(fixed[29:34], "000 0")
+	copy(fixed[35:38], languageCode(b.Language))
+	fixed[39] = 'd'
+
+	fields := []marcField{
+		{tag: "001", value: b.ID.String()},
+		{tag: "008", value: string(fixed)},
+	}
+	if b.ISBN != "" {
+		isbn := marcField{tag: "020", ind1: ' ', ind2: ' ', subfields: []marcSubfield{{'a', b.ISBN}}}
+		if b.BookFormat == repository.BookFormatHardcover || b.BookFormat == repository.BookFormatPaperback {
+			isbn.subfields = append(isbn.subfields, marcSubfield{'q', b.BookFormat})
+		}
+		fields = append(fields, isbn)
+	}
+	if b.Language != "" {
+		fields = append(fields, marcField{tag: "041", ind1: '0', ind2: ' ', subfields: []marcSubfield{{'a', languageCode(b.Language)}}})
+	}
+	titleIndicator := byte('0')
+	if b.Author != "" {
+		titleIndicator = '1'
+		fields = append(fields, marcField{tag: "100", ind1: '1', ind2: ' ', subfields: []marcSubfield{{'a', b.Author}}})
+	}
+	fields = append(fields, marcField{tag: "245", ind1: titleIndicator, ind2: '0', subfields: []marcSubfield{{'a', b.Title}}})
+	if b.Edition != "" {
+		fields = append(fields, marcField{tag: "250", ind1: ' ', ind2: ' ', subfields: []marcSubfield{{'a', b.Edition}}})
+	}
+	if b.Publisher != "" || b.PublicationDate != nil {
+		publication := marcField{tag: "264", ind1: ' ', ind2: '1'}
+		if b.Publisher != "" {
+			publication.subfields = append(publication.subfields, marcSubfield{'b', b.Publisher})
+		}
+		if b.PublicationDate != nil {
+			publication.subfields = append(publication.subfields, marcSubfield{'c', b.PublicationDate.Format("2006")})
+		}
+		fields = append(fields, publication)
+	}
+	if b.PageCount > 0 {
+		fields = append(fields, marcField{tag: "300", ind1: ' ', ind2: ' ', subfields: []marcSubfield{{'a', strconv.Itoa(b.PageCount) + " pages"}}})
+	}
+	if b.BookAttrs.Description != "" {
+		fields = append(fields, marcField{tag: "520", ind1: ' ', ind2: ' ', subfields: []marcSubfield{{'a', b.BookAttrs.Description}}})
+	}
+
+	return marcRecord{leader: string(leader), fields: fields}
+}
+
+// ParseMARC reads the records of a MARC 21 file in ISO 2709 format. Records
+// have to be encoded in UTF-8, MARC-8 is only read when it is plain ASCII.
+func ParseMARC(r io.Reader) ([]Record, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	records := []Record{}
+	for _, raw := range bytes.Split(data, []byte{recordTerminator}) {
+		raw = bytes.TrimLeft(raw, "\r\n ")
+		if len(raw) == 0 {
+			continue
+		}
+
+		m, err := decodeISO2709(raw)
+		if err != nil {
+			records = append(records, Record{Err: err})
+			continue
+		}
+		records = append(records, m.toRecord())
+	}
+
+	return records, nil
+}
+
+func decodeISO2709(raw []byte) (marcRecord, error) {
+	if len(raw) < 25 {
+		return marcRecord{}, errors.New("MARC record is shorter than its leader")
+	}
+
+	leader := string(raw[:24])
+	if leader[9] != 'a' && !isASCII(raw) {
+		return marcRecord{}, errors.New("MARC-8 encoded records are not supported, convert them to UTF-8")
+	}
+	if !utf8.Valid(raw) {
+		return marcRecord{}, errors.New("MARC record is not valid UTF-8")
+	}
+
+	base, err := strconv.Atoi(leader[12:17])
+	if err != nil || base <= 24 || base > len(raw) {
+		return marcRecord{}, fmt.Errorf("MARC leader has an invalid base address %q", leader[12:17])
+	}
+
+	directory := raw[24 : base-1]
+	if len(directory)%12 != 0 {
+		return marcRecord{}, errors.New("MARC directory is malformed")
+	}
+
+	m := marcRecord{leader: leader}
+	for entry := directory; len(entry) > 0; entry = entry[12:] {
+		length, lengthErr := strconv.Atoi(string(entry[3:7]))
+		start, startErr := strconv.Atoi(string(entry[7:12]))
+		if lengthErr != nil || startErr != nil || length <= 0 || start < 0 || base+start+length > len(raw) {
+			return marcRecord{}, fmt.Errorf("MARC directory entry %q is malformed", entry[:12])
+		}
+
+		data := bytes.TrimSuffix(raw[base+start:base+start+length], []byte{fieldTerminator})
+		f := marcField{tag: string(entry[:3])}
+		if f.isControl() {
+			f.value = string(data)
+		} else {
+			if len(data) < 2 {
+				return marcRecord{}, fmt.Errorf("MARC field %s has no indicators", f.tag)
+			}
+			f.ind1, f.ind2 = data[0], data[1]
+			for _, subfield := range bytes.Split(data[2:], []byte{subfieldDelimiter}) {
+				if len(subfield) > 0 {
+					f.subfields = append(f.subfields, marcSubfield{subfield[0], string(subfield[1:])})
+				}
+			}
+		}
+		m.fields = append(m.fields, f)
+	}
+
+	return m, nil
+}
+
+func isASCII(data []byte) bool {
+	for _, b := range data {
+		if b >= utf8.RuneSelf {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ISO 2709 limits, set by the widths of the lengths and offsets.
+const (
+	maxMARCFieldLength  = 9999
+	maxMARCRecordLength = 99999
+)
+
+// encodeISO2709 returns the record in ISO 2709 format, lengths and offsets
+// counted in bytes. Values too long for their field are cut, and the fields
+// that no longer fit in the record are left out.
+func encodeISO2709(m marcRecord) []byte {
+	directory, data := &bytes.Buffer{}, &bytes.Buffer{}
+	for _, f := range m.fields {
+		field := encodeMARCField(f)
+		if 24+directory.Len()+12+1+data.Len()+len(field)+1 > maxMARCRecordLength {
+			break
+		}
+		fmt.Fprintf(directory, "%s%04d%05d", f.tag, len(field), data.Len())
+		data.Write(field)
+	}
+	directory.WriteByte(fieldTerminator)
+
+	base := 24 + directory.Len()
+	length := base + data.Len() + 1
+	leader := []byte(m.leader)
+	copy(leader[0:5], fmt.Sprintf("%05d", length))
+	copy(leader[12:17], fmt.Sprintf("%05d", base))
+
+	record := make([]byte, 0, length)
+	record = append(record, leader...)
+	record = append(record, directory.Bytes()...)
+	record = append(record, data.Bytes()...)
+
+	return append(record, recordTerminator)
+}
+
+// encodeMARCField returns the field with its terminator, cutting the values
+// that go past maxMARCFieldLength.
+func encodeMARCField(f marcField) []byte {
+	room := maxMARCFieldLength - 1
+	field := &bytes.Buffer{}
+	if f.isControl() {
+		field.WriteString(text.Truncate(f.value, room))
+	} else {
+		field.WriteByte(f.ind1)
+		field.WriteByte(f.ind2)
+		room -= 2
+		for _, s := range f.subfields {
+			if room < 2 {
+				break
+			}
+			value := text.Truncate(s.value, room-2)
+			field.WriteByte(subfieldDelimiter)
+			field.WriteByte(s.code)
+			field.WriteString(value)
+			room -= 2 + len(value)
+		}
+	}
+	field.WriteByte(fieldTerminator)
+
+	return field.Bytes()
+}
+
+type marcWriter struct {
+	w io.Writer
+}
+
+func (w *marcWriter) Write(b models.Book) error {
+	_, err := w.w.Write(encodeISO2709(marcRecordOf(b)))
+	return err
+}
+
+func (w *marcWriter) Close() error {
+	return nil
+}
+
+// marcXMLNamespace is the namespace of MARC 21 XML, the MARCXML schema.
+const marcXMLNamespace = "http://www.loc.gov/MARC21/slim"
+
+type marcXMLRecord struct {
+	XMLName       xml.Name              `xml:"record"`
+	Leader        string                `xml:"leader"`
+	ControlFields []marcXMLControlField `xml:"controlfield"`
+	DataFields    []marcXMLDataField    `xml:"datafield"`
+}
+
+type marcXMLControlField struct {
+	Tag   string `xml:"tag,attr"`
+	Value string `xml:",chardata"`
+}
+
+type marcXMLDataField struct {
+	Tag       string            `xml:"tag,attr"`
+	Ind1      string            `xml:"ind1,attr"`
+	Ind2      string            `xml:"ind2,attr"`
+	Subfields []marcXMLSubfield `xml:"subfield"`
+}
+
+type marcXMLSubfield struct {
+	Code  string `xml:"code,attr"`
+	Value string `xml:",chardata"`
+}
+
+func indicator(s string) byte {
+	if s == "" {
+		return ' '
+	}
+
+	return s[0]
+}
+
+// ParseMARCXML reads the records of a MARCXML file, a collection of records
+// or a single one.
+func ParseMARCXML(r io.Reader) ([]Record, error) {
+	decoder := xml.NewDecoder(r)
+	records := []Record{}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "record" {
+			continue
+		}
+
+		x := marcXMLRecord{}
+		if err := decoder.DecodeElement(&x, &start); err != nil {
+			return nil, err
+		}
+
+		// MARCXML keeps the fields of each kind apart, the control fields
+		// come first in MARC anyway.
+		m := marcRecord{leader: x.Leader}
+		for _, f := range x.ControlFields {
+			m.fields = append(m.fields, marcField{tag: f.Tag, value: f.Value})
+		}
+		for _, f := range x.DataFields {
+			field := marcField{tag: f.Tag, ind1: indicator(f.Ind1), ind2: indicator(f.Ind2)}
+			for _, s := range f.Subfields {
+				if s.Code != "" {
+					field.subfields = append(field.subfields, marcSubfield{s.Code[0], s.Value})
+				}
+			}
+			m.fields = append(m.fields, field)
+		}
+		records = append(records, m.toRecord())
+	}
+}
+
+type marcXMLWriter struct {
+	w       io.Writer
+	encoder *xml.Encoder
+}
+
+func newMARCXMLWriter(w io.Writer) (*marcXMLWriter, error) {
+	if _, err := io.WriteString(w, xml.Header+`<collection xmlns="`+marcXMLNamespace+`">`+"\n"); err != nil {
+		return nil, err
+	}
+
+	return &marcXMLWriter{w, xml.NewEncoder(w)}, nil
+}
+
+func (w *marcXMLWriter) Write(b models.Book) error {
+	m := marcRecordOf(b)
+	x := marcXMLRecord{Leader: m.leader}
+	for _, f := range m.fields {
+		if f.isControl() {
+			x.ControlFields = append(x.ControlFields, marcXMLControlField{f.tag, f.value})
+			continue
+		}
+		field := marcXMLDataField{Tag: f.tag, Ind1: string(f.ind1), Ind2: string(f.ind2)}
+		for _, s := range f.subfields {
+			field.Subfields = append(field.Subfields, marcXMLSubfield{string(s.code), s.value})
+		}
+		x.DataFields = append(x.DataFields, field)
+	}
+
+	if err := w.encoder.Encode(x); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w.w, "\n")
+	return err
+}
+
+func (w *marcXMLWriter) Close() error {
+	_, err := io.WriteString(w.w, "</collection>\n")
+	return err
+}
@@ -0,0 +1,171 @@
+package book_interchange
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// assertHobbit checks the mapping of the first MARC record of testdata.
+func assertHobbit(t *testing.T, record Record) {
+	t.Helper()
+
+	assert.NoError(t, record.Err)
+	assert.Equal(t, models.BookCreate{
+		Title:      "The hobbit: or, There and back again",
+		Author:     "J. R. R. Tolkien",
+		BookStatus: 1,
+		BookAttrs:  models.BookAttrs{Description: "A hobbit is swept into a quest for treasure guarded by a dragon."},
+		BookMetadata: models.BookMetadata{
+			ISBN:            "0306406152",
+			Publisher:       "Plenum Press",
+			PublicationDate: date(1994, time.January, 1),
+			Language:        "en",
+			PageCount:       412,
+			Edition:         "2nd ed",
+			BookFormat:      repository.BookFormatHardcover,
+		},
+	}, record.Book)
+	assert.Equal(t, []string{"040", "650", "700"}, record.Unmapped)
+}
+
+func TestParseMARC(t *testing.T) {
+	records := parseSample(t, repository.BookImportFormatMARC, "sample.mrc")
+	assert.Len(t, records, 3)
+
+	assertHobbit(t, records[0])
+
+	assert.NoError(t, records[1].Err)
+	assert.Equal(t, "Ensaio sobre a cegueira: romance", records[1].Book.Title)
+	assert.Equal(t, "José Saramago and Ana Pereira", records[1].Book.Author)
+	assert.Equal(t, "9789722110211", records[1].Book.ISBN)
+	assert.Equal(t, repository.BookFormatPaperback, records[1].Book.BookFormat)
+	assert.Equal(t, "Caminho", records[1].Book.Publisher)
+	assert.Equal(t, date(1995, time.January, 1), records[1].Book.PublicationDate)
+	assert.Equal(t, "pt", records[1].Book.Language)
+	assert.Equal(t, 310, records[1].Book.PageCount)
+	assert.Empty(t, records[1].Unmapped)
+
+	assert.ErrorContains(t, records[2].Err, "base address")
+}
+
+func TestParseMARC_MARC8(t *testing.T) {
+	record := encodeISO2709(marcRecord{leader: "     nam  22     7c 4500", fields: []marcField{
+		{tag: "245", ind1: '0', ind2: '0', subfields: []marcSubfield{{'a', "Caf\xe2e"}}},
+	}})
+
+	records, err := ParseMARC(bytes.NewReader(record))
+	assert.NoError(t, err)
+	assert.ErrorContains(t, records[0].Err, "MARC-8")
+}
+
+func TestParseMARC_MalformedDirectory(t *testing.T) {
+	record := encodeISO2709(marcRecord{leader: "     nam a22     7a 4500", fields: []marcField{
+		{tag: "245", ind1: '0', ind2: '0', subfields: []marcSubfield{{'a', "The hobbit"}}},
+	}})
+	_, err := decodeISO2709(record)
+	assert.NoError(t, err)
+
+	// the entry is the tag, a length of 4 digits and an offset of 5
+	for _, entry := range []string{"245-00100000", "2450010-9999", "245000000000", "245001000-01"} {
+		raw := append([]byte{}, record...)
+		copy(raw[24:36], entry)
+
+		_, err := decodeISO2709(raw)
+		assert.ErrorContains(t, err, "is malformed", entry)
+	}
+}
+
+func TestParseMARCXML(t *testing.T) {
+	records := parseSample(t, repository.BookImportFormatMARCXML, "sample_marcxml.xml")
+	assert.Len(t, records, 1)
+
+	assertHobbit(t, records[0])
+
+	_, err := ParseMARCXML(strings.NewReader(`<collection><record><leader>`))
+	assert.Error(t, err)
+}
+
+func TestMARCWriter(t *testing.T) {
+	b := testBook()
+	record := roundTrip(t, repository.BookExportFormatMARC, b)
+
+	assert.Equal(t, b.Title, record.Book.Title)
+	assert.Equal(t, b.Author, record.Book.Author)
+	assert.Equal(t, b.BookAttrs.Description, record.Book.BookAttrs.Description)
+	assert.Equal(t, b.ISBN, record.Book.ISBN)
+	assert.Equal(t, b.Publisher, record.Book.Publisher)
+	assert.Equal(t, date(1994, time.January, 1), record.Book.PublicationDate)
+	assert.Equal(t, "pt", record.Book.Language)
+	assert.Equal(t, b.PageCount, record.Book.PageCount)
+	assert.Equal(t, b.Edition, record.Book.Edition)
+	assert.Equal(t, b.BookFormat, record.Book.BookFormat)
+	assert.Empty(t, record.Unmapped)
+
+	out := &bytes.Buffer{}
+	writer, _ := NewWriter(repository.BookExportFormatMARC, out)
+	assert.NoError(t, writer.Write(b))
+	assert.Equal(t, byte(recordTerminator), out.Bytes()[out.Len()-1])
+	assert.Equal(t, out.Len(), atoi(out.String()[:5]))
+}
+
+func TestMARCWriter_LongDescription(t *testing.T) {
+	b := testBook()
+	b.BookAttrs.Description = strings.Repeat("é", 20000)
+
+	out := &bytes.Buffer{}
+	writer, _ := NewWriter(repository.BookExportFormatMARC, out)
+	assert.NoError(t, writer.Write(b))
+	assert.LessOrEqual(t, out.Len(), maxMARCRecordLength)
+	assert.Equal(t, out.Len(), atoi(out.String()[:5]))
+
+	records, err := Parse(repository.BookImportFormatMARC, out)
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.NoError(t, records[0].Err)
+	assert.Equal(t, b.Title, records[0].Book.Title)
+	assert.True(t, strings.HasPrefix(b.BookAttrs.Description, records[0].Book.BookAttrs.Description))
+	assert.LessOrEqual(t, len(records[0].Book.BookAttrs.Description), maxMARCFieldLength)
+}
+
+func TestMARCWriter_Audiobook(t *testing.T) {
+	b := testBook()
+	b.BookFormat = repository.BookFormatAudiobook
+	b.PublicationDate = nil
+
+	record := roundTrip(t, repository.BookExportFormatMARC, b)
+	assert.Equal(t, repository.BookFormatAudiobook, record.Book.BookFormat)
+	assert.Nil(t, record.Book.PublicationDate)
+}
+
+func TestMARCXMLWriter(t *testing.T) {
+	b := testBook()
+	record := roundTrip(t, repository.BookExportFormatMARCXML, b)
+
+	assert.Equal(t, b.Title, record.Book.Title)
+	assert.Equal(t, b.Author, record.Book.Author)
+	assert.Equal(t, b.ISBN, record.Book.ISBN)
+	assert.Equal(t, 412, record.Book.PageCount)
+	assert.Empty(t, record.Unmapped)
+
+	out := &bytes.Buffer{}
+	writer, _ := NewWriter(repository.BookExportFormatMARCXML, out)
+	assert.NoError(t, writer.Write(b))
+	assert.NoError(t, writer.Close())
+	assert.Contains(t, out.String(), `<collection xmlns="http://www.loc.gov/MARC21/slim">`)
+	assert.Contains(t, out.String(), `<datafield tag="245" ind1="1" ind2="0"><subfield code="a">Go &amp; Rust`)
+	assert.True(t, strings.HasSuffix(out.String(), "</collection>\n"))
+}
+
+func atoi(s string) int {
+	n := 0
+	for _, c := range s {
+		n = n*10 + int(c-'0')
+	}
+	return n
+}
@@ -0,0 +1,445 @@
+package book_interchange
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+)
+
+// onixNamespace is the namespace of ONIX for Books 3.0 with reference tags.
+const onixNamespace = "http://ns.editeur.org/onix/3.0/reference"
+
+// onixNode is any element of an ONIX product, read generically so elements
+// left unmapped can be reported.
+type onixNode struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Nodes   []onixNode `xml:",any"`
+	Inner   string     `xml:",innerxml"`
+}
+
+func (n onixNode) name() string {
+	return n.XMLName.Local
+}
+
+// text returns the trimmed text of the first child with the name.
+func (n onixNode) text(name string) string {
+	for _, child := range n.Nodes {
+		if child.name() == name {
+			return child.plainText()
+		}
+	}
+
+	return ""
+}
+
+// attr returns the value of the attribute with the name.
+func (n onixNode) attr(name string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+
+	return ""
+}
+
+// plainText returns the text of the element, without the markup of XHTML
+// content.
+func (n onixNode) plainText() string {
+	decoder := xml.NewDecoder(strings.NewReader(n.Inner))
+	text := &strings.Builder{}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return strings.Join(strings.Fields(text.String()), " ")
+		}
+		if data, ok := token.(xml.CharData); ok {
+			text.Write(data)
+		}
+	}
+}
+
+// onixControlElements describe the record rather than the book, so they are
+// left out of the report of unmapped fields.
+var onixControlElements = map[string]bool{
+	"RecordReference": true, "NotificationType": true, "RecordSourceType": true, "RecordSourceName": true,
+}
+
+// toRecord maps an ONIX product onto a book. Unmapped elements are reported
+// by their path below Product.
+func (p onixNode) toRecord() Record {
+	record := newRecord()
+	book := &record.Book
+
+	for _, n := range p.Nodes {
+		switch n.name() {
+		case "ProductIdentifier":
+			if idType := n.text("ProductIDType"); (idType == "15" || idType == "02") && book.ISBN == "" {
+				book.ISBN = n.text("IDValue")
+			} else {
+				record.unmapped(n.name())
+			}
+		case "DescriptiveDetail":
+			onixDescriptiveDetail(&record, n)
+		case "CollateralDetail":
+			for _, c := range n.Nodes {
+				if textType := c.text("TextType"); c.name() == "TextContent" && (textType == "03" || textType == "02") &&
+					book.BookAttrs.Description == "" {
+					book.BookAttrs.Description = c.text("Text")
+				} else {
+					record.unmapped(n.name() + "/" + c.name())
+				}
+			}
+		case "PublishingDetail":
+			for _, c := range n.Nodes {
+				switch {
+				case c.name() == "Publisher" && c.text("PublishingRole") == "01" && book.Publisher == "":
+					book.Publisher = c.text("PublisherName")
+				case c.name() == "PublishingDate" && c.text("PublishingDateRole") == "01" && book.PublicationDate == nil:
+					book.PublicationDate = onixDate(c)
+				default:
+					record.unmapped(n.name() + "/" + c.name())
+				}
+			}
+		default:
+			if !onixControlElements[n.name()] {
+				record.unmapped(n.name())
+			}
+		}
+	}
+
+	return record
+}
+
+func onixDescriptiveDetail(record *Record, n onixNode) {
+	book := &record.Book
+	var authors []string
+
+	for _, c := range n.Nodes {
+		switch {
+		case c.name() == "ProductComposition":
+		case c.name() == "ProductForm" && onixFormat(c.plainText()) != "":
+			book.BookFormat = onixFormat(c.plainText())
+		case c.name() == "ProductForm" && (c.plainText() == "00" || c.plainText() == "BA"):
+		case c.name() == "TitleDetail" && c.text("TitleType") == "01" && book.Title == "":
+			for _, element := range c.Nodes {
+				if element.name() == "TitleElement" {
+					main := element.text("TitleText")
+					if main == "" {
+						main = strings.TrimSpace(element.text("TitlePrefix") + " " + element.text("TitleWithoutPrefix"))
+					}
+					book.Title = title(main, element.text("Subtitle"))
+					break
+				}
+			}
+		case c.name() == "Contributor" && onixIsAuthor(c):
+			authors = append(authors, onixName(c))
+		case c.name() == "EditionStatement":
+			book.Edition = c.plainText()
+		case c.name() == "EditionNumber":
+			if book.Edition == "" {
+				book.Edition = c.plainText()
+			}
+		case c.name() == "Language" && c.text("LanguageRole") == "01" && book.Language == "":
+			book.Language = languageTag(c.text("LanguageCode"))
+		case c.name() == "Extent" && onixIsPageCount(c) && book.PageCount == 0:
+			book.PageCount, _ = strconv.Atoi(c.text("ExtentValue"))
+		default:
+			record.unmapped(n.name() + "/" + c.name())
+		}
+	}
+
+	book.Author = byline(authors)
+}
+
+// onixFormat maps ONIX product forms onto book formats.
+func onixFormat(form string) string {
+	switch {
+	case form == "BB":
+		return repository.BookFormatHardcover
+	case form == "BC":
+		return repository.BookFormatPaperback
+	case strings.HasPrefix(form, "E"):
+		return repository.BookFormatEbook
+	case strings.HasPrefix(form, "A"):
+		return repository.BookFormatAudiobook
+	}
+
+	return ""
+}
+
+// onixIsAuthor reports whether the contributor is credited as author (A01).
+func onixIsAuthor(contributor onixNode) bool {
+	for _, c := range contributor.Nodes {
+		if c.name() == "ContributorRole" && c.plainText() == "A01" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func onixName(contributor onixNode) string {
+	if name := contributor.text("PersonName"); name != "" {
+		return name
+	}
+	if key := contributor.text("KeyNames"); key != "" {
+		return strings.TrimSpace(contributor.text("NamesBeforeKey") + " " + key)
+	}
+	if name := contributor.text("PersonNameInverted"); name != "" {
+		return name
+	}
+
+	return contributor.text("CorporateName")
+}
+
+// onixIsPageCount reports whether an extent counts the pages of the main
+// content.
+func onixIsPageCount(extent onixNode) bool {
+	switch extent.text("ExtentType") {
+	case "00", "05", "07", "11":
+		return extent.text("ExtentUnit") == "03"
+	}
+
+	return false
+}
+
+// onixDate reads a publishing date, YYYYMMDD unless its dateformat says
+// YYYYMM (01) or YYYY (05).
+func onixDate(publishingDate onixNode) *models.Date {
+	for _, c := range publishingDate.Nodes {
+		if c.name() != "Date" {
+			continue
+		}
+		date := c.plainText()
+		switch {
+		case len(date) >= 8:
+			return publicationDate(date[:4], date[4:6], date[6:8])
+		case len(date) >= 6:
+			return publicationDate(date[:4], date[4:6], "")
+		}
+		return publicationDate(date, "", "")
+	}
+
+	return nil
+}
+
+// ParseONIX reads the products of an ONIX for Books 3.0 message with
+// reference tags.
+func ParseONIX(r io.Reader) ([]Record, error) {
+	decoder := xml.NewDecoder(r)
+	records := []Record{}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "ONIXmessage":
+			return nil, errors.New("ONIX short tags are not supported, use reference tags")
+		case "ONIXMessage":
+			for _, a := range start.Attr {
+				if a.Name.Local == "release" && !strings.HasPrefix(a.Value, "3.") {
+					return nil, errors.New("only ONIX 3.0 messages are supported")
+				}
+			}
+		case "Product":
+			product := onixNode{}
+			if err := decoder.DecodeElement(&product, &start); err != nil {
+				return nil, err
+			}
+			records = append(records, product.toRecord())
+		}
+	}
+}
+
+type onixHeader struct {
+	XMLName      xml.Name `xml:"Header"`
+	SenderName   string   `xml:"Sender>SenderName"`
+	SentDateTime string   `xml:"SentDateTime"`
+}
+
+type onixProduct struct {
+	XMLName           xml.Name              `xml:"Product"`
+	RecordReference   string                `xml:"RecordReference"`
+	NotificationType  string                `xml:"NotificationType"`
+	ProductIdentifier onixProductIdentifier `xml:"ProductIdentifier"`
+	DescriptiveDetail onixDescriptive       `xml:"DescriptiveDetail"`
+	TextContent       *onixTextContent      `xml:"CollateralDetail>TextContent"`
+	PublishingDetail  *onixPublishing       `xml:"PublishingDetail"`
+}
+
+type onixProductIdentifier struct {
+	ProductIDType string `xml:"ProductIDType"`
+	IDValue       string `xml:"IDValue"`
+}
+
+type onixDescriptive struct {
+	ProductComposition string            `xml:"ProductComposition"`
+	ProductForm        string            `xml:"ProductForm"`
+	TitleType          string            `xml:"TitleDetail>TitleType"`
+	TitleElement       onixTitleElement  `xml:"TitleDetail>TitleElement"`
+	Contributors       []onixContributor `xml:"Contributor"`
+	EditionStatement   string            `xml:"EditionStatement,omitempty"`
+	Language           *onixLanguage     `xml:"Language"`
+	Extent             *onixExtent       `xml:"Extent"`
+}
+
+type onixTitleElement struct {
+	TitleElementLevel string `xml:"TitleElementLevel"`
+	TitleText         string `xml:"TitleText"`
+}
+
+type onixContributor struct {
+	SequenceNumber  int    `xml:"SequenceNumber"`
+	ContributorRole string `xml:"ContributorRole"`
+	PersonName      string `xml:"PersonName"`
+}
+
+type onixLanguage struct {
+	LanguageRole string `xml:"LanguageRole"`
+	LanguageCode string `xml:"LanguageCode"`
+}
+
+type onixExtent struct {
+	ExtentType  string `xml:"ExtentType"`
+	ExtentValue int    `xml:"ExtentValue"`
+	ExtentUnit  string `xml:"ExtentUnit"`
+}
+
+type onixTextContent struct {
+	TextType        string `xml:"TextType"`
+	ContentAudience string `xml:"ContentAudience"`
+	Text            string `xml:"Text"`
+}
+
+type onixPublishing struct {
+	Publisher      *onixPublisher      `xml:"Publisher"`
+	PublishingDate *onixPublishingDate `xml:"PublishingDate"`
+}
+
+type onixPublisher struct {
+	PublishingRole string `xml:"PublishingRole"`
+	PublisherName  string `xml:"PublisherName"`
+}
+
+type onixPublishingDate struct {
+	PublishingDateRole string `xml:"PublishingDateRole"`
+	Date               string `xml:"Date"`
+}
+
+// onixProductForms are the ONIX product forms of the book formats.
+var onixProductForms = map[string]string{
+	repository.BookFormatHardcover: "BB",
+	repository.BookFormatPaperback: "BC",
+	repository.BookFormatEbook:     "ED",
+	repository.BookFormatAudiobook: "AJ",
+}
+
+// onixProductOf maps a book onto an ONIX product. Books without an ISBN are
+// identified by their ID as a proprietary identifier.
+func onixProductOf(b models.Book) onixProduct {
+	product := onixProduct{
+		RecordReference:   "urn:uuid:" + b.ID.String(),
+		NotificationType:  "03",
+		ProductIdentifier: onixProductIdentifier{"01", b.ID.String()},
+		DescriptiveDetail: onixDescriptive{
+			ProductComposition: "00",
+			ProductForm:        "00",
+			TitleType:          "01",
+			TitleElement:       onixTitleElement{"01", b.Title},
+			EditionStatement:   b.Edition,
+		},
+	}
+	if b.ISBN != "" {
+		product.ProductIdentifier = onixProductIdentifier{"15", b.ISBN}
+	}
+	if form, ok := onixProductForms[b.BookFormat]; ok {
+		product.DescriptiveDetail.ProductForm = form
+	}
+	if b.Author != "" {
+		product.DescriptiveDetail.Contributors = []onixContributor{{1, "A01", b.Author}}
+	}
+	if b.Language != "" {
+		product.DescriptiveDetail.Language = &onixLanguage{"01", languageCode(b.Language)}
+	}
+	if b.PageCount > 0 {
+		product.DescriptiveDetail.Extent = &onixExtent{"00", b.PageCount, "03"}
+	}
+	if b.BookAttrs.Description != "" {
+		product.TextContent = &onixTextContent{"03", "00", b.BookAttrs.Description}
+	}
+	if b.Publisher != "" || b.PublicationDate != nil {
+		product.PublishingDetail = &onixPublishing{}
+		if b.Publisher != "" {
+			product.PublishingDetail.Publisher = &onixPublisher{"01", b.Publisher}
+		}
+		if b.PublicationDate != nil {
+			product.PublishingDetail.PublishingDate = &onixPublishingDate{"01", b.PublicationDate.Format("20060102")}
+		}
+	}
+
+	return product
+}
+
+// onixSenderName names the sender in the header of ONIX messages, read from
+// ONIX_SENDER_NAME.
+func onixSenderName() string {
+	if name := os.Getenv("ONIX_SENDER_NAME"); name != "" {
+		return name
+	}
+
+	return "Books API"
+}
+
+type onixWriter struct {
+	w       io.Writer
+	encoder *xml.Encoder
+}
+
+func newONIXWriter(w io.Writer, sentAt time.Time) (*onixWriter, error) {
+	if _, err := io.WriteString(w, xml.Header+`<ONIXMessage release="3.0" xmlns="`+onixNamespace+`">`+"\n"); err != nil {
+		return nil, err
+	}
+
+	writer := &onixWriter{w, xml.NewEncoder(w)}
+	header := onixHeader{SenderName: onixSenderName(), SentDateTime: sentAt.UTC().Format("20060102T1504Z")}
+	if err := writer.encode(header); err != nil {
+		return nil, err
+	}
+
+	return writer, nil
+}
+
+func (w *onixWriter) encode(v any) error {
+	if err := w.encoder.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w.w, "\n")
+	return err
+}
+
+func (w *onixWriter) Write(b models.Book) error {
+	return w.encode(onixProductOf(b))
+}
+
+func (w *onixWriter) Close() error {
+	_, err := io.WriteString(w.w, "</ONIXMessage>\n")
+	return err
+}
@@ -0,0 +1,89 @@
+package book_interchange
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseONIX(t *testing.T) {
+	records := parseSample(t, repository.BookImportFormatONIX, "sample_onix.xml")
+	assert.Len(t, records, 2)
+
+	assert.NoError(t, records[0].Err)
+	assert.Equal(t, models.BookCreate{
+		Title:      "The Hobbit: There and Back Again",
+		Author:     "J. R. R. Tolkien",
+		BookStatus: 1,
+		BookAttrs:  models.BookAttrs{Description: "A hobbit is swept into a quest for treasure."},
+		BookMetadata: models.BookMetadata{
+			ISBN:            "9780306406157",
+			Publisher:       "Plenum Press",
+			PublicationDate: date(1994, time.March, 1),
+			Language:        "en",
+			PageCount:       412,
+			Edition:         "Second edition",
+			BookFormat:      repository.BookFormatHardcover,
+		},
+	}, records[0].Book)
+	assert.Equal(t, []string{
+		"ProductIdentifier", "DescriptiveDetail/Contributor", "DescriptiveDetail/Subject",
+		"PublishingDetail/PublishingStatus", "ProductSupply",
+	}, records[0].Unmapped)
+
+	assert.Equal(t, "Ensaio sobre a cegueira", records[1].Book.Title)
+	assert.Equal(t, "José Saramago", records[1].Book.Author)
+	assert.Equal(t, repository.BookFormatEbook, records[1].Book.BookFormat)
+	assert.Equal(t, "pt", records[1].Book.Language)
+	assert.Equal(t, date(1995, time.January, 1), records[1].Book.PublicationDate)
+	assert.Empty(t, records[1].Unmapped)
+}
+
+func TestParseONIX_Unsupported(t *testing.T) {
+	_, err := ParseONIX(strings.NewReader(`<ONIXmessage release="3.0"><product/></ONIXmessage>`))
+	assert.ErrorContains(t, err, "short tags")
+
+	_, err = ParseONIX(strings.NewReader(`<ONIXMessage release="2.1"><Product/></ONIXMessage>`))
+	assert.ErrorContains(t, err, "3.0")
+}
+
+func TestONIXWriter(t *testing.T) {
+	b := testBook()
+	record := roundTrip(t, repository.BookExportFormatONIX, b)
+
+	assert.Equal(t, b.Title, record.Book.Title)
+	assert.Equal(t, b.Author, record.Book.Author)
+	assert.Equal(t, "Line one line two", record.Book.BookAttrs.Description)
+	assert.Equal(t, b.ISBN, record.Book.ISBN)
+	assert.Equal(t, b.Publisher, record.Book.Publisher)
+	assert.Equal(t, b.PublicationDate, record.Book.PublicationDate)
+	assert.Equal(t, "pt", record.Book.Language)
+	assert.Equal(t, b.PageCount, record.Book.PageCount)
+	assert.Equal(t, b.Edition, record.Book.Edition)
+	assert.Equal(t, b.BookFormat, record.Book.BookFormat)
+	assert.Empty(t, record.Unmapped)
+}
+
+func TestONIXWriter_Header(t *testing.T) {
+	t.Setenv("ONIX_SENDER_NAME", "Library")
+	b := testBook()
+	b.ISBN = ""
+	b.Publisher = ""
+	b.PublicationDate = nil
+
+	out := &bytes.Buffer{}
+	writer, err := newONIXWriter(out, time.Date(2025, time.October, 19, 10, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Write(b))
+	assert.NoError(t, writer.Close())
+
+	assert.Contains(t, out.String(), `<ONIXMessage release="3.0" xmlns="http://ns.editeur.org/onix/3.0/reference">`)
+	assert.Contains(t, out.String(), `<Header><Sender><SenderName>Library</SenderName></Sender><SentDateTime>20251019T1000Z</SentDateTime></Header>`)
+	assert.Contains(t, out.String(), `<ProductIdentifier><ProductIDType>01</ProductIDType><IDValue>`+b.ID.String()+`</IDValue>`)
+	assert.NotContains(t, out.String(), "PublishingDetail")
+}
@@ -0,0 +1,143 @@
+package book_interchange
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+)
+
+// risLine matches a tagged RIS line, "TI  - Title".
+var risLine = regexp.MustCompile(`^([A-Z][A-Z0-9])  -(?: (.*))?$`)
+
+// ParseRIS reads the references of a RIS file, of any type. Lines that are
+// not tagged continue the value of the previous line.
+func ParseRIS(r io.Reader) ([]Record, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	records := []Record{}
+	var tags []string
+	var values []string
+	for scanner.Scan() {
+		line := strings.TrimRight(strings.TrimPrefix(scanner.Text(), "\ufeff"), " \r")
+		match := risLine.FindStringSubmatch(line)
+		switch {
+		case match == nil && len(tags) > 0 && strings.TrimSpace(line) != "":
+			values[len(values)-1] += " " + strings.TrimSpace(line)
+		case match == nil:
+		case match[1] == "TY":
+			tags, values = []string{match[1]}, []string{match[2]}
+		case match[1] == "ER":
+			if len(tags) > 0 {
+				records = append(records, risRecord(tags, values))
+			}
+			tags, values = nil, nil
+		case len(tags) > 0:
+			tags, values = append(tags, match[1]), append(values, strings.TrimSpace(match[2]))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(tags) > 0 {
+		records = append(records, Record{Err: fmt.Errorf("RIS reference of type %q has no ER line", values[0])})
+	}
+
+	return records, nil
+}
+
+// risRecord maps the tagged values of a reference onto a book. Values of
+// single-valued fields after the first are reported as unmapped.
+func risRecord(tags, values []string) Record {
+	record := newRecord()
+	book := &record.Book
+	var authors []string
+	dated := false
+
+	for i, tag := range tags {
+		value := values[i]
+		switch {
+		case tag == "TY" || tag == "ID":
+		case (tag == "TI" || tag == "T1") && book.Title == "":
+			book.Title = title(value, "")
+		case tag == "AU" || tag == "A1":
+			authors = append(authors, value)
+		case (tag == "PY" || tag == "Y1") && !dated, tag == "DA":
+			parts := strings.Split(value, "/")
+			if date := publicationDate(parts[0], part(parts, 1), part(parts, 2)); date != nil {
+				book.PublicationDate, dated = date, true
+			}
+		case tag == "PB" && book.Publisher == "":
+			book.Publisher = value
+		case tag == "SN" && book.ISBN == "":
+			isbn, qualifier, _ := strings.Cut(strings.TrimSpace(strings.Split(value, ";")[0]), " ")
+			book.ISBN = isbn
+			if book.BookFormat == "" {
+				book.BookFormat = bookFormat(qualifier)
+			}
+		case tag == "LA" && book.Language == "":
+			if book.Language = languageName(value); book.Language == "" {
+				record.unmapped(tag)
+			}
+		case tag == "ET" && book.Edition == "":
+			book.Edition = value
+		case tag == "SP" && book.PageCount == 0:
+			book.PageCount = pages(value)
+		case (tag == "AB" || tag == "N2") && book.BookAttrs.Description == "":
+			book.BookAttrs.Description = value
+		default:
+			record.unmapped(tag)
+		}
+	}
+
+	book.Author = byline(authors)
+
+	return record
+}
+
+type risWriter struct {
+	w io.Writer
+}
+
+// Write writes the book as a BOOK reference, with CRLF line ends as RIS
+// asks for.
+func (w *risWriter) Write(b models.Book) error {
+	buffer := bufio.NewWriter(w.w)
+	tag := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(buffer, "%s  - %s\r\n", name, strings.Join(strings.Fields(value), " "))
+		}
+	}
+
+	tag("TY", "BOOK")
+	tag("ID", b.ID.String())
+	tag("TI", b.Title)
+	tag("AU", b.Author)
+	if b.PublicationDate != nil {
+		tag("PY", b.PublicationDate.Format("2006"))
+		tag("DA", b.PublicationDate.Format("2006/01/02/"))
+	}
+	tag("PB", b.Publisher)
+	tag("SN", b.ISBN)
+	if b.Language != "" {
+		tag("LA", englishName(b.Language))
+	}
+	tag("ET", b.Edition)
+	if b.PageCount > 0 {
+		tag("SP", strconv.Itoa(b.PageCount))
+	}
+	tag("AB", b.BookAttrs.Description)
+	buffer.WriteString("ER  - \r\n\r\n")
+
+	return buffer.Flush()
+}
+
+func (w *risWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,64 @@
+package book_interchange
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRIS(t *testing.T) {
+	records := parseSample(t, repository.BookImportFormatRIS, "sample.ris")
+	assert.Len(t, records, 3)
+
+	assert.NoError(t, records[0].Err)
+	assert.Equal(t, models.BookCreate{
+		Title:      "The Hobbit",
+		Author:     "J. R. R. Tolkien",
+		BookStatus: 1,
+		BookAttrs:  models.BookAttrs{Description: "A hobbit is swept into a quest for treasure guarded by a dragon."},
+		BookMetadata: models.BookMetadata{
+			ISBN:            "0306406152",
+			Publisher:       "Plenum Press",
+			PublicationDate: date(1994, time.March, 1),
+			Language:        "en",
+			PageCount:       412,
+			Edition:         "2nd",
+			BookFormat:      repository.BookFormatHardcover,
+		},
+	}, records[0].Book)
+	assert.Equal(t, []string{"CY", "KW"}, records[0].Unmapped)
+
+	assert.Equal(t, "José Saramago and Ana Pereira", records[1].Book.Author)
+	assert.Equal(t, date(1995, time.January, 1), records[1].Book.PublicationDate)
+	assert.Equal(t, "pt", records[1].Book.Language)
+	assert.Equal(t, []string{"UR"}, records[1].Unmapped)
+
+	assert.ErrorContains(t, records[2].Err, "no ER line")
+}
+
+func TestRISWriter(t *testing.T) {
+	b := testBook()
+	record := roundTrip(t, repository.BookExportFormatRIS, b)
+
+	assert.Equal(t, b.Title, record.Book.Title)
+	assert.Equal(t, b.Author, record.Book.Author)
+	assert.Equal(t, "Line one line two", record.Book.BookAttrs.Description)
+	assert.Equal(t, b.ISBN, record.Book.ISBN)
+	assert.Equal(t, b.Publisher, record.Book.Publisher)
+	assert.Equal(t, b.PublicationDate, record.Book.PublicationDate)
+	assert.Equal(t, "pt", record.Book.Language)
+	assert.Equal(t, b.PageCount, record.Book.PageCount)
+	assert.Equal(t, b.Edition, record.Book.Edition)
+	assert.Empty(t, record.Unmapped)
+
+	out := &bytes.Buffer{}
+	writer, _ := NewWriter(repository.BookExportFormatRIS, out)
+	assert.NoError(t, writer.Write(b))
+	assert.Contains(t, out.String(), "TY  - BOOK\r\nID  - "+b.ID.String()+"\r\n")
+	assert.Contains(t, out.String(), "LA  - Portuguese\r\n")
+	assert.Contains(t, out.String(), "ER  - \r\n")
+}
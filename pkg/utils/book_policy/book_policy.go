@@ -0,0 +1,144 @@
+package book_policy
+
+import (
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/google/uuid"
+)
+
+// Action is something a user does to an existing book.
+type Action int
+
+const (
+	Update Action = iota
+	Delete
+	// Share adds, changes and removes collaborators.
+	Share
+	// Transfer offers the ownership to another user.
+	Transfer
+	// ViewCollaborators lists the collaborators and pending transfer.
+	ViewCollaborators
+	// ViewRevisions lists the revisions and compares them.
+	ViewRevisions
+	// Restore brings back an earlier revision.
+	Restore
+)
+
+func (a Action) String() string {
+	switch a {
+	case Update:
+		return "update"
+	case Delete:
+		return "delete"
+	case Share:
+		return "share"
+	case Transfer:
+		return "transfer"
+	case ViewCollaborators:
+		return "view_collaborators"
+	case ViewRevisions:
+		return "view_revisions"
+	case Restore:
+		return "restore"
+	}
+
+	return "unknown"
+}
+
+// Relation is how the acting user relates to the book.
+type Relation int
+
+const (
+	Stranger Relation = iota
+	Owner
+	Editor
+	Viewer
+)
+
+// Grant tells whether an action is allowed and on which ground.
+type Grant int
+
+const (
+	Denied Grant = iota
+	// AsOwner allows users to act on their own books.
+	AsOwner
+	// AsModerator allows users to act on books of others through an ":any"
+	// credential. Changes made this way have to be recorded.
+	AsModerator
+	// AsCollaborator allows users the owner shared the book with.
+	AsCollaborator
+)
+
+// RelationOf returns how the user relates to the book. collaboratorRole is
+// the role the user was given on the book, empty when it was not shared with
+// them.
+func RelationOf(userID uuid.UUID, book models.Book, collaboratorRole string) Relation {
+	switch {
+	case book.UserID == userID:
+		return Owner
+	case collaboratorRole == repository.EditorCollaboratorRole:
+		return Editor
+	case collaboratorRole == repository.ViewerCollaboratorRole:
+		return Viewer
+	}
+
+	return Stranger
+}
+
+// Permits reports whether the credentials may allow the action on at least
+// some books, so requests can be refused before any book is read. Changing a
+// book in any way needs an update credential and deleting it a delete
+// credential; viewing is open to collaborators whatever their credentials.
+func Permits(credentials map[string]bool, action Action) bool {
+	switch action {
+	case Update, Share, Transfer, Restore:
+		return canUpdate(credentials)
+	case Delete:
+		return credentials[repository.BookDeleteCredential] || credentials[repository.BookDeleteAnyCredential]
+	}
+
+	return true
+}
+
+// Decide evaluates the action for a user holding the credentials and
+// standing in the relation to the book. Owners and editors act within their
+// credentials, so a token scoped down to fewer credentials is held to them.
+func Decide(credentials map[string]bool, action Action, relation Relation) Grant {
+	update := canUpdate(credentials)
+
+	switch action {
+	case Update:
+		return decide(relation == Owner && update, relation == Editor && update, credentials[repository.BookUpdateAnyCredential])
+	case Delete:
+		return decide(relation == Owner && (credentials[repository.BookDeleteCredential] || credentials[repository.BookDeleteAnyCredential]),
+			false, credentials[repository.BookDeleteAnyCredential])
+	case Share:
+		return decide(relation == Owner && update, false, credentials[repository.BookUpdateAnyCredential])
+	case Transfer:
+		return decide(relation == Owner && update, false, false)
+	case ViewCollaborators, ViewRevisions:
+		return decide(relation == Owner && update, relation == Editor || relation == Viewer, credentials[repository.BookUpdateAnyCredential])
+	case Restore:
+		return decide(relation == Owner && update, false, credentials[repository.BookUpdateAnyCredential])
+	}
+
+	return Denied
+}
+
+func canUpdate(credentials map[string]bool) bool {
+	return credentials[repository.BookUpdateCredential] || credentials[repository.BookUpdateAnyCredential]
+}
+
+// decide picks the strongest ground an action is allowed on.
+func decide(asOwner, asCollaborator, asModerator bool) Grant {
+	switch {
+	case asOwner:
+		return AsOwner
+	case asCollaborator:
+		return AsCollaborator
+	case asModerator:
+		return AsModerator
+	}
+
+	return Denied
+}
@@ -0,0 +1,83 @@
+package book_policy
+
+import (
+	"testing"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRelationOf(t *testing.T) {
+	userID := uuid.New()
+
+	assert.Equal(t, Owner, RelationOf(userID, models.Book{UserID: userID}, ""))
+	assert.Equal(t, Owner, RelationOf(userID, models.Book{UserID: userID}, repository.ViewerCollaboratorRole))
+	assert.Equal(t, Editor, RelationOf(userID, models.Book{UserID: uuid.New()}, repository.EditorCollaboratorRole))
+	assert.Equal(t, Viewer, RelationOf(userID, models.Book{UserID: uuid.New()}, repository.ViewerCollaboratorRole))
+	assert.Equal(t, Stranger, RelationOf(userID, models.Book{UserID: uuid.New()}, ""))
+}
+
+func TestPermits(t *testing.T) {
+	assert.False(t, Permits(map[string]bool{}, Update))
+	assert.False(t, Permits(map[string]bool{}, Share))
+	assert.False(t, Permits(map[string]bool{repository.BookCreateCredential: true}, Transfer))
+	assert.True(t, Permits(map[string]bool{repository.BookUpdateCredential: true}, Share))
+	assert.True(t, Permits(map[string]bool{}, ViewCollaborators))
+	assert.True(t, Permits(map[string]bool{repository.BookDeleteAnyCredential: true}, Delete))
+	assert.False(t, Permits(map[string]bool{repository.BookUpdateAnyCredential: true}, Delete))
+}
+
+func TestDecide(t *testing.T) {
+	own := map[string]bool{repository.BookUpdateCredential: true, repository.BookDeleteCredential: true}
+	anyBook := map[string]bool{repository.BookUpdateAnyCredential: true, repository.BookDeleteAnyCredential: true}
+
+	tests := []struct {
+		credentials map[string]bool
+		action      Action
+		relation    Relation
+		want        Grant
+	}{
+		{own, Update, Owner, AsOwner},
+		{own, Delete, Owner, AsOwner},
+		{own, Update, Stranger, Denied},
+		{anyBook, Update, Owner, AsOwner},
+		{anyBook, Update, Stranger, AsModerator},
+		{anyBook, Delete, Stranger, AsModerator},
+		{map[string]bool{}, Delete, Owner, Denied},
+		{own, Update, Editor, AsCollaborator},
+		{map[string]bool{}, Update, Editor, Denied},
+		{map[string]bool{}, Update, Viewer, Denied},
+		{own, Delete, Editor, Denied},
+		{own, Share, Owner, AsOwner},
+		{map[string]bool{}, Share, Owner, Denied},
+		{map[string]bool{}, Share, Editor, Denied},
+		{anyBook, Share, Stranger, AsModerator},
+		{own, Transfer, Owner, AsOwner},
+		{map[string]bool{}, Transfer, Owner, Denied},
+		{anyBook, Transfer, Stranger, Denied},
+		{map[string]bool{}, ViewCollaborators, Viewer, AsCollaborator},
+		{own, ViewCollaborators, Owner, AsOwner},
+		{map[string]bool{}, ViewCollaborators, Owner, Denied},
+		{map[string]bool{}, ViewCollaborators, Stranger, Denied},
+		{map[string]bool{}, ViewRevisions, Viewer, AsCollaborator},
+		{anyBook, ViewRevisions, Stranger, AsModerator},
+		{own, Restore, Owner, AsOwner},
+		{map[string]bool{}, Restore, Owner, Denied},
+		{own, Restore, Editor, Denied},
+		{anyBook, Restore, Stranger, AsModerator},
+		{anyBook, Action(42), Stranger, Denied},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Decide(tt.credentials, tt.action, tt.relation), "%v/%v/%v", tt.credentials, tt.action, tt.relation)
+	}
+}
+
+func TestAction_String(t *testing.T) {
+	assert.Equal(t, "update", Update.String())
+	assert.Equal(t, "delete", Delete.String())
+	assert.Equal(t, "transfer", Transfer.String())
+	assert.Equal(t, "restore", Restore.String())
+}
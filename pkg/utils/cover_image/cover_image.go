@@ -0,0 +1,193 @@
+package cover_image
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrUnsupportedType is returned for uploads that are not JPEG, PNG or
+	// GIF images, whatever their declared content type.
+	ErrUnsupportedType = errors.New("cover must be a JPEG, PNG or GIF image")
+	// ErrTooLarge is returned for images above MaxPixels.
+	ErrTooLarge = errors.New("cover image dimensions are too large")
+)
+
+// MaxPixels bounds width × height of an upload, so a small file cannot
+// decode into a huge image. Processing holds up to four copies of the image
+// at 4 bytes a pixel, about 200 MB at this size.
+const MaxPixels = 12_000_000
+
+// jpegQuality is the quality of the re-encoded JPEG images.
+const jpegQuality = 85
+
+// Encoded is an image ready to be stored.
+type Encoded struct {
+	Data        []byte
+	ContentType string
+	Extension   string
+}
+
+// Cover is the re-encoded upload and its thumbnails by width.
+type Cover struct {
+	Original   Encoded
+	Thumbnails map[int]Encoded
+}
+
+// MaxBytes is the largest upload accepted, read from COVER_MAX_BYTES (2 MiB
+// by default).
+func MaxBytes() int64 {
+	size, err := strconv.ParseInt(os.Getenv("COVER_MAX_BYTES"), 10, 64)
+	if err != nil || size <= 0 {
+		size = 2 << 20
+	}
+
+	return size
+}
+
+// ThumbnailWidths returns the widths read from COVER_THUMBNAIL_WIDTHS, a comma
+// separated list (128, 256 and 512 by default).
+func ThumbnailWidths() []int {
+	widths := []int{}
+	for _, field := range strings.Split(os.Getenv("COVER_THUMBNAIL_WIDTHS"), ",") {
+		width, err := strconv.Atoi(strings.TrimSpace(field))
+		if err == nil && width > 0 {
+			widths = append(widths, width)
+		}
+	}
+
+	if len(widths) == 0 {
+		return []int{128, 256, 512}
+	}
+
+	sort.Ints(widths)
+	return widths
+}
+
+// Process checks the actual type of the upload, decodes it and re-encodes it,
+// which drops any metadata like EXIF locations, then makes the thumbnails.
+// PNG images stay PNG to keep their transparency, the others become JPEG.
+func Process(data []byte, widths []int) (Cover, error) {
+	sniffed := http.DetectContentType(data)
+
+	var decode func([]byte) (image.Image, error)
+	switch sniffed {
+	case "image/jpeg":
+		decode = func(data []byte) (image.Image, error) { return jpeg.Decode(bytes.NewReader(data)) }
+	case "image/png":
+		decode = func(data []byte) (image.Image, error) { return png.Decode(bytes.NewReader(data)) }
+	case "image/gif":
+		decode = func(data []byte) (image.Image, error) { return gif.Decode(bytes.NewReader(data)) }
+	default:
+		return Cover{}, ErrUnsupportedType
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Cover{}, fmt.Errorf("%w: %v", ErrUnsupportedType, err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > MaxPixels {
+		return Cover{}, ErrTooLarge
+	}
+
+	decoded, err := decode(data)
+	if err != nil {
+		return Cover{}, fmt.Errorf("%w: %v", ErrUnsupportedType, err)
+	}
+
+	keepPNG := sniffed == "image/png"
+
+	original, err := encode(decoded, keepPNG)
+	if err != nil {
+		return Cover{}, err
+	}
+
+	cover := Cover{Original: original, Thumbnails: map[int]Encoded{}}
+	for _, width := range widths {
+		thumbnail, err := encode(resize(decoded, width), keepPNG)
+		if err != nil {
+			return Cover{}, err
+		}
+		cover.Thumbnails[width] = thumbnail
+	}
+
+	return cover, nil
+}
+
+func encode(img image.Image, asPNG bool) (Encoded, error) {
+	buffer := &bytes.Buffer{}
+
+	if asPNG {
+		if err := png.Encode(buffer, img); err != nil {
+			return Encoded{}, err
+		}
+		return Encoded{Data: buffer.Bytes(), ContentType: "image/png", Extension: "png"}, nil
+	}
+
+	// JPEG has no transparency, transparent areas become white.
+	opaque := image.NewRGBA(img.Bounds())
+	draw.Draw(opaque, opaque.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(opaque, opaque.Bounds(), img, img.Bounds().Min, draw.Over)
+
+	if err := jpeg.Encode(buffer, opaque, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return Encoded{}, err
+	}
+
+	return Encoded{Data: buffer.Bytes(), ContentType: "image/jpeg", Extension: "jpg"}, nil
+}
+
+// resize scales the image down to width, keeping its aspect ratio, by
+// averaging the source pixels each target pixel covers. Narrower images are
+// kept as they are.
+func resize(img image.Image, width int) image.Image {
+	bounds := img.Bounds()
+	sourceWidth, sourceHeight := bounds.Dx(), bounds.Dy()
+	if width >= sourceWidth {
+		return img
+	}
+
+	height := max(1, sourceHeight*width/sourceWidth)
+
+	source := image.NewRGBA(image.Rect(0, 0, sourceWidth, sourceHeight))
+	draw.Draw(source, source.Bounds(), img, bounds.Min, draw.Src)
+
+	target := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*sourceHeight/height, max((y+1)*sourceHeight/height, y*sourceHeight/height+1)
+		for x := 0; x < width; x++ {
+			x0, x1 := x*sourceWidth/width, max((x+1)*sourceWidth/width, x*sourceWidth/width+1)
+
+			var r, g, b, a, count int
+			for sy := y0; sy < y1; sy++ {
+				row := source.Pix[sy*source.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					pixel := row[sx*4 : sx*4+4]
+					r += int(pixel[0])
+					g += int(pixel[1])
+					b += int(pixel[2])
+					a += int(pixel[3])
+					count++
+				}
+			}
+
+			offset := y*target.Stride + x*4
+			target.Pix[offset] = uint8(r / count)
+			target.Pix[offset+1] = uint8(g / count)
+			target.Pix[offset+2] = uint8(b / count)
+			target.Pix[offset+3] = uint8(a / count)
+		}
+	}
+
+	return target
+}
@@ -0,0 +1,117 @@
+package cover_image
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testImage(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 100, A: 255})
+		}
+	}
+	return img
+}
+
+func encoded(t *testing.T, format string, img image.Image) []byte {
+	buffer := &bytes.Buffer{}
+	switch format {
+	case "png":
+		assert.NoError(t, png.Encode(buffer, img))
+	case "gif":
+		assert.NoError(t, gif.Encode(buffer, img, nil))
+	default:
+		assert.NoError(t, jpeg.Encode(buffer, img, nil))
+	}
+	return buffer.Bytes()
+}
+
+func TestMaxBytes(t *testing.T) {
+	t.Setenv("COVER_MAX_BYTES", "")
+	assert.Equal(t, int64(2<<20), MaxBytes())
+
+	t.Setenv("COVER_MAX_BYTES", "1024")
+	assert.Equal(t, int64(1024), MaxBytes())
+}
+
+func TestThumbnailWidths(t *testing.T) {
+	_ = os.Unsetenv("COVER_THUMBNAIL_WIDTHS")
+	assert.Equal(t, []int{128, 256, 512}, ThumbnailWidths())
+
+	t.Setenv("COVER_THUMBNAIL_WIDTHS", "300, 100,x,-5")
+	assert.Equal(t, []int{100, 300}, ThumbnailWidths())
+}
+
+func TestProcess_JPEG(t *testing.T) {
+	cover, err := Process(encoded(t, "jpeg", testImage(200, 100)), []int{50, 400})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "image/jpeg", cover.Original.ContentType)
+	assert.Equal(t, "jpg", cover.Original.Extension)
+
+	thumbnail, err := jpeg.Decode(bytes.NewReader(cover.Thumbnails[50].Data))
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 50, 25), thumbnail.Bounds())
+
+	// images are never scaled up
+	large, err := jpeg.Decode(bytes.NewReader(cover.Thumbnails[400].Data))
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 200, 100), large.Bounds())
+}
+
+func TestProcess_PNGKeepsTransparency(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 40, 40))
+	cover, err := Process(encoded(t, "png", img), []int{10})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "image/png", cover.Thumbnails[10].ContentType)
+	thumbnail, err := png.Decode(bytes.NewReader(cover.Thumbnails[10].Data))
+	assert.NoError(t, err)
+	_, _, _, alpha := thumbnail.At(5, 5).RGBA()
+	assert.Zero(t, alpha)
+}
+
+func TestProcess_GIFBecomesJPEG(t *testing.T) {
+	cover, err := Process(encoded(t, "gif", testImage(20, 20)), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/jpeg", cover.Original.ContentType)
+	assert.Empty(t, cover.Thumbnails)
+}
+
+func TestProcess_Rejects(t *testing.T) {
+	_, err := Process([]byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"), nil)
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+
+	// a PNG signature followed by garbage
+	_, err = Process(append([]byte("\x89PNG\x0D\x0A\x1A\x0A"), bytes.Repeat([]byte{0}, 64)...), nil)
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+
+	// the header claims more pixels than allowed
+	header := encoded(t, "png", image.NewGray(image.Rect(0, 0, 1, 1)))
+	header[16], header[17], header[18], header[19] = 0, 0, 0x40, 0 // width 16384
+	header[20], header[21], header[22], header[23] = 0, 0, 0x40, 0 // height 16384
+	binary.BigEndian.PutUint32(header[29:33], crc32.ChecksumIEEE(header[12:29]))
+	_, err = Process(header, nil)
+	assert.ErrorIs(t, err, ErrTooLarge)
+}
+
+func TestResize_Averages(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.RGBA{R: 0, A: 255})
+	img.Set(1, 0, color.RGBA{R: 200, A: 255})
+
+	resized := resize(img, 1)
+	assert.Equal(t, color.RGBA{R: 100, A: 255}, resized.At(0, 0))
+}
@@ -0,0 +1,42 @@
+package etag
+
+import (
+	"strconv"
+	"strings"
+)
+
+// FromVersion returns the strong entity tag of a resource version.
+func FromVersion(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// Match reports whether an If-Match header allows acting on the resource:
+// the header is absent, is "*" or lists the tag. If-Match uses strong
+// comparison, so weak tags in the header never match.
+func Match(header, tag string) bool {
+	if strings.TrimSpace(header) == "" {
+		return true
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NoneMatch reports whether an If-None-Match header lists the tag, meaning
+// the client already has this version. It uses weak comparison.
+func NoneMatch(header, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == strings.TrimPrefix(tag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,30 @@
+package etag
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromVersion(t *testing.T) {
+	assert.Equal(t, `"3"`, FromVersion(3))
+}
+
+func TestMatch(t *testing.T) {
+	tag := FromVersion(3)
+
+	assert.True(t, Match("", tag))
+	assert.True(t, Match("*", tag))
+	assert.True(t, Match(`"2", "3"`, tag))
+	assert.False(t, Match(`"2"`, tag))
+	assert.False(t, Match(`W/"3"`, tag))
+}
+
+func TestNoneMatch(t *testing.T) {
+	tag := FromVersion(3)
+
+	assert.False(t, NoneMatch("", tag))
+	assert.True(t, NoneMatch("*", tag))
+	assert.True(t, NoneMatch(`"1", W/"3"`, tag))
+	assert.False(t, NoneMatch(`"2"`, tag))
+}
@@ -0,0 +1,82 @@
+package isbn
+
+import "strings"
+
+// strip removes the hyphens and spaces ISBNs are usually printed with and
+// uppercases the X check digit of ISBN-10s.
+func strip(s string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(s)))
+}
+
+// Valid reports whether s is an ISBN-10 or ISBN-13 with a correct check
+// digit, hyphenated or not.
+func Valid(s string) bool {
+	s = strip(s)
+	switch len(s) {
+	case 10:
+		return valid10(s)
+	case 13:
+		return valid13(s)
+	}
+
+	return false
+}
+
+// Normalize returns the ISBN-13 of s without hyphens, converting ISBN-10s,
+// so both forms of a book compare equal. It returns s unchanged when it is
+// not a valid ISBN.
+func Normalize(s string) string {
+	digits := strip(s)
+	switch {
+	case len(digits) == 13 && valid13(digits):
+		return digits
+	case len(digits) == 10 && valid10(digits):
+		digits = "978" + digits[:9]
+		return digits + string(rune('0'+check13(digits)))
+	}
+
+	return s
+}
+
+func valid10(s string) bool {
+	sum := 0
+	for i, r := range s {
+		digit := int(r - '0')
+		switch {
+		case r == 'X' && i == 9:
+			digit = 10
+		case r < '0' || r > '9':
+			return false
+		}
+		sum += (10 - i) * digit
+	}
+
+	return sum%11 == 0
+}
+
+func valid13(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	if !strings.HasPrefix(s, "978") && !strings.HasPrefix(s, "979") {
+		return false
+	}
+
+	return check13(s[:12]) == int(s[12]-'0')
+}
+
+// check13 returns the check digit of the first 12 digits of an ISBN-13.
+func check13(s string) int {
+	sum := 0
+	for i, r := range s[:12] {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += weight * int(r-'0')
+	}
+
+	return (10 - sum%10) % 10
+}
@@ -0,0 +1,27 @@
+package isbn
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValid(t *testing.T) {
+	assert.True(t, Valid("978-0-306-40615-7"))
+	assert.True(t, Valid("9780306406157"))
+	assert.True(t, Valid("0-306-40615-2"))
+	assert.True(t, Valid("0-8044-2957-x"))
+	assert.False(t, Valid("978-0-306-40615-8"))
+	assert.False(t, Valid("0-306-40615-3"))
+	assert.False(t, Valid("X-306-40615-2"))
+	assert.False(t, Valid("1230306406157"))
+	assert.False(t, Valid("97803064061"))
+	assert.False(t, Valid(""))
+}
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "9780306406157", Normalize("978-0-306-40615-7"))
+	assert.Equal(t, "9780306406157", Normalize(" 0 306 40615 2 "))
+	assert.Equal(t, "9780804429573", Normalize("0-8044-2957-X"))
+	assert.Equal(t, "not an isbn", Normalize("not an isbn"))
+}
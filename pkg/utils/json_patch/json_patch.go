@@ -0,0 +1,356 @@
+package json_patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	MergePatchContentType = "application/merge-patch+json" // RFC 7396
+	JSONPatchContentType  = "application/json-patch+json"  // RFC 6902
+)
+
+var (
+	// ErrInvalidPatch is returned for patches that are malformed or cannot be
+	// applied to the document.
+	ErrInvalidPatch = errors.New("patch is invalid or cannot be applied")
+	// ErrTestFailed is returned when a JSON Patch "test" operation does not
+	// match the document.
+	ErrTestFailed = errors.New("patch test operation failed")
+)
+
+// MergePatch applies a JSON Merge Patch to a JSON document.
+func MergePatch(document, patch []byte) ([]byte, error) {
+	var target, changes any
+	if err := json.Unmarshal(document, &target); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, &changes); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	return json.Marshal(mergePatch(target, changes))
+}
+
+func mergePatch(target, patch any) any {
+	changes, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	object, ok := target.(map[string]any)
+	if !ok {
+		object = map[string]any{}
+	}
+
+	for key, value := range changes {
+		if value == nil {
+			delete(object, key)
+		} else {
+			object[key] = mergePatch(object[key], value)
+		}
+	}
+
+	return object
+}
+
+// Operation is one step of a JSON Patch. Value stays nil when the member is
+// absent, so an explicit null can be told apart.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Apply applies a JSON Patch to a JSON document. Operations run in order and
+// the document is left untouched unless all of them succeed.
+func Apply(document, patch []byte) ([]byte, error) {
+	var target any
+	if err := json.Unmarshal(document, &target); err != nil {
+		return nil, err
+	}
+
+	operations := []Operation{}
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	for i, operation := range operations {
+		var err error
+		if target, err = apply(target, operation); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+
+	return json.Marshal(target)
+}
+
+func apply(document any, operation Operation) (any, error) {
+	path, err := parsePointer(operation.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch operation.Op {
+	case "add", "replace", "test":
+		value, err := operationValue(operation)
+		if err != nil {
+			return nil, err
+		}
+
+		switch operation.Op {
+		case "add":
+			return add(document, path, value)
+		case "replace":
+			if _, err := get(document, path); err != nil {
+				return nil, err
+			}
+			return set(document, path, value)
+		default:
+			current, err := get(document, path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(current, value) {
+				return nil, ErrTestFailed
+			}
+			return document, nil
+		}
+
+	case "remove":
+		return remove(document, path)
+
+	case "move", "copy":
+		from, err := parsePointer(operation.From)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := get(document, from)
+		if err != nil {
+			return nil, err
+		}
+
+		if operation.Op == "copy" {
+			// Copies must not share maps or slices with the original.
+			if value, err = clone(value); err != nil {
+				return nil, err
+			}
+			return add(document, path, value)
+		}
+
+		if isProperPrefix(from, path) {
+			return nil, fmt.Errorf("%w: cannot move a value into itself", ErrInvalidPatch)
+		}
+
+		if document, err = remove(document, from); err != nil {
+			return nil, err
+		}
+		return add(document, path, value)
+	}
+
+	return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, operation.Op)
+}
+
+func operationValue(operation Operation) (any, error) {
+	if operation.Value == nil {
+		return nil, fmt.Errorf("%w: %s needs a value", ErrInvalidPatch, operation.Op)
+	}
+
+	var value any
+	if err := json.Unmarshal(operation.Value, &value); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	return value, nil
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into unescaped tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: pointer %q must start with /", ErrInvalidPatch, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+func isProperPrefix(prefix, path []string) bool {
+	if len(prefix) >= len(path) {
+		return false
+	}
+
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// arrayIndex parses an array index token. "-" stands for the end of the array
+// and is only allowed where a value is added.
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if token == "-" && allowEnd {
+		return length, nil
+	}
+
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+
+	limit := length - 1
+	if allowEnd {
+		limit = length
+	}
+	if index > limit {
+		return 0, fmt.Errorf("%w: array index %d out of range", ErrInvalidPatch, index)
+	}
+
+	return index, nil
+}
+
+func get(document any, path []string) (any, error) {
+	current := document
+
+	for _, token := range path {
+		switch node := current.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: member %q not found", ErrInvalidPatch, token)
+			}
+			current = value
+		case []any:
+			index, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("%w: cannot descend into %q", ErrInvalidPatch, token)
+		}
+	}
+
+	return current, nil
+}
+
+// set replaces the value at path, whose parent has to exist, and returns the
+// new document.
+func set(document any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parentPath, token := path[:len(path)-1], path[len(path)-1]
+	parent, err := get(document, parentPath)
+	if err != nil {
+		return nil, err
+	}
+
+	switch node := parent.(type) {
+	case map[string]any:
+		node[token] = value
+		return document, nil
+	case []any:
+		index, err := arrayIndex(token, len(node), false)
+		if err != nil {
+			return nil, err
+		}
+		node[index] = value
+		return document, nil
+	}
+
+	return nil, fmt.Errorf("%w: cannot set %q", ErrInvalidPatch, token)
+}
+
+func add(document any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parentPath, token := path[:len(path)-1], path[len(path)-1]
+	parent, err := get(document, parentPath)
+	if err != nil {
+		return nil, err
+	}
+
+	switch node := parent.(type) {
+	case map[string]any:
+		node[token] = value
+		return document, nil
+	case []any:
+		index, err := arrayIndex(token, len(node), true)
+		if err != nil {
+			return nil, err
+		}
+		grown := make([]any, 0, len(node)+1)
+		grown = append(grown, node[:index]...)
+		grown = append(grown, value)
+		grown = append(grown, node[index:]...)
+		// Arrays grow into a new slice, which has to replace the old one.
+		return set(document, parentPath, grown)
+	}
+
+	return nil, fmt.Errorf("%w: cannot add %q", ErrInvalidPatch, token)
+}
+
+func remove(document any, path []string) (any, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalidPatch)
+	}
+
+	parentPath, token := path[:len(path)-1], path[len(path)-1]
+	parent, err := get(document, parentPath)
+	if err != nil {
+		return nil, err
+	}
+
+	switch node := parent.(type) {
+	case map[string]any:
+		if _, ok := node[token]; !ok {
+			return nil, fmt.Errorf("%w: member %q not found", ErrInvalidPatch, token)
+		}
+		delete(node, token)
+		return document, nil
+	case []any:
+		index, err := arrayIndex(token, len(node), false)
+		if err != nil {
+			return nil, err
+		}
+		shrunk := make([]any, 0, len(node)-1)
+		shrunk = append(shrunk, node[:index]...)
+		shrunk = append(shrunk, node[index+1:]...)
+		return set(document, parentPath, shrunk)
+	}
+
+	return nil, fmt.Errorf("%w: cannot remove %q", ErrInvalidPatch, token)
+}
+
+func clone(value any) (any, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var copied any
+	if err := json.Unmarshal(encoded, &copied); err != nil {
+		return nil, err
+	}
+
+	return copied, nil
+}
@@ -0,0 +1,67 @@
+package json_patch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const book = `{"title":"Go","author":"Rob","book_status":1,"book_attrs":{"picture":"","description":"old","rating":5},"tags":["a","b"]}`
+
+func TestMergePatch(t *testing.T) {
+	patched, err := MergePatch([]byte(book), []byte(`{"title":"Go 2","book_attrs":{"rating":8,"picture":null},"tags":["c"]}`))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"title":"Go 2","author":"Rob","book_status":1,"book_attrs":{"description":"old","rating":8},"tags":["c"]}`, string(patched))
+
+	// a non-object patch replaces the whole document
+	patched, err = MergePatch([]byte(book), []byte(`"x"`))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `"x"`, string(patched))
+
+	_, err = MergePatch([]byte(book), []byte(`{`))
+	assert.ErrorIs(t, err, ErrInvalidPatch)
+}
+
+func TestApply(t *testing.T) {
+	patch := `[
+		{"op":"test","path":"/author","value":"Rob"},
+		{"op":"replace","path":"/book_attrs/rating","value":9},
+		{"op":"add","path":"/tags/-","value":"c"},
+		{"op":"add","path":"/tags/0","value":"z"},
+		{"op":"remove","path":"/tags/1"},
+		{"op":"copy","from":"/book_attrs/description","path":"/book_attrs/picture"},
+		{"op":"move","from":"/title","path":"/book_attrs/title"},
+		{"op":"add","path":"/title","value":"Go 2"}
+	]`
+
+	patched, err := Apply([]byte(book), []byte(patch))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"title":"Go 2","author":"Rob","book_status":1,"book_attrs":{"picture":"old","description":"old","rating":9,"title":"Go"},"tags":["z","b","c"]}`, string(patched))
+}
+
+func TestApply_EscapedPointer(t *testing.T) {
+	patched, err := Apply([]byte(`{"a/b":1,"m~n":2}`), []byte(`[{"op":"replace","path":"/a~1b","value":3},{"op":"remove","path":"/m~0n"}]`))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"a/b":3}`, string(patched))
+}
+
+func TestApply_Errors(t *testing.T) {
+	for _, patch := range []string{
+		`{`,
+		`[{"op":"jump","path":"/title"}]`,
+		`[{"op":"add","path":"title","value":1}]`,
+		`[{"op":"add","path":"/title"}]`,
+		`[{"op":"replace","path":"/missing","value":1}]`,
+		`[{"op":"remove","path":"/tags/2"}]`,
+		`[{"op":"remove","path":"/tags/01"}]`,
+		`[{"op":"add","path":"/missing/child","value":1}]`,
+		`[{"op":"move","from":"/book_attrs","path":"/book_attrs/inner"}]`,
+		`[{"op":"remove","path":""}]`,
+	} {
+		_, err := Apply([]byte(book), []byte(patch))
+		assert.ErrorIs(t, err, ErrInvalidPatch, patch)
+	}
+
+	_, err := Apply([]byte(book), []byte(`[{"op":"test","path":"/book_attrs/rating","value":4}]`))
+	assert.ErrorIs(t, err, ErrTestFailed)
+}
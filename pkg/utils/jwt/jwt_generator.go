@@ -1,17 +1,25 @@
 package jwt
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"hash"
+	"io"
 	"os"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/roles_credentials"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 // signTokenFunc is an overridable function used to sign JWTs. It allows tests to simulate signing errors.
@@ -24,37 +32,83 @@ var hashWriteFunc = func(h hash.Hash, data []byte) (int, error) {
 	return h.Write(data)
 }
 
-func GenerateNewTokens(id string, credentials []string) (*models.Tokens, error) {
-	accessToken, err := generateNewAccessToken(id, credentials)
+// randReader is overridable so tests can simulate entropy failures.
+var randReader io.Reader = rand.Reader
+
+// GenerateNewTokens issues an access token and a refresh token for a new
+// session in the tenant. Only the hash of the refresh token is meant to be
+// stored.
+func GenerateNewTokens(id string, tenantID uuid.UUID, credentials []string) (*models.Tokens, error) {
+	sessionID := uuid.New()
+
+	accessToken, err := generateNewAccessToken(id, sessionID, tenantID, credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := generateNewRefreshToken(sessionID)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshToken, err := generateNewRefreshToken()
+	refreshHash, err := HashRefreshToken(refreshToken)
 	if err != nil {
 		return nil, err
 	}
 
+	hoursCount, _ := strconv.Atoi(os.Getenv("JWT_REFRESH_KEY_EXPIRE_HOURS_COUNT"))
+
 	return &models.Tokens{
-		Access:  accessToken,
-		Refresh: refreshToken,
+		Access:           accessToken,
+		Refresh:          refreshToken,
+		SessionID:        sessionID,
+		RefreshHash:      refreshHash,
+		RefreshExpiresAt: time.Now().Add(time.Hour * time.Duration(hoursCount)),
 	}, nil
 }
 
-func generateNewAccessToken(id string, credentials []string) (string, error) {
-	secret := os.Getenv("JWT_SECRET_KEY")
-	if secret == "" {
-		return "", fmt.Errorf("JWT_SECRET_KEY is not set")
-	}
+func generateNewAccessToken(id string, sessionID, tenantID uuid.UUID, credentials []string) (string, error) {
 	minutesCount, _ := strconv.Atoi(os.Getenv("JWT_SECRET_KEY_EXPIRE_MINUTES_COUNT"))
 
 	claims := jwt.MapClaims{}
 
 	claims["id"] = id
-	claims["exp"] = time.Now().Add(time.Minute * time.Duration(minutesCount)).Unix()
-	claims["book:create"] = false
-	claims["book:update"] = false
-	claims["book:delete"] = false
+	claims["sid"] = sessionID.String()
+	setTenant(claims, tenantID)
+
+	return signAccessToken(claims, credentials, time.Now().Add(time.Minute*time.Duration(minutesCount)))
+}
+
+// GenerateImpersonationToken issues an access token for the user id that
+// carries the administrator actorID in the "act" claim (RFC 8693). It has no
+// session and therefore no refresh token.
+func GenerateImpersonationToken(id, actorID string, tenantID uuid.UUID, credentials []string, expires time.Time) (string, error) {
+	claims := jwt.MapClaims{}
+
+	claims["id"] = id
+	claims["act"] = map[string]string{"sub": actorID}
+	setTenant(claims, tenantID)
+
+	return signAccessToken(claims, credentials, expires)
+}
+
+// setTenant adds the "tid" claim unless the user belongs to no organization.
+func setTenant(claims jwt.MapClaims, tenantID uuid.UUID) {
+	if tenantID != uuid.Nil {
+		claims["tid"] = tenantID.String()
+	}
+}
+
+func signAccessToken(claims jwt.MapClaims, credentials []string, expires time.Time) (string, error) {
+	secret := os.Getenv("JWT_SECRET_KEY")
+	if secret == "" {
+		return "", fmt.Errorf("JWT_SECRET_KEY is not set")
+	}
+
+	claims["exp"] = expires.Unix()
+	for _, credential := range roles_credentials.AllCredentials() {
+		claims[credential] = false
+	}
 
 	for _, credential := range credentials {
 		claims[credential] = true
@@ -69,25 +123,45 @@ func generateNewAccessToken(id string, credentials []string) (string, error) {
 	return t, nil
 }
 
-func generateNewRefreshToken() (string, error) {
-	hasher := sha256.New()
-	base := os.Getenv("JWT_REFRESH_KEY")
-	if base == "" {
+// generateNewRefreshToken returns "<session ID>.<256 random bits>". The
+// token carries no expiry: it is kept server side with the session.
+func generateNewRefreshToken(sessionID uuid.UUID) (string, error) {
+	secret := make([]byte, 32)
+	if _, err := io.ReadFull(randReader, secret); err != nil {
+		return "", err
+	}
+
+	return sessionID.String() + "." + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// HashRefreshToken returns the HMAC-SHA256 of the token keyed with
+// JWT_REFRESH_KEY, the only form in which refresh tokens are stored.
+func HashRefreshToken(refreshToken string) (string, error) {
+	key := os.Getenv("JWT_REFRESH_KEY")
+	if key == "" {
 		return "", fmt.Errorf("JWT_REFRESH_KEY is not set")
 	}
-	refresh := base + time.Now().String()
-	_, err := hashWriteFunc(hasher, []byte(refresh))
-	if err != nil {
+
+	hasher := hmac.New(sha256.New, []byte(key))
+	if _, err := hashWriteFunc(hasher, []byte(refreshToken)); err != nil {
 		return "", err
 	}
 
-	hoursCount, _ := strconv.Atoi(os.Getenv("JWT_REFRESH_KEY_EXPIRE_HOURS_COUNT"))
-	expireTime := fmt.Sprint(time.Now().Add(time.Hour * time.Duration(hoursCount)).Unix())
-	t := hex.EncodeToString(hasher.Sum(nil)) + "." + expireTime
-
-	return t, nil
+	return hex.EncodeToString(hasher.Sum(nil)), nil
 }
 
-func ParseRefreshToken(refreshToken string) (int64, error) {
-	return strconv.ParseInt(strings.Split(refreshToken, ".")[1], 0, 64)
+// ParseRefreshToken returns the session ID embedded in a refresh token. It
+// only checks the format; the token is verified against the stored hash.
+func ParseRefreshToken(refreshToken string) (uuid.UUID, error) {
+	sessionID, secret, found := strings.Cut(refreshToken, ".")
+	if !found || secret == "" {
+		return uuid.Nil, errors.New(repository.InvalidRefreshTokenErrorMessage)
+	}
+
+	id, err := uuid.Parse(sessionID)
+	if err != nil {
+		return uuid.Nil, errors.New(repository.InvalidRefreshTokenErrorMessage)
+	}
+
+	return id, nil
 }
@@ -5,15 +5,25 @@ import (
 	"strings"
 
 	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/roles_credentials"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/session_cookie"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
+// TokenMetadataKey is the fiber.Ctx locals key under which middleware stores
+// metadata it has already verified (e.g. for API key requests).
+const TokenMetadataKey = "token_metadata"
+
 // verifyTokenFunc allows tests to stub token verification.
 var verifyTokenFunc = verifyToken
 
 func ExtractTokenMetadata(c *fiber.Ctx) (*models.TokenMetadata, error) {
+	if metadata, ok := c.Locals(TokenMetadataKey).(*models.TokenMetadata); ok {
+		return metadata, nil
+	}
+
 	token, err := verifyTokenFunc(c)
 	if err != nil {
 		return nil, err
@@ -28,23 +38,50 @@ func ExtractTokenMetadata(c *fiber.Ctx) (*models.TokenMetadata, error) {
 
 		expires := int64(claims["exp"].(float64))
 
-		credentials := map[string]bool{
-			"book:create": claims["book:create"].(bool),
-			"book:update": claims["book:update"].(bool),
-			"book:delete": claims["book:delete"].(bool),
+		credentials := map[string]bool{}
+		for _, credential := range roles_credentials.AllCredentials() {
+			credentials[credential], _ = claims[credential].(bool)
 		}
 
+		// Tokens issued before sessions existed have no session ID.
+		sid, _ := claims["sid"].(string)
+		sessionID, _ := uuid.Parse(sid)
+
+		tid, _ := claims["tid"].(string)
+		tenantID, _ := uuid.Parse(tid)
+
 		return &models.TokenMetadata{
 			UserID:      userID,
 			Credentials: credentials,
 			Expires:     expires,
+			SessionID:   sessionID,
+			ActorID:     actorID(claims),
+			TenantID:    tenantID,
 		}, nil
 	}
 
 	return nil, err
 }
 
-func extractToken(c *fiber.Ctx) string {
+// actorID returns the subject of the "act" claim of an impersonation token,
+// or uuid.Nil when the token is not one.
+func actorID(claims jwt.MapClaims) uuid.UUID {
+	act, ok := claims["act"].(map[string]interface{})
+	if !ok {
+		return uuid.Nil
+	}
+
+	sub, _ := act["sub"].(string)
+	id, err := uuid.Parse(sub)
+	if err != nil {
+		return uuid.Nil
+	}
+
+	return id
+}
+
+// ExtractBearerToken returns the credential from the Authorization header.
+func ExtractBearerToken(c *fiber.Ctx) string {
 	bearToken := c.Get("Authorization")
 
 	onlyToken := strings.Split(bearToken, " ")
@@ -55,6 +92,16 @@ func extractToken(c *fiber.Ctx) string {
 	return ""
 }
 
+// extractToken prefers the Authorization header and falls back to the access
+// token cookie set in cookie session mode.
+func extractToken(c *fiber.Ctx) string {
+	if token := ExtractBearerToken(c); token != "" {
+		return token
+	}
+
+	return c.Cookies(session_cookie.AccessCookieName)
+}
+
 func verifyToken(c *fiber.Ctx) (*jwt.Token, error) {
 	tokenString := extractToken(c)
 
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"testing/iotest"
 
 	"github.com/create-go-app/fiber-go-template/app/models"
 	gojwt "github.com/golang-jwt/jwt/v5"
@@ -14,6 +15,7 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -27,7 +29,7 @@ func setDefaultEnv() {
 func TestGenerateNewTokens(t *testing.T) {
 	setDefaultEnv()
 
-	tokens, err := GenerateNewTokens("123e4567-e89b-12d3-a456-426614174000", []string{"book:create", "book:update"})
+	tokens, err := GenerateNewTokens("123e4567-e89b-12d3-a456-426614174000", uuid.Nil, []string{"book:create", "book:update"})
 	assert.NoError(t, err)
 	assert.NotEmpty(t, tokens.Access)
 	assert.NotEmpty(t, tokens.Refresh)
@@ -36,7 +38,8 @@ func TestGenerateNewTokens(t *testing.T) {
 func TestExtractTokenMetadata(t *testing.T) {
 	setDefaultEnv()
 
-	tokens, err := GenerateNewTokens("123e4567-e89b-12d3-a456-426614174000", []string{"book:create"})
+	tenantID := uuid.New()
+	tokens, err := GenerateNewTokens("123e4567-e89b-12d3-a456-426614174000", tenantID, []string{"book:create"})
 	assert.NoError(t, err)
 
 	app := fiber.New()
@@ -64,11 +67,15 @@ func TestExtractTokenMetadata(t *testing.T) {
 	assert.True(t, meta.Credentials["book:create"])
 	assert.False(t, meta.Credentials["book:delete"])
 	assert.Greater(t, meta.Expires, time.Now().Unix())
+	assert.Equal(t, tokens.SessionID, meta.SessionID)
+	assert.Equal(t, tenantID, meta.TenantID)
 }
 
-func TestParseRefreshToken_InvalidNumeric(t *testing.T) {
-	_, err := ParseRefreshToken("abc.xyz")
-	assert.Error(t, err)
+func TestParseRefreshToken_Malformed(t *testing.T) {
+	for _, token := range []string{"", "no-dot", "abc.xyz", "123e4567-e89b-12d3-a456-426614174000.", ".secret"} {
+		_, err := ParseRefreshToken(token)
+		assert.Error(t, err, token)
+	}
 }
 
 func TestGenerateNewTokens_MissingSecret(t *testing.T) {
@@ -76,7 +83,7 @@ func TestGenerateNewTokens_MissingSecret(t *testing.T) {
 	setDefaultEnv()
 	_ = os.Unsetenv("JWT_SECRET_KEY")
 
-	_, err := GenerateNewTokens("id", nil)
+	_, err := GenerateNewTokens("id", uuid.Nil, nil)
 	assert.Error(t, err)
 }
 
@@ -88,7 +95,7 @@ func TestGenerateNewTokens_SignError(t *testing.T) {
 	}
 	defer func() { signTokenFunc = orig }()
 
-	_, err := GenerateNewTokens("id", nil)
+	_, err := GenerateNewTokens("id", uuid.Nil, nil)
 	assert.Error(t, err)
 }
 
@@ -100,7 +107,7 @@ func TestGenerateNewRefreshToken_HashError(t *testing.T) {
 	}
 	defer func() { hashWriteFunc = orig }()
 
-	_, err := GenerateNewTokens("id", nil)
+	_, err := GenerateNewTokens("id", uuid.Nil, nil)
 	assert.Error(t, err)
 }
 
@@ -108,7 +115,7 @@ func TestGenerateNewTokens_MissingRefreshKey(t *testing.T) {
 	setDefaultEnv()
 	_ = os.Unsetenv("JWT_REFRESH_KEY")
 
-	_, err := GenerateNewTokens("id", nil)
+	_, err := GenerateNewTokens("id", uuid.Nil, nil)
 	assert.Error(t, err)
 }
 
@@ -212,16 +219,46 @@ func TestExtractTokenMetadata_InvalidUserID(t *testing.T) {
 func TestParseRefreshToken(t *testing.T) {
 	setDefaultEnv()
 
-	tokens, err := GenerateNewTokens("123e4567-e89b-12d3-a456-426614174000", nil)
+	tokens, err := GenerateNewTokens("123e4567-e89b-12d3-a456-426614174000", uuid.Nil, nil)
 	assert.NoError(t, err)
 
-	exp, err := ParseRefreshToken(tokens.Refresh)
+	sessionID, err := ParseRefreshToken(tokens.Refresh)
 	assert.NoError(t, err)
+	assert.Equal(t, tokens.SessionID, sessionID)
 
-	now := time.Now().Unix()
-	oneHourLater := now + int64(3600)
-	assert.Greater(t, exp, now)
-	assert.LessOrEqual(t, exp, oneHourLater+int64(3600))
+	now := time.Now()
+	assert.True(t, tokens.RefreshExpiresAt.After(now))
+	assert.True(t, tokens.RefreshExpiresAt.Before(now.Add(time.Hour+time.Minute)))
+}
+
+func TestGenerateNewTokens_RandomRefreshTokens(t *testing.T) {
+	setDefaultEnv()
+
+	first, err := GenerateNewTokens("123e4567-e89b-12d3-a456-426614174000", uuid.Nil, nil)
+	assert.NoError(t, err)
+	second, err := GenerateNewTokens("123e4567-e89b-12d3-a456-426614174000", uuid.Nil, nil)
+	assert.NoError(t, err)
+
+	assert.NotEqual(t, first.Refresh, second.Refresh)
+	assert.NotEqual(t, first.SessionID, second.SessionID)
+
+	// 36 characters of session ID, a dot and 256 bits in unpadded base64.
+	assert.Len(t, first.Refresh, 36+1+43)
+
+	hash, err := HashRefreshToken(first.Refresh)
+	assert.NoError(t, err)
+	assert.Equal(t, first.RefreshHash, hash)
+	assert.NotContains(t, first.RefreshHash, first.Refresh)
+}
+
+func TestGenerateNewTokens_RandError(t *testing.T) {
+	setDefaultEnv()
+	orig := randReader
+	randReader = iotest.ErrReader(errors.New("no entropy"))
+	defer func() { randReader = orig }()
+
+	_, err := GenerateNewTokens("id", uuid.Nil, nil)
+	assert.Error(t, err)
 }
 
 func TestExtractTokenMetadata_FallbackBranch(t *testing.T) {
@@ -245,3 +282,39 @@ func TestExtractTokenMetadata_FallbackBranch(t *testing.T) {
 	assert.Nil(t, meta)
 	assert.Nil(t, err)
 }
+
+func TestGenerateImpersonationToken(t *testing.T) {
+	setDefaultEnv()
+
+	userID := "123e4567-e89b-12d3-a456-426614174000"
+	actorID := "00000000-0000-0000-0000-0000000000aa"
+	tenantID := uuid.New()
+	token, err := GenerateImpersonationToken(userID, actorID, tenantID, []string{"book:create"}, time.Now().Add(5*time.Minute))
+	assert.NoError(t, err)
+
+	app := fiber.New()
+	var meta *models.TokenMetadata
+	app.Get("/", func(c *fiber.Ctx) error {
+		meta, err = ExtractTokenMetadata(c)
+		return err
+	})
+
+	req := httptest.NewRequest("GET", "http://localhost/", http.NoBody)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	assert.Equal(t, userID, meta.UserID.String())
+	assert.Equal(t, actorID, meta.ActorID.String())
+	assert.True(t, meta.Impersonated())
+	assert.Equal(t, tenantID, meta.TenantID)
+	assert.True(t, meta.Credentials["book:create"])
+	assert.Equal(t, "00000000-0000-0000-0000-000000000000", meta.SessionID.String())
+}
+
+func TestActorID_Malformed(t *testing.T) {
+	assert.False(t, (&models.TokenMetadata{ActorID: actorID(gojwt.MapClaims{})}).Impersonated())
+	assert.False(t, (&models.TokenMetadata{ActorID: actorID(gojwt.MapClaims{"act": "admin"})}).Impersonated())
+	assert.False(t, (&models.TokenMetadata{ActorID: actorID(gojwt.MapClaims{"act": map[string]interface{}{"sub": "x"}})}).Impersonated())
+}
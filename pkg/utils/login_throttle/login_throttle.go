@@ -0,0 +1,182 @@
+package login_throttle
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/platform/cache"
+)
+
+type Config struct {
+	MaxAccountFailures int           // failures before the account is locked
+	MaxIPFailures      int           // failures before the IP address is locked
+	FailureWindow      time.Duration // how long failures are remembered
+	LockoutDuration    time.Duration
+	BaseDelay          time.Duration // wait after the first failure, doubled for every next one
+	MaxDelay           time.Duration
+}
+
+// ConfigFromEnv reads the LOGIN_* settings, falling back to safe defaults.
+func ConfigFromEnv() Config {
+	return Config{
+		MaxAccountFailures: envInt("LOGIN_MAX_ACCOUNT_FAILURES", 5),
+		MaxIPFailures:      envInt("LOGIN_MAX_IP_FAILURES", 20),
+		FailureWindow:      time.Minute * time.Duration(envInt("LOGIN_FAILURE_WINDOW_MINUTES", 15)),
+		LockoutDuration:    time.Minute * time.Duration(envInt("LOGIN_LOCKOUT_MINUTES", 15)),
+		BaseDelay:          time.Second * time.Duration(envInt("LOGIN_BASE_DELAY_SECONDS", 1)),
+		MaxDelay:           time.Second * time.Duration(envInt("LOGIN_MAX_DELAY_SECONDS", 30)),
+	}
+}
+
+func envInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+
+	return value
+}
+
+// memoryStore is shared by all throttlers so the fallback keeps its counters
+// between requests.
+var memoryStore = NewMemoryStore()
+
+// redisConnection allows tests to run without Redis.
+var redisConnection = func() (Store, error) {
+	client, err := cache.RedisConnection()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRedisStore(client), nil
+}
+
+type Throttler struct {
+	store  Store
+	config Config
+}
+
+func New(store Store, config Config) *Throttler {
+	return &Throttler{store: store, config: config}
+}
+
+// defaultStore is built on first use and shared by every default throttler,
+// so the process keeps a single Redis client.
+var (
+	defaultStore     Store
+	defaultStoreOnce sync.Once
+)
+
+// NewDefault builds a throttler backed by Redis with the in-memory fallback.
+func NewDefault() *Throttler {
+	defaultStoreOnce.Do(func() {
+		primary, err := redisConnection()
+		if err != nil {
+			primary = nil
+		}
+
+		defaultStore = NewFallbackStore(primary, memoryStore)
+	})
+
+	return New(defaultStore, ConfigFromEnv())
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func failuresKey(scope, id string) string { return "login:failures:" + scope + ":" + id }
+func lockKey(scope, id string) string     { return "login:lock:" + scope + ":" + id }
+func delayKey(scope, id string) string    { return "login:delay:" + scope + ":" + id }
+
+// Check returns how long the caller has to wait before the next attempt for
+// this account and IP address is allowed. Zero means the attempt may proceed.
+func (t *Throttler) Check(ctx context.Context, email, ip string) (time.Duration, error) {
+	keys := []string{
+		lockKey("account", normalizeEmail(email)),
+		lockKey("ip", ip),
+		delayKey("account", normalizeEmail(email)),
+		delayKey("ip", ip),
+	}
+
+	var retryAfter time.Duration
+	for _, key := range keys {
+		count, ttl, err := t.store.Get(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+		if count > 0 && ttl > retryAfter {
+			retryAfter = ttl
+		}
+	}
+
+	return retryAfter, nil
+}
+
+// RecordFailure counts a failed attempt and returns how long the caller has
+// to wait before trying again.
+func (t *Throttler) RecordFailure(ctx context.Context, email, ip string) (time.Duration, error) {
+	accountWait, err := t.recordFailure(ctx, "account", normalizeEmail(email), t.config.MaxAccountFailures)
+	if err != nil {
+		return 0, err
+	}
+
+	ipWait, err := t.recordFailure(ctx, "ip", ip, t.config.MaxIPFailures)
+	if err != nil {
+		return 0, err
+	}
+
+	return max(accountWait, ipWait), nil
+}
+
+func (t *Throttler) recordFailure(ctx context.Context, scope, id string, maxFailures int) (time.Duration, error) {
+	failures, err := t.store.Incr(ctx, failuresKey(scope, id), t.config.FailureWindow)
+	if err != nil {
+		return 0, err
+	}
+
+	if failures >= int64(maxFailures) {
+		if _, err := t.store.Incr(ctx, lockKey(scope, id), t.config.LockoutDuration); err != nil {
+			return 0, err
+		}
+		return t.config.LockoutDuration, nil
+	}
+
+	delay := t.Delay(failures)
+	if _, err := t.store.Incr(ctx, delayKey(scope, id), delay); err != nil {
+		return 0, err
+	}
+
+	return delay, nil
+}
+
+// Delay is the progressive wait after the given number of failures.
+func (t *Throttler) Delay(failures int64) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+
+	delay := t.config.BaseDelay
+	for i := int64(1); i < failures && delay < t.config.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, t.config.MaxDelay)
+}
+
+// RecordSuccess clears the account counters. IP counters are kept so a
+// successful login cannot be used to reset a credential-stuffing run.
+func (t *Throttler) RecordSuccess(ctx context.Context, email string) error {
+	return t.Unlock(ctx, email)
+}
+
+// Unlock removes the lockout and failure counters of an account.
+func (t *Throttler) Unlock(ctx context.Context, email string) error {
+	id := normalizeEmail(email)
+
+	return t.store.Del(ctx, failuresKey("account", id), lockKey("account", id), delayKey("account", id))
+}
@@ -0,0 +1,185 @@
+package login_throttle
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testConfig = Config{
+	MaxAccountFailures: 3,
+	MaxIPFailures:      5,
+	FailureWindow:      time.Minute,
+	LockoutDuration:    10 * time.Minute,
+	BaseDelay:          time.Second,
+	MaxDelay:           4 * time.Second,
+}
+
+type failingStore struct{}
+
+func (failingStore) Incr(context.Context, string, time.Duration) (int64, error) {
+	return 0, errors.New("store error")
+}
+func (failingStore) Get(context.Context, string) (int64, time.Duration, error) {
+	return 0, 0, errors.New("store error")
+}
+func (failingStore) Del(context.Context, ...string) error { return errors.New("store error") }
+
+func TestThrottler_ProgressiveDelayAndLockout(t *testing.T) {
+	ctx := context.Background()
+	throttler := New(NewMemoryStore(), testConfig)
+
+	wait, err := throttler.Check(ctx, "user@example.com", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Zero(t, wait)
+
+	wait, err = throttler.RecordFailure(ctx, "user@example.com", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second, wait)
+
+	wait, err = throttler.Check(ctx, "USER@example.com ", "10.0.0.2")
+	assert.NoError(t, err)
+	assert.Greater(t, wait, time.Duration(0))
+
+	wait, _ = throttler.RecordFailure(ctx, "user@example.com", "10.0.0.1")
+	assert.Equal(t, 2*time.Second, wait)
+
+	wait, _ = throttler.RecordFailure(ctx, "user@example.com", "10.0.0.1")
+	assert.Equal(t, testConfig.LockoutDuration, wait)
+
+	wait, _ = throttler.Check(ctx, "user@example.com", "10.0.0.3")
+	assert.Greater(t, wait, 9*time.Minute)
+
+	assert.NoError(t, throttler.Unlock(ctx, "user@example.com"))
+	wait, _ = throttler.Check(ctx, "user@example.com", "10.0.0.3")
+	assert.Zero(t, wait)
+}
+
+func TestThrottler_IPLockout(t *testing.T) {
+	ctx := context.Background()
+	throttler := New(NewMemoryStore(), testConfig)
+
+	emails := []string{"a@example.com", "b@example.com", "c@example.com", "d@example.com", "e@example.com"}
+	var wait time.Duration
+	for _, email := range emails {
+		wait, _ = throttler.RecordFailure(ctx, email, "10.0.0.9")
+	}
+	assert.Equal(t, testConfig.LockoutDuration, wait)
+
+	wait, _ = throttler.Check(ctx, "fresh@example.com", "10.0.0.9")
+	assert.Greater(t, wait, 9*time.Minute)
+
+	// Success clears account counters but keeps the IP lock.
+	assert.NoError(t, throttler.RecordSuccess(ctx, "fresh@example.com"))
+	wait, _ = throttler.Check(ctx, "fresh@example.com", "10.0.0.9")
+	assert.Greater(t, wait, time.Duration(0))
+}
+
+func TestThrottler_Delay(t *testing.T) {
+	throttler := New(NewMemoryStore(), testConfig)
+
+	assert.Zero(t, throttler.Delay(0))
+	assert.Equal(t, time.Second, throttler.Delay(1))
+	assert.Equal(t, 2*time.Second, throttler.Delay(2))
+	assert.Equal(t, 4*time.Second, throttler.Delay(3))
+	assert.Equal(t, 4*time.Second, throttler.Delay(30))
+}
+
+func TestMemoryStore_Expiry(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	count, err := store.Incr(ctx, "k", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	count, ttl, _ := store.Get(ctx, "k")
+	assert.Equal(t, int64(1), count)
+	assert.Equal(t, time.Minute, ttl)
+
+	now = now.Add(2 * time.Minute)
+	count, _, _ = store.Get(ctx, "k")
+	assert.Zero(t, count)
+
+	count, _ = store.Incr(ctx, "k", time.Minute)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestFallbackStore(t *testing.T) {
+	ctx := context.Background()
+	fallback := NewMemoryStore()
+	store := NewFallbackStore(failingStore{}, fallback)
+
+	count, err := store.Incr(ctx, "k", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	count, _, err = store.Get(ctx, "k")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	assert.NoError(t, store.Del(ctx, "k"))
+	count, _, _ = fallback.Get(ctx, "k")
+	assert.Zero(t, count)
+
+	throttler := New(NewFallbackStore(nil, NewMemoryStore()), testConfig)
+	_, err = throttler.RecordFailure(ctx, "user@example.com", "10.0.0.1")
+	assert.NoError(t, err)
+}
+
+func TestThrottler_StoreErrors(t *testing.T) {
+	ctx := context.Background()
+	throttler := New(failingStore{}, testConfig)
+
+	_, err := throttler.Check(ctx, "user@example.com", "10.0.0.1")
+	assert.Error(t, err)
+
+	_, err = throttler.RecordFailure(ctx, "user@example.com", "10.0.0.1")
+	assert.Error(t, err)
+
+	assert.Error(t, throttler.Unlock(ctx, "user@example.com"))
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	_ = os.Setenv("LOGIN_MAX_ACCOUNT_FAILURES", "7")
+	_ = os.Setenv("LOGIN_LOCKOUT_MINUTES", "invalid")
+	defer os.Unsetenv("LOGIN_MAX_ACCOUNT_FAILURES")
+	defer os.Unsetenv("LOGIN_LOCKOUT_MINUTES")
+
+	config := ConfigFromEnv()
+	assert.Equal(t, 7, config.MaxAccountFailures)
+	assert.Equal(t, 15*time.Minute, config.LockoutDuration)
+}
+
+func TestNewDefault_WithoutRedis(t *testing.T) {
+	orig := redisConnection
+	redisConnection = func() (Store, error) { return nil, errors.New("redis unavailable") }
+	defaultStoreOnce = sync.Once{}
+	defer func() { redisConnection = orig; defaultStoreOnce = sync.Once{} }()
+
+	throttler := NewDefault()
+	_, err := throttler.RecordFailure(context.Background(), "user@example.com", "10.0.0.1")
+	assert.NoError(t, err)
+}
+
+func TestNewDefault_SharesConnection(t *testing.T) {
+	orig := redisConnection
+	connections := 0
+	redisConnection = func() (Store, error) {
+		connections++
+		return NewMemoryStore(), nil
+	}
+	defaultStoreOnce = sync.Once{}
+	defer func() { redisConnection = orig; defaultStoreOnce = sync.Once{} }()
+
+	NewDefault()
+	NewDefault()
+	assert.Equal(t, 1, connections)
+}
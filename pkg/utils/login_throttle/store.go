@@ -0,0 +1,169 @@
+package login_throttle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Store keeps expiring counters for the throttler.
+type Store interface {
+	// Incr increments the counter and starts its TTL when it is created.
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// Get returns the counter value and its remaining TTL, or zeros when the
+	// counter does not exist.
+	Get(ctx context.Context, key string) (int64, time.Duration, error)
+	Del(ctx context.Context, keys ...string) error
+}
+
+// RedisStore keeps counters in Redis, so limits are shared by all instances.
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+// Incr creates the counter with its expiry and increments it in one
+// transaction, so a counter never outlives its window.
+func (s *RedisStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	var incr *redis.IntCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SetNX(ctx, key, 0, ttl)
+		incr = pipe.Incr(ctx, key)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return incr.Val(), nil
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) (int64, time.Duration, error) {
+	count, err := s.client.Get(ctx, key).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	ttl, err := s.client.TTL(ctx, key).Result()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return count, ttl, nil
+}
+
+func (s *RedisStore) Del(ctx context.Context, keys ...string) error {
+	return s.client.Del(ctx, keys...).Err()
+}
+
+type memoryEntry struct {
+	count   int64
+	expires time.Time
+}
+
+// MemoryStore keeps counters in process memory. It is used when Redis is not
+// reachable, so limits then apply per instance only.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	now     func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: map[string]memoryEntry{}, now: time.Now}
+}
+
+func (s *MemoryStore) Incr(_ context.Context, key string, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	entry, ok := s.entries[key]
+	if !ok || !now.Before(entry.expires) {
+		entry = memoryEntry{expires: now.Add(ttl)}
+	}
+	entry.count++
+	s.entries[key] = entry
+
+	return entry.count, nil
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string) (int64, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	entry, ok := s.entries[key]
+	if !ok {
+		return 0, 0, nil
+	}
+	if !now.Before(entry.expires) {
+		delete(s.entries, key)
+		return 0, 0, nil
+	}
+
+	return entry.count, entry.expires.Sub(now), nil
+}
+
+func (s *MemoryStore) Del(_ context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		delete(s.entries, key)
+	}
+
+	return nil
+}
+
+// FallbackStore uses the primary store and switches to the fallback for any
+// operation the primary fails. A nil primary always uses the fallback.
+type FallbackStore struct {
+	primary  Store
+	fallback Store
+}
+
+func NewFallbackStore(primary, fallback Store) *FallbackStore {
+	return &FallbackStore{primary: primary, fallback: fallback}
+}
+
+func (s *FallbackStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	if s.primary != nil {
+		if count, err := s.primary.Incr(ctx, key, ttl); err == nil {
+			return count, nil
+		}
+	}
+
+	return s.fallback.Incr(ctx, key, ttl)
+}
+
+func (s *FallbackStore) Get(ctx context.Context, key string) (int64, time.Duration, error) {
+	if s.primary != nil {
+		if count, ttl, err := s.primary.Get(ctx, key); err == nil {
+			return count, ttl, nil
+		}
+	}
+
+	return s.fallback.Get(ctx, key)
+}
+
+func (s *FallbackStore) Del(ctx context.Context, keys ...string) error {
+	// Clear both stores so a lock set during a Redis outage does not outlive it.
+	fallbackErr := s.fallback.Del(ctx, keys...)
+	if s.primary != nil {
+		if err := s.primary.Del(ctx, keys...); err == nil {
+			return nil
+		}
+	}
+
+	return fallbackErr
+}
@@ -0,0 +1,45 @@
+package mailer
+
+import (
+	"net/smtp"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMailer(t *testing.T) {
+	_ = os.Unsetenv("SMTP_HOST")
+	assert.IsType(t, LogMailer{}, NewMailer())
+
+	_ = os.Setenv("SMTP_HOST", "smtp.example.com")
+	_ = os.Setenv("SMTP_PORT", "587")
+	defer os.Unsetenv("SMTP_HOST")
+	defer os.Unsetenv("SMTP_PORT")
+
+	m, ok := NewMailer().(SMTPMailer)
+	assert.True(t, ok)
+	assert.Equal(t, "smtp.example.com:587", m.Addr)
+}
+
+func TestLogMailer_Send(t *testing.T) {
+	assert.NoError(t, LogMailer{}.Send("user@example.com", "subject", "body"))
+}
+
+func TestSMTPMailer_Send(t *testing.T) {
+	orig := sendMailFunc
+	defer func() { sendMailFunc = orig }()
+
+	var sent string
+	sendMailFunc = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		sent = string(msg)
+		return nil
+	}
+
+	m := SMTPMailer{Addr: "localhost:25", Host: "localhost", Username: "u", Password: "p", From: "noreply@example.com"}
+	assert.NoError(t, m.Send("user@example.com", "Hello", "body"))
+	assert.True(t, strings.HasPrefix(sent, "From: noreply@example.com\r\nTo: user@example.com\r\nSubject: Hello\r\n"))
+
+	assert.Error(t, m.Send("user@example.com\r\nBcc: x@example.com", "Hello", "body"))
+}
@@ -1,6 +1,15 @@
 package password_generator
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	dummyHash     string
+	dummyHashOnce sync.Once
+)
 
 func NormalizePassword(p string) []byte {
 	return []byte(p)
@@ -27,3 +36,16 @@ func ComparePasswords(hashedPwd, inputPwd string) bool {
 
 	return true
 }
+
+// CompareDummyPassword spends the same time as ComparePasswords against a
+// real hash and always returns false. Use it when the account is unknown, so
+// response timing does not reveal which emails are registered.
+func CompareDummyPassword(inputPwd string) bool {
+	dummyHashOnce.Do(func() {
+		dummyHash = GeneratePassword("dummy-password-for-timing")
+	})
+
+	ComparePasswords(dummyHash, inputPwd)
+
+	return false
+}
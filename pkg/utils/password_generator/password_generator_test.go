@@ -41,6 +41,16 @@ func TestPasswordGenerationAndComparison(t *testing.T) {
 	}
 }
 
+func TestCompareDummyPassword(t *testing.T) {
+	if CompareDummyPassword("dummy-password-for-timing") {
+		t.Fatalf("CompareDummyPassword should always return false")
+	}
+
+	if dummyHash == "" {
+		t.Fatalf("dummy hash should be generated on first use")
+	}
+}
+
 func TestWrapperResponses(t *testing.T) {
 	app := fiber.New()
 
@@ -0,0 +1,92 @@
+package password_policy
+
+import (
+	"bufio"
+	"crypto/sha1" // #nosec G505 -- SHA-1 only matches the format of public breach corpora
+	"encoding/hex"
+	"os"
+	"strings"
+	"sync"
+)
+
+// BreachedList is a set of known-compromised passwords. The file holds one
+// entry per line, either the plaintext password or its uppercase SHA-1 hex
+// digest (the format of public breach corpora). A ":count" suffix is ignored.
+type BreachedList struct {
+	plain  map[string]struct{}
+	hashed map[string]struct{}
+}
+
+var (
+	breachedListsMu sync.Mutex
+	breachedLists   = map[string]*BreachedList{}
+)
+
+// LoadBreachedList reads the file once and caches it by path.
+func LoadBreachedList(path string) (*BreachedList, error) {
+	breachedListsMu.Lock()
+	defer breachedListsMu.Unlock()
+
+	if list, ok := breachedLists[path]; ok {
+		return list, nil
+	}
+
+	file, err := os.Open(path) // #nosec G304 -- path comes from configuration
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	list := &BreachedList{plain: map[string]struct{}{}, hashed: map[string]struct{}{}}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if entry, _, found := strings.Cut(line, ":"); found && isSHA1Hex(entry) {
+			line = entry
+		}
+
+		if isSHA1Hex(line) {
+			list.hashed[strings.ToUpper(line)] = struct{}{}
+		} else {
+			list.plain[strings.ToLower(line)] = struct{}{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	breachedLists[path] = list
+
+	return list, nil
+}
+
+// Contains reports whether the password is on the list. Plaintext entries are
+// matched case-insensitively.
+func (l *BreachedList) Contains(password string) bool {
+	if l == nil {
+		return false
+	}
+
+	if _, ok := l.plain[strings.ToLower(password)]; ok {
+		return true
+	}
+
+	sum := sha1.Sum([]byte(password)) // #nosec G401
+	_, ok := l.hashed[strings.ToUpper(hex.EncodeToString(sum[:]))]
+
+	return ok
+}
+
+func isSHA1Hex(s string) bool {
+	if len(s) != sha1.Size*2 {
+		return false
+	}
+
+	_, err := hex.DecodeString(s)
+
+	return err == nil
+}
@@ -0,0 +1,132 @@
+package password_policy
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Rule names double as validator tags, so a violation surfaces as e.g.
+// "Password: password_min_length".
+const (
+	RuleMinLength = "password_min_length"
+	RuleMaxLength = "password_max_length"
+	RuleUpper     = "password_upper"
+	RuleLower     = "password_lower"
+	RuleDigit     = "password_digit"
+	RuleSymbol    = "password_symbol"
+	RuleStrength  = "password_strength"
+	RuleBreached  = "password_breached"
+)
+
+// Rules lists every rule in the order they are checked.
+var Rules = []string{
+	RuleMinLength, RuleMaxLength, RuleUpper, RuleLower, RuleDigit, RuleSymbol, RuleStrength, RuleBreached,
+}
+
+type Policy struct {
+	MinLength        int // 0 disables the rule
+	MaxLength        int // 0 disables the rule
+	RequireUpper     bool
+	RequireLower     bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	MinStrength      int    // 0..4, see Strength; 0 disables the rule
+	BreachedListPath string // empty disables the rule
+}
+
+// PolicyFromEnv reads the PASSWORD_* settings, falling back to defaults.
+func PolicyFromEnv() Policy {
+	return Policy{
+		MinLength:        envInt("PASSWORD_MIN_LENGTH", 8),
+		MaxLength:        envInt("PASSWORD_MAX_LENGTH", 72), // bcrypt ignores everything past 72 bytes
+		RequireUpper:     envBool("PASSWORD_REQUIRE_UPPER", true),
+		RequireLower:     envBool("PASSWORD_REQUIRE_LOWER", true),
+		RequireDigit:     envBool("PASSWORD_REQUIRE_DIGIT", true),
+		RequireSymbol:    envBool("PASSWORD_REQUIRE_SYMBOL", false),
+		MinStrength:      envInt("PASSWORD_MIN_STRENGTH", 2),
+		BreachedListPath: os.Getenv("PASSWORD_BREACHED_LIST_PATH"),
+	}
+}
+
+func envInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value < 0 {
+		return fallback
+	}
+
+	return value
+}
+
+func envBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+
+	return value
+}
+
+// Check returns the names of all rules the password violates.
+func (p Policy) Check(password string) []string {
+	violations := make([]string, 0)
+	for _, rule := range Rules {
+		if !p.Satisfies(rule, password) {
+			violations = append(violations, rule)
+		}
+	}
+
+	return violations
+}
+
+// Satisfies reports whether the password passes a single rule. Disabled and
+// unknown rules always pass.
+func (p Policy) Satisfies(rule, password string) bool {
+	switch rule {
+	case RuleMinLength:
+		return p.MinLength == 0 || utf8.RuneCountInString(password) >= p.MinLength
+	case RuleMaxLength:
+		return p.MaxLength == 0 || len(password) <= p.MaxLength
+	case RuleUpper:
+		return !p.RequireUpper || strings.IndexFunc(password, unicode.IsUpper) >= 0
+	case RuleLower:
+		return !p.RequireLower || strings.IndexFunc(password, unicode.IsLower) >= 0
+	case RuleDigit:
+		return !p.RequireDigit || strings.IndexFunc(password, unicode.IsDigit) >= 0
+	case RuleSymbol:
+		return !p.RequireSymbol || strings.IndexFunc(password, isSymbol) >= 0
+	case RuleStrength:
+		list, err := p.breachedList()
+		return p.MinStrength == 0 || (err == nil && Strength(password, list) >= p.MinStrength)
+	case RuleBreached:
+		if p.BreachedListPath == "" {
+			return true
+		}
+		// A list that cannot be read fails every password rather than none.
+		list, err := p.breachedList()
+		return err == nil && !list.Contains(password)
+	}
+
+	return true
+}
+
+// Preload reads the breached password list, if any, so a missing or unreadable
+// file is reported at startup instead of on the first password checked.
+func (p Policy) Preload() error {
+	_, err := p.breachedList()
+	return err
+}
+
+func (p Policy) breachedList() (*BreachedList, error) {
+	if p.BreachedListPath == "" {
+		return nil, nil
+	}
+
+	return LoadBreachedList(p.BreachedListPath)
+}
+
+func isSymbol(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsSpace(r)
+}
@@ -0,0 +1,120 @@
+package password_policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var strictPolicy = Policy{
+	MinLength:     10,
+	MaxLength:     20,
+	RequireUpper:  true,
+	RequireLower:  true,
+	RequireDigit:  true,
+	RequireSymbol: true,
+	MinStrength:   3,
+}
+
+func writeBreachedList(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write breached list: %v", err)
+	}
+	return path
+}
+
+func TestPolicy_Check(t *testing.T) {
+	assert.Empty(t, strictPolicy.Check("Xk9#mQ2v-Lp"))
+
+	assert.ElementsMatch(t,
+		[]string{RuleMinLength, RuleUpper, RuleDigit, RuleSymbol, RuleStrength},
+		strictPolicy.Check("password"))
+
+	assert.Contains(t, strictPolicy.Check("Xk9#mQ2v-Lp-Xk9#mQ2v-Lp"), RuleMaxLength)
+}
+
+func TestPolicy_DisabledRules(t *testing.T) {
+	assert.Empty(t, Policy{}.Check("a"))
+	assert.True(t, Policy{}.Satisfies("unknown_rule", ""))
+}
+
+func TestPolicy_Breached(t *testing.T) {
+	// SHA-1 of "Tr0ub4dour&3" with a count suffix, as in public corpora.
+	path := writeBreachedList(t, "# comment\nhunter22\n9F206FA9619ECB33A6F1D80FF54995760F6663D0:12\n")
+	policy := Policy{BreachedListPath: path, MinStrength: 1}
+
+	assert.Equal(t, []string{RuleStrength, RuleBreached}, policy.Check("HUNTER22"))
+	assert.Equal(t, []string{RuleStrength, RuleBreached}, policy.Check("Tr0ub4dour&3"))
+	assert.Empty(t, policy.Check("Xk9#mQ2v-Lp"))
+
+	assert.NoError(t, policy.Preload())
+}
+
+func TestPolicy_BreachedListMissing(t *testing.T) {
+	missing := Policy{BreachedListPath: filepath.Join(t.TempDir(), "missing.txt"), MinStrength: 1}
+
+	assert.Error(t, missing.Preload())
+	assert.Equal(t, []string{RuleStrength, RuleBreached}, missing.Check("Xk9#mQ2v-Lp"))
+	assert.NoError(t, Policy{}.Preload())
+}
+
+func TestLoadBreachedList(t *testing.T) {
+	path := writeBreachedList(t, "letmein\n")
+
+	list, err := LoadBreachedList(path)
+	assert.NoError(t, err)
+	assert.True(t, list.Contains("LetMeIn"))
+	assert.False(t, list.Contains("letmeout"))
+
+	cached, _ := LoadBreachedList(path)
+	assert.Same(t, list, cached)
+
+	_, err = LoadBreachedList(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+
+	var nilList *BreachedList
+	assert.False(t, nilList.Contains("letmein"))
+}
+
+func TestStrength(t *testing.T) {
+	tests := []struct {
+		password string
+		max      int
+		min      int
+	}{
+		{password: "", max: 0},
+		{password: "password", max: 0},
+		{password: "P@ssw0rd1", max: 1},
+		{password: "aaaaaaaa", max: 0},
+		{password: "12345678", max: 0},
+		{password: "qwertyuiop", max: 1},
+		{password: "abcdefgh", max: 0},
+		{password: "Xk9#mQ2v", min: 4, max: 4},
+		{password: "correct horse battery staple", min: 4, max: 4},
+	}
+
+	for _, tt := range tests {
+		score := Strength(tt.password, nil)
+		assert.GreaterOrEqual(t, score, tt.min, tt.password)
+		assert.LessOrEqual(t, score, tt.max, tt.password)
+	}
+}
+
+func TestPolicyFromEnv(t *testing.T) {
+	_ = os.Setenv("PASSWORD_MIN_LENGTH", "12")
+	_ = os.Setenv("PASSWORD_REQUIRE_SYMBOL", "true")
+	_ = os.Setenv("PASSWORD_REQUIRE_UPPER", "not-a-bool")
+	defer os.Unsetenv("PASSWORD_MIN_LENGTH")
+	defer os.Unsetenv("PASSWORD_REQUIRE_SYMBOL")
+	defer os.Unsetenv("PASSWORD_REQUIRE_UPPER")
+
+	policy := PolicyFromEnv()
+	assert.Equal(t, 12, policy.MinLength)
+	assert.True(t, policy.RequireSymbol)
+	assert.True(t, policy.RequireUpper)
+	assert.Equal(t, 72, policy.MaxLength)
+}
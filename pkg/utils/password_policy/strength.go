@@ -0,0 +1,188 @@
+package password_policy
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// commonPasswords is a small built-in dictionary of the most used passwords
+// and password fragments.
+var commonPasswords = []string{
+	"password", "passw0rd", "p@ssword", "p@ssw0rd", "qwerty", "letmein", "welcome", "admin",
+	"administrator", "login", "master", "monkey", "dragon", "football", "baseball", "iloveyou",
+	"princess", "sunshine", "shadow", "superman", "batman", "trustno1", "starwars", "whatever",
+	"freedom", "secret", "hello", "charlie", "michael", "jordan", "ninja", "mustang", "access",
+	"flower", "cheese", "summer", "winter", "spring", "autumn", "computer", "internet", "google",
+	"changeme", "default", "guest", "root", "user", "test", "qazwsx", "zaq12wsx", "abc123",
+}
+
+// keyboardRows are checked for runs like "qwer" or "asdf" in either direction.
+var keyboardRows = []string{
+	"`1234567890-=", "qwertyuiop[]\\", "asdfghjkl;'", "zxcvbnm,./",
+}
+
+var leetSubstitutions = strings.NewReplacer(
+	"4", "a", "@", "a", "8", "b", "3", "e", "6", "g", "1", "i", "!", "i", "0", "o", "5", "s", "$", "s", "7", "t", "+", "t",
+)
+
+// Strength estimates how hard the password is to guess on the zxcvbn scale:
+// 0 (too guessable) to 4 (very unguessable). The password is split greedily
+// into the cheapest known patterns (dictionary words, repeats, sequences,
+// keyboard runs) and the log10 guesses of every segment are added up. A
+// password on the breached list always scores 0.
+func Strength(password string, breached *BreachedList) int {
+	if password == "" || breached.Contains(password) {
+		return 0
+	}
+
+	runes := []rune(password)
+	lower := []rune(strings.ToLower(password))
+
+	guessesLog10 := 0.0
+	for i := 0; i < len(runes); {
+		length, cost := cheapestMatch(lower, runes, i)
+		guessesLog10 += cost
+		i += length
+	}
+
+	switch {
+	case guessesLog10 < 3:
+		return 0
+	case guessesLog10 < 6:
+		return 1
+	case guessesLog10 < 8:
+		return 2
+	case guessesLog10 < 10:
+		return 3
+	}
+
+	return 4
+}
+
+// cheapestMatch returns the length and log10 guesses of the longest pattern
+// starting at i, falling back to a single brute-forced character.
+func cheapestMatch(lower, original []rune, i int) (int, float64) {
+	bestLength, bestCost := 1, math.Log10(float64(charsetSize(original[i])))
+
+	consider := func(length int, cost float64) {
+		if length > 1 && cost/float64(length) < bestCost/float64(bestLength) {
+			bestLength, bestCost = length, cost
+		}
+	}
+
+	if length := dictionaryMatch(lower, i); length > 0 {
+		cost := math.Log10(float64(len(commonPasswords)))
+		if hasUpper(original[i : i+length]) {
+			cost += math.Log10(2)
+		}
+		consider(length, cost)
+	}
+
+	if length := repeatMatch(lower, i); length >= 3 {
+		consider(length, math.Log10(float64(charsetSize(original[i])*length)))
+	}
+
+	if length := sequenceMatch(lower, i); length >= 3 {
+		consider(length, math.Log10(float64(charsetSize(original[i])*length)))
+	}
+
+	if length := keyboardMatch(lower, i); length >= 3 {
+		consider(length, math.Log10(float64(len(keyboardRows)*2*length*10)))
+	}
+
+	return bestLength, bestCost
+}
+
+func dictionaryMatch(lower []rune, i int) int {
+	for end := len(lower); end > i+2; end-- {
+		candidate := leetSubstitutions.Replace(string(lower[i:end]))
+		for _, word := range commonPasswords {
+			if candidate == word {
+				return end - i
+			}
+		}
+	}
+
+	return 0
+}
+
+func repeatMatch(lower []rune, i int) int {
+	length := 1
+	for i+length < len(lower) && lower[i+length] == lower[i] {
+		length++
+	}
+
+	return length
+}
+
+func sequenceMatch(lower []rune, i int) int {
+	if i+1 >= len(lower) {
+		return 1
+	}
+
+	delta := lower[i+1] - lower[i]
+	if delta != 1 && delta != -1 {
+		return 1
+	}
+
+	length := 2
+	for i+length < len(lower) && lower[i+length]-lower[i+length-1] == delta {
+		length++
+	}
+
+	return length
+}
+
+func keyboardMatch(lower []rune, i int) int {
+	best := 1
+	for _, row := range keyboardRows {
+		for _, r := range []string{row, reverse(row)} {
+			start := strings.IndexRune(r, lower[i])
+			if start < 0 {
+				continue
+			}
+
+			rowRunes := []rune(r)
+			length := 1
+			for i+length < len(lower) && start+length < len(rowRunes) && lower[i+length] == rowRunes[start+length] {
+				length++
+			}
+			best = max(best, length)
+		}
+	}
+
+	return best
+}
+
+func charsetSize(r rune) int {
+	switch {
+	case unicode.IsDigit(r):
+		return 10
+	case unicode.IsLower(r), unicode.IsUpper(r):
+		return 26
+	case r < unicode.MaxASCII:
+		return 33
+	}
+
+	return 100
+}
+
+func hasUpper(runes []rune) bool {
+	for _, r := range runes {
+		if unicode.IsUpper(r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func reverse(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+
+	return string(runes)
+}
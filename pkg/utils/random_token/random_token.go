@@ -0,0 +1,31 @@
+package random_token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+)
+
+// randReader is overridable so tests can simulate entropy failures.
+var randReader io.Reader = rand.Reader
+
+// Generate returns a URL-safe token with 256 bits of entropy and the hash to
+// store server side. Only the hash should ever be persisted.
+func Generate() (token, hash string, err error) {
+	secret := make([]byte, 32)
+	if _, err := io.ReadFull(randReader, secret); err != nil {
+		return "", "", err
+	}
+
+	token = base64.RawURLEncoding.EncodeToString(secret)
+
+	return token, Hash(token), nil
+}
+
+// Hash returns the hex SHA-256 digest of a token.
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,32 @@
+package random_token
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) { return 0, errors.New("entropy error") }
+
+func TestGenerate(t *testing.T) {
+	token, hash, err := Generate()
+	assert.NoError(t, err)
+	assert.Len(t, token, 43)
+	assert.Equal(t, Hash(token), hash)
+
+	other, _, err := Generate()
+	assert.NoError(t, err)
+	assert.NotEqual(t, token, other)
+}
+
+func TestGenerate_RandError(t *testing.T) {
+	orig := randReader
+	randReader = failingReader{}
+	defer func() { randReader = orig }()
+
+	_, _, err := Generate()
+	assert.Error(t, err)
+}
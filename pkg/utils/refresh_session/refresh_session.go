@@ -0,0 +1,86 @@
+package refresh_session
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/jwt"
+	"github.com/create-go-app/fiber-go-template/platform/cache"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidToken is returned for any refresh token that cannot be redeemed,
+// whatever the reason.
+var ErrInvalidToken = errors.New(repository.InvalidRefreshTokenErrorMessage)
+
+// redisConnection allows tests to run without Redis.
+var redisConnection = func() (Store, error) {
+	client, err := cache.RedisConnection()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRedisStore(client), nil
+}
+
+// NewDefault returns the Redis backed store.
+func NewDefault() (Store, error) {
+	return redisConnection()
+}
+
+// Start stores the session of freshly issued tokens, acting in the tenant.
+func Start(ctx context.Context, store Store, userID, tenantID uuid.UUID, tokens *models.Tokens) error {
+	return store.Save(ctx, Session{
+		ID:        tokens.SessionID,
+		UserID:    userID,
+		TenantID:  tenantID,
+		Hash:      tokens.RefreshHash,
+		ExpiresAt: tokens.RefreshExpiresAt,
+	})
+}
+
+// Redeem checks a refresh token against its stored session and revokes the
+// session in the same step, so every refresh token can be used once, even by
+// concurrent requests. Expiry is read from the
+// stored session, never from the token.
+func Redeem(ctx context.Context, store Store, refreshToken string, now time.Time) (Session, error) {
+	sessionID, err := jwt.ParseRefreshToken(refreshToken)
+	if err != nil {
+		return Session{}, ErrInvalidToken
+	}
+
+	hash, err := jwt.HashRefreshToken(refreshToken)
+	if err != nil {
+		return Session{}, err
+	}
+
+	session, err := store.Take(ctx, sessionID, func(session Session) bool {
+		return subtle.ConstantTimeCompare([]byte(hash), []byte(session.Hash)) == 1
+	})
+	if errors.Is(err, ErrNotFound) {
+		return Session{}, ErrInvalidToken
+	}
+	if err != nil {
+		return Session{}, err
+	}
+
+	if !now.Before(session.ExpiresAt) {
+		return Session{}, ErrInvalidToken
+	}
+
+	return session, nil
+}
+
+// Revoke ends one session of the user.
+func Revoke(ctx context.Context, store Store, userID, sessionID uuid.UUID) error {
+	return store.Delete(ctx, Session{ID: sessionID, UserID: userID})
+}
+
+// RevokeAll ends every session of the user.
+func RevokeAll(ctx context.Context, store Store, userID uuid.UUID) error {
+	return store.DeleteAll(ctx, userID)
+}
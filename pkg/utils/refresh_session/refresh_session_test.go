@@ -0,0 +1,186 @@
+package refresh_session
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/pkg/utils/jwt"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func issue(t *testing.T, store Store, userID uuid.UUID) string {
+	t.Helper()
+
+	_ = os.Setenv("JWT_SECRET_KEY", "test-secret")
+	_ = os.Setenv("JWT_REFRESH_KEY", "test-refresh")
+	_ = os.Setenv("JWT_REFRESH_KEY_EXPIRE_HOURS_COUNT", "1")
+
+	tokens, err := jwt.GenerateNewTokens(userID.String(), uuid.Nil, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, Start(context.Background(), store, userID, uuid.Nil, tokens))
+
+	return tokens.Refresh
+}
+
+func TestRedeem(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	userID := uuid.New()
+	token := issue(t, store, userID)
+
+	session, err := Redeem(ctx, store, token, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, userID, session.UserID)
+
+	// a refresh token can only be used once
+	_, err = Redeem(ctx, store, token, time.Now())
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestRedeem_Tenant(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	userID, tenantID := uuid.New(), uuid.New()
+	t.Setenv("JWT_SECRET_KEY", "test-secret")
+	t.Setenv("JWT_REFRESH_KEY", "test-refresh")
+	t.Setenv("JWT_REFRESH_KEY_EXPIRE_HOURS_COUNT", "1")
+
+	tokens, err := jwt.GenerateNewTokens(userID.String(), tenantID, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, Start(ctx, store, userID, tenantID, tokens))
+
+	// renewed tokens stay in the tenant of the session
+	session, err := Redeem(ctx, store, tokens.Refresh, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, tenantID, session.TenantID)
+}
+
+func TestRedeem_Invalid(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	token := issue(t, store, uuid.New())
+	sessionID, _ := jwt.ParseRefreshToken(token)
+
+	for _, candidate := range []string{"", "no-dot", uuid.NewString() + ".secret", sessionID.String() + ".forged"} {
+		_, err := Redeem(ctx, store, candidate, time.Now())
+		assert.ErrorIs(t, err, ErrInvalidToken, candidate)
+	}
+
+	// the original token still works after forged attempts
+	_, err := Redeem(ctx, store, token, time.Now())
+	assert.NoError(t, err)
+}
+
+func TestRedeem_ExpiredOnServerSide(t *testing.T) {
+	store := NewMemoryStore()
+	token := issue(t, store, uuid.New())
+
+	_, err := Redeem(context.Background(), store, token, time.Now().Add(2*time.Hour))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestRevoke(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	userID, otherID := uuid.New(), uuid.New()
+
+	first := issue(t, store, userID)
+	second := issue(t, store, userID)
+	other := issue(t, store, otherID)
+
+	firstID, _ := jwt.ParseRefreshToken(first)
+	assert.NoError(t, Revoke(ctx, store, userID, firstID))
+	_, err := Redeem(ctx, store, first, time.Now())
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	third := issue(t, store, userID)
+	assert.NoError(t, RevokeAll(ctx, store, userID))
+	for _, token := range []string{second, third} {
+		_, err := Redeem(ctx, store, token, time.Now())
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	}
+
+	_, err = Redeem(ctx, store, other, time.Now())
+	assert.NoError(t, err)
+}
+
+func TestMemoryStore_List(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	userID := uuid.New()
+
+	issue(t, store, userID)
+	issue(t, store, uuid.New())
+
+	sessions, err := store.List(ctx, userID)
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, userID, sessions[0].UserID)
+
+	store.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	sessions, err = store.List(ctx, userID)
+	assert.NoError(t, err)
+	assert.Empty(t, sessions)
+}
+
+func TestRedeem_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	token := issue(t, store, uuid.New())
+
+	redeemed := make(chan error, 8)
+	for i := 0; i < cap(redeemed); i++ {
+		go func() {
+			_, err := Redeem(ctx, store, token, time.Now())
+			redeemed <- err
+		}()
+	}
+
+	succeeded := 0
+	for i := 0; i < cap(redeemed); i++ {
+		if err := <-redeemed; err == nil {
+			succeeded++
+		} else {
+			assert.ErrorIs(t, err, ErrInvalidToken)
+		}
+	}
+	assert.Equal(t, 1, succeeded)
+}
+
+func TestMemoryStore_SaveExpired(t *testing.T) {
+	store := NewMemoryStore()
+	session := Session{ID: uuid.New(), UserID: uuid.New(), ExpiresAt: time.Now().Add(-time.Minute)}
+
+	assert.ErrorIs(t, store.Save(context.Background(), session), ErrExpired)
+}
+
+type failingStore struct{ *MemoryStore }
+
+func (s failingStore) Take(context.Context, uuid.UUID, func(Session) bool) (Session, error) {
+	return Session{}, errors.New("redis down")
+}
+
+func TestRedeem_StoreError(t *testing.T) {
+	store := failingStore{NewMemoryStore()}
+	token := issue(t, store, uuid.New())
+
+	_, err := Redeem(context.Background(), store, token, time.Now())
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestNewDefault(t *testing.T) {
+	orig := redisConnection
+	defer func() { redisConnection = orig }()
+
+	memory := NewMemoryStore()
+	redisConnection = func() (Store, error) { return memory, nil }
+
+	store, err := NewDefault()
+	assert.NoError(t, err)
+	assert.Same(t, memory, store)
+}
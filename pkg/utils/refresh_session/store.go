@@ -0,0 +1,265 @@
+package refresh_session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrNotFound is returned for sessions that never existed, expired or were
+// revoked.
+var ErrNotFound = errors.New("refresh session not found")
+
+// ErrExpired is returned when saving a session that has already expired.
+var ErrExpired = errors.New("refresh session has already expired")
+
+// Session is the server side half of a refresh token.
+type Session struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	TenantID  uuid.UUID `json:"tenant_id"`
+	Hash      string    `json:"hash"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Store keeps sessions until they expire.
+type Store interface {
+	Save(ctx context.Context, session Session) error
+	// Take removes the session and returns it, provided match accepts it, in
+	// one step: of concurrent calls for a session at most one succeeds. It
+	// returns ErrNotFound for a missing session or one match refuses, which
+	// is left in place.
+	Take(ctx context.Context, id uuid.UUID, match func(Session) bool) (Session, error)
+	Delete(ctx context.Context, session Session) error
+	// DeleteAll revokes every session of the user.
+	DeleteAll(ctx context.Context, userID uuid.UUID) error
+	// List returns the live sessions of the user.
+	List(ctx context.Context, userID uuid.UUID) ([]Session, error)
+}
+
+// RedisStore keeps each session under its own key expiring with the session,
+// plus a set of session IDs per user so all of them can be revoked at once.
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func sessionKey(id uuid.UUID) string { return "refresh:session:" + id.String() }
+
+func userKey(userID uuid.UUID) string { return "refresh:user:" + userID.String() }
+
+func (s *RedisStore) Save(ctx context.Context, session Session) error {
+	ttl := time.Until(session.ExpiresAt)
+	if ttl <= 0 {
+		return ErrExpired
+	}
+
+	value, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, sessionKey(session.ID), value, ttl)
+		pipe.SAdd(ctx, userKey(session.UserID), session.ID.String())
+		// Every session has the same lifetime, so the newest one outlives the
+		// others and the set can expire with it.
+		pipe.Expire(ctx, userKey(session.UserID), ttl)
+		return nil
+	})
+
+	return err
+}
+
+func (s *RedisStore) Take(ctx context.Context, id uuid.UUID, match func(Session) bool) (Session, error) {
+	session := Session{}
+
+	// The session key is watched, so the delete fails when another call
+	// took the session in between.
+	err := s.client.Watch(ctx, func(tx *redis.Tx) error {
+		value, err := tx.Get(ctx, sessionKey(id)).Bytes()
+		if errors.Is(err, redis.Nil) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := json.Unmarshal(value, &session); err != nil {
+			return err
+		}
+
+		if !match(session) {
+			return ErrNotFound
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, sessionKey(id))
+			pipe.SRem(ctx, userKey(session.UserID), id.String())
+			return nil
+		})
+		return err
+	}, sessionKey(id))
+	if errors.Is(err, redis.TxFailedErr) {
+		return Session{}, ErrNotFound
+	}
+	if err != nil {
+		return Session{}, err
+	}
+
+	return session, nil
+}
+
+func (s *RedisStore) Delete(ctx context.Context, session Session) error {
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, sessionKey(session.ID))
+		pipe.SRem(ctx, userKey(session.UserID), session.ID.String())
+		return nil
+	})
+
+	return err
+}
+
+func (s *RedisStore) DeleteAll(ctx context.Context, userID uuid.UUID) error {
+	ids, err := s.client.SMembers(ctx, userKey(userID)).Result()
+	if err != nil {
+		return err
+	}
+
+	keys := []string{userKey(userID)}
+	for _, id := range ids {
+		if sessionID, err := uuid.Parse(id); err == nil {
+			keys = append(keys, sessionKey(sessionID))
+		}
+	}
+
+	return s.client.Del(ctx, keys...).Err()
+}
+
+func (s *RedisStore) List(ctx context.Context, userID uuid.UUID) ([]Session, error) {
+	sessions := []Session{}
+
+	ids, err := s.client.SMembers(ctx, userKey(userID)).Result()
+	if err != nil || len(ids) == 0 {
+		return sessions, err
+	}
+
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if sessionID, err := uuid.Parse(id); err == nil {
+			keys = append(keys, sessionKey(sessionID))
+		}
+	}
+
+	// Sessions that expired or were revoked are still in the set, and read
+	// back as nil.
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return sessions, err
+	}
+
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+
+		session := Session{}
+		if err := json.Unmarshal([]byte(data), &session); err != nil {
+			return sessions, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+// MemoryStore keeps sessions in process memory. It is meant for tests and
+// single instance setups.
+type MemoryStore struct {
+	mu       sync.Mutex
+	sessions map[uuid.UUID]Session
+	now      func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: map[uuid.UUID]Session{}, now: time.Now}
+}
+
+func (s *MemoryStore) Save(_ context.Context, session Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.now().Before(session.ExpiresAt) {
+		return ErrExpired
+	}
+
+	s.sessions[session.ID] = session
+
+	return nil
+}
+
+func (s *MemoryStore) Take(_ context.Context, id uuid.UUID, match func(Session) bool) (Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return Session{}, ErrNotFound
+	}
+	if !s.now().Before(session.ExpiresAt) {
+		delete(s.sessions, id)
+		return Session{}, ErrNotFound
+	}
+	if !match(session) {
+		return Session{}, ErrNotFound
+	}
+
+	delete(s.sessions, id)
+
+	return session, nil
+}
+
+func (s *MemoryStore) Delete(_ context.Context, session Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, session.ID)
+
+	return nil
+}
+
+func (s *MemoryStore) DeleteAll(_ context.Context, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, session := range s.sessions {
+		if session.UserID == userID {
+			delete(s.sessions, id)
+		}
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) List(_ context.Context, userID uuid.UUID) ([]Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions := []Session{}
+	for _, session := range s.sessions {
+		if session.UserID == userID && s.now().Before(session.ExpiresAt) {
+			sessions = append(sessions, session)
+		}
+	}
+
+	return sessions, nil
+}
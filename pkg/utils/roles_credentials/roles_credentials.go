@@ -18,6 +18,20 @@ var VerifyRole = func(role string) (string, error) {
 	return role, nil
 }
 
+// AllCredentials lists every credential a token can carry.
+func AllCredentials() []string {
+	return []string{
+		repository.BookCreateCredential,
+		repository.BookUpdateCredential,
+		repository.BookDeleteCredential,
+		repository.BookUpdateAnyCredential,
+		repository.BookDeleteAnyCredential,
+		repository.ReviewModerateCredential,
+		repository.CategoryManageCredential,
+		repository.UserManageCredential,
+	}
+}
+
 func GetCredentialsByRole(role string) ([]string, error) {
 	var credentials []string
 	switch role {
@@ -26,11 +40,20 @@ func GetCredentialsByRole(role string) ([]string, error) {
 			repository.BookCreateCredential,
 			repository.BookUpdateCredential,
 			repository.BookDeleteCredential,
+			repository.BookUpdateAnyCredential,
+			repository.BookDeleteAnyCredential,
+			repository.ReviewModerateCredential,
+			repository.CategoryManageCredential,
+			repository.UserManageCredential,
 		}
 	case repository.ModeratorRoleName:
 		credentials = []string{
 			repository.BookCreateCredential,
 			repository.BookUpdateCredential,
+			repository.BookUpdateAnyCredential,
+			repository.BookDeleteAnyCredential,
+			repository.ReviewModerateCredential,
+			repository.CategoryManageCredential,
 		}
 	case repository.UserRoleName:
 		credentials = []string{
@@ -42,3 +65,43 @@ func GetCredentialsByRole(role string) ([]string, error) {
 
 	return credentials, nil
 }
+
+// isGlobal reports whether the credential applies across organizations.
+func isGlobal(credential string) bool {
+	return credential == repository.UserManageCredential
+}
+
+// TenantCredentials returns what a user may do while acting in an
+// organization: the global credentials of their user role plus the
+// organization credentials of their role in it. An empty memberRole means
+// the user is not a member and gets no organization credentials.
+func TenantCredentials(userRole, memberRole string) ([]string, error) {
+	userCredentials, err := GetCredentialsByRole(userRole)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := []string{}
+	for _, credential := range userCredentials {
+		if isGlobal(credential) {
+			credentials = append(credentials, credential)
+		}
+	}
+
+	if memberRole == "" {
+		return credentials, nil
+	}
+
+	memberCredentials, err := GetCredentialsByRole(memberRole)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, credential := range memberCredentials {
+		if !isGlobal(credential) {
+			credentials = append(credentials, credential)
+		}
+	}
+
+	return credentials, nil
+}
@@ -35,6 +35,11 @@ func TestGetCredentialsByRole(t *testing.T) {
 				repository.BookCreateCredential,
 				repository.BookUpdateCredential,
 				repository.BookDeleteCredential,
+				repository.BookUpdateAnyCredential,
+				repository.BookDeleteAnyCredential,
+				repository.ReviewModerateCredential,
+				repository.CategoryManageCredential,
+				repository.UserManageCredential,
 			},
 		},
 		{
@@ -42,6 +47,10 @@ func TestGetCredentialsByRole(t *testing.T) {
 			want: []string{
 				repository.BookCreateCredential,
 				repository.BookUpdateCredential,
+				repository.BookUpdateAnyCredential,
+				repository.BookDeleteAnyCredential,
+				repository.ReviewModerateCredential,
+				repository.CategoryManageCredential,
 			},
 		},
 		{
@@ -78,3 +87,46 @@ func TestGetCredentialsByRole(t *testing.T) {
 		}
 	}
 }
+
+func TestTenantCredentials(t *testing.T) {
+	tests := []struct {
+		userRole   string
+		memberRole string
+		want       []string
+	}{
+		{repository.AdminRoleName, repository.UserRoleName, []string{repository.UserManageCredential, repository.BookCreateCredential}},
+		{repository.UserRoleName, repository.AdminRoleName, []string{
+			repository.BookCreateCredential,
+			repository.BookUpdateCredential,
+			repository.BookDeleteCredential,
+			repository.BookUpdateAnyCredential,
+			repository.BookDeleteAnyCredential,
+			repository.ReviewModerateCredential,
+			repository.CategoryManageCredential,
+		}},
+		{repository.AdminRoleName, "", []string{repository.UserManageCredential}},
+		{repository.ModeratorRoleName, "", []string{}},
+	}
+
+	for _, tt := range tests {
+		got, err := TenantCredentials(tt.userRole, tt.memberRole)
+		if err != nil {
+			t.Fatalf("unexpected error for %s/%s: %v", tt.userRole, tt.memberRole, err)
+		}
+		if len(got) != len(tt.want) {
+			t.Fatalf("%s/%s: expected %v, got %v", tt.userRole, tt.memberRole, tt.want, got)
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Fatalf("%s/%s: expected %v, got %v", tt.userRole, tt.memberRole, tt.want, got)
+			}
+		}
+	}
+
+	if _, err := TenantCredentials("invalid", ""); err == nil {
+		t.Fatalf("expected error for invalid user role")
+	}
+	if _, err := TenantCredentials(repository.UserRoleName, "invalid"); err == nil {
+		t.Fatalf("expected error for invalid member role")
+	}
+}
@@ -0,0 +1,141 @@
+package session_cookie
+
+import (
+	"crypto/subtle"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/create-go-app/fiber-go-template/pkg/repository"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/random_token"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	AccessCookieName  = "access_token"
+	RefreshCookieName = "refresh_token"
+	CSRFCookieName    = "csrf_token"
+	CSRFHeaderName    = "X-CSRF-Token"
+)
+
+type Config struct {
+	Enabled     bool   // tokens are set as cookies instead of being returned in the body
+	AccessToken bool   // the access token is set as a cookie too, not only the refresh token
+	Secure      bool   // cookies are only sent over HTTPS
+	SameSite    string // Strict, Lax or None
+	Domain      string
+	RefreshPath string // the refresh cookie is only sent to this path
+	AccessTTL   time.Duration
+	RefreshTTL  time.Duration
+}
+
+// ConfigFromEnv reads the AUTH_COOKIE_* settings. Cookie mode is off unless
+// AUTH_COOKIE_MODE is true.
+func ConfigFromEnv() Config {
+	config := Config{
+		Enabled:     envBool("AUTH_COOKIE_MODE", false),
+		AccessToken: envBool("AUTH_COOKIE_ACCESS_TOKEN", false),
+		Secure:      envBool("AUTH_COOKIE_SECURE", true),
+		SameSite:    fiber.CookieSameSiteStrictMode,
+		Domain:      os.Getenv("AUTH_COOKIE_DOMAIN"),
+		RefreshPath: "/api/v1/token/renew",
+		AccessTTL:   time.Minute * time.Duration(envInt("JWT_SECRET_KEY_EXPIRE_MINUTES_COUNT", 15)),
+		RefreshTTL:  time.Hour * time.Duration(envInt("JWT_REFRESH_KEY_EXPIRE_HOURS_COUNT", 720)),
+	}
+
+	switch strings.ToLower(os.Getenv("AUTH_COOKIE_SAMESITE")) {
+	case "lax":
+		config.SameSite = fiber.CookieSameSiteLaxMode
+	case "none":
+		// Browsers drop SameSite=None cookies that are not Secure.
+		config.SameSite = fiber.CookieSameSiteNoneMode
+		config.Secure = true
+	}
+
+	if path := os.Getenv("AUTH_COOKIE_REFRESH_PATH"); path != "" {
+		config.RefreshPath = path
+	}
+
+	return config
+}
+
+func envBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+
+	return value
+}
+
+func envInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+
+	return value
+}
+
+// SetTokens sets the refresh token (and the access token when configured) as
+// HttpOnly cookies, together with a fresh CSRF token readable by the client.
+// It returns the CSRF token so it can also be sent in the body.
+func SetTokens(c *fiber.Ctx, config Config, tokens *models.Tokens) (string, error) {
+	csrfToken, _, err := random_token.Generate()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+
+	c.Cookie(config.cookie(RefreshCookieName, tokens.Refresh, config.RefreshPath, now.Add(config.RefreshTTL), true))
+	if config.AccessToken {
+		c.Cookie(config.cookie(AccessCookieName, tokens.Access, "/", now.Add(config.AccessTTL), true))
+	}
+	c.Cookie(config.cookie(CSRFCookieName, csrfToken, "/", now.Add(config.RefreshTTL), false))
+
+	return csrfToken, nil
+}
+
+// Clear expires every session cookie.
+func Clear(c *fiber.Ctx, config Config) {
+	expired := time.Unix(0, 0)
+
+	c.Cookie(config.cookie(RefreshCookieName, "", config.RefreshPath, expired, true))
+	c.Cookie(config.cookie(AccessCookieName, "", "/", expired, true))
+	c.Cookie(config.cookie(CSRFCookieName, "", "/", expired, false))
+}
+
+func (config Config) cookie(name, value, path string, expires time.Time, httpOnly bool) *fiber.Cookie {
+	return &fiber.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   config.Domain,
+		Expires:  expires,
+		Secure:   config.Secure,
+		HTTPOnly: httpOnly,
+		SameSite: config.SameSite,
+	}
+}
+
+// VerifyCSRF enforces the double-submit check on unsafe methods: the
+// X-CSRF-Token header has to match the CSRF cookie. A cross-site page can make
+// the browser send the cookie but cannot read it to set the header.
+func VerifyCSRF(c *fiber.Ctx) error {
+	switch c.Method() {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions, fiber.MethodTrace:
+		return nil
+	}
+
+	cookie := c.Cookies(CSRFCookieName)
+	header := c.Get(CSRFHeaderName)
+	if cookie == "" || subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) != 1 {
+		return errors.New(repository.InvalidCSRFTokenErrorMessage)
+	}
+
+	return nil
+}
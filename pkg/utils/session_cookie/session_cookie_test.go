@@ -0,0 +1,106 @@
+package session_cookie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/create-go-app/fiber-go-template/app/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	for _, key := range []string{"AUTH_COOKIE_MODE", "AUTH_COOKIE_SECURE", "AUTH_COOKIE_SAMESITE", "AUTH_COOKIE_REFRESH_PATH"} {
+		_ = os.Unsetenv(key)
+	}
+
+	config := ConfigFromEnv()
+	assert.False(t, config.Enabled)
+	assert.True(t, config.Secure)
+	assert.Equal(t, fiber.CookieSameSiteStrictMode, config.SameSite)
+	assert.Equal(t, "/api/v1/token/renew", config.RefreshPath)
+
+	_ = os.Setenv("AUTH_COOKIE_MODE", "true")
+	_ = os.Setenv("AUTH_COOKIE_SECURE", "false")
+	_ = os.Setenv("AUTH_COOKIE_SAMESITE", "None")
+	defer os.Unsetenv("AUTH_COOKIE_MODE")
+	defer os.Unsetenv("AUTH_COOKIE_SECURE")
+	defer os.Unsetenv("AUTH_COOKIE_SAMESITE")
+
+	config = ConfigFromEnv()
+	assert.True(t, config.Enabled)
+	assert.True(t, config.Secure, "SameSite=None forces Secure")
+	assert.Equal(t, fiber.CookieSameSiteNoneMode, config.SameSite)
+}
+
+func TestSetTokensAndClear(t *testing.T) {
+	config := Config{Enabled: true, AccessToken: true, Secure: true, SameSite: fiber.CookieSameSiteStrictMode, RefreshPath: "/api/v1/token/renew"}
+
+	app := fiber.New()
+	var csrfToken string
+	app.Get("/set", func(c *fiber.Ctx) error {
+		var err error
+		csrfToken, err = SetTokens(c, config, &models.Tokens{Access: "access", Refresh: "refresh"})
+		return err
+	})
+	app.Get("/clear", func(c *fiber.Ctx) error {
+		Clear(c, config)
+		return nil
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/set", http.NoBody))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, csrfToken)
+
+	cookies := map[string]*http.Cookie{}
+	for _, cookie := range resp.Cookies() {
+		cookies[cookie.Name] = cookie
+	}
+
+	assert.Equal(t, "refresh", cookies[RefreshCookieName].Value)
+	assert.Equal(t, "/api/v1/token/renew", cookies[RefreshCookieName].Path)
+	assert.True(t, cookies[RefreshCookieName].HttpOnly)
+	assert.True(t, cookies[RefreshCookieName].Secure)
+	assert.Equal(t, http.SameSiteStrictMode, cookies[RefreshCookieName].SameSite)
+	assert.Equal(t, "access", cookies[AccessCookieName].Value)
+	assert.True(t, cookies[AccessCookieName].HttpOnly)
+	assert.Equal(t, csrfToken, cookies[CSRFCookieName].Value)
+	assert.False(t, cookies[CSRFCookieName].HttpOnly)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/clear", http.NoBody))
+	assert.NoError(t, err)
+	for _, header := range resp.Header.Values("Set-Cookie") {
+		assert.True(t, strings.Contains(header, "expires=Thu, 01 Jan 1970"), header)
+	}
+}
+
+func TestVerifyCSRF(t *testing.T) {
+	app := fiber.New()
+	app.All("/", func(c *fiber.Ctx) error {
+		if err := VerifyCSRF(c); err != nil {
+			return c.SendStatus(fiber.StatusForbidden)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	send := func(method, cookie, header string) int {
+		req := httptest.NewRequest(method, "/", http.NoBody)
+		if cookie != "" {
+			req.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: cookie})
+		}
+		if header != "" {
+			req.Header.Set(CSRFHeaderName, header)
+		}
+		resp, _ := app.Test(req)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, fiber.StatusOK, send(http.MethodGet, "", ""))
+	assert.Equal(t, fiber.StatusForbidden, send(http.MethodPost, "", ""))
+	assert.Equal(t, fiber.StatusForbidden, send(http.MethodPost, "", "token"))
+	assert.Equal(t, fiber.StatusForbidden, send(http.MethodDelete, "token", "other"))
+	assert.Equal(t, fiber.StatusOK, send(http.MethodPatch, "token", "token"))
+}
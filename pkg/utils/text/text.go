@@ -0,0 +1,13 @@
+package text
+
+import "strings"
+
+// Truncate cuts s to at most n bytes without splitting a UTF-8 sequence, for
+// columns with a length limit.
+func Truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	return strings.ToValidUTF8(s[:n], "")
+}
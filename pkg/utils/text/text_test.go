@@ -0,0 +1,14 @@
+package text
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "short", Truncate("short", 10))
+	assert.Equal(t, "abc", Truncate("abcdef", 3))
+	// "é" takes two bytes and is dropped rather than split.
+	assert.Equal(t, "ab", Truncate("abé", 3))
+}
@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/create-go-app/fiber-go-template/pkg/utils/isbn"
+	"github.com/create-go-app/fiber-go-template/pkg/utils/password_policy"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
+	"golang.org/x/text/language"
 )
 
 func NewValidator() *validator.Validate {
@@ -17,9 +20,35 @@ func NewValidator() *validator.Validate {
 		}
 		return false // invalid UUID
 	})
+
+	// isbn replaces the built-in rule, which only accepts unhyphenated ISBNs.
+	_ = validate.RegisterValidation("isbn", func(fl validator.FieldLevel) bool {
+		return isbn.Valid(fl.Field().String())
+	})
+	_ = validate.RegisterValidation("bcp47", func(fl validator.FieldLevel) bool {
+		_, err := language.Parse(fl.Field().String())
+		return err == nil
+	})
+
+	registerPasswordPolicy(validate, password_policy.PolicyFromEnv())
+
 	return validate
 }
 
+// registerPasswordPolicy registers every policy rule as its own tag and the
+// "password" alias running all of them, so a failing rule is reported by
+// name (e.g. "Password: password_min_length").
+func registerPasswordPolicy(validate *validator.Validate, policy password_policy.Policy) {
+	for _, rule := range password_policy.Rules {
+		rule := rule
+		_ = validate.RegisterValidation(rule, func(fl validator.FieldLevel) bool {
+			return policy.Satisfies(rule, fl.Field().String())
+		})
+	}
+
+	validate.RegisterAlias("password", strings.Join(password_policy.Rules, ","))
+}
+
 func ValidatorErrors(err error) string {
 	if err == nil {
 		return ""
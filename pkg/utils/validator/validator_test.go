@@ -30,6 +30,31 @@ func TestNewValidator_UUIDValidation(t *testing.T) {
 	}
 }
 
+func TestNewValidator_PasswordPolicy(t *testing.T) {
+	v := NewValidator()
+
+	type S struct {
+		Password string `validate:"required,password"`
+	}
+
+	if err := v.Struct(S{Password: "Xk9#mQ2v-Lp"}); err != nil {
+		t.Fatalf("expected no error for strong password, got: %v", err)
+	}
+
+	err := v.Struct(S{Password: "a"})
+	if err == nil {
+		t.Fatalf("expected error for weak password, got nil")
+	}
+	if msg := ValidatorErrors(err); msg != "Password: password_min_length" {
+		t.Errorf("unexpected ValidatorErrors output: %q", msg)
+	}
+
+	err = v.Struct(S{Password: "password12"})
+	if msg := ValidatorErrors(err); msg != "Password: password_upper" {
+		t.Errorf("unexpected ValidatorErrors output: %q", msg)
+	}
+}
+
 func TestValidatorErrors(t *testing.T) {
 	if msg := ValidatorErrors(nil); msg != "" {
 		t.Errorf("expected empty string for nil error, got %q", msg)
@@ -69,3 +94,24 @@ func (f fakeErr) Translate(_ ut.Translator) string { return f.Error() }
 type assertAnError struct{}
 
 func (assertAnError) Error() string { return "assert error" }
+
+func TestNewValidator_BookMetadata(t *testing.T) {
+	v := NewValidator()
+
+	type S struct {
+		ISBN     string `validate:"omitempty,isbn"`
+		Language string `validate:"omitempty,bcp47"`
+	}
+
+	if err := v.Struct(S{ISBN: "978-0-306-40615-7", Language: "pt-BR"}); err != nil {
+		t.Fatalf("expected no error for valid metadata, got: %v", err)
+	}
+	if err := v.Struct(S{ISBN: "0-306-40615-2", Language: "sr-Latn"}); err != nil {
+		t.Fatalf("expected no error for valid metadata, got: %v", err)
+	}
+
+	err := v.Struct(S{ISBN: "978-0-306-40615-8", Language: "english"})
+	if msg := ValidatorErrors(err); msg != "ISBN: isbn\nLanguage: bcp47" {
+		t.Errorf("unexpected ValidatorErrors output: %q", msg)
+	}
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"fmt"
 	"os"
+	"strconv"
 
 	"github.com/create-go-app/fiber-go-template/app/queries"
 	"github.com/jmoiron/sqlx"
@@ -12,6 +13,18 @@ import (
 type Queries struct {
 	*queries.UserQueries
 	*queries.BookQueries
+	*queries.APIKeyQueries
+	*queries.LoginAttemptQueries
+	*queries.EmailVerificationQueries
+	*queries.PasswordResetQueries
+	*queries.ImpersonationLogQueries
+	*queries.OrganizationQueries
+	*queries.BookAuditLogQueries
+	*queries.BookImportJobQueries
+	*queries.BookExportJobQueries
+	*queries.AuthorQueries
+	*queries.TagQueries
+	*queries.CategoryQueries
 }
 
 // These function variables allow us to mock the database connections in tests
@@ -44,7 +57,26 @@ var OpenDBConnection = func() (*Queries, error) {
 	}
 
 	return &Queries{
-		UserQueries: &queries.UserQueries{DB: db},
-		BookQueries: &queries.BookQueries{DB: db},
+		UserQueries:              &queries.UserQueries{DB: db},
+		BookQueries:              &queries.BookQueries{DB: db, RowLevelSecurity: rowLevelSecurity()},
+		APIKeyQueries:            &queries.APIKeyQueries{DB: db},
+		LoginAttemptQueries:      &queries.LoginAttemptQueries{DB: db},
+		EmailVerificationQueries: &queries.EmailVerificationQueries{DB: db},
+		PasswordResetQueries:     &queries.PasswordResetQueries{DB: db},
+		ImpersonationLogQueries:  &queries.ImpersonationLogQueries{DB: db},
+		OrganizationQueries:      &queries.OrganizationQueries{DB: db},
+		BookAuditLogQueries:      &queries.BookAuditLogQueries{DB: db},
+		BookImportJobQueries:     &queries.BookImportJobQueries{DB: db},
+		BookExportJobQueries:     &queries.BookExportJobQueries{DB: db},
+		AuthorQueries:            &queries.AuthorQueries{DB: db},
+		TagQueries:               &queries.TagQueries{DB: db},
+		CategoryQueries:          &queries.CategoryQueries{DB: db},
 	}, nil
 }
+
+// rowLevelSecurity reports whether DB_ROW_LEVEL_SECURITY asks for the tenant
+// to be set for Postgres row-level security policies.
+func rowLevelSecurity() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("DB_ROW_LEVEL_SECURITY"))
+	return enabled && os.Getenv("DB_TYPE") == "pgx"
+}
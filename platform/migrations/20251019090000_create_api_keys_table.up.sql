@@ -0,0 +1,19 @@
+CREATE TABLE api_keys (
+    id UUID DEFAULT uuid_generate_v4 () PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW (),
+    updated_at TIMESTAMP NULL,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name VARCHAR (100) NOT NULL,
+    key_prefix VARCHAR (16) NOT NULL,
+    key_hash VARCHAR (64) NOT NULL UNIQUE,
+    credentials JSONB NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_used_at TIMESTAMP WITH TIME ZONE NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE NULL
+);
+CREATE INDEX active_api_keys ON api_keys (user_id) WHERE revoked_at IS NULL;
+
+CREATE TRIGGER update_api_keys_updated_at
+BEFORE UPDATE ON api_keys
+FOR EACH ROW
+EXECUTE PROCEDURE update_updated_at();
@@ -0,0 +1,12 @@
+CREATE TABLE login_attempts (
+    id UUID DEFAULT uuid_generate_v4 () PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW (),
+    email VARCHAR (255) NOT NULL,
+    user_id UUID NULL REFERENCES users (id) ON DELETE SET NULL,
+    ip_address VARCHAR (45) NOT NULL,
+    user_agent VARCHAR (255) NOT NULL,
+    succeeded BOOLEAN NOT NULL,
+    reason VARCHAR (50) NOT NULL
+);
+CREATE INDEX login_attempts_email ON login_attempts (email, created_at DESC);
+CREATE INDEX login_attempts_ip_address ON login_attempts (ip_address, created_at DESC);
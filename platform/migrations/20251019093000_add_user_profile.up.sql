@@ -0,0 +1,15 @@
+ALTER TABLE users
+    ADD COLUMN display_name VARCHAR (100) NOT NULL DEFAULT '',
+    ADD COLUMN avatar_url VARCHAR (2048) NOT NULL DEFAULT '',
+    ADD COLUMN locale VARCHAR (35) NOT NULL DEFAULT 'en',
+    ADD COLUMN timezone VARCHAR (64) NOT NULL DEFAULT 'UTC';
+
+CREATE TABLE email_verifications (
+    id UUID DEFAULT uuid_generate_v4 () PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW (),
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    new_email VARCHAR (255) NOT NULL,
+    token_hash VARCHAR (64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+CREATE INDEX email_verifications_user_id ON email_verifications (user_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS books_user_id;
+DROP INDEX IF EXISTS users_created_at;
+DROP TABLE IF EXISTS password_resets;
@@ -0,0 +1,11 @@
+CREATE TABLE password_resets (
+    id UUID DEFAULT uuid_generate_v4 () PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW (),
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    token_hash VARCHAR (64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+CREATE INDEX password_resets_user_id ON password_resets (user_id);
+
+CREATE INDEX users_created_at ON users (created_at);
+CREATE INDEX books_user_id ON books (user_id);
@@ -0,0 +1,4 @@
+ALTER TABLE users
+    ADD COLUMN deletion_scheduled_at TIMESTAMP WITH TIME ZONE NULL,
+    ADD COLUMN deletion_mode VARCHAR (10) NOT NULL DEFAULT '';
+CREATE INDEX users_deletion_scheduled_at ON users (deletion_scheduled_at) WHERE deletion_scheduled_at IS NOT NULL;
@@ -0,0 +1,12 @@
+CREATE TABLE impersonation_logs (
+    id UUID DEFAULT uuid_generate_v4 () PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW (),
+    actor_id UUID NULL REFERENCES users (id) ON DELETE SET NULL,
+    user_id UUID NULL REFERENCES users (id) ON DELETE SET NULL,
+    method VARCHAR (10) NOT NULL,
+    path VARCHAR (255) NOT NULL,
+    status INT NOT NULL,
+    ip_address VARCHAR (45) NOT NULL
+);
+CREATE INDEX impersonation_logs_actor_id ON impersonation_logs (actor_id, created_at DESC);
+CREATE INDEX impersonation_logs_user_id ON impersonation_logs (user_id, created_at DESC);
@@ -0,0 +1,7 @@
+DROP POLICY IF EXISTS books_tenant_isolation ON books;
+ALTER TABLE books DISABLE ROW LEVEL SECURITY;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS tenant_id;
+DROP INDEX IF EXISTS books_tenant_id;
+ALTER TABLE books DROP COLUMN IF EXISTS tenant_id;
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
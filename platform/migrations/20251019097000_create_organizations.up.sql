@@ -0,0 +1,43 @@
+CREATE TABLE organizations (
+    id UUID DEFAULT uuid_generate_v4 () PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW (),
+    updated_at TIMESTAMP NULL,
+    name VARCHAR (255) NOT NULL,
+    slug VARCHAR (63) NOT NULL UNIQUE
+);
+
+CREATE TRIGGER update_organizations_updated_at
+BEFORE UPDATE ON organizations
+FOR EACH ROW
+EXECUTE PROCEDURE update_updated_at();
+
+CREATE TABLE organization_members (
+    organization_id UUID NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    member_role VARCHAR (25) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW (),
+    PRIMARY KEY (organization_id, user_id)
+);
+CREATE INDEX organization_members_user_id ON organization_members (user_id);
+
+-- Everything that existed before organizations belongs to the default one.
+INSERT INTO organizations (id, name, slug) VALUES ('00000000-0000-0000-0000-000000000001', 'Default', 'default');
+
+INSERT INTO organization_members (organization_id, user_id, member_role, created_at)
+SELECT '00000000-0000-0000-0000-000000000001', id, user_role, created_at FROM users;
+
+ALTER TABLE books ADD COLUMN tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organizations (id) ON DELETE CASCADE;
+ALTER TABLE books ALTER COLUMN tenant_id DROP DEFAULT;
+CREATE INDEX books_tenant_id ON books (tenant_id);
+
+ALTER TABLE api_keys ADD COLUMN tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organizations (id) ON DELETE CASCADE;
+ALTER TABLE api_keys ALTER COLUMN tenant_id DROP DEFAULT;
+
+-- Row-level security is a second line of defence next to the tenant filter
+-- in every query. It is only applied to roles that do not own the table (or
+-- after ALTER TABLE books FORCE ROW LEVEL SECURITY), and the application
+-- sets app.tenant_id for it when DB_ROW_LEVEL_SECURITY is true.
+ALTER TABLE books ENABLE ROW LEVEL SECURITY;
+CREATE POLICY books_tenant_isolation ON books
+    USING (tenant_id = NULLIF (current_setting ('app.tenant_id', true), '')::uuid)
+    WITH CHECK (tenant_id = NULLIF (current_setting ('app.tenant_id', true), '')::uuid);
@@ -0,0 +1 @@
+ALTER TABLE books ADD COLUMN version INT NOT NULL DEFAULT 1;
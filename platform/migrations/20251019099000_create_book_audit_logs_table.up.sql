@@ -0,0 +1,13 @@
+CREATE TABLE book_audit_logs (
+    id UUID DEFAULT uuid_generate_v4 () PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW (),
+    tenant_id UUID NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    book_id UUID NOT NULL,
+    owner_id UUID NULL REFERENCES users (id) ON DELETE SET NULL,
+    actor_id UUID NULL REFERENCES users (id) ON DELETE SET NULL,
+    action VARCHAR (10) NOT NULL,
+    before JSONB NOT NULL,
+    after JSONB NULL
+);
+CREATE INDEX book_audit_logs_tenant_id ON book_audit_logs (tenant_id, created_at DESC);
+CREATE INDEX book_audit_logs_book_id ON book_audit_logs (book_id, created_at DESC);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS book_transfers;
+DROP TABLE IF EXISTS book_collaborators;
@@ -0,0 +1,18 @@
+CREATE TABLE book_collaborators (
+    book_id UUID NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    collaborator_role VARCHAR (10) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW (),
+    PRIMARY KEY (book_id, user_id)
+);
+CREATE INDEX book_collaborators_user_id ON book_collaborators (user_id);
+
+-- A book has at most one pending ownership transfer.
+CREATE TABLE book_transfers (
+    book_id UUID PRIMARY KEY REFERENCES books (id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW (),
+    from_user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    to_user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+CREATE INDEX book_transfers_to_user_id ON book_transfers (to_user_id);
@@ -0,0 +1,15 @@
+CREATE TABLE book_export_jobs (
+    id UUID DEFAULT uuid_generate_v4 () PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW (),
+    updated_at TIMESTAMP NULL,
+    tenant_id UUID NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    format VARCHAR (10) NOT NULL,
+    filter JSONB NOT NULL,
+    status VARCHAR (10) NOT NULL,
+    total_rows INT NOT NULL DEFAULT 0,
+    exported_rows INT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    finished_at TIMESTAMP WITH TIME ZONE NULL
+);
+CREATE INDEX book_export_jobs_tenant_id ON book_export_jobs (tenant_id, created_at DESC);
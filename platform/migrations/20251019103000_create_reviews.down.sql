@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS book_ratings;
+DROP TABLE IF EXISTS reviews;
+DROP FUNCTION IF EXISTS update_book_ratings();
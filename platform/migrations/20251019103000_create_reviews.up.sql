@@ -0,0 +1,60 @@
+CREATE TABLE reviews (
+    id UUID DEFAULT uuid_generate_v4 () PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW (),
+    updated_at TIMESTAMP NULL,
+    book_id UUID NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    rating INT NOT NULL CHECK (rating BETWEEN 1 AND 10),
+    body TEXT NOT NULL DEFAULT '',
+    review_status VARCHAR (10) NOT NULL DEFAULT 'published',
+    moderated_by UUID NULL REFERENCES users (id) ON DELETE SET NULL,
+    moderated_at TIMESTAMP WITH TIME ZONE NULL,
+    UNIQUE (book_id, user_id)
+);
+CREATE INDEX reviews_user_id ON reviews (user_id);
+
+CREATE TRIGGER update_reviews_updated_at
+BEFORE UPDATE ON reviews
+FOR EACH ROW
+EXECUTE PROCEDURE update_updated_at();
+
+-- Rating aggregates of the published reviews of each book. histogram[n] is
+-- the number of reviews rating the book n.
+CREATE TABLE book_ratings (
+    book_id UUID PRIMARY KEY REFERENCES books (id) ON DELETE CASCADE,
+    rating_count INT NOT NULL DEFAULT 0,
+    rating_sum INT NOT NULL DEFAULT 0,
+    histogram INT [] NOT NULL DEFAULT array_fill (0, ARRAY[10]),
+    rating_average DOUBLE PRECISION GENERATED ALWAYS AS (
+        CASE WHEN rating_count = 0 THEN 0 ELSE rating_sum::DOUBLE PRECISION / rating_count END
+    ) STORED
+);
+CREATE INDEX book_ratings_rating_average ON book_ratings (rating_average DESC, rating_count DESC);
+
+-- The aggregates are kept up to date by a trigger rather than by the
+-- application, so reviews removed along with their author are accounted for
+-- too.
+CREATE OR REPLACE FUNCTION update_book_ratings()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') AND OLD.review_status = 'published' THEN
+        UPDATE book_ratings SET rating_count = rating_count - 1, rating_sum = rating_sum - OLD.rating,
+            histogram[OLD.rating] = histogram[OLD.rating] - 1
+        WHERE book_id = OLD.book_id;
+    END IF;
+
+    IF TG_OP IN ('INSERT', 'UPDATE') AND NEW.review_status = 'published' THEN
+        INSERT INTO book_ratings (book_id) VALUES (NEW.book_id) ON CONFLICT (book_id) DO NOTHING;
+        UPDATE book_ratings SET rating_count = rating_count + 1, rating_sum = rating_sum + NEW.rating,
+            histogram[NEW.rating] = histogram[NEW.rating] + 1
+        WHERE book_id = NEW.book_id;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER update_reviews_book_ratings
+AFTER INSERT OR UPDATE OF rating, review_status OR DELETE ON reviews
+FOR EACH ROW
+EXECUTE PROCEDURE update_book_ratings();
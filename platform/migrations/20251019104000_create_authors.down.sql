@@ -0,0 +1,6 @@
+-- The author fields of the books keep the de-duplicated names.
+DROP TRIGGER IF EXISTS link_books_author ON books;
+DROP FUNCTION IF EXISTS link_book_author();
+DROP TABLE IF EXISTS book_authors;
+DROP TABLE IF EXISTS authors;
+DROP FUNCTION IF EXISTS author_name_key(TEXT);
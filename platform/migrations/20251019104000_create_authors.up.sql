@@ -0,0 +1,88 @@
+-- author_name_key is what two spellings of an author name have in common:
+-- "Last, First" is turned into "First Last", then everything but letters
+-- and digits is dropped and the rest lowercased, so "J.R.R. Tolkien" and
+-- "Tolkien, J. R. R." both become "jrrtolkien".
+CREATE OR REPLACE FUNCTION author_name_key(name TEXT)
+RETURNS TEXT AS $$
+    SELECT lower(regexp_replace(
+        CASE WHEN name ~ '^[^,]+,[^,]+$' THEN split_part(name, ',', 2) || ' ' || split_part(name, ',', 1) ELSE name END,
+        '[^[:alnum:]]+', '', 'g'
+    ))
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE TABLE authors (
+    id UUID DEFAULT uuid_generate_v4 () PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW (),
+    updated_at TIMESTAMP NULL,
+    tenant_id UUID NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    name VARCHAR (255) NOT NULL,
+    name_key VARCHAR (255) NOT NULL,
+    UNIQUE (tenant_id, name_key)
+);
+
+CREATE TRIGGER update_authors_updated_at
+BEFORE UPDATE ON authors
+FOR EACH ROW
+EXECUTE PROCEDURE update_updated_at();
+
+-- Authors in use cannot be deleted, their credits have to be removed first.
+CREATE TABLE book_authors (
+    book_id UUID NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    author_id UUID NOT NULL REFERENCES authors (id) ON DELETE RESTRICT,
+    author_role VARCHAR (15) NOT NULL,
+    position INT NOT NULL,
+    PRIMARY KEY (book_id, author_id, author_role)
+);
+CREATE INDEX book_authors_author_id ON book_authors (author_id);
+
+-- Every spelling of an author becomes one author, named after the spelling
+-- most books use, and the books are credited to it.
+INSERT INTO authors (tenant_id, name, name_key, updated_at)
+SELECT DISTINCT ON (tenant_id, name_key) tenant_id, name, name_key, NOW ()
+FROM (
+    SELECT tenant_id, btrim (author) AS name, author_name_key (author) AS name_key,
+        COUNT(*) AS books, MIN(created_at) AS first_used
+    FROM books
+    GROUP BY tenant_id, btrim (author), author_name_key (author)
+) spellings
+WHERE name_key <> ''
+ORDER BY tenant_id, name_key, books DESC, first_used;
+
+INSERT INTO book_authors (book_id, author_id, author_role, position)
+SELECT b.id, a.id, 'author', 1
+FROM books b JOIN authors a ON a.tenant_id = b.tenant_id AND a.name_key = author_name_key (b.author);
+
+UPDATE books b SET author = a.name, version = b.version + 1
+FROM authors a
+WHERE a.tenant_id = b.tenant_id AND a.name_key = author_name_key (b.author) AND b.author <> a.name;
+
+-- New books are credited to the author their author field names, which is
+-- created when the organization does not have it yet. Later credits are
+-- managed through the book_authors of the book.
+CREATE OR REPLACE FUNCTION link_book_author()
+RETURNS TRIGGER AS $$
+DECLARE
+    linked_author_id UUID;
+BEGIN
+    IF author_name_key(NEW.author) = '' THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO authors (tenant_id, name, name_key, updated_at)
+    VALUES (NEW.tenant_id, btrim(NEW.author), author_name_key(NEW.author), NOW())
+    ON CONFLICT (tenant_id, name_key) DO NOTHING;
+
+    SELECT id INTO linked_author_id FROM authors
+    WHERE tenant_id = NEW.tenant_id AND name_key = author_name_key(NEW.author);
+
+    INSERT INTO book_authors (book_id, author_id, author_role, position)
+    VALUES (NEW.id, linked_author_id, 'author', 1);
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER link_books_author
+AFTER INSERT ON books
+FOR EACH ROW
+EXECUTE PROCEDURE link_book_author();
@@ -0,0 +1,43 @@
+-- Tags are free-form and stored lowercased, so every spelling of a tag is
+-- one tag.
+CREATE TABLE tags (
+    id UUID DEFAULT uuid_generate_v4 () PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW (),
+    tenant_id UUID NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    name VARCHAR (50) NOT NULL,
+    UNIQUE (tenant_id, name)
+);
+
+CREATE TABLE book_tags (
+    book_id UUID NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    tag_id UUID NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (book_id, tag_id)
+);
+CREATE INDEX book_tags_tag_id ON book_tags (tag_id);
+
+-- Categories form a tree curated by moderators. Categories with
+-- subcategories cannot be deleted, and sibling names are unique.
+CREATE TABLE categories (
+    id UUID DEFAULT uuid_generate_v4 () PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW (),
+    updated_at TIMESTAMP NULL,
+    tenant_id UUID NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    parent_id UUID NULL REFERENCES categories (id) ON DELETE RESTRICT,
+    name VARCHAR (100) NOT NULL
+);
+CREATE UNIQUE INDEX categories_sibling_name ON categories (
+    tenant_id, COALESCE (parent_id, '00000000-0000-0000-0000-000000000000'), lower (name)
+);
+CREATE INDEX categories_parent_id ON categories (parent_id);
+
+CREATE TRIGGER update_categories_updated_at
+BEFORE UPDATE ON categories
+FOR EACH ROW
+EXECUTE PROCEDURE update_updated_at();
+
+CREATE TABLE book_categories (
+    book_id UUID NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    category_id UUID NOT NULL REFERENCES categories (id) ON DELETE CASCADE,
+    PRIMARY KEY (book_id, category_id)
+);
+CREATE INDEX book_categories_category_id ON book_categories (category_id);
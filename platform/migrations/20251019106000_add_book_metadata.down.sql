@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS books_tenant_isbn_key;
+ALTER TABLE books
+    DROP COLUMN IF EXISTS book_format,
+    DROP COLUMN IF EXISTS edition,
+    DROP COLUMN IF EXISTS page_count,
+    DROP COLUMN IF EXISTS language,
+    DROP COLUMN IF EXISTS publication_date,
+    DROP COLUMN IF EXISTS publisher,
+    DROP COLUMN IF EXISTS isbn;
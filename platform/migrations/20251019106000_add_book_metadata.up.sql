@@ -0,0 +1,13 @@
+-- Add bibliographic metadata to books. ISBNs are stored as ISBN-13 without
+-- hyphens, empty when unknown, and are unique per organization.
+ALTER TABLE books
+    ADD COLUMN isbn VARCHAR (13) NOT NULL DEFAULT '',
+    ADD COLUMN publisher VARCHAR (255) NOT NULL DEFAULT '',
+    ADD COLUMN publication_date DATE NULL,
+    ADD COLUMN language VARCHAR (35) NOT NULL DEFAULT '',
+    ADD COLUMN page_count INT NOT NULL DEFAULT 0 CHECK (page_count >= 0),
+    ADD COLUMN edition VARCHAR (100) NOT NULL DEFAULT '',
+    ADD COLUMN book_format VARCHAR (20) NOT NULL DEFAULT ''
+        CHECK (book_format IN ('', 'hardcover', 'paperback', 'ebook', 'audiobook'));
+
+CREATE UNIQUE INDEX books_tenant_isbn_key ON books (tenant_id, isbn) WHERE isbn <> '';
@@ -0,0 +1 @@
+ALTER TABLE book_import_jobs DROP COLUMN IF EXISTS unmapped_fields;
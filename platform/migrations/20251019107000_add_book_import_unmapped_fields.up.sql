@@ -0,0 +1,3 @@
+-- Count, per field, the records of an import whose field has no book column,
+-- like a MARC tag or an ONIX element.
+ALTER TABLE book_import_jobs ADD COLUMN unmapped_fields JSONB NOT NULL DEFAULT '{}';
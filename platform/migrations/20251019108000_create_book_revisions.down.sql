@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS record_books_revision ON books;
+DROP FUNCTION IF EXISTS record_book_revision();
+DROP FUNCTION IF EXISTS book_revision_diff(JSONB, JSONB);
+DROP FUNCTION IF EXISTS book_revision_fields(JSONB);
+DROP TABLE IF EXISTS book_revisions;
@@ -0,0 +1,79 @@
+-- Every change to a book is stored as a revision, numbered from 1 per book,
+-- with a snapshot of the row (as it was before a deletion) and the fields
+-- that changed. Revisions outlive the book so deleted books can be restored.
+CREATE TABLE book_revisions (
+    id UUID DEFAULT uuid_generate_v4 () PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW (),
+    tenant_id UUID NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    book_id UUID NOT NULL,
+    revision INT NOT NULL,
+    action VARCHAR (10) NOT NULL,
+    actor_id UUID NULL REFERENCES users (id) ON DELETE SET NULL,
+    restored_from INT NULL,
+    snapshot JSONB NOT NULL,
+    diff JSONB NOT NULL,
+    UNIQUE (book_id, revision)
+);
+CREATE INDEX book_revisions_tenant_id ON book_revisions (tenant_id, book_id);
+
+-- book_revision_fields flattens book_attrs into "book_attrs.<name>" fields
+-- and leaves out the bookkeeping columns, so diffs name what an editor
+-- changed.
+CREATE OR REPLACE FUNCTION book_revision_fields(book JSONB)
+RETURNS JSONB AS $$
+    SELECT (book - 'book_attrs' - 'updated_at' - 'version')
+        || COALESCE((SELECT jsonb_object_agg('book_attrs.' || key, value) FROM jsonb_each(book -> 'book_attrs')), '{}')
+$$ LANGUAGE SQL IMMUTABLE;
+
+-- book_revision_diff maps each field that differs between two snapshots to
+-- {"from": ..., "to": ...}. A NULL snapshot has no fields.
+CREATE OR REPLACE FUNCTION book_revision_diff(old_book JSONB, new_book JSONB)
+RETURNS JSONB AS $$
+    SELECT COALESCE(jsonb_object_agg(key, jsonb_build_object('from', o.value, 'to', n.value)), '{}')
+    FROM jsonb_each(book_revision_fields(old_book)) o
+    FULL JOIN jsonb_each(book_revision_fields(new_book)) n USING (key)
+    WHERE o.value IS DISTINCT FROM n.value
+$$ LANGUAGE SQL IMMUTABLE;
+
+-- The revisions are written by a trigger rather than by the application, so
+-- changes made outside of the book endpoints, like ownership transfers, are
+-- recorded too. The application sets app.actor_id, and app.restored_from
+-- when the change restores a revision, for the transaction of the change.
+CREATE OR REPLACE FUNCTION record_book_revision()
+RETURNS TRIGGER AS $$
+DECLARE
+    book books;
+    old_snapshot JSONB;
+    new_snapshot JSONB;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        book := OLD;
+    ELSE
+        book := NEW;
+        new_snapshot := to_jsonb(NEW);
+    END IF;
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        old_snapshot := to_jsonb(OLD);
+    END IF;
+
+    INSERT INTO book_revisions (tenant_id, book_id, revision, action, actor_id, restored_from, snapshot, diff)
+    SELECT book.tenant_id, book.id, COALESCE(MAX(revision), 0) + 1,
+        CASE TG_OP WHEN 'INSERT' THEN 'create' ELSE lower(TG_OP) END,
+        NULLIF (current_setting ('app.actor_id', true), '')::uuid,
+        NULLIF (current_setting ('app.restored_from', true), '')::int,
+        to_jsonb(book), book_revision_diff(old_snapshot, new_snapshot)
+    FROM book_revisions WHERE book_id = book.id;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER record_books_revision
+AFTER INSERT OR UPDATE OR DELETE ON books
+FOR EACH ROW
+EXECUTE PROCEDURE record_book_revision();
+
+-- Existing books start their history with their current state.
+INSERT INTO book_revisions (created_at, tenant_id, book_id, revision, action, snapshot, diff)
+SELECT created_at, tenant_id, id, 1, 'create', to_jsonb(books), book_revision_diff(NULL, to_jsonb(books))
+FROM books;
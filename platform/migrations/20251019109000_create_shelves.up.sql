@@ -0,0 +1,32 @@
+-- Shelves are named lists of books a user keeps in an organization. Every
+-- user has the built-in "want_to_read" and "favorites" shelves, created on
+-- first use, next to their custom ones. Names are unique per user regardless
+-- of case.
+CREATE TABLE shelves (
+    id UUID DEFAULT uuid_generate_v4 () PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW (),
+    updated_at TIMESTAMP NULL,
+    tenant_id UUID NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name VARCHAR (100) NOT NULL,
+    kind VARCHAR (20) NOT NULL DEFAULT 'custom' CHECK (kind IN ('want_to_read', 'favorites', 'custom')),
+    is_public BOOLEAN NOT NULL DEFAULT FALSE
+);
+CREATE UNIQUE INDEX shelves_user_name_key ON shelves (tenant_id, user_id, lower(name));
+CREATE UNIQUE INDEX shelves_user_kind_key ON shelves (tenant_id, user_id, kind) WHERE kind <> 'custom';
+
+CREATE TRIGGER update_shelves_updated_at
+BEFORE UPDATE ON shelves
+FOR EACH ROW
+EXECUTE PROCEDURE update_updated_at();
+
+-- Books are ordered on a shelf by position, from 1 without gaps.
+CREATE TABLE shelf_books (
+    shelf_id UUID NOT NULL REFERENCES shelves (id) ON DELETE CASCADE,
+    book_id UUID NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    added_at TIMESTAMP WITH TIME ZONE DEFAULT NOW (),
+    PRIMARY KEY (shelf_id, book_id)
+);
+CREATE INDEX shelf_books_position ON shelf_books (shelf_id, position);
+CREATE INDEX shelf_books_book_id ON shelf_books (book_id);
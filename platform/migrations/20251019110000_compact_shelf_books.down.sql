@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS compact_shelf_books ON shelf_books;
+DROP FUNCTION IF EXISTS compact_shelf_books();
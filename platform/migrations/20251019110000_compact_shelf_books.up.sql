@@ -0,0 +1,31 @@
+-- Books leave shelves along with the book or the shelf too, so the gaps they
+-- leave are closed by a trigger rather than by the application. It runs once
+-- per statement, numbering the remaining books of every shelf it touched
+-- from 1 again.
+CREATE OR REPLACE FUNCTION compact_shelf_books()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE shelf_books sb SET position = numbered.position
+    FROM (
+        SELECT shelf_id, book_id, ROW_NUMBER() OVER (PARTITION BY shelf_id ORDER BY position, added_at) AS position
+        FROM shelf_books WHERE shelf_id IN (SELECT DISTINCT shelf_id FROM removed)
+    ) numbered
+    WHERE sb.shelf_id = numbered.shelf_id AND sb.book_id = numbered.book_id AND sb.position <> numbered.position;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER compact_shelf_books
+AFTER DELETE ON shelf_books
+REFERENCING OLD TABLE AS removed
+FOR EACH STATEMENT
+EXECUTE PROCEDURE compact_shelf_books();
+
+-- Close the gaps books deleted so far left.
+UPDATE shelf_books sb SET position = numbered.position
+FROM (
+    SELECT shelf_id, book_id, ROW_NUMBER() OVER (PARTITION BY shelf_id ORDER BY position, added_at) AS position
+    FROM shelf_books
+) numbered
+WHERE sb.shelf_id = numbered.shelf_id AND sb.book_id = numbered.book_id AND sb.position <> numbered.position;
@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// BlobStore keeps files under keys made of slash separated names, like
+// "covers/<book>/original.jpg", and tells where they are publicly served.
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// ErrInvalidKey is returned for keys that are empty or leave the store, like
+// "../secret".
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// Backend returns the store selected by STORAGE_BACKEND, "local" by default.
+func Backend() string {
+	if backend := os.Getenv("STORAGE_BACKEND"); backend != "" {
+		return backend
+	}
+
+	return "local"
+}
+
+// NewBlobStore returns the store selected by STORAGE_BACKEND, configured
+// from the environment.
+func NewBlobStore() (BlobStore, error) {
+	switch Backend() {
+	case "local":
+		return NewLocalStore(LocalDir()), nil
+	case "s3":
+		return NewS3StoreFromEnv()
+	}
+
+	return nil, fmt.Errorf("unsupported storage backend: %s", Backend())
+}
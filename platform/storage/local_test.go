@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalStore(t *testing.T) {
+	dir := t.TempDir()
+	store := NewLocalStore(dir)
+	ctx := context.Background()
+
+	assert.NoError(t, store.Put(ctx, "covers/book/original.jpg", []byte("jpeg"), "image/jpeg"))
+
+	content, err := os.ReadFile(filepath.Join(dir, "covers", "book", "original.jpg"))
+	assert.NoError(t, err)
+	assert.Equal(t, "jpeg", string(content))
+	assert.Equal(t, "/storage/covers/book/original.jpg", store.URL("covers/book/original.jpg"))
+
+	// no partial file is left behind
+	entries, err := os.ReadDir(filepath.Join(dir, "covers", "book"))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	assert.NoError(t, store.Delete(ctx, "covers/book/original.jpg"))
+	_, err = os.Stat(filepath.Join(dir, "covers", "book", "original.jpg"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// deleting again is fine
+	assert.NoError(t, store.Delete(ctx, "covers/book/original.jpg"))
+}
+
+func TestLocalStore_InvalidKeys(t *testing.T) {
+	store := NewLocalStore(t.TempDir())
+
+	for _, key := range []string{"", "../escape", "covers/../../escape", "/absolute", "covers//double", "covers/"} {
+		assert.ErrorIs(t, store.Put(context.Background(), key, []byte("x"), ""), ErrInvalidKey, key)
+		assert.ErrorIs(t, store.Delete(context.Background(), key), ErrInvalidKey, key)
+	}
+}
+
+func TestNewBlobStore(t *testing.T) {
+	t.Setenv("STORAGE_BACKEND", "")
+	store, err := NewBlobStore()
+	assert.NoError(t, err)
+	assert.IsType(t, &LocalStore{}, store)
+
+	t.Setenv("STORAGE_BACKEND", "s3")
+	t.Setenv("S3_ENDPOINT", "")
+	_, err = NewBlobStore()
+	assert.Error(t, err)
+
+	t.Setenv("S3_ENDPOINT", "http://localhost:9000/")
+	t.Setenv("S3_BUCKET", "covers")
+	store, err = NewBlobStore()
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost:9000/covers/a/b.jpg", store.URL("a/b.jpg"))
+
+	t.Setenv("STORAGE_BACKEND", "ftp")
+	_, err = NewBlobStore()
+	assert.Error(t, err)
+}
@@ -0,0 +1,204 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Store keeps blobs in a bucket of an S3-compatible service, like AWS S3
+// or MinIO. Objects are addressed path-style (endpoint/bucket/key), which
+// every such service supports, and requests are signed with AWS Signature
+// Version 4.
+type S3Store struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PublicURL is where the objects are served from, like a CDN. The
+	// bucket URL is used when it is empty.
+	PublicURL string
+	Client    *http.Client
+
+	now func() time.Time
+}
+
+// NewS3StoreFromEnv configures the store from S3_ENDPOINT, S3_REGION,
+// S3_BUCKET, S3_ACCESS_KEY, S3_SECRET_KEY and STORAGE_PUBLIC_URL.
+func NewS3StoreFromEnv() (*S3Store, error) {
+	store := &S3Store{
+		Endpoint:  strings.TrimSuffix(os.Getenv("S3_ENDPOINT"), "/"),
+		Region:    os.Getenv("S3_REGION"),
+		Bucket:    os.Getenv("S3_BUCKET"),
+		AccessKey: os.Getenv("S3_ACCESS_KEY"),
+		SecretKey: os.Getenv("S3_SECRET_KEY"),
+		PublicURL: strings.TrimSuffix(os.Getenv("STORAGE_PUBLIC_URL"), "/"),
+		Client:    &http.Client{Timeout: 30 * time.Second},
+	}
+
+	if store.Endpoint == "" || store.Bucket == "" {
+		return nil, errors.New("S3_ENDPOINT and S3_BUCKET are required")
+	}
+	if store.Region == "" {
+		store.Region = "us-east-1"
+	}
+
+	return store, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	return s.do(ctx, http.MethodPut, key, data, contentType)
+}
+
+// Delete does not fail for missing objects, S3 answers 204 for them too.
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	return s.do(ctx, http.MethodDelete, key, nil, "")
+}
+
+func (s *S3Store) URL(key string) string {
+	if s.PublicURL != "" {
+		return s.PublicURL + "/" + uriEncode(key, false)
+	}
+
+	return s.Endpoint + s.objectPath(key)
+}
+
+func (s *S3Store) objectPath(key string) string {
+	return "/" + uriEncode(s.Bucket, true) + "/" + uriEncode(key, false)
+}
+
+func (s *S3Store) do(ctx context.Context, method, key string, data []byte, contentType string) error {
+	if key == "" {
+		return ErrInvalidKey
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, s.Endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	// The path is set already encoded, so it is sent exactly as signed.
+	request.URL.RawPath = s.objectPath(key)
+	request.URL.Path, _ = url.PathUnescape(request.URL.RawPath)
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+
+	s.sign(request, data)
+
+	response, err := s.client().Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+		return fmt.Errorf("s3 %s %s: %s: %s", method, key, response.Status, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
+
+func (s *S3Store) client() *http.Client {
+	if s.Client != nil {
+		return s.Client
+	}
+
+	return http.DefaultClient
+}
+
+// sign adds the AWS Signature Version 4 headers to the request.
+func (s *S3Store) sign(request *http.Request, payload []byte) {
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	moment := now().UTC()
+	amzDate := moment.Format("20060102T150405Z")
+	date := moment.Format("20060102")
+
+	payloadHash := sha256Hex(payload)
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": request.URL.Host}
+	for name, values := range request.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	canonicalHeaders := &strings.Builder{}
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		request.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+	signature := hex.EncodeToString(hmacSHA256(signingKey(s.SecretKey, date, s.Region, "s3"), stringToSign))
+
+	request.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.AccessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func signingKey(secretKey, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// uriEncode escapes everything but the unreserved characters, as Signature
+// Version 4 expects. Slashes are kept unless encodeSlash is set.
+func uriEncode(value string, encodeSlash bool) string {
+	encoded := &strings.Builder{}
+	for _, b := range []byte(value) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9', b == '-', b == '_', b == '.', b == '~':
+			encoded.WriteByte(b)
+		case b == '/' && !encodeSlash:
+			encoded.WriteByte(b)
+		default:
+			fmt.Fprintf(encoded, "%%%02X", b)
+		}
+	}
+
+	return encoded.String()
+}